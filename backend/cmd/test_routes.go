@@ -23,10 +23,26 @@ func setupTestRoutes(api *gin.RouterGroup) {
 			db := config.GetDB()
 			
 			// Delete all data
+			db.Exec("DELETE FROM email_outbox")
+			db.Exec("DELETE FROM digest_subscriptions")
+			db.Exec("DELETE FROM email_opt_outs")
+			db.Exec("DELETE FROM notifications")
+			db.Exec("DELETE FROM notification_mutes")
+			db.Exec("DELETE FROM webhook_deliveries")
+			db.Exec("DELETE FROM webhooks")
+			db.Exec("DELETE FROM audit_logs")
+			db.Exec("DELETE FROM share_links")
+			db.Exec("DELETE FROM household_members")
+			db.Exec("DELETE FROM classroom_students")
+			db.Exec("DELETE FROM classrooms")
 			db.Exec("DELETE FROM permissions")
+			db.Exec("DELETE FROM book_reading_levels")
 			db.Exec("DELETE FROM books")
 			db.Exec("DELETE FROM children")
+			db.Exec("DELETE FROM households")
+			db.Exec("DELETE FROM organization_invitations")
 			db.Exec("DELETE FROM users")
+			db.Exec("DELETE FROM organizations")
 			
 			c.JSON(http.StatusOK, gin.H{"message": "Database reset successfully"})
 		})
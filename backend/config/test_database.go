@@ -51,10 +51,24 @@ func CleanupTestDatabase() {
 	}
 
 	// Delete data in order to respect foreign key constraints
+	TestDB.Exec("DELETE FROM email_outbox")
+	TestDB.Exec("DELETE FROM digest_subscriptions")
+	TestDB.Exec("DELETE FROM email_opt_outs")
+	TestDB.Exec("DELETE FROM notifications")
+	TestDB.Exec("DELETE FROM notification_mutes")
+	TestDB.Exec("DELETE FROM webhook_deliveries")
+	TestDB.Exec("DELETE FROM webhooks")
+	TestDB.Exec("DELETE FROM audit_logs")
+	TestDB.Exec("DELETE FROM share_links")
+	TestDB.Exec("DELETE FROM household_members")
+	TestDB.Exec("DELETE FROM classroom_students")
+	TestDB.Exec("DELETE FROM classrooms")
 	TestDB.Exec("DELETE FROM books")
 	TestDB.Exec("DELETE FROM permissions")
 	TestDB.Exec("DELETE FROM children")
+	TestDB.Exec("DELETE FROM households")
 	TestDB.Exec("DELETE FROM users")
+	TestDB.Exec("DELETE FROM organizations")
 	
 	// Reset auto-increment counters
 	TestDB.Exec("DELETE FROM sqlite_sequence")
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/booktracker/backend/middleware"
+	"github.com/booktracker/backend/models"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetChildAuditLog handles getting the audit trail of a child (owner only)
+func GetChildAuditLog(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid child ID",
+		})
+		return
+	}
+
+	child, err := services.GetChildByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if child.OwnerID != userID {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Message: "Only the owner can view the audit log",
+		})
+		return
+	}
+
+	limit, offset := parsePagination(c)
+	entries, err := services.GetAuditLogsByChild(uint(id), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get audit log: " + err.Error(),
+		})
+		return
+	}
+
+	responses, err := convertAuditLogsToResponses(entries)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get audit log: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// GetAuditLog handles querying the global audit log (admin only)
+func GetAuditLog(c *gin.Context) {
+	filter := services.AuditLogFilter{
+		EntityType: c.Query("entityType"),
+	}
+	filter.Limit, filter.Offset = parsePagination(c)
+
+	if actorIDParam := c.Query("actorId"); actorIDParam != "" {
+		actorID, err := strconv.ParseUint(actorIDParam, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Message: "Invalid actor ID",
+			})
+			return
+		}
+		filter.ActorID = uint(actorID)
+	}
+
+	if childIDParam := c.Query("childId"); childIDParam != "" {
+		childID, err := strconv.ParseUint(childIDParam, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Message: "Invalid child ID",
+			})
+			return
+		}
+		filter.ChildID = uint(childID)
+	}
+
+	entries, err := services.GetAuditLogs(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get audit log: " + err.Error(),
+		})
+		return
+	}
+
+	responses, err := convertAuditLogsToResponses(entries)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get audit log: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// parsePagination reads optional limit and offset query parameters
+func parsePagination(c *gin.Context) (int, int) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// convertAuditLogsToResponses decodes stored JSON and attaches actors loaded in one query
+func convertAuditLogsToResponses(entries []models.AuditLog) ([]models.AuditLogResponse, error) {
+	var actorIDs []uint
+	for _, entry := range entries {
+		if entry.ActorID != 0 {
+			actorIDs = append(actorIDs, entry.ActorID)
+		}
+	}
+
+	actors, err := services.GetUsersByIDs(actorIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.AuditLogResponse, len(entries))
+	for i, entry := range entries {
+		responses[i] = models.AuditLogResponse{
+			ID:         entry.ID,
+			Action:     entry.Action,
+			EntityType: entry.EntityType,
+			EntityID:   entry.EntityID,
+			ChildID:    entry.ChildID,
+			Before:     decodeAuditJSON(entry.Before),
+			After:      decodeAuditJSON(entry.After),
+			Changes:    decodeAuditJSON(entry.Changes),
+			IPAddress:  entry.IPAddress,
+			CreatedAt:  entry.CreatedAt,
+		}
+
+		if actor, found := actors[entry.ActorID]; found {
+			responses[i].Actor = &models.UserResponse{
+				ID:        actor.ID,
+				Email:     actor.Email,
+				FirstName: actor.FirstName,
+				LastName:  actor.LastName,
+			}
+		}
+	}
+
+	return responses, nil
+}
+
+func decodeAuditJSON(data string) map[string]interface{} {
+	if data == "" {
+		return nil
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &decoded); err != nil {
+		return nil
+	}
+	return decoded
+}
@@ -34,22 +34,25 @@ func RegisterUser(c *gin.Context) {
 	}
 
 	// Send verification email
-	emailService := services.NewEmailService()
+	emailService := services.NewEmailService().ForLocale(user.Locale)
 	err = emailService.SendVerificationEmail(user.Email, user.FirstName, user.EmailVerificationToken)
 	if err != nil {
-		// Don't fail registration if email fails, but log the error
-		// In production you might want to queue this for retry
+		// Don't fail registration if the email can't be queued
 		c.Header("X-Email-Warning", "Verification email failed to send")
 	}
 
 	userResponse := models.UserResponse{
-		ID:            user.ID,
-		Email:         user.Email,
-		FirstName:     user.FirstName,
-		LastName:      user.LastName,
-		IsAdmin:       user.IsAdmin,
-		EmailVerified: user.EmailVerified,
-		CreatedAt:     user.CreatedAt,
+		ID:                 user.ID,
+		Email:              user.Email,
+		FirstName:          user.FirstName,
+		LastName:           user.LastName,
+		IsAdmin:            user.IsAdmin,
+		EmailVerified:      user.EmailVerified,
+		OrganizationID:     user.OrganizationID,
+		OrgRole:            user.OrgRole,
+		Locale:             user.Locale,
+		ReadingLevelSystem: user.ReadingLevelSystem,
+		CreatedAt:          user.CreatedAt,
 	}
 
 	c.JSON(http.StatusCreated, userResponse)
@@ -74,7 +77,7 @@ func RegisterUserWithInvitation(c *gin.Context) {
 	}
 
 	// Send verification email (optional, since they're invited)
-	emailService := services.NewEmailService()
+	emailService := services.NewEmailService().ForLocale(user.Locale)
 	err = emailService.SendVerificationEmail(user.Email, user.FirstName, user.EmailVerificationToken)
 	if err != nil {
 		// Don't fail registration if email fails
@@ -82,13 +85,17 @@ func RegisterUserWithInvitation(c *gin.Context) {
 	}
 
 	userResponse := models.UserResponse{
-		ID:            user.ID,
-		Email:         user.Email,
-		FirstName:     user.FirstName,
-		LastName:      user.LastName,
-		IsAdmin:       user.IsAdmin,
-		EmailVerified: user.EmailVerified,
-		CreatedAt:     user.CreatedAt,
+		ID:                 user.ID,
+		Email:              user.Email,
+		FirstName:          user.FirstName,
+		LastName:           user.LastName,
+		IsAdmin:            user.IsAdmin,
+		EmailVerified:      user.EmailVerified,
+		OrganizationID:     user.OrganizationID,
+		OrgRole:            user.OrgRole,
+		Locale:             user.Locale,
+		ReadingLevelSystem: user.ReadingLevelSystem,
+		CreatedAt:          user.CreatedAt,
 	}
 
 	c.JSON(http.StatusCreated, userResponse)
@@ -163,11 +170,11 @@ func ForgotPassword(c *gin.Context) {
 	}
 
 	// Send password reset email
-	emailService := services.NewEmailService()
+	emailService := services.NewEmailService().ForLocale(user.Locale)
 	err = emailService.SendPasswordResetEmail(user.Email, user.FirstName, user.PasswordResetToken)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Message: "Failed to send password reset email",
+			Message: "Failed to queue password reset email",
 		})
 		return
 	}
@@ -196,13 +203,17 @@ func ResetPassword(c *gin.Context) {
 	}
 
 	userResponse := models.UserResponse{
-		ID:            user.ID,
-		Email:         user.Email,
-		FirstName:     user.FirstName,
-		LastName:      user.LastName,
-		IsAdmin:       user.IsAdmin,
-		EmailVerified: user.EmailVerified,
-		CreatedAt:     user.CreatedAt,
+		ID:                 user.ID,
+		Email:              user.Email,
+		FirstName:          user.FirstName,
+		LastName:           user.LastName,
+		IsAdmin:            user.IsAdmin,
+		EmailVerified:      user.EmailVerified,
+		OrganizationID:     user.OrganizationID,
+		OrgRole:            user.OrgRole,
+		Locale:             user.Locale,
+		ReadingLevelSystem: user.ReadingLevelSystem,
+		CreatedAt:          user.CreatedAt,
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -271,7 +282,7 @@ func BulkInviteUser(c *gin.Context) {
 		err = services.SendSystemInvitationEmail(req.Email, token, currentUser)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Message: "Failed to send invitation email: " + err.Error(),
+				Message: "Failed to queue invitation email: " + err.Error(),
 			})
 			return
 		}
@@ -284,7 +295,7 @@ func BulkInviteUser(c *gin.Context) {
 
 	// User exists - create permissions directly
 	for _, childPerm := range req.Children {
-		err := services.CreateOrUpdatePermission(targetUser.ID, childPerm.ChildID, childPerm.PermissionType)
+		err := services.CreateOrUpdatePermission(targetUser.ID, childPerm.ChildID, childPerm.PermissionType, middleware.GetActor(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 				Message: "Failed to create permissions: " + err.Error(),
@@ -402,13 +413,17 @@ func GoogleCallback(c *gin.Context) {
 			}
 
 			userResponse := models.UserResponse{
-				ID:            newUser.ID,
-				Email:         newUser.Email,
-				FirstName:     newUser.FirstName,
-				LastName:      newUser.LastName,
-				IsAdmin:       newUser.IsAdmin,
-				EmailVerified: newUser.EmailVerified,
-				CreatedAt:     newUser.CreatedAt,
+				ID:                 newUser.ID,
+				Email:              newUser.Email,
+				FirstName:          newUser.FirstName,
+				LastName:           newUser.LastName,
+				IsAdmin:            newUser.IsAdmin,
+				EmailVerified:      newUser.EmailVerified,
+				OrganizationID:     newUser.OrganizationID,
+				OrgRole:            newUser.OrgRole,
+				Locale:             newUser.Locale,
+				ReadingLevelSystem: newUser.ReadingLevelSystem,
+				CreatedAt:          newUser.CreatedAt,
 			}
 
 			// Redirect to frontend with token and user info
@@ -446,13 +461,17 @@ func GoogleCallback(c *gin.Context) {
 		}
 
 		userResponse := models.UserResponse{
-			ID:            newUser.ID,
-			Email:         newUser.Email,
-			FirstName:     newUser.FirstName,
-			LastName:      newUser.LastName,
-			IsAdmin:       newUser.IsAdmin,
-			EmailVerified: newUser.EmailVerified,
-			CreatedAt:     newUser.CreatedAt,
+			ID:                 newUser.ID,
+			Email:              newUser.Email,
+			FirstName:          newUser.FirstName,
+			LastName:           newUser.LastName,
+			IsAdmin:            newUser.IsAdmin,
+			EmailVerified:      newUser.EmailVerified,
+			OrganizationID:     newUser.OrganizationID,
+			OrgRole:            newUser.OrgRole,
+			Locale:             newUser.Locale,
+			ReadingLevelSystem: newUser.ReadingLevelSystem,
+			CreatedAt:          newUser.CreatedAt,
 		}
 
 		// Redirect to frontend with token and user info
@@ -492,13 +511,17 @@ func GoogleCallback(c *gin.Context) {
 	}
 
 	userResponse := models.UserResponse{
-		ID:            existingUser.ID,
-		Email:         existingUser.Email,
-		FirstName:     existingUser.FirstName,
-		LastName:      existingUser.LastName,
-		IsAdmin:       existingUser.IsAdmin,
-		EmailVerified: existingUser.EmailVerified,
-		CreatedAt:     existingUser.CreatedAt,
+		ID:                 existingUser.ID,
+		Email:              existingUser.Email,
+		FirstName:          existingUser.FirstName,
+		LastName:           existingUser.LastName,
+		IsAdmin:            existingUser.IsAdmin,
+		EmailVerified:      existingUser.EmailVerified,
+		OrganizationID:     existingUser.OrganizationID,
+		OrgRole:            existingUser.OrgRole,
+		Locale:             existingUser.Locale,
+		ReadingLevelSystem: existingUser.ReadingLevelSystem,
+		CreatedAt:          existingUser.CreatedAt,
 	}
 
 	// Redirect to frontend with token and user info
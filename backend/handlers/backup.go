@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/booktracker/backend/middleware"
+	"github.com/booktracker/backend/models"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// maxBackupFileSize leaves room for many years of a large family's reading
+const maxBackupFileSize = 50 << 20
+
+// ExportBackup downloads an archive of the children the user owns, with their books and sharing
+func ExportBackup(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	// Built in memory first so a failure can still be reported as an error response
+	var archive bytes.Buffer
+	if err := services.ExportBackup(&archive, userID); err != nil {
+		log.Printf("Failed to export backup for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to export backup: " + err.Error(),
+		})
+		return
+	}
+
+	filename := "booktracker_backup_" + time.Now().Format("2006-01-02") + ".json"
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, "application/json", archive.Bytes())
+}
+
+// RestoreBackup adds the children in an uploaded backup archive to the user's account
+func RestoreBackup(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "A backup file must be uploaded in the file field",
+		})
+		return
+	}
+	if header.Size > maxBackupFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{
+			Message: "File is too large to restore",
+		})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Failed to read file: " + err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	result, err := services.RestoreBackup(file, userID, middleware.GetActor(c))
+	if errors.Is(err, services.ErrInvalidBackup) || errors.Is(err, services.ErrUnsupportedBackupVersion) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to restore backup: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
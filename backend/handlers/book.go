@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -29,7 +30,7 @@ func CreateBook(c *gin.Context) {
 	}
 
 	// Check permission to edit the child
-	hasPermission, err := services.CheckChildPermission(userID, req.ChildID, "EDIT")
+	hasPermission, err := middleware.GetPermissionCache(c).GetOrCheck(userID, req.ChildID, "EDIT")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Message: "Failed to check permission: " + err.Error(),
@@ -43,9 +44,9 @@ func CreateBook(c *gin.Context) {
 		return
 	}
 
-	book, err := services.CreateBook(req)
+	book, err := services.CreateBook(req, middleware.GetActor(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		c.JSON(createBookErrorStatus(err), models.ErrorResponse{
 			Message: "Failed to create book: " + err.Error(),
 		})
 		return
@@ -78,7 +79,7 @@ func GetBooks(c *gin.Context) {
 		}
 
 		// Check permission
-		hasPermission, err := services.CheckChildPermission(userID, uint(childID), "VIEW")
+		hasPermission, err := middleware.GetPermissionCache(c).GetOrCheck(userID, uint(childID), "VIEW")
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 				Message: "Failed to check permission: " + err.Error(),
@@ -100,9 +101,7 @@ func GetBooks(c *gin.Context) {
 			return
 		}
 
-		bookResponses := convertBooksToResponses(books)
-
-		c.JSON(http.StatusOK, bookResponses)
+		writeBookList(c, userID, books)
 		return
 	}
 
@@ -115,7 +114,23 @@ func GetBooks(c *gin.Context) {
 		return
 	}
 
+	writeBookList(c, userID, books)
+}
+
+// writeBookList responds with the books between the minLevel and maxLevel query parameters, with
+// their levels in the levelSystem query parameter or the user's preferred system
+func writeBookList(c *gin.Context, userID uint, books []models.Book) {
+	system, ok := readingLevelSystem(c, userID)
+	if !ok {
+		return
+	}
+	books, ok = filterBooksByReadingLevel(c, books, system)
+	if !ok {
+		return
+	}
+
 	bookResponses := convertBooksToResponses(books)
+	setReadingLevels(bookResponses, books, system)
 	c.JSON(http.StatusOK, bookResponses)
 }
 
@@ -147,7 +162,7 @@ func GetBookByID(c *gin.Context) {
 	}
 
 	// Check permission
-	hasPermission, err := services.CheckChildPermission(userID, book.ChildID, "VIEW")
+	hasPermission, err := middleware.GetPermissionCache(c).GetOrCheck(userID, book.ChildID, "VIEW")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Message: "Failed to check permission: " + err.Error(),
@@ -161,7 +176,16 @@ func GetBookByID(c *gin.Context) {
 		return
 	}
 
+	system, ok := readingLevelSystem(c, userID)
+	if !ok {
+		return
+	}
+
 	bookResponse := convertBookToResponse(book)
+	if level := services.BookReadingLevelIn(book, system); level != nil {
+		response := level.Response()
+		bookResponse.ReadingLevel = &response
+	}
 
 	c.JSON(http.StatusOK, bookResponse)
 }
@@ -195,7 +219,7 @@ func UpdateBook(c *gin.Context) {
 	}
 
 	// Check permission
-	hasPermission, err := services.CheckChildPermission(userID, book.ChildID, "EDIT")
+	hasPermission, err := middleware.GetPermissionCache(c).GetOrCheck(userID, book.ChildID, "EDIT")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Message: "Failed to check permission: " + err.Error(),
@@ -217,7 +241,7 @@ func UpdateBook(c *gin.Context) {
 		return
 	}
 
-	updatedBook, err := services.UpdateBook(uint(id), req)
+	updatedBook, err := services.UpdateBook(uint(id), req, middleware.GetActor(c))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Message: err.Error(),
@@ -258,7 +282,7 @@ func DeleteBook(c *gin.Context) {
 	}
 
 	// Check permission
-	hasPermission, err := services.CheckChildPermission(userID, book.ChildID, "EDIT")
+	hasPermission, err := middleware.GetPermissionCache(c).GetOrCheck(userID, book.ChildID, "EDIT")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Message: "Failed to check permission: " + err.Error(),
@@ -272,7 +296,7 @@ func DeleteBook(c *gin.Context) {
 		return
 	}
 
-	err = services.DeleteBook(uint(id))
+	err = services.DeleteBook(uint(id), middleware.GetActor(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Message: err.Error(),
@@ -314,7 +338,7 @@ func CreateBookForChild(c *gin.Context) {
 	req.ChildID = uint(childID)
 
 	// Check permission to edit the child
-	hasPermission, err := services.CheckChildPermission(userID, req.ChildID, "EDIT")
+	hasPermission, err := middleware.GetPermissionCache(c).GetOrCheck(userID, req.ChildID, "EDIT")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Message: "Failed to check permission: " + err.Error(),
@@ -328,9 +352,9 @@ func CreateBookForChild(c *gin.Context) {
 		return
 	}
 
-	book, err := services.CreateBook(req)
+	book, err := services.CreateBook(req, middleware.GetActor(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		c.JSON(createBookErrorStatus(err), models.ErrorResponse{
 			Message: "Failed to create book: " + err.Error(),
 		})
 		return
@@ -421,8 +445,7 @@ func GetBooksForChild(c *gin.Context) {
 		return
 	}
 
-	bookResponses := convertBooksToResponses(books)
-	c.JSON(http.StatusOK, bookResponses)
+	writeBookList(c, userID, books)
 }
 
 // GetMyBooksReport handles getting all books for report generation
@@ -441,6 +464,11 @@ func GetMyBooksReport(c *gin.Context) {
 	
 	var books []models.Book
 	var err error
+
+	system, ok := readingLevelSystem(c, userID)
+	if !ok {
+		return
+	}
 	
 	// Get all children for user
 	children, err := services.GetChildrenWithPermission(userID)
@@ -477,15 +505,17 @@ func GetMyBooksReport(c *gin.Context) {
 		}
 		
 		bookResponses := convertBooksToResponses(books)
+		setReadingLevels(bookResponses, books, system)
 		
 		childReport := models.ChildReportResponse{
 			Child: models.ChildResponse{
-				ID:        child.ID,
-				FirstName: child.FirstName,
-				LastName:  child.LastName,
-				Grade:     child.Grade,
-				OwnerID:   child.OwnerID,
-				CreatedAt: child.CreatedAt,
+				ID:          child.ID,
+				FirstName:   child.FirstName,
+				LastName:    child.LastName,
+				Grade:       child.Grade,
+				OwnerID:     child.OwnerID,
+				HouseholdID: child.HouseholdID,
+				CreatedAt:   child.CreatedAt,
 			},
 			Books:      bookResponses,
 			TotalBooks: len(bookResponses),
@@ -516,8 +546,10 @@ func convertBooksToResponses(books []models.Book) []models.BookResponse {
 			DateRead:       book.DateRead,
 			ChildID:        book.ChildID,
 			LexileLevel:    book.LexileLevel,
+			LexileMeasure:  book.LexileMeasure,
 			IsPartial:      book.IsPartial,
 			PartialComment: book.PartialComment,
+			ReadingLevels:  readingLevelResponses(&books[i]),
 			CreatedAt:      book.CreatedAt,
 		}
 		
@@ -527,7 +559,7 @@ func convertBooksToResponses(books []models.Book) []models.BookResponse {
 			responses[i].ISBN = book.SharedBook.ISBN
 			responses[i].Title = book.SharedBook.Title
 			responses[i].Author = book.SharedBook.Author
-			responses[i].CoverURL = book.SharedBook.CoverURL
+			responses[i].CoverURL = services.SharedBookCoverURL(book.SharedBook)
 			responses[i].IsCustomBook = false
 			responses[i].SharedBookID = book.SharedBookID
 			// Lexile level is always from the user's reading record (per-user)
@@ -551,8 +583,10 @@ func convertBookToResponse(book *models.Book) models.BookResponse {
 		DateRead:       book.DateRead,
 		ChildID:        book.ChildID,
 		LexileLevel:    book.LexileLevel,
+		LexileMeasure:  book.LexileMeasure,
 		IsPartial:      book.IsPartial,
 		PartialComment: book.PartialComment,
+		ReadingLevels:  readingLevelResponses(book),
 		CreatedAt:      book.CreatedAt,
 	}
 	
@@ -562,7 +596,7 @@ func convertBookToResponse(book *models.Book) models.BookResponse {
 		response.ISBN = book.SharedBook.ISBN
 		response.Title = book.SharedBook.Title
 		response.Author = book.SharedBook.Author
-		response.CoverURL = book.SharedBook.CoverURL
+		response.CoverURL = services.SharedBookCoverURL(book.SharedBook)
 		response.IsCustomBook = false
 		response.SharedBookID = book.SharedBookID
 		// Lexile level is always from the user's reading record (per-user)
@@ -609,7 +643,7 @@ func CreateCustomBookForChild(c *gin.Context) {
 	req.ChildID = uint(childID)
 
 	// Check permission to edit the child
-	hasPermission, err := services.CheckChildPermission(userID, req.ChildID, "EDIT")
+	hasPermission, err := middleware.GetPermissionCache(c).GetOrCheck(userID, req.ChildID, "EDIT")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Message: "Failed to check permission: " + err.Error(),
@@ -623,9 +657,9 @@ func CreateCustomBookForChild(c *gin.Context) {
 		return
 	}
 
-	book, err := services.CreateCustomBook(req)
+	book, err := services.CreateCustomBook(req, middleware.GetActor(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		c.JSON(createBookErrorStatus(err), models.ErrorResponse{
 			Message: "Failed to create book: " + err.Error(),
 		})
 		return
@@ -633,4 +667,13 @@ func CreateCustomBookForChild(c *gin.Context) {
 
 	bookResponse := convertBookToResponse(book)
 	c.JSON(http.StatusCreated, bookResponse)
-}
\ No newline at end of file
+}
+
+// createBookErrorStatus is 400 for a reading level that does not parse and 500 otherwise
+func createBookErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInvalidLexile) || errors.Is(err, services.ErrInvalidReadingLevel) ||
+		errors.Is(err, services.ErrInvalidReadingLevelSystem) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
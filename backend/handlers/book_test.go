@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -81,7 +82,8 @@ func (suite *BookHandlerTestSuite) TestConvertBooksToResponsesSharedBooks() {
 	assert.Equal(suite.T(), "9781234567890", responses[0].ISBN)
 	assert.Equal(suite.T(), "Test Book", responses[0].Title)
 	assert.Equal(suite.T(), "Test Author", responses[0].Author)
-	assert.Equal(suite.T(), "http://example.com/cover.jpg", responses[0].CoverURL)
+	// Covers are served from the local cover store, not hot-linked
+	assert.Equal(suite.T(), fmt.Sprintf("http://localhost:8080/api/covers/%d", sharedBook.ID), responses[0].CoverURL)
 	assert.Equal(suite.T(), "500L", responses[0].LexileLevel)
 	assert.False(suite.T(), responses[0].IsCustomBook)
 	assert.False(suite.T(), responses[0].IsPartial)
@@ -223,7 +225,7 @@ func (suite *BookHandlerTestSuite) TestConvertSingleBookToResponse() {
 	assert.Equal(suite.T(), "9781234567890", response.ISBN)
 	assert.Equal(suite.T(), "Single Test Book", response.Title)
 	assert.Equal(suite.T(), "Test Author", response.Author)
-	assert.Equal(suite.T(), "http://example.com/cover.jpg", response.CoverURL)
+	assert.Equal(suite.T(), fmt.Sprintf("http://localhost:8080/api/covers/%d", sharedBook.ID), response.CoverURL)
 	assert.False(suite.T(), response.IsCustomBook)
 	assert.Equal(suite.T(), &sharedBook.ID, response.SharedBookID)
 }
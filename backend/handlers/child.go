@@ -28,6 +28,23 @@ func CreateChild(c *gin.Context) {
 		return
 	}
 
+	// Adding a child to a household requires a role that can edit its children
+	if req.HouseholdID != nil {
+		role, err := services.GetHouseholdRole(*req.HouseholdID, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Message: "Failed to check household: " + err.Error(),
+			})
+			return
+		}
+		if role == "" || services.HouseholdRoleAccess(role) != "EDIT" {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Message: "Access denied to household",
+			})
+			return
+		}
+	}
+
 	child, err := services.CreateChild(req, userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
@@ -37,12 +54,13 @@ func CreateChild(c *gin.Context) {
 	}
 
 	childResponse := models.ChildResponse{
-		ID:        child.ID,
-		FirstName: child.FirstName,
-		LastName:  child.LastName,
-		Grade:     child.Grade,
-		OwnerID:   child.OwnerID,
-		CreatedAt: child.CreatedAt,
+		ID:          child.ID,
+		FirstName:   child.FirstName,
+		LastName:    child.LastName,
+		Grade:       child.Grade,
+		OwnerID:     child.OwnerID,
+		HouseholdID: child.HouseholdID,
+		CreatedAt:   child.CreatedAt,
 	}
 
 	c.JSON(http.StatusCreated, childResponse)
@@ -69,12 +87,13 @@ func GetChildren(c *gin.Context) {
 	var childResponses []models.ChildResponse
 	for _, child := range children {
 		childResponses = append(childResponses, models.ChildResponse{
-			ID:        child.ID,
-			FirstName: child.FirstName,
-			LastName:  child.LastName,
-			Grade:     child.Grade,
-			OwnerID:   child.OwnerID,
-			CreatedAt: child.CreatedAt,
+			ID:          child.ID,
+			FirstName:   child.FirstName,
+			LastName:    child.LastName,
+			Grade:       child.Grade,
+			OwnerID:     child.OwnerID,
+			HouseholdID: child.HouseholdID,
+			CreatedAt:   child.CreatedAt,
 		})
 	}
 
@@ -101,7 +120,7 @@ func GetChildByID(c *gin.Context) {
 	}
 
 	// Check permission
-	hasPermission, err := services.CheckChildPermission(userID, uint(id), "VIEW")
+	hasPermission, err := middleware.GetPermissionCache(c).GetOrCheck(userID, uint(id), "VIEW")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Message: "Failed to check permission: " + err.Error(),
@@ -124,12 +143,13 @@ func GetChildByID(c *gin.Context) {
 	}
 
 	childResponse := models.ChildResponse{
-		ID:        child.ID,
-		FirstName: child.FirstName,
-		LastName:  child.LastName,
-		Grade:     child.Grade,
-		OwnerID:   child.OwnerID,
-		CreatedAt: child.CreatedAt,
+		ID:          child.ID,
+		FirstName:   child.FirstName,
+		LastName:    child.LastName,
+		Grade:       child.Grade,
+		OwnerID:     child.OwnerID,
+		HouseholdID: child.HouseholdID,
+		CreatedAt:   child.CreatedAt,
 	}
 
 	c.JSON(http.StatusOK, childResponse)
@@ -155,7 +175,7 @@ func UpdateChild(c *gin.Context) {
 	}
 
 	// Check permission
-	hasPermission, err := services.CheckChildPermission(userID, uint(id), "EDIT")
+	hasPermission, err := middleware.GetPermissionCache(c).GetOrCheck(userID, uint(id), "EDIT")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Message: "Failed to check permission: " + err.Error(),
@@ -177,7 +197,7 @@ func UpdateChild(c *gin.Context) {
 		return
 	}
 
-	child, err := services.UpdateChild(uint(id), req)
+	child, err := services.UpdateChild(uint(id), req, middleware.GetActor(c))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Message: err.Error(),
@@ -186,12 +206,13 @@ func UpdateChild(c *gin.Context) {
 	}
 
 	childResponse := models.ChildResponse{
-		ID:        child.ID,
-		FirstName: child.FirstName,
-		LastName:  child.LastName,
-		Grade:     child.Grade,
-		OwnerID:   child.OwnerID,
-		CreatedAt: child.CreatedAt,
+		ID:          child.ID,
+		FirstName:   child.FirstName,
+		LastName:    child.LastName,
+		Grade:       child.Grade,
+		OwnerID:     child.OwnerID,
+		HouseholdID: child.HouseholdID,
+		CreatedAt:   child.CreatedAt,
 	}
 
 	c.JSON(http.StatusOK, childResponse)
@@ -232,7 +253,7 @@ func DeleteChild(c *gin.Context) {
 		return
 	}
 
-	err = services.DeleteChild(uint(id))
+	err = services.DeleteChild(uint(id), middleware.GetActor(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Message: err.Error(),
@@ -243,6 +264,78 @@ func DeleteChild(c *gin.Context) {
 	c.JSON(http.StatusNoContent, nil)
 }
 
+// TransferChildOwnership handles handing a child over to another registered user
+func TransferChildOwnership(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid child ID",
+		})
+		return
+	}
+
+	var req models.TransferChildOwnershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	// Check permission (only owner can transfer)
+	child, err := services.GetChildByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if child.OwnerID != userID {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Message: "Only the owner can transfer a child",
+		})
+		return
+	}
+
+	newOwner, err := services.GetUserByEmail(req.Email)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: "New owner must have an account",
+		})
+		return
+	}
+
+	child, err = services.TransferChildOwnership(uint(id), newOwner.ID, middleware.GetActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	childResponse := models.ChildResponse{
+		ID:          child.ID,
+		FirstName:   child.FirstName,
+		LastName:    child.LastName,
+		Grade:       child.Grade,
+		OwnerID:     child.OwnerID,
+		HouseholdID: child.HouseholdID,
+		CreatedAt:   child.CreatedAt,
+	}
+
+	c.JSON(http.StatusOK, childResponse)
+}
+
 // InviteUser handles inviting a user to access a child's data
 func InviteUser(c *gin.Context) {
 	userID, exists := middleware.GetCurrentUserID(c)
@@ -310,7 +403,7 @@ func InviteUser(c *gin.Context) {
 		err = services.SendInvitationEmail(req.Email, invitation.Token, currentUser, child)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Message: "Failed to send invitation email: " + err.Error(),
+				Message: "Failed to queue invitation email: " + err.Error(),
 			})
 			return
 		}
@@ -320,7 +413,7 @@ func InviteUser(c *gin.Context) {
 	}
 
 	// User exists - check if they already have permission
-	hasExistingPermission, err := services.CheckChildPermission(targetUser.ID, uint(childID), "VIEW")
+	hasExistingPermission, err := middleware.GetPermissionCache(c).GetOrCheck(targetUser.ID, uint(childID), "VIEW")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Message: "Failed to check existing permissions: " + err.Error(),
@@ -338,7 +431,7 @@ func InviteUser(c *gin.Context) {
 	}
 
 	// Create permission for the existing user
-	err = services.CreatePermission(targetUser.ID, uint(childID), req.PermissionType)
+	err = services.CreatePermission(targetUser.ID, uint(childID), req.PermissionType, middleware.GetActor(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Message: "Failed to create permission: " + err.Error(),
@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/booktracker/backend/middleware"
+	"github.com/booktracker/backend/models"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateClassroom handles creating a classroom taught by the current user
+func CreateClassroom(c *gin.Context) {
+	if _, exists := middleware.GetCurrentUserID(c); !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	var req models.CreateClassroomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	classroom, err := services.CreateClassroom(req, middleware.GetActor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to create classroom: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, convertClassroomToResponse(classroom, 0, true))
+}
+
+// GetClassrooms handles listing the classrooms taught by the current user
+func GetClassrooms(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	classrooms, err := services.GetClassroomsByTeacher(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get classrooms: " + err.Error(),
+		})
+		return
+	}
+
+	responses := make([]models.ClassroomResponse, len(classrooms))
+	for i := range classrooms {
+		count, err := services.CountClassroomStudents(classrooms[i].ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Message: "Failed to get classrooms: " + err.Error(),
+			})
+			return
+		}
+		responses[i] = convertClassroomToResponse(&classrooms[i], count, true)
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// GetClassroomByID handles getting a classroom (teacher only)
+func GetClassroomByID(c *gin.Context) {
+	classroom, ok := requireClassroomTeacher(c)
+	if !ok {
+		return
+	}
+
+	count, err := services.CountClassroomStudents(classroom.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get classroom: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, convertClassroomToResponse(classroom, count, true))
+}
+
+// UpdateClassroom handles updating a classroom's name and goal (teacher only)
+func UpdateClassroom(c *gin.Context) {
+	classroom, ok := requireClassroomTeacher(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateClassroomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	updated, err := services.UpdateClassroom(classroom.ID, req, middleware.GetActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	count, _ := services.CountClassroomStudents(updated.ID)
+	c.JSON(http.StatusOK, convertClassroomToResponse(updated, count, true))
+}
+
+// DeleteClassroom handles deleting a classroom (teacher only)
+func DeleteClassroom(c *gin.Context) {
+	classroom, ok := requireClassroomTeacher(c)
+	if !ok {
+		return
+	}
+
+	if err := services.DeleteClassroom(classroom.ID, middleware.GetActor(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// RegenerateClassroomJoinCode handles replacing a leaked or shared join code (teacher only)
+func RegenerateClassroomJoinCode(c *gin.Context) {
+	classroom, ok := requireClassroomTeacher(c)
+	if !ok {
+		return
+	}
+
+	updated, err := services.RegenerateJoinCode(classroom.ID, middleware.GetActor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to regenerate join code: " + err.Error(),
+		})
+		return
+	}
+
+	count, _ := services.CountClassroomStudents(updated.ID)
+	c.JSON(http.StatusOK, convertClassroomToResponse(updated, count, true))
+}
+
+// GetClassroomRoster handles listing the students of a classroom (teacher only)
+func GetClassroomRoster(c *gin.Context) {
+	classroom, ok := requireClassroomTeacher(c)
+	if !ok {
+		return
+	}
+
+	students, err := services.GetClassroomRoster(classroom.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get roster: " + err.Error(),
+		})
+		return
+	}
+
+	responses := make([]models.ClassroomStudentResponse, len(students))
+	for i, student := range students {
+		responses[i] = models.ClassroomStudentResponse{
+			Child: models.ChildResponse{
+				ID:          student.Child.ID,
+				FirstName:   student.Child.FirstName,
+				LastName:    student.Child.LastName,
+				Grade:       student.Child.Grade,
+				OwnerID:     student.Child.OwnerID,
+				HouseholdID: student.Child.HouseholdID,
+				CreatedAt:   student.Child.CreatedAt,
+			},
+			Parent: models.UserResponse{
+				ID:        student.Child.Owner.ID,
+				Email:     student.Child.Owner.Email,
+				FirstName: student.Child.Owner.FirstName,
+				LastName:  student.Child.Owner.LastName,
+			},
+			JoinedAt: student.CreatedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// GetClassroomStats handles class-wide monthly aggregates (teacher only).
+// Defaults to the current month when year and month are omitted.
+func GetClassroomStats(c *gin.Context) {
+	classroom, ok := requireClassroomTeacher(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	year, month := now.Year(), int(now.Month())
+	if c.Query("year") != "" || c.Query("month") != "" {
+		var valid bool
+		if year, month, valid = parseYearMonth(c); !valid {
+			return
+		}
+	}
+
+	stats, err := services.GetClassroomStats(classroom.ID, year, month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get classroom stats: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// JoinClassroom handles a parent linking their child to a classroom with its join code
+func JoinClassroom(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	var req models.JoinClassroomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	child, err := services.GetChildByID(req.ChildID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: "Child not found",
+		})
+		return
+	}
+
+	if child.OwnerID != userID {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Message: "Only the owner can add this child to a classroom",
+		})
+		return
+	}
+
+	classroom, err := services.JoinClassroom(req.JoinCode, child.ID, middleware.GetActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, convertClassroomToResponse(classroom, 0, false))
+}
+
+// GetChildClassrooms handles listing the classrooms a child is in (owner only)
+func GetChildClassrooms(c *gin.Context) {
+	childID, ok := requireChildOwner(c, "Only the owner can view a child's classrooms")
+	if !ok {
+		return
+	}
+
+	classrooms, err := services.GetClassroomsByChild(childID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get classrooms: " + err.Error(),
+		})
+		return
+	}
+
+	responses := make([]models.ClassroomResponse, len(classrooms))
+	for i := range classrooms {
+		responses[i] = convertClassroomToResponse(&classrooms[i], 0, false)
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// RemoveClassroomStudent handles unlinking a child; allowed for the teacher and the child's owner
+func RemoveClassroomStudent(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	classroomID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid classroom ID",
+		})
+		return
+	}
+
+	childID, err := strconv.ParseUint(c.Param("childId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid child ID",
+		})
+		return
+	}
+
+	classroom, err := services.GetClassroomByID(uint(classroomID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if classroom.TeacherID != userID {
+		child, err := services.GetChildByID(uint(childID))
+		if err != nil || child.OwnerID != userID {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Message: "Access denied",
+			})
+			return
+		}
+	}
+
+	if err := services.RemoveClassroomStudent(uint(classroomID), uint(childID), middleware.GetActor(c)); err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// requireClassroomTeacher parses the :id classroom parameter and checks the current user teaches it
+func requireClassroomTeacher(c *gin.Context) (*models.Classroom, bool) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return nil, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid classroom ID",
+		})
+		return nil, false
+	}
+
+	classroom, err := services.GetClassroomByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return nil, false
+	}
+
+	if classroom.TeacherID != userID {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Message: "Only the teacher can manage this classroom",
+		})
+		return nil, false
+	}
+
+	return classroom, true
+}
+
+// convertClassroomToResponse hides the join code unless the viewer is the teacher
+func convertClassroomToResponse(classroom *models.Classroom, studentCount int, isTeacher bool) models.ClassroomResponse {
+	response := models.ClassroomResponse{
+		ID:             classroom.ID,
+		Name:           classroom.Name,
+		TeacherID:      classroom.TeacherID,
+		MonthlyGoal:    classroom.MonthlyGoal,
+		StudentCount:   studentCount,
+		OrganizationID: classroom.OrganizationID,
+		CreatedAt:      classroom.CreatedAt,
+	}
+	if isTeacher {
+		response.JoinCode = classroom.JoinCode
+	}
+	return response
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/booktracker/backend/models"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetCover handles serving a shared book's cover from the cover store (size=small|medium|large, default medium)
+func GetCover(c *gin.Context) {
+	sharedBookID, err := strconv.ParseUint(c.Param("sharedBookId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid shared book ID",
+		})
+		return
+	}
+
+	cover, err := services.GetSharedBookCover(uint(sharedBookID), c.DefaultQuery("size", services.CoverSizeMedium))
+	if errors.Is(err, services.ErrInvalidCoverSize) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, services.ErrCoverNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: "Cover not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, models.ErrorResponse{
+			Message: "Failed to fetch cover: " + err.Error(),
+		})
+		return
+	}
+
+	// A shared book's cover does not change, so browsers may keep it
+	c.Header("Cache-Control", "public, max-age=604800")
+	c.Data(http.StatusOK, cover.ContentType, cover.Data)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/booktracker/backend/middleware"
+	"github.com/booktracker/backend/models"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetDigestSubscription handles getting the current user's digest settings
+func GetDigestSubscription(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	subscription, err := services.GetDigestSubscription(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get digest subscription: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, convertDigestSubscriptionToResponse(subscription))
+}
+
+// UpdateDigestSubscription handles opting into digests or changing their frequency
+func UpdateDigestSubscription(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	var req models.UpdateDigestSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	subscription, err := services.SubscribeToDigest(userID, req.Frequency)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to update digest subscription: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, convertDigestSubscriptionToResponse(subscription))
+}
+
+// DeleteDigestSubscription handles opting out of digests
+func DeleteDigestSubscription(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	if err := services.UnsubscribeFromDigest(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to unsubscribe: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetDigestReport handles the monthly PDF links in digest emails (no authentication).
+// Each link is signed for one recipient, child and month and expires.
+func GetDigestReport(c *gin.Context) {
+	grant, err := services.ParseDigestReportToken(c.Query("token"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// The link only works while its recipient can still view the child
+	hasPermission, err := services.HasChildPermission(grant.UserID, grant.ChildID, "VIEW")
+	if err != nil || !hasPermission {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: services.ErrDigestTokenInvalid.Error(),
+		})
+		return
+	}
+
+	child, err := services.GetChildByID(grant.ChildID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: "Child not found",
+		})
+		return
+	}
+
+	report, err := services.GenerateMonthlyBooksPDFForChild(child, grant.Year, grant.Month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to generate PDF: " + err.Error(),
+		})
+		return
+	}
+
+	serveBooksReport(c, report)
+}
+
+func convertDigestSubscriptionToResponse(subscription *models.DigestSubscription) models.DigestSubscriptionResponse {
+	if subscription == nil {
+		return models.DigestSubscriptionResponse{}
+	}
+	return models.DigestSubscriptionResponse{
+		Subscribed: true,
+		Frequency:  subscription.Frequency,
+		LastSentAt: subscription.LastSentAt,
+	}
+}
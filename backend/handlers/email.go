@@ -27,13 +27,17 @@ func VerifyEmail(c *gin.Context) {
 	}
 
 	userResponse := models.UserResponse{
-		ID:            user.ID,
-		Email:         user.Email,
-		FirstName:     user.FirstName,
-		LastName:      user.LastName,
-		IsAdmin:       user.IsAdmin,
-		EmailVerified: user.EmailVerified,
-		CreatedAt:     user.CreatedAt,
+		ID:                 user.ID,
+		Email:              user.Email,
+		FirstName:          user.FirstName,
+		LastName:           user.LastName,
+		IsAdmin:            user.IsAdmin,
+		EmailVerified:      user.EmailVerified,
+		OrganizationID:     user.OrganizationID,
+		OrgRole:            user.OrgRole,
+		Locale:             user.Locale,
+		ReadingLevelSystem: user.ReadingLevelSystem,
+		CreatedAt:          user.CreatedAt,
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -64,11 +68,11 @@ func ResendVerification(c *gin.Context) {
 	}
 
 	// Send verification email
-	emailService := services.NewEmailService()
+	emailService := services.NewEmailService().ForLocale(user.Locale)
 	err = emailService.SendVerificationEmail(user.Email, user.FirstName, user.EmailVerificationToken)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Message: "Failed to send verification email: " + err.Error(),
+			Message: "Failed to queue verification email: " + err.Error(),
 		})
 		return
 	}
@@ -76,4 +80,33 @@ func ResendVerification(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Verification email sent successfully",
 	})
-}
\ No newline at end of file
+}
+
+// GetEmailTemplates handles listing the email templates and their languages (admin only)
+func GetEmailTemplates(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"templates": services.EmailTemplateNames(),
+		"locales":   services.SupportedLocales,
+	})
+}
+
+// PreviewEmailTemplate handles rendering an email template with sample data (admin only).
+// format=html or format=text returns that body on its own for viewing in a browser.
+func PreviewEmailTemplate(c *gin.Context) {
+	rendered, err := services.PreviewEmail(c.Param("name"), c.DefaultQuery("locale", services.DefaultLocale))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	switch c.Query("format") {
+	case "html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(rendered.HTMLBody))
+	case "text":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(rendered.TextBody))
+	default:
+		c.JSON(http.StatusOK, rendered)
+	}
+}
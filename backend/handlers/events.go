@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/booktracker/backend/middleware"
+	"github.com/booktracker/backend/models"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// eventStreamHeartbeat keeps proxies from closing an idle stream
+const eventStreamHeartbeat = 25 * time.Second
+
+// CreateEventStreamToken handles issuing a short-lived token for opening the event stream with
+// EventSource, which cannot send the Authorization header
+func CreateEventStreamToken(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	expiresAt := time.Now().Add(services.EventStreamTokenTTL)
+	c.JSON(http.StatusOK, gin.H{
+		"token":     services.EventStreamToken(userID, expiresAt),
+		"expiresAt": expiresAt.UTC(),
+	})
+}
+
+// StreamEvents handles the server-sent events stream of book and permission changes
+// for the children the current user can view
+func StreamEvents(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	hub := services.SharedEventHub()
+	subscription := hub.Subscribe(userID)
+	defer hub.Unsubscribe(subscription)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	// Tell the client the stream is live so it can refetch anything it missed while connecting
+	c.SSEvent("ready", gin.H{"userId": userID})
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		case <-heartbeat.C:
+			io.WriteString(w, ": ping\n\n")
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/middleware"
+	"github.com/booktracker/backend/models"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type EventStreamHandlerTestSuite struct {
+	suite.Suite
+	server *httptest.Server
+	owner  *models.User
+	child  *models.Child
+	token  string
+}
+
+func (suite *EventStreamHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *EventStreamHandlerTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+	services.SharedPermissionCache().Clear()
+
+	router := gin.New()
+	router.GET("/events/stream", middleware.EventStreamAuth(), StreamEvents)
+	suite.server = httptest.NewServer(router)
+
+	owner, err := services.CreateUser(models.CreateUserRequest{
+		Email:     "owner@example.com",
+		Password:  "password123",
+		FirstName: "Owner",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	suite.owner = owner
+
+	suite.child, err = services.CreateChild(models.CreateChildRequest{
+		FirstName: "Test",
+		LastName:  "Child",
+		Grade:     "3rd",
+	}, owner.ID)
+	assert.NoError(suite.T(), err)
+
+	suite.token, err = services.GenerateToken(owner)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *EventStreamHandlerTestSuite) TearDownTest() {
+	suite.server.Close()
+	config.CleanupTestDatabase()
+}
+
+// readEvent reads lines until the next event name, failing after a timeout
+func readEvent(t *testing.T, reader *bufio.Reader) string {
+	result := make(chan string, 1)
+	go func() {
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				result <- ""
+				return
+			}
+			if strings.HasPrefix(line, "event:") {
+				result <- strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+				return
+			}
+		}
+	}()
+
+	select {
+	case event := <-result:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for an event")
+		return ""
+	}
+}
+
+func (suite *EventStreamHandlerTestSuite) TestStreamsBookEventsWithStreamToken() {
+	streamToken := services.EventStreamToken(suite.owner.ID, time.Now().Add(services.EventStreamTokenTTL))
+	resp, err := http.Get(suite.server.URL + "/events/stream?token=" + streamToken)
+	assert.NoError(suite.T(), err)
+	defer resp.Body.Close()
+	assert.Equal(suite.T(), http.StatusOK, resp.StatusCode)
+	assert.Equal(suite.T(), "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	assert.Equal(suite.T(), "ready", readEvent(suite.T(), reader))
+
+	_, err = services.CreateCustomBook(models.CreateCustomBookRequest{
+		Title:    "Live Title",
+		DateRead: "2024-03-05",
+		ChildID:  suite.child.ID,
+	}, services.Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), services.LiveEventBookCreated, readEvent(suite.T(), reader))
+	data, err := reader.ReadString('\n')
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), data, `"childId":`)
+}
+
+func (suite *EventStreamHandlerTestSuite) TestRejectsMissingToken() {
+	resp, err := http.Get(suite.server.URL + "/events/stream")
+	assert.NoError(suite.T(), err)
+	defer resp.Body.Close()
+	assert.Equal(suite.T(), http.StatusUnauthorized, resp.StatusCode)
+
+	resp, err = http.Get(suite.server.URL + "/events/stream?token=invalid")
+	assert.NoError(suite.T(), err)
+	defer resp.Body.Close()
+	assert.Equal(suite.T(), http.StatusUnauthorized, resp.StatusCode)
+}
+
+func (suite *EventStreamHandlerTestSuite) TestLoginTokenStaysOutOfTheURL() {
+	// The login token only works in the Authorization header
+	for _, query := range []string{"?token=", "?access_token="} {
+		resp, err := http.Get(suite.server.URL + "/events/stream" + query + suite.token)
+		assert.NoError(suite.T(), err)
+		resp.Body.Close()
+		assert.Equal(suite.T(), http.StatusUnauthorized, resp.StatusCode, query)
+	}
+
+	expired := services.EventStreamToken(suite.owner.ID, time.Now().Add(-time.Second))
+	resp, err := http.Get(suite.server.URL + "/events/stream?token=" + expired)
+	assert.NoError(suite.T(), err)
+	resp.Body.Close()
+	assert.Equal(suite.T(), http.StatusUnauthorized, resp.StatusCode)
+}
+
+func (suite *EventStreamHandlerTestSuite) TestCreateEventStreamToken() {
+	router := gin.New()
+	router.POST("/events/token", middleware.AuthMiddleware(), CreateEventStreamToken)
+	req := httptest.NewRequest(http.MethodPost, "/events/token", nil)
+	req.Header.Set("Authorization", "Bearer "+suite.token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expiresAt"`
+	}
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.WithinDuration(suite.T(), time.Now().Add(services.EventStreamTokenTTL), response.ExpiresAt, 5*time.Second)
+
+	userID, err := services.ParseEventStreamToken(response.Token, time.Now())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.owner.ID, userID)
+}
+
+func TestEventStreamHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(EventStreamHandlerTestSuite))
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/booktracker/backend/middleware"
+	"github.com/booktracker/backend/models"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportBooksCSV streams the reading history of the selected children as CSV
+func ExportBooksCSV(c *gin.Context) {
+	exportBooks(c, services.ExportFormatCSV, "text/csv; charset=utf-8")
+}
+
+// ExportBooksXLSX streams the reading history of the selected children as an Excel workbook
+func ExportBooksXLSX(c *gin.Context) {
+	exportBooks(c, services.ExportFormatXLSX, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+}
+
+// exportBooks exports the books of the children in childIds (default: every child the user can
+// view) read between the optional from and to dates, with the columns listed in columns
+func exportBooks(c *gin.Context, format, contentType string) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	columns, err := services.ParseExportColumns(c.Query("columns"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid columns parameter: " + err.Error(),
+		})
+		return
+	}
+
+	var from, to time.Time
+	for _, date := range []struct {
+		param string
+		value *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		if c.Query(date.param) == "" {
+			continue
+		}
+		if *date.value, err = time.Parse("2006-01-02", c.Query(date.param)); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Message: "From and to parameters must be dates formatted YYYY-MM-DD",
+			})
+			return
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "To date is before from date",
+		})
+		return
+	}
+
+	children, ok := parseChildIDs(c, userID)
+	if !ok {
+		return
+	}
+	levelSystem, ok := readingLevelSystem(c, userID)
+	if !ok {
+		return
+	}
+
+	name := "Family"
+	if len(children) == 1 {
+		name = strings.TrimSpace(children[0].FirstName + " " + children[0].LastName)
+	}
+	filename := "reading_history_" + strings.ReplaceAll(name, " ", "_") + "." + format
+
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Header("Content-Type", contentType)
+	c.Status(http.StatusOK)
+
+	// Rows are written as they are read, so a failure part way can only be logged
+	err = services.ExportBooks(c.Writer, format, services.BookExport{
+		Children:    children,
+		From:        from,
+		To:          to,
+		Columns:     columns,
+		LevelSystem: levelSystem,
+	})
+	if err != nil {
+		log.Printf("Failed to write export %s: %v", filename, err)
+	}
+}
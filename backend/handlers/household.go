@@ -0,0 +1,379 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/booktracker/backend/middleware"
+	"github.com/booktracker/backend/models"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateHousehold handles creating a household owned by the current user
+func CreateHousehold(c *gin.Context) {
+	if _, exists := middleware.GetCurrentUserID(c); !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	var req models.CreateHouseholdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	household, err := services.CreateHousehold(req, middleware.GetActor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to create household: " + err.Error(),
+		})
+		return
+	}
+
+	respondWithHousehold(c, http.StatusCreated, household.ID, services.HouseholdRoleOwner)
+}
+
+// GetHouseholds handles listing the households of the current user
+func GetHouseholds(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	households, err := services.GetHouseholdsForUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get households: " + err.Error(),
+		})
+		return
+	}
+
+	responses := make([]models.HouseholdResponse, len(households))
+	for i := range households {
+		responses[i] = convertHouseholdToResponse(&households[i], userID)
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// GetHouseholdByID handles getting a household (members only)
+func GetHouseholdByID(c *gin.Context) {
+	householdID, role, ok := requireHouseholdRole(c, services.HouseholdRoleViewer)
+	if !ok {
+		return
+	}
+
+	respondWithHousehold(c, http.StatusOK, householdID, role)
+}
+
+// UpdateHousehold handles renaming a household (owners only)
+func UpdateHousehold(c *gin.Context) {
+	householdID, role, ok := requireHouseholdRole(c, services.HouseholdRoleOwner)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateHouseholdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	if _, err := services.UpdateHousehold(householdID, req, middleware.GetActor(c)); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	respondWithHousehold(c, http.StatusOK, householdID, role)
+}
+
+// DeleteHousehold handles deleting a household (owners only)
+func DeleteHousehold(c *gin.Context) {
+	householdID, _, ok := requireHouseholdRole(c, services.HouseholdRoleOwner)
+	if !ok {
+		return
+	}
+
+	if err := services.DeleteHousehold(householdID, middleware.GetActor(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// AddHouseholdMember handles adding an existing user to a household (owners only)
+func AddHouseholdMember(c *gin.Context) {
+	householdID, role, ok := requireHouseholdRole(c, services.HouseholdRoleOwner)
+	if !ok {
+		return
+	}
+
+	var req models.AddHouseholdMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	user, err := services.GetUserByEmail(req.Email)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: "No account exists for that email",
+		})
+		return
+	}
+
+	if _, err := services.AddHouseholdMember(householdID, user.ID, req.Role, middleware.GetActor(c)); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	respondWithHousehold(c, http.StatusCreated, householdID, role)
+}
+
+// UpdateHouseholdMember handles changing a member's role (owners only)
+func UpdateHouseholdMember(c *gin.Context) {
+	householdID, _, ok := requireHouseholdRole(c, services.HouseholdRoleOwner)
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	var req models.UpdateHouseholdMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	if _, err := services.UpdateHouseholdMember(householdID, uint(memberID), req.Role, middleware.GetActor(c)); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	userID, _ := middleware.GetCurrentUserID(c)
+	role, _ := services.GetHouseholdRole(householdID, userID)
+	respondWithHousehold(c, http.StatusOK, householdID, role)
+}
+
+// RemoveHouseholdMember handles removing a member; owners remove anyone, members may leave
+func RemoveHouseholdMember(c *gin.Context) {
+	householdID, role, ok := requireHouseholdRole(c, services.HouseholdRoleViewer)
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	userID, _ := middleware.GetCurrentUserID(c)
+	if role != services.HouseholdRoleOwner && uint(memberID) != userID {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Message: "Only household owners can remove other members",
+		})
+		return
+	}
+
+	if err := services.RemoveHouseholdMember(householdID, uint(memberID), middleware.GetActor(c)); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// SetChildHousehold handles moving a child into or out of a household.
+// Only the child's owner may do this, and only into a household they can edit.
+func SetChildHousehold(c *gin.Context) {
+	childID, ok := requireChildOwner(c, "Only the owner can change a child's household")
+	if !ok {
+		return
+	}
+
+	var req models.SetChildHouseholdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	if req.HouseholdID != nil {
+		userID, _ := middleware.GetCurrentUserID(c)
+		role, err := services.GetHouseholdRole(*req.HouseholdID, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Message: "Failed to check household: " + err.Error(),
+			})
+			return
+		}
+		if role == "" || services.HouseholdRoleAccess(role) != "EDIT" {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Message: "Access denied to household",
+			})
+			return
+		}
+	}
+
+	child, err := services.SetChildHousehold(childID, req.HouseholdID, middleware.GetActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ChildResponse{
+		ID:          child.ID,
+		FirstName:   child.FirstName,
+		LastName:    child.LastName,
+		Grade:       child.Grade,
+		OwnerID:     child.OwnerID,
+		HouseholdID: child.HouseholdID,
+		CreatedAt:   child.CreatedAt,
+	})
+}
+
+// requireHouseholdRole parses the :id household parameter and checks the
+// current user's role is at least minimumRole (VIEWER < EDITOR < OWNER)
+func requireHouseholdRole(c *gin.Context, minimumRole string) (uint, string, bool) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return 0, "", false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid household ID",
+		})
+		return 0, "", false
+	}
+
+	role, err := services.GetHouseholdRole(uint(id), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to check household: " + err.Error(),
+		})
+		return 0, "", false
+	}
+	if role == "" {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: "household not found",
+		})
+		return 0, "", false
+	}
+	if householdRoleRank(role) < householdRoleRank(minimumRole) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Message: "Access denied",
+		})
+		return 0, "", false
+	}
+
+	return uint(id), role, true
+}
+
+func householdRoleRank(role string) int {
+	switch role {
+	case services.HouseholdRoleOwner:
+		return 3
+	case services.HouseholdRoleEditor:
+		return 2
+	case services.HouseholdRoleViewer:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func respondWithHousehold(c *gin.Context, status int, householdID uint, role string) {
+	household, err := services.GetHouseholdByID(householdID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get household: " + err.Error(),
+		})
+		return
+	}
+
+	response := convertHouseholdToResponse(household, 0)
+	response.Role = role
+	c.JSON(status, response)
+}
+
+// convertHouseholdToResponse converts a household with preloaded members and children;
+// the role is taken from the member entry of userID when given
+func convertHouseholdToResponse(household *models.Household, userID uint) models.HouseholdResponse {
+	response := models.HouseholdResponse{
+		ID:        household.ID,
+		Name:      household.Name,
+		Members:   make([]models.HouseholdMemberResponse, len(household.Members)),
+		Children:  make([]models.ChildResponse, len(household.Children)),
+		CreatedAt: household.CreatedAt,
+	}
+
+	for i, member := range household.Members {
+		response.Members[i] = models.HouseholdMemberResponse{
+			UserID:    member.UserID,
+			Email:     member.User.Email,
+			FirstName: member.User.FirstName,
+			LastName:  member.User.LastName,
+			Role:      member.Role,
+			CreatedAt: member.CreatedAt,
+		}
+		if userID != 0 && member.UserID == userID {
+			response.Role = member.Role
+		}
+	}
+
+	for i, child := range household.Children {
+		response.Children[i] = models.ChildResponse{
+			ID:          child.ID,
+			FirstName:   child.FirstName,
+			LastName:    child.LastName,
+			Grade:       child.Grade,
+			OwnerID:     child.OwnerID,
+			HouseholdID: child.HouseholdID,
+			CreatedAt:   child.CreatedAt,
+		}
+	}
+
+	return response
+}
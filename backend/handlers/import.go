@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/booktracker/backend/middleware"
+	"github.com/booktracker/backend/models"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportFileSize is well above a Goodreads export of several thousand books
+const maxImportFileSize = 10 << 20
+
+// ImportBooks logs the books in an uploaded CSV reading log, either our own export or a
+// Goodreads export. Rows without a Child column go to childId. With dryRun=true nothing is
+// saved and the response previews what an import would do.
+func ImportBooks(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	dryRun := false
+	if c.Query("dryRun") != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(c.Query("dryRun")); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Message: "Invalid dryRun parameter",
+			})
+			return
+		}
+	}
+
+	var childID uint
+	if c.Query("childId") != "" {
+		id, err := strconv.ParseUint(c.Query("childId"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Message: "Invalid child ID",
+			})
+			return
+		}
+		childID = uint(id)
+	}
+
+	// Books can only be imported for children the user can edit
+	viewable, err := services.GetChildrenWithPermission(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get children: " + err.Error(),
+		})
+		return
+	}
+	var children []*models.Child
+	childAllowed := false
+	for i := range viewable {
+		hasPermission, err := middleware.GetPermissionCache(c).GetOrCheck(userID, viewable[i].ID, "EDIT")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Message: "Failed to check permission: " + err.Error(),
+			})
+			return
+		}
+		if hasPermission {
+			children = append(children, &viewable[i])
+			childAllowed = childAllowed || viewable[i].ID == childID
+		}
+	}
+	if childID != 0 && !childAllowed {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Message: "Access denied",
+		})
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "A CSV file must be uploaded in the file field",
+		})
+		return
+	}
+	if header.Size > maxImportFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{
+			Message: "File is too large to import",
+		})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Failed to read file: " + err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	result, err := services.ImportBooks(file, services.BookImport{
+		Children: children,
+		ChildID:  childID,
+		DryRun:   dryRun,
+	}, middleware.GetActor(c))
+	if errors.Is(err, services.ErrInvalidImportFile) || errors.Is(err, services.ErrImportTooLarge) ||
+		errors.Is(err, services.ErrImportChildRequired) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to import books: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -82,7 +83,7 @@ func (suite *ISBNOptimizationTestSuite) TestISBNLookupDatabaseCache() {
 	assert.Equal(suite.T(), "9780061120084", response.ISBN)
 	assert.Equal(suite.T(), "To Kill a Mockingbird", response.Title)
 	assert.Equal(suite.T(), "Harper Lee", response.Author)
-	assert.Equal(suite.T(), "http://example.com/mockingbird.jpg", response.CoverURL)
+	assert.Equal(suite.T(), fmt.Sprintf("http://localhost:8080/api/covers/%d", sharedBook.ID), response.CoverURL)
 	assert.Equal(suite.T(), &sharedBook.ID, response.SharedBookID)
 
 	// Should be fast (database lookup, not API call)
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/booktracker/backend/models"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// RunScheduledJobs handles the cron request of deployments without background workers.
+// The caller authenticates with "Authorization: Bearer $CRON_SECRET", which is how Vercel Cron calls it.
+func RunScheduledJobs(c *gin.Context) {
+	secret := os.Getenv("CRON_SECRET")
+	if secret == "" {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: "Scheduled jobs are not enabled",
+		})
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte("Bearer "+secret)) != 1 {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "Invalid cron secret",
+		})
+		return
+	}
+
+	result, err := services.RunScheduledJobs(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Scheduled jobs failed: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
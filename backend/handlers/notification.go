@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/booktracker/backend/middleware"
+	"github.com/booktracker/backend/models"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetNotifications handles listing the current user's notification feed with the unread count
+func GetNotifications(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	limit, offset := parsePagination(c)
+	notifications, err := services.GetNotifications(userID, c.Query("unread") == "true", limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get notifications: " + err.Error(),
+		})
+		return
+	}
+
+	unreadCount, err := services.CountUnreadNotifications(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get notifications: " + err.Error(),
+		})
+		return
+	}
+
+	responses := make([]models.NotificationResponse, len(notifications))
+	for i := range notifications {
+		responses[i] = convertNotificationToResponse(&notifications[i])
+	}
+
+	c.JSON(http.StatusOK, models.NotificationListResponse{
+		Notifications: responses,
+		UnreadCount:   unreadCount,
+	})
+}
+
+// GetUnreadNotificationCount handles the unread badge count, cheap enough to poll
+func GetUnreadNotificationCount(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	unreadCount, err := services.CountUnreadNotifications(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to count notifications: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unreadCount": unreadCount})
+}
+
+// MarkNotificationRead handles marking one notification as read
+func MarkNotificationRead(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid notification ID",
+		})
+		return
+	}
+
+	notification, err := services.MarkNotificationRead(userID, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, convertNotificationToResponse(notification))
+}
+
+// MarkAllNotificationsRead handles marking the whole feed as read
+func MarkAllNotificationsRead(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	updated, err := services.MarkAllNotificationsRead(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to mark notifications read: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": updated})
+}
+
+// GetNotificationMutes handles listing the notification types the current user muted
+func GetNotificationMutes(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	muted, err := services.GetNotificationMutes(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get muted notifications: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"muted": muted,
+		"types": services.NotificationTypes,
+	})
+}
+
+// MuteNotificationType handles muting one notification type
+func MuteNotificationType(c *gin.Context) {
+	setNotificationMuted(c, true)
+}
+
+// UnmuteNotificationType handles unmuting one notification type
+func UnmuteNotificationType(c *gin.Context) {
+	setNotificationMuted(c, false)
+}
+
+func setNotificationMuted(c *gin.Context, muted bool) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	if err := services.SetNotificationMuted(userID, c.Param("type"), muted); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func convertNotificationToResponse(notification *models.Notification) models.NotificationResponse {
+	return models.NotificationResponse{
+		ID:         notification.ID,
+		Type:       notification.Type,
+		Message:    notification.Message,
+		ActorID:    notification.ActorID,
+		ChildID:    notification.ChildID,
+		EntityType: notification.EntityType,
+		EntityID:   notification.EntityID,
+		Read:       notification.ReadAt != nil,
+		ReadAt:     notification.ReadAt,
+		CreatedAt:  notification.CreatedAt,
+	}
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+
+	"github.com/booktracker/backend/middleware"
+	"github.com/booktracker/backend/models"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetNotificationPreferences handles getting which emails the current user receives
+func GetNotificationPreferences(c *gin.Context) {
+	currentUser, _ := middleware.GetCurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	preferences, err := services.GetNotificationPreferences(currentUser.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get notification preferences: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, convertNotificationPreferencesToResponse(preferences))
+}
+
+// UpdateNotificationPreferences handles turning notification categories on or off
+func UpdateNotificationPreferences(c *gin.Context) {
+	currentUser, _ := middleware.GetCurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	var req models.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	changes := map[string]*bool{
+		services.NotificationInvitations:    req.Invitations,
+		services.NotificationDigests:        req.Digests,
+		services.NotificationReminders:      req.Reminders,
+		services.NotificationSecurityAlerts: req.SecurityAlerts,
+	}
+	for category, enabled := range changes {
+		if enabled == nil {
+			continue
+		}
+		if err := services.SetNotificationEnabled(currentUser.Email, category, *enabled); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Message: "Failed to update notification preferences: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	preferences, err := services.GetNotificationPreferences(currentUser.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get notification preferences: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, convertNotificationPreferencesToResponse(preferences))
+}
+
+// unsubscribeCategoryNames describe each category on the unsubscribe page
+var unsubscribeCategoryNames = map[string]string{
+	services.NotificationInvitations:    "invitation",
+	services.NotificationDigests:        "reading digest",
+	services.NotificationReminders:      "reminder",
+	services.NotificationSecurityAlerts: "security alert",
+}
+
+// unsubscribePage asks for confirmation before unsubscribing, and confirms once it is done
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Unsubscribe</title>
+</head>
+<body style="font-family: sans-serif; max-width: 32rem; margin: 4rem auto; padding: 0 1rem;">
+{{if .Done}}
+<h1>You have been unsubscribed</h1>
+<p>{{.Email}} will no longer receive {{.Category}} emails.</p>
+{{else}}
+<h1>Unsubscribe?</h1>
+<p>Stop sending {{.Category}} emails to {{.Email}}?</p>
+<form method="post" action="?token={{.Token}}">
+<input type="hidden" name="List-Unsubscribe" value="One-Click">
+<button type="submit">Unsubscribe</button>
+</form>
+{{end}}
+</body>
+</html>
+`))
+
+// ConfirmUnsubscribe handles opening an unsubscribe link (no authentication). Mail scanners and link
+// prefetchers follow links too, so this only shows a page that POSTs to Unsubscribe.
+func ConfirmUnsubscribe(c *gin.Context) {
+	token := c.Query("token")
+	email, category, err := services.ParseUnsubscribeToken(token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	renderUnsubscribePage(c, token, email, category, false)
+}
+
+// Unsubscribe handles RFC 8058 one-click unsubscribe POSTs from mail clients and from the confirmation
+// page (no authentication). Only a body of List-Unsubscribe=One-Click changes anything.
+func Unsubscribe(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Unsubscribe token is required",
+		})
+		return
+	}
+	if c.PostForm("List-Unsubscribe") != "One-Click" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "List-Unsubscribe=One-Click is required",
+		})
+		return
+	}
+
+	email, category, err := services.ParseUnsubscribeToken(token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+	if err := services.SetNotificationEnabled(email, category, false); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to unsubscribe: " + err.Error(),
+		})
+		return
+	}
+
+	// Mail clients get JSON; people who confirmed on the page get a page back
+	if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML {
+		renderUnsubscribePage(c, token, email, category, true)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "You have been unsubscribed",
+		"category": category,
+	})
+}
+
+func renderUnsubscribePage(c *gin.Context, token, email, category string, done bool) {
+	var page bytes.Buffer
+	err := unsubscribePage.Execute(&page, map[string]interface{}{
+		"Token":    token,
+		"Email":    email,
+		"Category": unsubscribeCategoryNames[category],
+		"Done":     done,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to render page: " + err.Error(),
+		})
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
+
+func convertNotificationPreferencesToResponse(preferences map[string]bool) models.NotificationPreferencesResponse {
+	return models.NotificationPreferencesResponse{
+		Invitations:    preferences[services.NotificationInvitations],
+		Digests:        preferences[services.NotificationDigests],
+		Reminders:      preferences[services.NotificationReminders],
+		SecurityAlerts: preferences[services.NotificationSecurityAlerts],
+	}
+}
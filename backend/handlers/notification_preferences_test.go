@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnsubscribeHandlerTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	path   string
+}
+
+func (suite *UnsubscribeHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *UnsubscribeHandlerTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+
+	suite.router = gin.New()
+	suite.router.GET("/public/unsubscribe", ConfirmUnsubscribe)
+	suite.router.POST("/public/unsubscribe", Unsubscribe)
+
+	suite.path = "/public/unsubscribe?token=" + url.QueryEscape(services.UnsubscribeToken("parent@example.com", services.NotificationDigests))
+}
+
+func (suite *UnsubscribeHandlerTestSuite) TearDownTest() {
+	config.CleanupTestDatabase()
+}
+
+func (suite *UnsubscribeHandlerTestSuite) digestsEnabled() bool {
+	enabled, err := services.IsNotificationEnabled("parent@example.com", services.NotificationDigests)
+	assert.NoError(suite.T(), err)
+	return enabled
+}
+
+func (suite *UnsubscribeHandlerTestSuite) post(body, accept string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", suite.path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *UnsubscribeHandlerTestSuite) TestOpeningTheLinkOnlyAsks() {
+	req, _ := http.NewRequest("GET", suite.path, nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `<form method="post"`)
+	assert.Contains(suite.T(), w.Body.String(), `name="List-Unsubscribe" value="One-Click"`)
+	assert.Contains(suite.T(), w.Body.String(), "reading digest emails to parent@example.com")
+	assert.True(suite.T(), suite.digestsEnabled())
+
+	req, _ = http.NewRequest("GET", "/public/unsubscribe?token=forged", nil)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *UnsubscribeHandlerTestSuite) TestOneClickPost() {
+	// A POST without the RFC 8058 body changes nothing
+	w := suite.post("", "")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.True(suite.T(), suite.digestsEnabled())
+
+	w = suite.post("List-Unsubscribe=One-Click", "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"category":"DIGESTS"`)
+	assert.False(suite.T(), suite.digestsEnabled())
+
+	// The confirmation page's form gets a page back
+	w = suite.post("List-Unsubscribe=One-Click", "text/html,application/xhtml+xml,*/*;q=0.8")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "You have been unsubscribed")
+}
+
+func TestUnsubscribeHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(UnsubscribeHandlerTestSuite))
+}
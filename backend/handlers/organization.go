@@ -0,0 +1,479 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/booktracker/backend/middleware"
+	"github.com/booktracker/backend/models"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateOrganization handles creating an organization (global admin only)
+func CreateOrganization(c *gin.Context) {
+	var req models.CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	organization, err := services.CreateOrganization(req, middleware.GetActor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to create organization: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, convertOrganizationToResponse(organization, 0))
+}
+
+// GetOrganizations handles listing organizations; organization admins only get their own
+func GetOrganizations(c *gin.Context) {
+	currentUser, _ := middleware.GetCurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	var organizations []models.Organization
+	if currentUser.IsAdmin {
+		var err error
+		organizations, err = services.GetAllOrganizations()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Message: "Failed to get organizations: " + err.Error(),
+			})
+			return
+		}
+	} else {
+		organization, err := services.GetOrganizationByID(*currentUser.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Message: err.Error(),
+			})
+			return
+		}
+		organizations = []models.Organization{*organization}
+	}
+
+	responses := make([]models.OrganizationResponse, len(organizations))
+	for i := range organizations {
+		count, err := services.CountOrganizationMembers(organizations[i].ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Message: "Failed to get organizations: " + err.Error(),
+			})
+			return
+		}
+		responses[i] = convertOrganizationToResponse(&organizations[i], count)
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// DeleteOrganization handles deleting an organization (global admin only)
+func DeleteOrganization(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid organization ID",
+		})
+		return
+	}
+
+	if err := services.DeleteOrganization(uint(id), middleware.GetActor(c)); err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetOrganizationMembers handles listing the users of an organization (organization admins)
+func GetOrganizationMembers(c *gin.Context) {
+	organization, ok := requireOrganizationAdmin(c)
+	if !ok {
+		return
+	}
+
+	users, err := services.GetUsersByOrganization(organization.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get members: " + err.Error(),
+		})
+		return
+	}
+
+	responses := make([]models.UserResponse, len(users))
+	for i := range users {
+		responses[i] = convertUserToResponse(&users[i])
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// InviteOrganizationMember handles inviting an existing account to an organization (organization admins).
+// The user joins only once they accept.
+func InviteOrganizationMember(c *gin.Context) {
+	organization, ok := requireOrganizationAdmin(c)
+	if !ok {
+		return
+	}
+
+	var req models.InviteOrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	user, err := services.GetUserByEmail(req.Email)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: "No account exists for that email",
+		})
+		return
+	}
+
+	invitation, err := services.InviteOrganizationMember(organization.ID, user.ID, req.Role, middleware.GetActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, convertOrganizationInvitationToResponse(invitation))
+}
+
+// GetOrganizationInvitations handles listing the pending invitations of an organization (organization admins)
+func GetOrganizationInvitations(c *gin.Context) {
+	organization, ok := requireOrganizationAdmin(c)
+	if !ok {
+		return
+	}
+
+	invitations, err := services.GetOrganizationInvitations(organization.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get invitations: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, convertOrganizationInvitationsToResponse(invitations))
+}
+
+// RevokeOrganizationInvitation handles withdrawing a pending invitation (organization admins)
+func RevokeOrganizationInvitation(c *gin.Context) {
+	organization, ok := requireOrganizationAdmin(c)
+	if !ok {
+		return
+	}
+
+	invitationID, err := strconv.ParseUint(c.Param("invitationId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid invitation ID",
+		})
+		return
+	}
+
+	if err := services.RevokeOrganizationInvitation(organization.ID, uint(invitationID), middleware.GetActor(c)); err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetMyOrganizationInvitations handles listing the organization invitations waiting for the current user
+func GetMyOrganizationInvitations(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	invitations, err := services.GetOrganizationInvitationsForUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get invitations: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, convertOrganizationInvitationsToResponse(invitations))
+}
+
+// AcceptOrganizationInvitation handles the current user joining the organization that invited them
+func AcceptOrganizationInvitation(c *gin.Context) {
+	userID, invitationID, ok := parseOwnInvitation(c)
+	if !ok {
+		return
+	}
+
+	user, err := services.AcceptOrganizationInvitation(invitationID, userID, middleware.GetActor(c))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrOrganizationInvitationNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, convertUserToResponse(user))
+}
+
+// DeclineOrganizationInvitation handles the current user turning down an organization invitation
+func DeclineOrganizationInvitation(c *gin.Context) {
+	userID, invitationID, ok := parseOwnInvitation(c)
+	if !ok {
+		return
+	}
+
+	if err := services.DeclineOrganizationInvitation(invitationID, userID, middleware.GetActor(c)); err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// parseOwnInvitation gets the current user and the :id invitation parameter
+func parseOwnInvitation(c *gin.Context) (uint, uint, bool) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return 0, 0, false
+	}
+
+	invitationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid invitation ID",
+		})
+		return 0, 0, false
+	}
+
+	return userID, uint(invitationID), true
+}
+
+// UpdateOrganizationMember handles changing a member's organization role (organization admins)
+func UpdateOrganizationMember(c *gin.Context) {
+	organization, ok := requireOrganizationAdmin(c)
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	var req models.UpdateOrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	member, err := services.UpdateOrganizationMember(organization.ID, uint(memberID), req.Role, middleware.GetActor(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, convertUserToResponse(member))
+}
+
+// RemoveOrganizationMember handles taking a user out of an organization without deleting the account (organization admins)
+func RemoveOrganizationMember(c *gin.Context) {
+	organization, ok := requireOrganizationAdmin(c)
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	if _, err := services.RemoveOrganizationMember(organization.ID, uint(memberID), middleware.GetActor(c)); err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetOrganizationClassrooms handles listing the classrooms of an organization (organization admins)
+func GetOrganizationClassrooms(c *gin.Context) {
+	organization, ok := requireOrganizationAdmin(c)
+	if !ok {
+		return
+	}
+
+	classrooms, err := services.GetClassroomsByOrganization(organization.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get classrooms: " + err.Error(),
+		})
+		return
+	}
+
+	responses := make([]models.ClassroomResponse, len(classrooms))
+	for i := range classrooms {
+		count, err := services.CountClassroomStudents(classrooms[i].ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Message: "Failed to get classrooms: " + err.Error(),
+			})
+			return
+		}
+		responses[i] = convertClassroomToResponse(&classrooms[i], count, false)
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// SetUserOrganization handles moving any user into or out of an organization (global admin only)
+func SetUserOrganization(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	var req models.SetUserOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	user, err := services.SetUserOrganization(uint(id), req.OrganizationID, req.Role, middleware.GetActor(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, convertUserToResponse(user))
+}
+
+// requireOrganizationAdmin parses the :id organization parameter and checks the current user administers it
+func requireOrganizationAdmin(c *gin.Context) (*models.Organization, bool) {
+	currentUser, _ := middleware.GetCurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return nil, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid organization ID",
+		})
+		return nil, false
+	}
+
+	if !services.IsOrganizationAdmin(currentUser, uint(id)) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Message: "Organization admin access required",
+		})
+		return nil, false
+	}
+
+	organization, err := services.GetOrganizationByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return nil, false
+	}
+
+	return organization, true
+}
+
+func convertOrganizationToResponse(organization *models.Organization, memberCount int) models.OrganizationResponse {
+	return models.OrganizationResponse{
+		ID:          organization.ID,
+		Name:        organization.Name,
+		MemberCount: memberCount,
+		CreatedAt:   organization.CreatedAt,
+	}
+}
+
+func convertOrganizationInvitationsToResponse(invitations []models.OrganizationInvitation) []models.OrganizationInvitationResponse {
+	responses := make([]models.OrganizationInvitationResponse, len(invitations))
+	for i := range invitations {
+		responses[i] = convertOrganizationInvitationToResponse(&invitations[i])
+	}
+	return responses
+}
+
+func convertOrganizationInvitationToResponse(invitation *models.OrganizationInvitation) models.OrganizationInvitationResponse {
+	return models.OrganizationInvitationResponse{
+		ID:               invitation.ID,
+		OrganizationID:   invitation.OrganizationID,
+		OrganizationName: invitation.Organization.Name,
+		Role:             invitation.Role,
+		User:             convertUserToResponse(&invitation.User),
+		CreatedAt:        invitation.CreatedAt,
+	}
+}
+
+func convertUserToResponse(user *models.User) models.UserResponse {
+	return models.UserResponse{
+		ID:                 user.ID,
+		Email:              user.Email,
+		FirstName:          user.FirstName,
+		LastName:           user.LastName,
+		IsAdmin:            user.IsAdmin,
+		EmailVerified:      user.EmailVerified,
+		OrganizationID:     user.OrganizationID,
+		OrgRole:            user.OrgRole,
+		Locale:             user.Locale,
+		ReadingLevelSystem: user.ReadingLevelSystem,
+		CreatedAt:          user.CreatedAt,
+	}
+}
@@ -1,10 +1,11 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/booktracker/backend/middleware"
 	"github.com/booktracker/backend/models"
@@ -32,35 +33,70 @@ func GenerateMonthlyPDFReport(c *gin.Context) {
 		return
 	}
 
-	// Parse year and month from query params
-	yearParam := c.Query("year")
-	monthParam := c.Query("month")
-	
-	if yearParam == "" || monthParam == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Message: "Year and month parameters are required",
+	year, month, ok := parseYearMonth(c)
+	if !ok {
+		return
+	}
+	template, ok := parseReportTemplate(c)
+	if !ok {
+		return
+	}
+
+	// Check permission to access this child
+	hasPermission, err := middleware.GetPermissionCache(c).GetOrCheck(userID, uint(childID), "VIEW")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to check permission: " + err.Error(),
+		})
+		return
+	}
+	if !hasPermission {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Message: "Access denied",
 		})
 		return
 	}
 
-	year, err := strconv.Atoi(yearParam)
+	// Generate PDF
+	report, err := services.GenerateBooksPDF(uint(childID), services.MonthRange(year, month), template)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Message: "Invalid year parameter",
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to generate PDF: " + err.Error(),
 		})
 		return
 	}
 
-	month, err := strconv.Atoi(monthParam)
-	if err != nil || month < 1 || month > 12 {
+	serveBooksReport(c, report)
+}
+
+// GeneratePDFReport generates a PDF report for a child's books over a preset or custom period
+func GeneratePDFReport(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	childID, err := strconv.ParseUint(c.Param("childId"), 10, 32)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Message: "Invalid month parameter",
+			Message: "Invalid child ID",
 		})
 		return
 	}
 
-	// Check permission to access this child
-	hasPermission, err := services.CheckChildPermission(userID, uint(childID), "VIEW")
+	reportRange, ok := parseReportRange(c)
+	if !ok {
+		return
+	}
+	template, ok := parseReportTemplate(c)
+	if !ok {
+		return
+	}
+
+	hasPermission, err := middleware.GetPermissionCache(c).GetOrCheck(userID, uint(childID), "VIEW")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Message: "Failed to check permission: " + err.Error(),
@@ -74,8 +110,7 @@ func GenerateMonthlyPDFReport(c *gin.Context) {
 		return
 	}
 
-	// Generate PDF
-	pdfPath, err := services.GenerateMonthlyBooksPDF(uint(childID), year, month)
+	report, err := services.GenerateBooksPDF(uint(childID), reportRange, template)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Message: "Failed to generate PDF: " + err.Error(),
@@ -83,28 +118,192 @@ func GenerateMonthlyPDFReport(c *gin.Context) {
 		return
 	}
 
-	// Ensure cleanup after serving
-	defer func() {
-		os.Remove(pdfPath)
-	}()
+	serveBooksReport(c, report)
+}
+
+// GenerateFamilyPDFReport generates one PDF covering several children: those listed in childIds,
+// or every child the user can view
+func GenerateFamilyPDFReport(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	reportRange, ok := parseReportRange(c)
+	if !ok {
+		return
+	}
+	template, ok := parseReportTemplate(c)
+	if !ok {
+		return
+	}
+
+	children, ok := parseChildIDs(c, userID)
+	if !ok {
+		return
+	}
+
+	if len(children) == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "No children to report on",
+		})
+		return
+	}
 
-	// Get file info
-	fileInfo, err := os.Stat(pdfPath)
+	report, err := services.GenerateBooksPDFForChildren(children, reportRange, template)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Message: "Failed to access generated PDF",
+			Message: "Failed to generate PDF: " + err.Error(),
 		})
 		return
 	}
 
+	serveBooksReport(c, report)
+}
+
+// parseReportRange reads the report period: preset=month|year|school-year|semester with year
+// (and month or term), or from and to dates. Without a preset, year and month select a month.
+func parseReportRange(c *gin.Context) (services.ReportRange, bool) {
+	preset := c.Query("preset")
+	if preset == "" {
+		preset = services.ReportPresetMonth
+		if c.Query("from") != "" || c.Query("to") != "" {
+			preset = services.ReportPresetCustom
+		}
+	}
+
+	switch preset {
+	case services.ReportPresetMonth:
+		year, month, ok := parseYearMonth(c)
+		return services.MonthRange(year, month), ok
+
+	case services.ReportPresetCustom:
+		from, fromErr := time.Parse("2006-01-02", c.Query("from"))
+		to, toErr := time.Parse("2006-01-02", c.Query("to"))
+		if fromErr != nil || toErr != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Message: "From and to parameters must be dates formatted YYYY-MM-DD",
+			})
+			return services.ReportRange{}, false
+		}
+		reportRange, err := services.DateRange(from, to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Message: "Invalid date range: " + err.Error(),
+			})
+			return services.ReportRange{}, false
+		}
+		return reportRange, true
+
+	case services.ReportPresetYear, services.ReportPresetSchoolYear, services.ReportPresetSemester:
+		year, err := strconv.Atoi(c.Query("year"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Message: "Invalid year parameter",
+			})
+			return services.ReportRange{}, false
+		}
+		switch preset {
+		case services.ReportPresetYear:
+			return services.YearRange(year), true
+		case services.ReportPresetSchoolYear:
+			return services.SchoolYearRange(year), true
+		}
+		reportRange, err := services.SemesterRange(year, c.Query("term"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Message: "Invalid term parameter: " + err.Error(),
+			})
+			return services.ReportRange{}, false
+		}
+		return reportRange, true
+	}
+
+	c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		Message: "Invalid preset parameter",
+	})
+	return services.ReportRange{}, false
+}
+
+// parseChildIDs loads the children listed in childIds, checking the user can view each, or
+// every child the user can view when the parameter is absent
+func parseChildIDs(c *gin.Context, userID uint) ([]*models.Child, bool) {
+	var children []*models.Child
+	if childIDsParam := c.Query("childIds"); childIDsParam != "" {
+		for _, idParam := range strings.Split(childIDsParam, ",") {
+			childID, err := strconv.ParseUint(strings.TrimSpace(idParam), 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{
+					Message: "Invalid child ID",
+				})
+				return nil, false
+			}
+
+			hasPermission, err := middleware.GetPermissionCache(c).GetOrCheck(userID, uint(childID), "VIEW")
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+					Message: "Failed to check permission: " + err.Error(),
+				})
+				return nil, false
+			}
+			if !hasPermission {
+				c.JSON(http.StatusForbidden, models.ErrorResponse{
+					Message: "Access denied",
+				})
+				return nil, false
+			}
+
+			child, err := services.GetChildByID(uint(childID))
+			if err != nil {
+				c.JSON(http.StatusNotFound, models.ErrorResponse{
+					Message: "Child not found",
+				})
+				return nil, false
+			}
+			children = append(children, child)
+		}
+	} else {
+		viewable, err := services.GetChildrenWithPermission(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Message: "Failed to get children: " + err.Error(),
+			})
+			return nil, false
+		}
+		for i := range viewable {
+			children = append(children, &viewable[i])
+		}
+	}
+
+	return children, true
+}
+
+// parseReportTemplate reads the template parameter: grid (the default), log or certificate
+func parseReportTemplate(c *gin.Context) (string, bool) {
+	template := c.DefaultQuery("template", services.ReportTemplateGrid)
+	if !services.IsReportTemplate(template) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid template parameter: " + services.ErrInvalidReportTemplate.Error(),
+		})
+		return "", false
+	}
+	return template, true
+}
+
+// serveBooksReport streams a rendered report to the response as a download
+func serveBooksReport(c *gin.Context, report *services.BooksReport) {
 	// Set headers for PDF download
-	filename := filepath.Base(pdfPath)
 	c.Header("Content-Description", "File Transfer")
 	c.Header("Content-Transfer-Encoding", "binary")
-	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Header("Content-Disposition", "attachment; filename="+report.Filename)
 	c.Header("Content-Type", "application/pdf")
-	c.Header("Content-Length", strconv.FormatInt(fileInfo.Size(), 10))
+	c.Status(http.StatusOK)
 
-	// Serve the PDF file
-	c.File(pdfPath)
-}
\ No newline at end of file
+	// The status is already sent, so a failure part way can only be logged
+	if err := report.Write(c.Writer); err != nil {
+		log.Printf("Failed to write PDF %s: %v", report.Filename, err)
+	}
+}
@@ -30,7 +30,7 @@ func GetPermissionsByChild(c *gin.Context) {
 	}
 
 	// Check if user has EDIT permission for this child (only owners and editors can see permissions)
-	hasPermission, err := services.CheckChildPermission(userID, uint(childID), "EDIT")
+	hasPermission, err := middleware.GetPermissionCache(c).GetOrCheck(userID, uint(childID), "EDIT")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Message: "Failed to check permission: " + err.Error(),
@@ -105,7 +105,7 @@ func DeletePermissionByID(c *gin.Context) {
 	}
 
 	// Check if user has EDIT permission for this child
-	hasPermission, err := services.CheckChildPermission(userID, permission.ChildID, "EDIT")
+	hasPermission, err := middleware.GetPermissionCache(c).GetOrCheck(userID, permission.ChildID, "EDIT")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Message: "Failed to check permission: " + err.Error(),
@@ -119,7 +119,7 @@ func DeletePermissionByID(c *gin.Context) {
 		return
 	}
 
-	err = services.DeletePermissionByID(uint(permissionID))
+	err = services.DeletePermissionByID(uint(permissionID), middleware.GetActor(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Message: "Failed to delete permission: " + err.Error(),
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/booktracker/backend/models"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ConvertReadingLevel gives the approximate equivalents of a level in the other systems
+func ConvertReadingLevel(c *gin.Context) {
+	system, err := services.ParseReadingLevelSystem(c.Query("system"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid system parameter: " + err.Error(),
+		})
+		return
+	}
+
+	conversion, err := services.ConvertReadingLevel(system, c.Query("level"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid level parameter: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, conversion)
+}
+
+// GetReadingLevelChart lists where each system stands at the start of each grade
+func GetReadingLevelChart(c *gin.Context) {
+	c.JSON(http.StatusOK, services.ReadingLevelChart())
+}
+
+// readingLevelSystem is the levelSystem query parameter, or else the user's preferred system
+func readingLevelSystem(c *gin.Context, userID uint) (string, bool) {
+	if c.Query("levelSystem") != "" {
+		system, err := services.ParseReadingLevelSystem(c.Query("levelSystem"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Message: "Invalid levelSystem parameter: " + err.Error(),
+			})
+			return "", false
+		}
+		return system, true
+	}
+
+	user, err := services.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get user: " + err.Error(),
+		})
+		return "", false
+	}
+	if user.ReadingLevelSystem == "" {
+		return models.ReadingLevelLexile, true
+	}
+	return user.ReadingLevelSystem, true
+}
+
+// filterBooksByReadingLevel keeps the books from the minLevel to the maxLevel query parameters,
+// which are levels in system
+func filterBooksByReadingLevel(c *gin.Context, books []models.Book, system string) ([]models.Book, bool) {
+	if c.Query("minLevel") == "" && c.Query("maxLevel") == "" {
+		return books, true
+	}
+	filtered, err := services.FilterBooksByReadingLevel(books, system, c.Query("minLevel"), c.Query("maxLevel"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid level filter: " + err.Error(),
+		})
+		return nil, false
+	}
+	return filtered, true
+}
+
+// readingLevelResponses are the levels entered for a book
+func readingLevelResponses(book *models.Book) []models.ReadingLevelResponse {
+	levels := services.BookReadingLevels(book)
+	if len(levels) == 0 {
+		return nil
+	}
+	responses := make([]models.ReadingLevelResponse, len(levels))
+	for i, level := range levels {
+		responses[i] = level.Response()
+	}
+	return responses
+}
+
+// setReadingLevels fills in the level in system of each response, which is for the book at the
+// same index
+func setReadingLevels(responses []models.BookResponse, books []models.Book, system string) {
+	for i := range responses {
+		if level := services.BookReadingLevelIn(&books[i], system); level != nil {
+			response := level.Response()
+			responses[i].ReadingLevel = &response
+		}
+	}
+}
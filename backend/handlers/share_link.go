@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/booktracker/backend/middleware"
+	"github.com/booktracker/backend/models"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateShareLink handles creating a public read-only link to a child (owner only)
+func CreateShareLink(c *gin.Context) {
+	childID, ok := requireChildOwner(c, "Only the owner can share a child publicly")
+	if !ok {
+		return
+	}
+
+	var req models.CreateShareLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	link, err := services.CreateShareLink(childID, req, middleware.GetActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Failed to create share link: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, convertShareLinkToResponse(link))
+}
+
+// GetShareLinks handles listing the share links of a child (owner only)
+func GetShareLinks(c *gin.Context) {
+	childID, ok := requireChildOwner(c, "Only the owner can view share links")
+	if !ok {
+		return
+	}
+
+	links, err := services.GetShareLinksByChild(childID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get share links: " + err.Error(),
+		})
+		return
+	}
+
+	responses := make([]models.ShareLinkResponse, len(links))
+	for i := range links {
+		responses[i] = convertShareLinkToResponse(&links[i])
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+// RevokeShareLink handles revoking a share link (owner of the child only)
+func RevokeShareLink(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid share link ID",
+		})
+		return
+	}
+
+	link, err := services.GetShareLinkByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	child, err := services.GetChildByID(link.ChildID)
+	if err != nil || child.OwnerID != userID {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Message: "Only the owner can revoke share links",
+		})
+		return
+	}
+
+	revoked, err := services.RevokeShareLink(uint(id), middleware.GetActor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to revoke share link: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, convertShareLinkToResponse(revoked))
+}
+
+// GetSharedReadingLog handles the public view of a child's books
+func GetSharedReadingLog(c *gin.Context) {
+	_, child, ok := openShareLink(c)
+	if !ok {
+		return
+	}
+
+	books, err := services.GetBooksByChild(child.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get books: " + err.Error(),
+		})
+		return
+	}
+
+	bookResponses := convertSharedBooksToResponses(books)
+	c.JSON(http.StatusOK, models.SharedReadingLogResponse{
+		Child:      convertChildToSharedResponse(child),
+		Books:      bookResponses,
+		TotalBooks: len(bookResponses),
+	})
+}
+
+// GetSharedMonthlyReport handles the public monthly report as JSON
+func GetSharedMonthlyReport(c *gin.Context) {
+	year, month, ok := parseYearMonth(c)
+	if !ok {
+		return
+	}
+
+	_, child, ok := openShareLink(c)
+	if !ok {
+		return
+	}
+
+	books, err := services.GetBooksByChildAndMonth(child.ID, year, month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get books: " + err.Error(),
+		})
+		return
+	}
+
+	bookResponses := convertSharedBooksToResponses(books)
+	c.JSON(http.StatusOK, models.SharedMonthlyReportResponse{
+		Child:      convertChildToSharedResponse(child),
+		Year:       year,
+		Month:      month,
+		Books:      bookResponses,
+		TotalBooks: len(bookResponses),
+	})
+}
+
+// GetSharedMonthlyPDF handles the public monthly report as PDF
+func GetSharedMonthlyPDF(c *gin.Context) {
+	year, month, ok := parseYearMonth(c)
+	if !ok {
+		return
+	}
+
+	_, child, ok := openShareLink(c)
+	if !ok {
+		return
+	}
+
+	report, err := services.GenerateMonthlyBooksPDFForChild(child, year, month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to generate PDF: " + err.Error(),
+		})
+		return
+	}
+
+	serveBooksReport(c, report)
+}
+
+// requireChildOwner parses the :id child parameter and checks the current user owns it
+func requireChildOwner(c *gin.Context, forbiddenMessage string) (uint, bool) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return 0, false
+	}
+
+	idParam := c.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid child ID",
+		})
+		return 0, false
+	}
+
+	child, err := services.GetChildByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return 0, false
+	}
+
+	if child.OwnerID != userID {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Message: forbiddenMessage,
+		})
+		return 0, false
+	}
+
+	return child.ID, true
+}
+
+// openShareLink resolves the :token parameter, answering 404 for unusable links
+func openShareLink(c *gin.Context) (*models.ShareLink, *models.Child, bool) {
+	link, child, err := services.OpenShareLink(c.Param("token"))
+	if err != nil {
+		if errors.Is(err, services.ErrShareLinkUnavailable) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Message: err.Error(),
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Message: "Failed to open share link: " + err.Error(),
+			})
+		}
+		return nil, nil, false
+	}
+	return link, child, true
+}
+
+// parseYearMonth reads the required year and month query parameters
+func parseYearMonth(c *gin.Context) (int, int, bool) {
+	yearParam := c.Query("year")
+	monthParam := c.Query("month")
+	if yearParam == "" || monthParam == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Year and month parameters are required",
+		})
+		return 0, 0, false
+	}
+
+	year, err := strconv.Atoi(yearParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid year parameter",
+		})
+		return 0, 0, false
+	}
+
+	month, err := strconv.Atoi(monthParam)
+	if err != nil || month < 1 || month > 12 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid month parameter",
+		})
+		return 0, 0, false
+	}
+
+	return year, month, true
+}
+
+func convertShareLinkToResponse(link *models.ShareLink) models.ShareLinkResponse {
+	return models.ShareLinkResponse{
+		ID:           link.ID,
+		Token:        link.Token,
+		ChildID:      link.ChildID,
+		HideLastName: link.HideLastName,
+		ExpiresAt:    link.ExpiresAt,
+		RevokedAt:    link.RevokedAt,
+		ViewCount:    link.ViewCount,
+		LastViewedAt: link.LastViewedAt,
+		CreatedAt:    link.CreatedAt,
+	}
+}
+
+func convertChildToSharedResponse(child *models.Child) models.SharedChildResponse {
+	return models.SharedChildResponse{
+		FirstName: child.FirstName,
+		LastName:  child.LastName,
+		Grade:     child.Grade,
+	}
+}
+
+// convertSharedBooksToResponses drops internal IDs from books shown publicly
+func convertSharedBooksToResponses(books []models.Book) []models.BookResponse {
+	responses := convertBooksToResponses(books)
+	for i := range responses {
+		responses[i].ChildID = 0
+		responses[i].SharedBookID = nil
+	}
+	return responses
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ShareLinkHandlerTestSuite struct {
+	suite.Suite
+	router *gin.Engine
+	link   *models.ShareLink
+}
+
+func (suite *ShareLinkHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *ShareLinkHandlerTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+	services.SharedPermissionCache().Clear()
+
+	suite.router = gin.New()
+	public := suite.router.Group("/public/share")
+	{
+		public.GET("/:token", GetSharedReadingLog)
+		public.GET("/:token/monthly", GetSharedMonthlyReport)
+	}
+
+	owner, err := services.CreateUser(models.CreateUserRequest{
+		Email:     "owner@example.com",
+		Password:  "password123",
+		FirstName: "Owner",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+
+	child, err := services.CreateChild(models.CreateChildRequest{
+		FirstName: "Test",
+		LastName:  "Child",
+		Grade:     "3rd",
+	}, owner.ID)
+	assert.NoError(suite.T(), err)
+
+	_, err = services.CreateCustomBook(models.CreateCustomBookRequest{
+		Title:    "Shared Title",
+		Author:   "Author",
+		DateRead: "2024-03-05",
+		ChildID:  child.ID,
+	}, services.SystemActor)
+	assert.NoError(suite.T(), err)
+
+	suite.link, err = services.CreateShareLink(child.ID, models.CreateShareLinkRequest{HideLastName: true}, services.Actor{UserID: owner.ID})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *ShareLinkHandlerTestSuite) TearDownTest() {
+	config.CleanupTestDatabase()
+}
+
+func (suite *ShareLinkHandlerTestSuite) TestGetSharedReadingLog() {
+	req, _ := http.NewRequest("GET", "/public/share/"+suite.link.Token, nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response models.SharedReadingLogResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "Test", response.Child.FirstName)
+	assert.Empty(suite.T(), response.Child.LastName)
+	assert.Equal(suite.T(), 1, response.TotalBooks)
+	assert.Equal(suite.T(), "Shared Title", response.Books[0].Title)
+	assert.Zero(suite.T(), response.Books[0].ChildID)
+}
+
+func (suite *ShareLinkHandlerTestSuite) TestGetSharedMonthlyReport() {
+	req, _ := http.NewRequest("GET", "/public/share/"+suite.link.Token+"/monthly?year=2024&month=3", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response models.SharedMonthlyReportResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), 1, response.TotalBooks)
+
+	req, _ = http.NewRequest("GET", "/public/share/"+suite.link.Token+"/monthly", nil)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *ShareLinkHandlerTestSuite) TestUnknownTokenIsNotFound() {
+	req, _ := http.NewRequest("GET", "/public/share/unknown", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func TestShareLinkHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(ShareLinkHandlerTestSuite))
+}
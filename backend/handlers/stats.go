@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/booktracker/backend/middleware"
+	"github.com/booktracker/backend/models"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetChildReadingStats summarises one child's reading over a window of months
+func GetChildReadingStats(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	childID, err := strconv.ParseUint(c.Param("childId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid child ID",
+		})
+		return
+	}
+
+	start, months, ok := parseStatsWindow(c)
+	if !ok {
+		return
+	}
+	topAuthors, ok := parseStatsTopAuthors(c)
+	if !ok {
+		return
+	}
+
+	// Check permission to access this child
+	hasPermission, err := middleware.GetPermissionCache(c).GetOrCheck(userID, uint(childID), "VIEW")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to check permission: " + err.Error(),
+		})
+		return
+	}
+	if !hasPermission {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Message: "Access denied",
+		})
+		return
+	}
+
+	stats, err := services.GetReadingStats([]uint{uint(childID)}, start, months, topAuthors)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get reading statistics: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetFamilyReadingStats summarises the reading of the children in childIds (default: every
+// child the user can view) together
+func GetFamilyReadingStats(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	start, months, ok := parseStatsWindow(c)
+	if !ok {
+		return
+	}
+	topAuthors, ok := parseStatsTopAuthors(c)
+	if !ok {
+		return
+	}
+
+	children, ok := parseChildIDs(c, userID)
+	if !ok {
+		return
+	}
+	if len(children) == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "No children to summarise",
+		})
+		return
+	}
+	childIDs := make([]uint, len(children))
+	for i, child := range children {
+		childIDs[i] = child.ID
+	}
+
+	stats, err := services.GetReadingStats(childIDs, start, months, topAuthors)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get reading statistics: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetLexileProgression follows a child's rolling median Lexile measure against their grade band
+func GetLexileProgression(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	childID, err := strconv.ParseUint(c.Param("childId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid child ID",
+		})
+		return
+	}
+
+	start, months, ok := parseStatsWindow(c)
+	if !ok {
+		return
+	}
+	window, err := strconv.Atoi(c.DefaultQuery("window", strconv.Itoa(services.DefaultLexileWindowMonths)))
+	if err != nil || window < 1 || window > services.MaxLexileWindowMonths {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid window parameter: " + services.ErrInvalidLexileWindow.Error(),
+		})
+		return
+	}
+
+	// Check permission to access this child
+	hasPermission, err := middleware.GetPermissionCache(c).GetOrCheck(userID, uint(childID), "VIEW")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to check permission: " + err.Error(),
+		})
+		return
+	}
+	if !hasPermission {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Message: "Access denied",
+		})
+		return
+	}
+
+	progression, err := services.GetLexileProgression(uint(childID), start, months, window)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get Lexile progression: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, progression)
+}
+
+// parseStatsWindow reads the window of months ending with end (YYYY-MM, default: this month)
+func parseStatsWindow(c *gin.Context) (time.Time, int, bool) {
+	end := time.Now()
+	if c.Query("end") != "" {
+		var err error
+		if end, err = time.Parse("2006-01", c.Query("end")); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Message: "End parameter must be a month formatted YYYY-MM",
+			})
+			return time.Time{}, 0, false
+		}
+	}
+
+	months, err := strconv.Atoi(c.DefaultQuery("months", strconv.Itoa(services.DefaultStatsMonths)))
+	if err != nil || months < 1 || months > services.MaxStatsMonths {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid months parameter: " + services.ErrInvalidStatsWindow.Error(),
+		})
+		return time.Time{}, 0, false
+	}
+
+	start := time.Date(end.Year(), end.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1-months, 0)
+	return start, months, true
+}
+
+// parseStatsTopAuthors reads how many of the most read authors to list
+func parseStatsTopAuthors(c *gin.Context) (int, bool) {
+	topAuthors, err := strconv.Atoi(c.DefaultQuery("topAuthors", strconv.Itoa(services.DefaultStatsTopAuthors)))
+	if err != nil || topAuthors < 1 || topAuthors > services.MaxStatsTopAuthors {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid topAuthors parameter: " + services.ErrInvalidStatsTopAuthors.Error(),
+		})
+		return 0, false
+	}
+	return topAuthors, true
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/booktracker/backend/middleware"
+	"github.com/booktracker/backend/models"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetTrash handles listing the deleted children and books the user can restore
+func GetTrash(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	children, err := services.GetDeletedChildrenByOwner(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get trash: " + err.Error(),
+		})
+		return
+	}
+
+	books, err := services.GetDeletedBooksForUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get trash: " + err.Error(),
+		})
+		return
+	}
+
+	response := models.TrashResponse{
+		Children:      make([]models.TrashedChildResponse, 0, len(children)),
+		Books:         make([]models.TrashedBookResponse, 0, len(books)),
+		RetentionDays: int(services.TrashRetention() / (24 * time.Hour)),
+	}
+
+	for i := range children {
+		bookCount, err := services.CountBooksDeletedWithChild(&children[i])
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Message: "Failed to get trash: " + err.Error(),
+			})
+			return
+		}
+
+		response.Children = append(response.Children, models.TrashedChildResponse{
+			ChildResponse: models.ChildResponse{
+				ID:          children[i].ID,
+				FirstName:   children[i].FirstName,
+				LastName:    children[i].LastName,
+				Grade:       children[i].Grade,
+				OwnerID:     children[i].OwnerID,
+				HouseholdID: children[i].HouseholdID,
+				CreatedAt:   children[i].CreatedAt,
+			},
+			DeletedAt: children[i].DeletedAt.Time,
+			BookCount: bookCount,
+		})
+	}
+
+	for i := range books {
+		response.Books = append(response.Books, models.TrashedBookResponse{
+			BookResponse: convertBookToResponse(&books[i]),
+			DeletedAt:    books[i].DeletedAt.Time,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RestoreChild handles restoring a deleted child (owner only)
+func RestoreChild(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid child ID",
+		})
+		return
+	}
+
+	child, err := services.GetDeletedChildByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if child.OwnerID != userID {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Message: "Only the owner can restore a child",
+		})
+		return
+	}
+
+	restored, err := services.RestoreChild(uint(id), middleware.GetActor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to restore child: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ChildResponse{
+		ID:          restored.ID,
+		FirstName:   restored.FirstName,
+		LastName:    restored.LastName,
+		Grade:       restored.Grade,
+		OwnerID:     restored.OwnerID,
+		HouseholdID: restored.HouseholdID,
+		CreatedAt:   restored.CreatedAt,
+	})
+}
+
+// RestoreBook handles restoring a deleted book
+func RestoreBook(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid book ID",
+		})
+		return
+	}
+
+	book, err := services.GetDeletedBookByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Check permission
+	hasPermission, err := middleware.GetPermissionCache(c).GetOrCheck(userID, book.ChildID, "EDIT")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to check permission: " + err.Error(),
+		})
+		return
+	}
+	if !hasPermission {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Message: "Access denied",
+		})
+		return
+	}
+
+	restored, err := services.RestoreBook(uint(id), middleware.GetActor(c))
+	if err != nil {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, convertBookToResponse(restored))
+}
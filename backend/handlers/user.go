@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -10,9 +11,23 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// GetAllUsers handles getting all users (admin only)
+// GetAllUsers handles getting all users (admin only); organization admins only get their organization's users
 func GetAllUsers(c *gin.Context) {
-	users, err := services.GetAllUsers()
+	currentUser, _ := middleware.GetCurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	var users []models.User
+	var err error
+	if currentUser.IsAdmin {
+		users, err = services.GetAllUsers()
+	} else {
+		users, err = services.GetUsersByOrganization(*currentUser.OrganizationID)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Message: "Failed to get users: " + err.Error(),
@@ -23,13 +38,17 @@ func GetAllUsers(c *gin.Context) {
 	var userResponses []models.UserResponse
 	for _, user := range users {
 		userResponses = append(userResponses, models.UserResponse{
-			ID:            user.ID,
-			Email:         user.Email,
-			FirstName:     user.FirstName,
-			LastName:      user.LastName,
-			IsAdmin:       user.IsAdmin,
-			EmailVerified: user.EmailVerified,
-			CreatedAt:     user.CreatedAt,
+			ID:                 user.ID,
+			Email:              user.Email,
+			FirstName:          user.FirstName,
+			LastName:           user.LastName,
+			IsAdmin:            user.IsAdmin,
+			EmailVerified:      user.EmailVerified,
+			OrganizationID:     user.OrganizationID,
+			OrgRole:            user.OrgRole,
+			Locale:             user.Locale,
+			ReadingLevelSystem: user.ReadingLevelSystem,
+			CreatedAt:          user.CreatedAt,
 		})
 	}
 
@@ -47,16 +66,25 @@ func GetUserByID(c *gin.Context) {
 		return
 	}
 
-	// Check if user is admin or requesting their own data
+	// Check if user is admin (of an organization) or requesting their own data, before anything
+	// is looked up, so other users cannot tell which IDs exist
 	currentUser, _ := middleware.GetCurrentUser(c)
-	if currentUser != nil && !currentUser.IsAdmin && currentUser.ID != uint(id) {
+	orgAdmin := currentUser != nil && !currentUser.IsAdmin && currentUser.ID != uint(id) &&
+		currentUser.OrgRole == services.OrgRoleAdmin && currentUser.OrganizationID != nil
+	if currentUser != nil && !currentUser.IsAdmin && !orgAdmin && currentUser.ID != uint(id) {
 		c.JSON(http.StatusForbidden, models.ErrorResponse{
 			Message: "Access denied",
 		})
 		return
 	}
 
-	user, err := services.GetUserByID(uint(id))
+	// Org admins only find the users of their own organization
+	var user *models.User
+	if orgAdmin {
+		user, err = services.GetOrganizationUser(*currentUser.OrganizationID, uint(id))
+	} else {
+		user, err = services.GetUserByID(uint(id))
+	}
 	if err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Message: err.Error(),
@@ -65,13 +93,17 @@ func GetUserByID(c *gin.Context) {
 	}
 
 	userResponse := models.UserResponse{
-		ID:            user.ID,
-		Email:         user.Email,
-		FirstName:     user.FirstName,
-		LastName:      user.LastName,
-		IsAdmin:       user.IsAdmin,
-		EmailVerified: user.EmailVerified,
-		CreatedAt:     user.CreatedAt,
+		ID:                 user.ID,
+		Email:              user.Email,
+		FirstName:          user.FirstName,
+		LastName:           user.LastName,
+		IsAdmin:            user.IsAdmin,
+		EmailVerified:      user.EmailVerified,
+		OrganizationID:     user.OrganizationID,
+		OrgRole:            user.OrgRole,
+		Locale:             user.Locale,
+		ReadingLevelSystem: user.ReadingLevelSystem,
+		CreatedAt:          user.CreatedAt,
 	}
 
 	c.JSON(http.StatusOK, userResponse)
@@ -127,19 +159,23 @@ func UpdateUser(c *gin.Context) {
 	}
 
 	userResponse := models.UserResponse{
-		ID:            user.ID,
-		Email:         user.Email,
-		FirstName:     user.FirstName,
-		LastName:      user.LastName,
-		IsAdmin:       user.IsAdmin,
-		EmailVerified: user.EmailVerified,
-		CreatedAt:     user.CreatedAt,
+		ID:                 user.ID,
+		Email:              user.Email,
+		FirstName:          user.FirstName,
+		LastName:           user.LastName,
+		IsAdmin:            user.IsAdmin,
+		EmailVerified:      user.EmailVerified,
+		OrganizationID:     user.OrganizationID,
+		OrgRole:            user.OrgRole,
+		Locale:             user.Locale,
+		ReadingLevelSystem: user.ReadingLevelSystem,
+		CreatedAt:          user.CreatedAt,
 	}
 
 	c.JSON(http.StatusOK, userResponse)
 }
 
-// DeleteUser handles deleting a user
+// DeleteUser handles deleting a user (admin only); organization admins can only delete their organization's users
 func DeleteUser(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.ParseUint(idParam, 10, 32)
@@ -150,7 +186,25 @@ func DeleteUser(c *gin.Context) {
 		return
 	}
 
-	err = services.DeleteUser(uint(id))
+	currentUser, _ := middleware.GetCurrentUser(c)
+	if currentUser == nil {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	if currentUser.IsAdmin {
+		err = services.DeleteUser(uint(id))
+	} else {
+		err = services.DeleteOrganizationUser(*currentUser.OrganizationID, uint(id))
+	}
+	if errors.Is(err, services.ErrProtectedOrganizationUser) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Message: err.Error(),
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UserHandlerTestSuite struct {
+	suite.Suite
+	orgAdmin *models.User
+	member   *models.User
+	outsider *models.User
+}
+
+func (suite *UserHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *UserHandlerTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+
+	organization := models.Organization{Name: "Maple School"}
+	assert.NoError(suite.T(), config.DB.Create(&organization).Error)
+	suite.orgAdmin = suite.createUser("admin@example.com", &organization.ID, services.OrgRoleAdmin)
+	suite.member = suite.createUser("member@example.com", &organization.ID, services.OrgRoleMember)
+	suite.outsider = suite.createUser("outsider@example.com", nil, "")
+}
+
+func (suite *UserHandlerTestSuite) TearDownTest() {
+	config.CleanupTestDatabase()
+}
+
+func (suite *UserHandlerTestSuite) createUser(email string, organizationID *uint, role string) *models.User {
+	user, err := services.CreateUser(models.CreateUserRequest{
+		Email:     email,
+		Password:  "password123",
+		FirstName: "Test",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	// The first account is made a global admin
+	user.IsAdmin, user.OrganizationID, user.OrgRole = false, organizationID, role
+	assert.NoError(suite.T(), config.DB.Save(user).Error)
+	return user
+}
+
+func (suite *UserHandlerTestSuite) get(as *models.User, id uint) int {
+	router := gin.New()
+	router.GET("/users/:id", func(c *gin.Context) {
+		c.Set("user", as)
+		c.Set("userId", as.ID)
+	}, GetUserByID)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/users/%d", id), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func (suite *UserHandlerTestSuite) TestUsersCannotTellWhichIDsExist() {
+	assert.Equal(suite.T(), http.StatusOK, suite.get(suite.outsider, suite.outsider.ID))
+	assert.Equal(suite.T(), http.StatusForbidden, suite.get(suite.outsider, suite.member.ID))
+	assert.Equal(suite.T(), http.StatusForbidden, suite.get(suite.outsider, 9999))
+	assert.Equal(suite.T(), http.StatusForbidden, suite.get(suite.member, suite.orgAdmin.ID))
+}
+
+func (suite *UserHandlerTestSuite) TestOrgAdminsOnlyFindTheirMembers() {
+	assert.Equal(suite.T(), http.StatusOK, suite.get(suite.orgAdmin, suite.member.ID))
+	// Users of no or another organization look like IDs that do not exist
+	assert.Equal(suite.T(), http.StatusNotFound, suite.get(suite.orgAdmin, suite.outsider.ID))
+	assert.Equal(suite.T(), http.StatusNotFound, suite.get(suite.orgAdmin, 9999))
+}
+
+func TestUserHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(UserHandlerTestSuite))
+}
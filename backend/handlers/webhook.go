@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/booktracker/backend/middleware"
+	"github.com/booktracker/backend/models"
+	"github.com/booktracker/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetWebhooks handles listing the current user's webhooks
+func GetWebhooks(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	webhooks, err := services.GetWebhooksByUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get webhooks: " + err.Error(),
+		})
+		return
+	}
+
+	responses := make([]models.WebhookResponse, len(webhooks))
+	for i := range webhooks {
+		responses[i] = convertWebhookToResponse(&webhooks[i])
+	}
+	c.JSON(http.StatusOK, responses)
+}
+
+// CreateWebhook handles registering a webhook. The signing secret is only returned here.
+func CreateWebhook(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return
+	}
+
+	var req models.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	webhook, err := services.CreateWebhook(userID, req, middleware.GetActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Failed to create webhook: " + err.Error(),
+		})
+		return
+	}
+
+	response := convertWebhookToResponse(webhook)
+	response.Secret = webhook.Secret
+	c.JSON(http.StatusCreated, response)
+}
+
+// UpdateWebhook handles changing a webhook's URL, events or active flag
+func UpdateWebhook(c *gin.Context) {
+	userID, id, ok := parseWebhookRequest(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	webhook, err := services.UpdateWebhook(userID, id, req, middleware.GetActor(c))
+	if errors.Is(err, services.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Failed to update webhook: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, convertWebhookToResponse(webhook))
+}
+
+// DeleteWebhook handles removing a webhook and its delivery log
+func DeleteWebhook(c *gin.Context) {
+	userID, id, ok := parseWebhookRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := services.DeleteWebhook(userID, id, middleware.GetActor(c)); err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetWebhookDeliveries handles the delivery log of a webhook, newest first
+func GetWebhookDeliveries(c *gin.Context) {
+	userID, id, ok := parseWebhookRequest(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := services.GetWebhookForUser(userID, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	limit, offset := parsePagination(c)
+	deliveries, err := services.GetWebhookDeliveries(webhook.ID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to get webhook deliveries: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// SendTestWebhook handles sending a test event to a webhook right away
+func SendTestWebhook(c *gin.Context) {
+	userID, id, ok := parseWebhookRequest(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := services.GetWebhookForUser(userID, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	delivery, err := services.SendTestWebhook(webhook)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Message: "Failed to send test webhook: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// parseWebhookRequest reads the current user and the webhook ID, writing the error response when either is missing
+func parseWebhookRequest(c *gin.Context) (uint, uint, bool) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Message: "User not found",
+		})
+		return 0, 0, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Message: "Invalid webhook ID",
+		})
+		return 0, 0, false
+	}
+	return userID, uint(id), true
+}
+
+func convertWebhookToResponse(webhook *models.Webhook) models.WebhookResponse {
+	return models.WebhookResponse{
+		ID:        webhook.ID,
+		URL:       webhook.URL,
+		Events:    services.WebhookEventList(webhook),
+		Active:    webhook.Active,
+		CreatedAt: webhook.CreatedAt,
+	}
+}
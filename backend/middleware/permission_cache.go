@@ -5,15 +5,12 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// PermissionCacheMiddleware adds permission cache to request context
+// PermissionCacheMiddleware adds the process-wide permission cache to request context
 func PermissionCacheMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Create new permission cache for this request
-		cache := services.NewPermissionCache(5 * 60 * 1000) // 5 minutes in milliseconds
-		
-		// Store in gin context for easy access
-		c.Set("permissionCache", cache)
-		
+		// Share one cache across requests so grants survive beyond a single request
+		c.Set("permissionCache", services.SharedPermissionCache())
+
 		c.Next()
 	}
 }
@@ -25,6 +22,6 @@ func GetPermissionCache(c *gin.Context) *services.PermissionCache {
 			return permCache
 		}
 	}
-	// Fallback to new cache if not found
-	return services.NewPermissionCache(5 * 60 * 1000)
-}
\ No newline at end of file
+	// Fallback to the shared cache if the middleware is not installed
+	return services.SharedPermissionCache()
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"gorm.io/gorm"
@@ -24,6 +25,16 @@ type User struct {
 	GoogleID       string    `json:"-" gorm:"index"` // Google OAuth user ID
 	AuthProvider   string    `json:"authProvider" gorm:"default:'local'"` // 'local', 'google'
 	ProfilePicture string    `json:"profilePicture,omitempty"` // OAuth profile picture URL
+
+	// Organization (school) membership; global admins use IsAdmin instead
+	OrganizationID *uint  `json:"organizationId,omitempty" gorm:"index:idx_user_organization"`
+	OrgRole        string `json:"orgRole,omitempty"` // 'ADMIN', 'TEACHER', 'MEMBER' when OrganizationID is set
+
+	// Language of emails sent to the user
+	Locale string `json:"locale" gorm:"default:'en'"` // 'en', 'es'
+
+	// Reading-level system books are shown, filtered and exported in
+	ReadingLevelSystem string `json:"readingLevelSystem" gorm:"default:'LEXILE'"` // 'LEXILE', 'ATOS', 'GUIDED_READING', 'GRADE'
 	
 	CreatedAt      time.Time `json:"createdAt"`
 	UpdatedAt      time.Time `json:"updatedAt"`
@@ -40,8 +51,10 @@ type Child struct {
 	LastName  string    `json:"lastName" gorm:"not null"`
 	Grade     string    `json:"grade" gorm:"not null"`
 	OwnerID   uint      `json:"ownerId" gorm:"not null;index:idx_child_owner"`
+	HouseholdID *uint   `json:"householdId,omitempty" gorm:"index:idx_child_household"` // Members of the household get access by role
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"` // Soft delete; purged after the retention period
 
 	// Relationships
 	Owner       User         `json:"owner,omitempty" gorm:"foreignKey:OwnerID"`
@@ -49,6 +62,83 @@ type Child struct {
 	Permissions []Permission `json:"permissions,omitempty" gorm:"foreignKey:ChildID"`
 }
 
+// Household groups the adults of a family; its members reach every child in it by role
+type Household struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"not null"`
+	CreatedByID uint      `json:"createdById" gorm:"not null"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+
+	// Relationships
+	Members  []HouseholdMember `json:"members,omitempty" gorm:"foreignKey:HouseholdID"`
+	Children []Child           `json:"children,omitempty" gorm:"foreignKey:HouseholdID"`
+}
+
+// HouseholdMember gives a user a role in a household.
+// OWNER manages the household and edits its children, EDITOR edits them, VIEWER views them.
+type HouseholdMember struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	HouseholdID uint      `json:"householdId" gorm:"not null;uniqueIndex:idx_household_user_unique"`
+	UserID      uint      `json:"userId" gorm:"not null;index:idx_household_member_user;uniqueIndex:idx_household_user_unique"`
+	Role        string    `json:"role" gorm:"not null;check:role IN ('OWNER', 'EDITOR', 'VIEWER')"`
+	CreatedAt   time.Time `json:"createdAt"`
+
+	// Relationships
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// Organization is a school or district with its own admins, teachers and classrooms
+type Organization struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// OrganizationInvitation asks an existing user to join an organization; they become a member only once they accept
+type OrganizationInvitation struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	OrganizationID uint      `json:"organizationId" gorm:"not null;uniqueIndex:idx_organization_invitation"`
+	UserID         uint      `json:"userId" gorm:"not null;uniqueIndex:idx_organization_invitation;index:idx_organization_invitation_user"`
+	Role           string    `json:"role" gorm:"not null"` // 'ADMIN', 'TEACHER', 'MEMBER'
+	InvitedByID    uint      `json:"invitedById" gorm:"not null"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+
+	// Relationships
+	Organization Organization `json:"organization,omitempty" gorm:"foreignKey:OrganizationID"`
+	User         User         `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// Classroom is owned by a teacher; parents link children with the join code and the teacher gets VIEW access
+type Classroom struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	Name           string    `json:"name" gorm:"not null"`
+	TeacherID      uint      `json:"teacherId" gorm:"not null;index:idx_classroom_teacher"`
+	JoinCode       string    `json:"joinCode" gorm:"uniqueIndex;not null"`
+	MonthlyGoal    int       `json:"monthlyGoal" gorm:"default:0"`                                    // Books per student per month, 0 for none
+	OrganizationID *uint     `json:"organizationId,omitempty" gorm:"index:idx_classroom_organization"` // Teacher's organization when created
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+
+	// Relationships
+	Teacher  User               `json:"teacher,omitempty" gorm:"foreignKey:TeacherID"`
+	Students []ClassroomStudent `json:"students,omitempty" gorm:"foreignKey:ClassroomID"`
+}
+
+// ClassroomStudent links a child to a classroom
+type ClassroomStudent struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ClassroomID uint      `json:"classroomId" gorm:"not null;uniqueIndex:idx_classroom_child_unique"`
+	ChildID     uint      `json:"childId" gorm:"not null;index:idx_classroom_student_child;uniqueIndex:idx_classroom_child_unique"`
+	AddedByID   uint      `json:"addedById" gorm:"not null"`
+	CreatedAt   time.Time `json:"createdAt"`
+
+	// Relationships
+	Child Child `json:"child,omitempty" gorm:"foreignKey:ChildID"`
+}
+
 // SharedBook represents a book from Open Library that can be reused by all users
 type SharedBook struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
@@ -72,15 +162,39 @@ type Book struct {
 	CustomAuthor string    `json:"customAuthor,omitempty" gorm:"index:idx_custom_author"`
 	CustomISBN   string    `json:"customIsbn,omitempty"`
 	LexileLevel  string    `json:"lexileLevel,omitempty"`
+	LexileMeasure *int     `json:"lexileMeasure,omitempty" gorm:"index:idx_book_lexile"` // Parsed from LexileLevel; negative for BR levels
+	LexileParsed  bool     `json:"-" gorm:"not null;default:false"` // LexileLevel went through the parser, whether or not it parsed
 	// For partial books
 	IsPartial       bool   `json:"isPartial" gorm:"default:false;index:idx_book_partial"`
 	PartialComment  string `json:"partialComment,omitempty"` // Description of what portion was read
 	CreatedAt       time.Time `json:"createdAt"`
 	UpdatedAt       time.Time `json:"updatedAt"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"` // Soft delete; purged after the retention period
 
 	// Relationships
-	Child      Child        `json:"child,omitempty" gorm:"foreignKey:ChildID"`
-	SharedBook *SharedBook  `json:"sharedBook,omitempty" gorm:"foreignKey:SharedBookID"`
+	Child         Child              `json:"child,omitempty" gorm:"foreignKey:ChildID"`
+	SharedBook    *SharedBook        `json:"sharedBook,omitempty" gorm:"foreignKey:SharedBookID"`
+	ReadingLevels []BookReadingLevel `json:"readingLevels,omitempty" gorm:"foreignKey:BookID"` // Levels in systems other than Lexile
+}
+
+// Reading-level systems, from the most to the least precise
+const (
+	ReadingLevelLexile        = "LEXILE"
+	ReadingLevelATOS          = "ATOS"           // Accelerated Reader book level, e.g. 4.5
+	ReadingLevelGuidedReading = "GUIDED_READING" // Fountas & Pinnell letter, A to Z
+	ReadingLevelGrade         = "GRADE"          // K to 12
+)
+
+// BookReadingLevel is a book's level in a reading-level system other than Lexile, which is kept
+// on the book itself
+type BookReadingLevel struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	BookID          uint      `json:"bookId" gorm:"not null;uniqueIndex:idx_book_reading_level"`
+	System          string    `json:"system" gorm:"not null;uniqueIndex:idx_book_reading_level;check:system IN ('ATOS', 'GUIDED_READING', 'GRADE')"`
+	Level           string    `json:"level" gorm:"not null"`           // In the system's usual spelling
+	GradeEquivalent float64   `json:"gradeEquivalent" gorm:"not null"` // Where the level falls on the common scale conversions go through
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
 }
 
 // Permission represents user permissions for children
@@ -90,6 +204,7 @@ type Permission struct {
 	ChildID        uint      `json:"childId" gorm:"not null;index:idx_permission_child;uniqueIndex:idx_user_child_unique"`
 	PermissionType string    `json:"permissionType" gorm:"not null;check:permission_type IN ('VIEW', 'EDIT')"`
 	CreatedAt      time.Time `json:"createdAt"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"` // Soft-deleted together with its child
 
 	// Relationships
 	User  User  `json:"user,omitempty" gorm:"foreignKey:UserID"`
@@ -112,6 +227,141 @@ type PendingInvitation struct {
 	InvitedBy User  `json:"invitedBy,omitempty" gorm:"foreignKey:InvitedByID"`
 }
 
+// AuditLog is an append-only record of who changed what
+type AuditLog struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ActorID    uint      `json:"actorId" gorm:"index:idx_audit_actor"` // 0 for system changes
+	Action     string    `json:"action" gorm:"not null"`                // 'create', 'update', 'delete', 'transfer'
+	EntityType string    `json:"entityType" gorm:"not null;index:idx_audit_entity"`
+	EntityID   uint      `json:"entityId" gorm:"index:idx_audit_entity"`
+	ChildID    uint      `json:"childId" gorm:"index:idx_audit_child"`
+	Before     string    `json:"before,omitempty"`  // JSON snapshot before the change
+	After      string    `json:"after,omitempty"`   // JSON snapshot after the change
+	Changes    string    `json:"changes,omitempty"` // JSON diff of changed fields
+	IPAddress  string    `json:"ipAddress,omitempty"`
+	CreatedAt  time.Time `json:"createdAt" gorm:"index:idx_audit_created"`
+}
+
+// BeforeUpdate keeps audit entries append-only
+func (a *AuditLog) BeforeUpdate(tx *gorm.DB) error {
+	return errors.New("audit log entries cannot be modified")
+}
+
+// BeforeDelete keeps audit entries append-only
+func (a *AuditLog) BeforeDelete(tx *gorm.DB) error {
+	return errors.New("audit log entries cannot be deleted")
+}
+
+// EmailOutbox holds outgoing mail until the outbox worker delivers it,
+// so requests never wait on or fail because of the mail provider
+type EmailOutbox struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	FromAddress   string     `json:"fromAddress" gorm:"not null"`
+	ToAddress     string     `json:"toAddress" gorm:"not null"`
+	Subject       string     `json:"subject" gorm:"not null"`
+	HTMLBody      string     `json:"-"`
+	TextBody      string     `json:"-"`
+	Headers       string     `json:"-"` // JSON object of extra headers
+	Status        string     `json:"status" gorm:"not null;default:'PENDING';index:idx_email_outbox_due,priority:1;check:status IN ('PENDING', 'SENT', 'FAILED')"`
+	Attempts      int        `json:"attempts" gorm:"default:0"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt" gorm:"not null;index:idx_email_outbox_due,priority:2"`
+	LastError     string     `json:"lastError,omitempty"`
+	SentAt        *time.Time `json:"sentAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// TableName keeps the outbox table name singular
+func (EmailOutbox) TableName() string {
+	return "email_outbox"
+}
+
+// Webhook posts signed JSON to a user's URL when events happen to the children they can view
+type Webhook struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"userId" gorm:"not null;index"`
+	URL       string    `json:"url" gorm:"not null"`
+	Secret    string    `json:"-" gorm:"not null"` // HMAC key for the X-BookTracker-Signature header
+	Events    string    `json:"events" gorm:"not null"` // Comma-separated event names
+	Active    bool      `json:"active" gorm:"not null"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// WebhookDelivery is one event queued for a webhook, kept as its delivery log
+type WebhookDelivery struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	WebhookID      uint       `json:"webhookId" gorm:"not null;index"`
+	Event          string     `json:"event" gorm:"not null"`
+	Payload        string     `json:"payload" gorm:"not null"`
+	Status         string     `json:"status" gorm:"not null;default:'PENDING';index:idx_webhook_delivery_due,priority:1;check:status IN ('PENDING', 'SUCCEEDED', 'FAILED')"`
+	Attempts       int        `json:"attempts" gorm:"default:0"`
+	NextAttemptAt  time.Time  `json:"nextAttemptAt" gorm:"not null;index:idx_webhook_delivery_due,priority:2"`
+	ResponseStatus int        `json:"responseStatus,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// DigestSubscription opts a user into periodic reading summaries of the children they can view
+type DigestSubscription struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"userId" gorm:"uniqueIndex;not null"`
+	Frequency  string     `json:"frequency" gorm:"not null;check:frequency IN ('WEEKLY', 'MONTHLY')"`
+	LastSentAt *time.Time `json:"lastSentAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// EmailOptOut records a notification category an email address no longer wants.
+// It is keyed by address rather than user so invitees without an account can unsubscribe too.
+type EmailOptOut struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Email     string    `json:"email" gorm:"uniqueIndex:idx_email_opt_out;not null"`
+	Category  string    `json:"category" gorm:"uniqueIndex:idx_email_opt_out;not null;check:category IN ('INVITATIONS', 'DIGESTS', 'REMINDERS', 'SECURITY_ALERTS')"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Notification is an entry in a user's in-app notification feed
+type Notification struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"userId" gorm:"not null;index:idx_notification_user_read"`
+	Type       string     `json:"type" gorm:"not null"`
+	Message    string     `json:"message" gorm:"not null"`
+	ActorID    *uint      `json:"actorId,omitempty"`
+	ChildID    *uint      `json:"childId,omitempty"`
+	EntityType string     `json:"entityType,omitempty"`
+	EntityID   uint       `json:"entityId,omitempty"`
+	ReadAt     *time.Time `json:"readAt,omitempty" gorm:"index:idx_notification_user_read"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// NotificationMute keeps one notification type out of a user's feed
+type NotificationMute struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"userId" gorm:"not null;uniqueIndex:idx_notification_mute"`
+	Type      string    `json:"type" gorm:"not null;uniqueIndex:idx_notification_mute"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ShareLink is a revocable, unauthenticated read-only view of one child's reading log
+type ShareLink struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	Token        string     `json:"token" gorm:"uniqueIndex;not null"`
+	ChildID      uint       `json:"childId" gorm:"not null;index:idx_share_link_child"`
+	CreatedByID  uint       `json:"createdById" gorm:"not null"`
+	HideLastName bool       `json:"hideLastName" gorm:"default:false"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"` // nil never expires
+	RevokedAt    *time.Time `json:"revokedAt,omitempty"`
+	ViewCount    int        `json:"viewCount" gorm:"default:0"`
+	LastViewedAt *time.Time `json:"lastViewedAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+
+	// Relationships
+	Child Child `json:"child,omitempty" gorm:"foreignKey:ChildID"`
+}
+
 // Request DTOs
 type CreateUserRequest struct {
 	Email     string `json:"email" binding:"required,email"`
@@ -119,6 +369,7 @@ type CreateUserRequest struct {
 	FirstName string `json:"firstName" binding:"required"`
 	LastName  string `json:"lastName" binding:"required"`
 	IsAdmin   bool   `json:"isAdmin"`
+	Locale    string `json:"locale" binding:"omitempty,oneof=en es"`
 }
 
 type CreateUserWithInvitationRequest struct {
@@ -134,6 +385,9 @@ type UpdateUserRequest struct {
 	FirstName string `json:"firstName" binding:"required"`
 	LastName  string `json:"lastName" binding:"required"`
 	IsAdmin   bool   `json:"isAdmin"`
+	Locale    string `json:"locale" binding:"omitempty,oneof=en es"` // Empty keeps the current locale
+	// Empty keeps the current system
+	ReadingLevelSystem string `json:"readingLevelSystem" binding:"omitempty,oneof=LEXILE ATOS GUIDED_READING GRADE"`
 }
 
 type LoginRequest struct {
@@ -142,9 +396,10 @@ type LoginRequest struct {
 }
 
 type CreateChildRequest struct {
-	FirstName string `json:"firstName" binding:"required"`
-	LastName  string `json:"lastName" binding:"required"`
-	Grade     string `json:"grade" binding:"required"`
+	FirstName   string `json:"firstName" binding:"required"`
+	LastName    string `json:"lastName" binding:"required"`
+	Grade       string `json:"grade" binding:"required"`
+	HouseholdID *uint  `json:"householdId,omitempty"`
 }
 
 type UpdateChildRequest struct {
@@ -153,6 +408,93 @@ type UpdateChildRequest struct {
 	Grade     string `json:"grade" binding:"required"`
 }
 
+type TransferChildOwnershipRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type SetChildHouseholdRequest struct {
+	HouseholdID *uint `json:"householdId"` // null removes the child from its household
+}
+
+type CreateHouseholdRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type UpdateHouseholdRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type AddHouseholdMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=OWNER EDITOR VIEWER"`
+}
+
+type UpdateHouseholdMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=OWNER EDITOR VIEWER"`
+}
+
+type CreateClassroomRequest struct {
+	Name        string `json:"name" binding:"required"`
+	MonthlyGoal int    `json:"monthlyGoal" binding:"min=0"`
+}
+
+type UpdateClassroomRequest struct {
+	Name        string `json:"name" binding:"required"`
+	MonthlyGoal int    `json:"monthlyGoal" binding:"min=0"`
+}
+
+type JoinClassroomRequest struct {
+	JoinCode string `json:"joinCode" binding:"required"`
+	ChildID  uint   `json:"childId" binding:"required"`
+}
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type SetUserOrganizationRequest struct {
+	OrganizationID *uint  `json:"organizationId"` // null removes the user from their organization
+	Role           string `json:"role" binding:"omitempty,oneof=ADMIN TEACHER MEMBER"`
+}
+
+type InviteOrganizationMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=ADMIN TEACHER MEMBER"`
+}
+
+type UpdateOrganizationMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=ADMIN TEACHER MEMBER"`
+}
+
+type UpdateDigestSubscriptionRequest struct {
+	Frequency string `json:"frequency" binding:"required,oneof=WEEKLY MONTHLY"`
+}
+
+// UpdateNotificationPreferencesRequest changes the categories that are set; nil fields are left alone
+type UpdateNotificationPreferencesRequest struct {
+	Invitations    *bool `json:"invitations"`
+	Digests        *bool `json:"digests"`
+	Reminders      *bool `json:"reminders"`
+	SecurityAlerts *bool `json:"securityAlerts"`
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required,url"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=book.created book.updated book.deleted child.created goal.reached"`
+}
+
+// UpdateWebhookRequest changes the fields that are set; nil fields are left alone
+type UpdateWebhookRequest struct {
+	URL    *string  `json:"url" binding:"omitempty,url"`
+	Events []string `json:"events" binding:"omitempty,min=1,dive,oneof=book.created book.updated book.deleted child.created goal.reached"`
+	Active *bool    `json:"active"`
+}
+
+type CreateShareLinkRequest struct {
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+	HideLastName bool       `json:"hideLastName"`
+}
+
 type CreateBookRequest struct {
 	ISBN         string `json:"isbn,omitempty"`
 	Title        string `json:"title,omitempty"`
@@ -164,6 +506,13 @@ type CreateBookRequest struct {
 	IsCustomBook bool   `json:"isCustomBook"` // true for user-specific custom books
 	IsPartial       bool   `json:"isPartial"` // true for partial book readings
 	PartialComment  string `json:"partialComment,omitempty"` // Description of what portion was read
+	ReadingLevels   []ReadingLevelInput `json:"readingLevels,omitempty" binding:"omitempty,dive"` // A Lexile entry replaces LexileLevel
+}
+
+// ReadingLevelInput is a book's level in one reading-level system
+type ReadingLevelInput struct {
+	System string `json:"system" binding:"required,oneof=LEXILE ATOS GUIDED_READING GRADE"`
+	Level  string `json:"level" binding:"required"`
 }
 
 type ISBNLookupRequest struct {
@@ -179,6 +528,7 @@ type CreateCustomBookRequest struct {
 	ChildID     uint   `json:"childId" binding:"required"`
 	IsPartial       bool   `json:"isPartial"` // true for partial book readings
 	PartialComment  string `json:"partialComment,omitempty"` // Description of what portion was read
+	ReadingLevels   []ReadingLevelInput `json:"readingLevels,omitempty" binding:"omitempty,dive"` // A Lexile entry replaces LexileLevel
 }
 
 type BookInfoResponse struct {
@@ -223,6 +573,8 @@ type UpdateBookRequest struct {
 	DateRead    string `json:"dateRead" binding:"required"`
 	IsPartial       bool   `json:"isPartial"` // true for partial book readings
 	PartialComment  string `json:"partialComment,omitempty"` // Description of what portion was read
+	// When set, replaces the levels in systems other than Lexile; a Lexile entry replaces LexileLevel
+	ReadingLevels []ReadingLevelInput `json:"readingLevels" binding:"omitempty,dive"`
 }
 
 type CreatePermissionRequest struct {
@@ -233,13 +585,17 @@ type CreatePermissionRequest struct {
 
 // Response DTOs
 type UserResponse struct {
-	ID            uint      `json:"id"`
-	Email         string    `json:"email"`
-	FirstName     string    `json:"firstName"`
-	LastName      string    `json:"lastName"`
-	IsAdmin       bool      `json:"isAdmin"`
-	EmailVerified bool      `json:"emailVerified"`
-	CreatedAt     time.Time `json:"createdAt"`
+	ID                 uint      `json:"id"`
+	Email              string    `json:"email"`
+	FirstName          string    `json:"firstName"`
+	LastName           string    `json:"lastName"`
+	IsAdmin            bool      `json:"isAdmin"`
+	EmailVerified      bool      `json:"emailVerified"`
+	OrganizationID     *uint     `json:"organizationId,omitempty"`
+	OrgRole            string    `json:"orgRole,omitempty"`
+	Locale             string    `json:"locale"`
+	ReadingLevelSystem string    `json:"readingLevelSystem,omitempty"`
+	CreatedAt          time.Time `json:"createdAt"`
 }
 
 type LoginResponse struct {
@@ -248,12 +604,13 @@ type LoginResponse struct {
 }
 
 type ChildResponse struct {
-	ID        uint      `json:"id"`
-	FirstName string    `json:"firstName"`
-	LastName  string    `json:"lastName"`
-	Grade     string    `json:"grade"`
-	OwnerID   uint      `json:"ownerId"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID          uint      `json:"id"`
+	FirstName   string    `json:"firstName"`
+	LastName    string    `json:"lastName"`
+	Grade       string    `json:"grade"`
+	OwnerID     uint      `json:"ownerId"`
+	HouseholdID *uint     `json:"householdId,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
 }
 
 type ChildWithBookCountResponse struct {
@@ -277,6 +634,7 @@ type BookResponse struct {
 	Title        string    `json:"title"`
 	Author       string    `json:"author"`
 	LexileLevel  string    `json:"lexileLevel,omitempty"`
+	LexileMeasure *int     `json:"lexileMeasure,omitempty"`
 	CoverURL     string    `json:"coverUrl,omitempty"`
 	DateRead     string    `json:"dateRead"`
 	ChildID      uint      `json:"childId"`
@@ -284,9 +642,18 @@ type BookResponse struct {
 	SharedBookID *uint     `json:"sharedBookId,omitempty"`
 	IsPartial       bool   `json:"isPartial"`
 	PartialComment  string `json:"partialComment,omitempty"`
+	ReadingLevels   []ReadingLevelResponse `json:"readingLevels,omitempty"` // Levels entered, Lexile included
+	ReadingLevel    *ReadingLevelResponse  `json:"readingLevel,omitempty"`  // In the requested or preferred system
 	CreatedAt    time.Time `json:"createdAt"`
 }
 
+// ReadingLevelResponse is a level in one reading-level system
+type ReadingLevelResponse struct {
+	System      string `json:"system"`
+	Level       string `json:"level"`
+	Approximate bool   `json:"approximate,omitempty"` // Converted from a level in another system
+}
+
 type PermissionResponse struct {
 	ID             uint          `json:"id"`
 	UserID         uint          `json:"userId"`
@@ -296,6 +663,274 @@ type PermissionResponse struct {
 	User           *UserResponse `json:"user,omitempty"`
 }
 
+type AuditLogResponse struct {
+	ID         uint                   `json:"id"`
+	Actor      *UserResponse          `json:"actor,omitempty"`
+	Action     string                 `json:"action"`
+	EntityType string                 `json:"entityType"`
+	EntityID   uint                   `json:"entityId"`
+	ChildID    uint                   `json:"childId"`
+	Before     map[string]interface{} `json:"before,omitempty"`
+	After      map[string]interface{} `json:"after,omitempty"`
+	Changes    map[string]interface{} `json:"changes,omitempty"`
+	IPAddress  string                 `json:"ipAddress,omitempty"`
+	CreatedAt  time.Time              `json:"createdAt"`
+}
+
+type TrashedChildResponse struct {
+	ChildResponse
+	DeletedAt time.Time `json:"deletedAt"`
+	BookCount int       `json:"bookCount"` // Books deleted along with the child
+}
+
+type TrashedBookResponse struct {
+	BookResponse
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+type TrashResponse struct {
+	Children      []TrashedChildResponse `json:"children"`
+	Books         []TrashedBookResponse  `json:"books"`
+	RetentionDays int                    `json:"retentionDays"`
+}
+
+type HouseholdMemberResponse struct {
+	UserID    uint      `json:"userId"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"firstName"`
+	LastName  string    `json:"lastName"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type HouseholdResponse struct {
+	ID        uint                      `json:"id"`
+	Name      string                    `json:"name"`
+	Role      string                    `json:"role"` // The current user's role
+	Members   []HouseholdMemberResponse `json:"members"`
+	Children  []ChildResponse           `json:"children"`
+	CreatedAt time.Time                 `json:"createdAt"`
+}
+
+type DigestSubscriptionResponse struct {
+	Subscribed bool       `json:"subscribed"`
+	Frequency  string     `json:"frequency,omitempty"`
+	LastSentAt *time.Time `json:"lastSentAt,omitempty"`
+}
+
+type NotificationPreferencesResponse struct {
+	Invitations    bool `json:"invitations"`
+	Digests        bool `json:"digests"`
+	Reminders      bool `json:"reminders"`
+	SecurityAlerts bool `json:"securityAlerts"`
+}
+
+type NotificationResponse struct {
+	ID         uint       `json:"id"`
+	Type       string     `json:"type"`
+	Message    string     `json:"message"`
+	ActorID    *uint      `json:"actorId,omitempty"`
+	ChildID    *uint      `json:"childId,omitempty"`
+	EntityType string     `json:"entityType,omitempty"`
+	EntityID   uint       `json:"entityId,omitempty"`
+	Read       bool       `json:"read"`
+	ReadAt     *time.Time `json:"readAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+type NotificationListResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	UnreadCount   int64                  `json:"unreadCount"`
+}
+
+type WebhookResponse struct {
+	ID        uint      `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	Secret    string    `json:"secret,omitempty"` // Only returned when the webhook is created
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type OrganizationResponse struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	MemberCount int       `json:"memberCount"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type OrganizationInvitationResponse struct {
+	ID               uint         `json:"id"`
+	OrganizationID   uint         `json:"organizationId"`
+	OrganizationName string       `json:"organizationName"`
+	Role             string       `json:"role"`
+	User             UserResponse `json:"user"`
+	CreatedAt        time.Time    `json:"createdAt"`
+}
+
+type ClassroomResponse struct {
+	ID             uint      `json:"id"`
+	Name           string    `json:"name"`
+	TeacherID      uint      `json:"teacherId"`
+	JoinCode       string    `json:"joinCode,omitempty"` // Only shown to the teacher
+	MonthlyGoal    int       `json:"monthlyGoal"`
+	StudentCount   int       `json:"studentCount"`
+	OrganizationID *uint     `json:"organizationId,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+type ClassroomStudentResponse struct {
+	Child    ChildResponse `json:"child"`
+	Parent   UserResponse  `json:"parent"`
+	JoinedAt time.Time     `json:"joinedAt"`
+}
+
+type ClassroomStudentStats struct {
+	ChildID   uint   `json:"childId"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	BookCount int    `json:"bookCount"`
+	BelowGoal bool   `json:"belowGoal"`
+}
+
+type ClassroomStatsResponse struct {
+	ClassroomID       uint                    `json:"classroomId"`
+	Year              int                     `json:"year"`
+	Month             int                     `json:"month"`
+	MonthlyGoal       int                     `json:"monthlyGoal"`
+	TotalBooks        int                     `json:"totalBooks"`
+	AverageBooks      float64                 `json:"averageBooks"`
+	Students          []ClassroomStudentStats `json:"students"`
+	StudentsBelowGoal []ClassroomStudentStats `json:"studentsBelowGoal"`
+}
+
+// MonthlyReadingStats counts one month of a reading statistics window
+type MonthlyReadingStats struct {
+	Month         string   `json:"month"` // YYYY-MM
+	Books         int      `json:"books"`
+	PartialBooks  int      `json:"partialBooks"`
+	AverageLexile *float64 `json:"averageLexile,omitempty"`
+}
+
+type AuthorReadingStats struct {
+	Author string `json:"author"`
+	Books  int    `json:"books"`
+}
+
+type LexileReadingStats struct {
+	Books   int      `json:"books"` // books with a numeric Lexile level
+	Average *float64 `json:"average,omitempty"`
+	// TrendPerMonth is the least-squares change in Lexile level per month over the window
+	TrendPerMonth *float64 `json:"trendPerMonth,omitempty"`
+}
+
+type ReadingStatsResponse struct {
+	ChildIDs      []uint                `json:"childIds"`
+	From          string                `json:"from"` // first month of the window, YYYY-MM
+	To            string                `json:"to"`   // last month of the window, YYYY-MM
+	TotalBooks    int                   `json:"totalBooks"`
+	CompleteBooks int                   `json:"completeBooks"`
+	PartialBooks  int                   `json:"partialBooks"`
+	PartialShare  float64               `json:"partialShare"` // 0 to 1
+	SharedBooks   int                   `json:"sharedBooks"`
+	CustomBooks   int                   `json:"customBooks"`
+	SharedShare   float64               `json:"sharedShare"` // 0 to 1
+	Months        []MonthlyReadingStats `json:"months"`
+	TopAuthors    []AuthorReadingStats  `json:"topAuthors"`
+	Lexile        LexileReadingStats    `json:"lexile"`
+}
+
+// Where a Lexile measure falls against a grade band
+const (
+	LexilePositionBelow  = "below"
+	LexilePositionWithin = "within"
+	LexilePositionAbove  = "above"
+)
+
+type LexileBandResponse struct {
+	Grade    int    `json:"grade"`
+	Min      int    `json:"min"`
+	Max      int    `json:"max"`
+	MinLevel string `json:"minLevel"` // Min formatted as a Lexile level, e.g. BR120L
+	MaxLevel string `json:"maxLevel"`
+}
+
+type LexileProgressionPoint struct {
+	Month    string   `json:"month"` // YYYY-MM
+	Books    int      `json:"books"` // measured books in the window ending this month
+	Median   *float64 `json:"median,omitempty"`
+	Position string   `json:"position,omitempty"` // of the median against the band
+}
+
+type LexileFlaggedBook struct {
+	BookID        uint   `json:"bookId"`
+	Title         string `json:"title"`
+	DateRead      string `json:"dateRead"`
+	LexileLevel   string `json:"lexileLevel"`
+	LexileMeasure int    `json:"lexileMeasure"`
+	Position      string `json:"position"`
+	Distance      int    `json:"distance"` // Lexile points outside the band
+}
+
+type LexileProgressionResponse struct {
+	ChildID      uint                     `json:"childId"`
+	Grade        string                   `json:"grade"`
+	Band         *LexileBandResponse      `json:"band"` // nil when the grade has no published band
+	WindowMonths int                      `json:"windowMonths"`
+	FlagMargin   int                      `json:"flagMargin"`
+	Points       []LexileProgressionPoint `json:"points"`
+	FlaggedBooks []LexileFlaggedBook      `json:"flaggedBooks"`
+}
+
+// ReadingLevelConversionResponse is a level with its approximate equivalents in the other systems
+type ReadingLevelConversionResponse struct {
+	System          string                 `json:"system"`
+	Level           string                 `json:"level"`
+	GradeEquivalent *float64               `json:"gradeEquivalent"` // nil for Lexile codes without a measure, which do not convert
+	Conversions     []ReadingLevelResponse `json:"conversions"`
+}
+
+// ReadingLevelChartRow is where each system stands at the start of a grade
+type ReadingLevelChartRow struct {
+	Grade         string `json:"grade"` // K to 12
+	Lexile        string `json:"lexile"`
+	ATOS          string `json:"atos"`
+	GuidedReading string `json:"guidedReading"`
+}
+
+type ShareLinkResponse struct {
+	ID           uint       `json:"id"`
+	Token        string     `json:"token"`
+	ChildID      uint       `json:"childId"`
+	HideLastName bool       `json:"hideLastName"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty"`
+	ViewCount    int        `json:"viewCount"`
+	LastViewedAt *time.Time `json:"lastViewedAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// SharedChildResponse is the public view of a child, without IDs or owner details
+type SharedChildResponse struct {
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName,omitempty"`
+	Grade     string `json:"grade"`
+}
+
+type SharedReadingLogResponse struct {
+	Child      SharedChildResponse `json:"child"`
+	Books      []BookResponse      `json:"books"`
+	TotalBooks int                 `json:"totalBooks"`
+}
+
+type SharedMonthlyReportResponse struct {
+	Child      SharedChildResponse `json:"child"`
+	Year       int                 `json:"year"`
+	Month      int                 `json:"month"`
+	Books      []BookResponse      `json:"books"`
+	TotalBooks int                 `json:"totalBooks"`
+}
+
 type ErrorResponse struct {
 	Message string `json:"message"`
 	Code    string `json:"code,omitempty"`
@@ -320,9 +955,9 @@ func AutoMigrate(db *gorm.DB) error {
 	// 	return err
 	// }
 	
-	return db.AutoMigrate(&User{}, &Child{}, &SharedBook{}, &Book{}, &Permission{}, &PendingInvitation{})
+	return db.AutoMigrate(&User{}, &Child{}, &SharedBook{}, &Book{}, &Permission{}, &PendingInvitation{}, &AuditLog{}, &ShareLink{}, &Household{}, &HouseholdMember{}, &Classroom{}, &ClassroomStudent{}, &Organization{}, &OrganizationInvitation{}, &EmailOutbox{}, &DigestSubscription{}, &EmailOptOut{}, &Notification{}, &NotificationMute{}, &Webhook{}, &WebhookDelivery{}, &BookReadingLevel{})
 }
 
 // migrateChildrenTable - REMOVED to prevent data deletion
 // This migration has been disabled to preserve data between deployments.
-// The schema migration from 'name' to 'firstName'/'lastName' has already been applied.
\ No newline at end of file
+// The schema migration from 'name' to 'firstName'/'lastName' has already been applied.
@@ -0,0 +1,303 @@
+// Package routes registers the API on the router of the server and of the serverless function,
+// so both deployments serve the same endpoints
+package routes
+
+import (
+	"net/http"
+
+	"github.com/booktracker/backend/handlers"
+	"github.com/booktracker/backend/middleware"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// Setup adds CORS, the permission cache and every route to the router and returns the /api group
+func Setup(router *gin.Engine) *gin.RouterGroup {
+	// Setup CORS
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
+	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	router.Use(cors.New(corsConfig))
+
+	// Add permission cache middleware
+	router.Use(middleware.PermissionCacheMiddleware())
+
+	// Health check endpoint
+	router.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "OK"})
+	})
+
+	// API routes
+	api := router.Group("/api")
+	{
+		// Health check endpoint for tests
+		api.GET("/health", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "OK"})
+		})
+
+		// Auth routes (no authentication required)
+		auth := api.Group("/auth")
+		{
+			auth.POST("/register", handlers.RegisterUser)
+			auth.POST("/register-with-invitation", handlers.RegisterUserWithInvitation)
+			auth.GET("/invitation-details", handlers.GetInvitationDetails)
+			auth.POST("/login", handlers.LoginUser)
+			auth.GET("/verify-email", handlers.VerifyEmail)
+			auth.POST("/resend-verification", handlers.ResendVerification)
+			auth.POST("/forgot-password", handlers.ForgotPassword)
+			auth.POST("/reset-password", handlers.ResetPassword)
+
+			// Google OAuth routes
+			auth.GET("/google", handlers.GoogleLogin)
+			auth.GET("/google/callback", handlers.GoogleCallback)
+		}
+
+		// Public share link routes (token in the URL, no authentication)
+		public := api.Group("/public/share")
+		{
+			public.GET("/:token", handlers.GetSharedReadingLog)
+			public.GET("/:token/monthly", handlers.GetSharedMonthlyReport)
+			public.GET("/:token/monthly-pdf", handlers.GetSharedMonthlyPDF)
+		}
+
+		// Public digest routes (token in the query, no authentication)
+		publicDigest := api.Group("/public/digest")
+		{
+			publicDigest.GET("/report", handlers.GetDigestReport)
+		}
+
+		// Unsubscribe links in emails (signed token, no authentication); only the POST unsubscribes
+		api.GET("/public/unsubscribe", handlers.ConfirmUnsubscribe)
+		api.POST("/public/unsubscribe", handlers.Unsubscribe)
+
+		// Book covers, cached locally so the frontend and reports do not hot-link the source
+		api.GET("/covers/:sharedBookId", handlers.GetCover)
+
+		// Live updates; EventSource cannot send headers, so a short-lived stream token may come in the query
+		api.GET("/events/stream", middleware.EventStreamAuth(), handlers.StreamEvents)
+
+		// Protected routes (authentication required)
+		protected := api.Group("")
+		protected.Use(middleware.AuthMiddleware())
+		{
+			// Invitation routes
+			protected.POST("/invite-user", handlers.BulkInviteUser)
+
+			// Stream tokens for opening /events/stream with EventSource
+			protected.POST("/events/token", handlers.CreateEventStreamToken)
+
+			// User routes
+			users := protected.Group("/users")
+			{
+				users.GET("", middleware.OrgAdminMiddleware(), handlers.GetAllUsers)
+				users.GET("/:id", handlers.GetUserByID)
+				users.PUT("/:id", handlers.UpdateUser)
+				users.DELETE("/:id", middleware.OrgAdminMiddleware(), handlers.DeleteUser)
+			}
+
+			// Children routes
+			children := protected.Group("/children")
+			{
+				children.POST("", handlers.CreateChild)
+				children.GET("", handlers.GetChildren)
+				children.GET("/with-counts", handlers.GetChildrenWithBookCounts)
+				children.GET("/book-counts", handlers.GetBookCountsForChildren)
+				children.GET("/:id", handlers.GetChildByID)
+				children.PUT("/:id", handlers.UpdateChild)
+				children.DELETE("/:id", handlers.DeleteChild)
+				children.PUT("/:id/owner", handlers.TransferChildOwnership)
+				children.POST("/:id/invite", handlers.InviteUser)
+				children.GET("/:id/permissions", handlers.GetPermissionsByChild)
+				children.GET("/:id/audit-log", handlers.GetChildAuditLog)
+				children.PUT("/:id/household", handlers.SetChildHousehold)
+				children.GET("/:id/classrooms", handlers.GetChildClassrooms)
+				children.POST("/:id/share-links", handlers.CreateShareLink)
+				children.GET("/:id/share-links", handlers.GetShareLinks)
+			}
+
+			// Permission routes
+			permissions := protected.Group("/permissions")
+			{
+				permissions.DELETE("/:id", handlers.DeletePermissionByID)
+			}
+
+			// Books routes
+			books := protected.Group("/books")
+			{
+				books.POST("", handlers.CreateBook)
+				books.GET("", handlers.GetBooks)
+				books.GET("/:id", handlers.GetBookByID)
+				books.PUT("/:id", handlers.UpdateBook)
+				books.DELETE("/:id", handlers.DeleteBook)
+
+				// Child-specific book routes
+				books.POST("/child/:childId", handlers.CreateBookForChild)
+				books.POST("/child/:childId/custom", handlers.CreateCustomBookForChild)
+				books.GET("/child/:childId", handlers.GetBooksForChild)
+
+				// ISBN lookup route
+				books.POST("/lookup-isbn", handlers.LookupISBN)
+			}
+
+			// Household routes
+			households := protected.Group("/households")
+			{
+				households.POST("", handlers.CreateHousehold)
+				households.GET("", handlers.GetHouseholds)
+				households.GET("/:id", handlers.GetHouseholdByID)
+				households.PUT("/:id", handlers.UpdateHousehold)
+				households.DELETE("/:id", handlers.DeleteHousehold)
+				households.POST("/:id/members", handlers.AddHouseholdMember)
+				households.PUT("/:id/members/:userId", handlers.UpdateHouseholdMember)
+				households.DELETE("/:id/members/:userId", handlers.RemoveHouseholdMember)
+			}
+
+			// Classroom routes
+			classrooms := protected.Group("/classrooms")
+			{
+				classrooms.POST("", handlers.CreateClassroom)
+				classrooms.GET("", handlers.GetClassrooms)
+				classrooms.POST("/join", handlers.JoinClassroom)
+				classrooms.GET("/:id", handlers.GetClassroomByID)
+				classrooms.PUT("/:id", handlers.UpdateClassroom)
+				classrooms.DELETE("/:id", handlers.DeleteClassroom)
+				classrooms.POST("/:id/join-code", handlers.RegenerateClassroomJoinCode)
+				classrooms.GET("/:id/roster", handlers.GetClassroomRoster)
+				classrooms.GET("/:id/stats", handlers.GetClassroomStats)
+				classrooms.DELETE("/:id/students/:childId", handlers.RemoveClassroomStudent)
+			}
+
+			// Organization routes
+			organizations := protected.Group("/organizations")
+			{
+				organizations.POST("", middleware.AdminMiddleware(), handlers.CreateOrganization)
+				organizations.GET("", middleware.OrgAdminMiddleware(), handlers.GetOrganizations)
+				organizations.DELETE("/:id", middleware.AdminMiddleware(), handlers.DeleteOrganization)
+				organizations.GET("/:id/members", handlers.GetOrganizationMembers)
+				organizations.PUT("/:id/members/:userId", handlers.UpdateOrganizationMember)
+				organizations.DELETE("/:id/members/:userId", handlers.RemoveOrganizationMember)
+				organizations.GET("/:id/classrooms", handlers.GetOrganizationClassrooms)
+				organizations.GET("/:id/invitations", handlers.GetOrganizationInvitations)
+				organizations.POST("/:id/invitations", handlers.InviteOrganizationMember)
+				organizations.DELETE("/:id/invitations/:invitationId", handlers.RevokeOrganizationInvitation)
+			}
+
+			// Organization invitations of the current user
+			organizationInvitations := protected.Group("/organization-invitations")
+			{
+				organizationInvitations.GET("", handlers.GetMyOrganizationInvitations)
+				organizationInvitations.POST("/:id/accept", handlers.AcceptOrganizationInvitation)
+				organizationInvitations.DELETE("/:id", handlers.DeclineOrganizationInvitation)
+			}
+
+			// Share link routes
+			shareLinks := protected.Group("/share-links")
+			{
+				shareLinks.DELETE("/:id", handlers.RevokeShareLink)
+			}
+
+			// Digest routes
+			digest := protected.Group("/digest")
+			{
+				digest.GET("", handlers.GetDigestSubscription)
+				digest.PUT("", handlers.UpdateDigestSubscription)
+				digest.DELETE("", handlers.DeleteDigestSubscription)
+			}
+
+			// Notification feed routes
+			notifications := protected.Group("/notifications")
+			{
+				notifications.GET("", handlers.GetNotifications)
+				notifications.GET("/unread-count", handlers.GetUnreadNotificationCount)
+				notifications.POST("/read-all", handlers.MarkAllNotificationsRead)
+				notifications.PUT("/:id/read", handlers.MarkNotificationRead)
+				notifications.GET("/mutes", handlers.GetNotificationMutes)
+				notifications.PUT("/mutes/:type", handlers.MuteNotificationType)
+				notifications.DELETE("/mutes/:type", handlers.UnmuteNotificationType)
+			}
+
+			// Webhook routes
+			webhooks := protected.Group("/webhooks")
+			{
+				webhooks.GET("", handlers.GetWebhooks)
+				webhooks.POST("", handlers.CreateWebhook)
+				webhooks.PUT("/:id", handlers.UpdateWebhook)
+				webhooks.DELETE("/:id", handlers.DeleteWebhook)
+				webhooks.GET("/:id/deliveries", handlers.GetWebhookDeliveries)
+				webhooks.POST("/:id/test", handlers.SendTestWebhook)
+			}
+
+			// Notification preference routes
+			notificationPreferences := protected.Group("/notification-preferences")
+			{
+				notificationPreferences.GET("", handlers.GetNotificationPreferences)
+				notificationPreferences.PUT("", handlers.UpdateNotificationPreferences)
+			}
+
+			// Trash routes
+			trash := protected.Group("/trash")
+			{
+				trash.GET("", handlers.GetTrash)
+				trash.POST("/children/:id/restore", handlers.RestoreChild)
+				trash.POST("/books/:id/restore", handlers.RestoreBook)
+			}
+
+			// Admin routes
+			admin := protected.Group("/admin")
+			admin.Use(middleware.AdminMiddleware())
+			{
+				admin.GET("/audit-log", handlers.GetAuditLog)
+				admin.PUT("/users/:id/organization", handlers.SetUserOrganization)
+				admin.GET("/email-templates", handlers.GetEmailTemplates)
+				admin.GET("/email-templates/:name/preview", handlers.PreviewEmailTemplate)
+			}
+
+			// Reports routes
+			reports := protected.Group("/reports")
+			{
+				reports.GET("/my-books", handlers.GetMyBooksReport)
+				reports.GET("/child/:childId/monthly-pdf", handlers.GenerateMonthlyPDFReport)
+				reports.GET("/child/:childId/pdf", handlers.GeneratePDFReport)
+				reports.GET("/family-pdf", handlers.GenerateFamilyPDFReport)
+			}
+
+			// Statistics routes
+			stats := protected.Group("/stats")
+			{
+				stats.GET("/child/:childId", handlers.GetChildReadingStats)
+				stats.GET("/child/:childId/lexile", handlers.GetLexileProgression)
+				stats.GET("/family", handlers.GetFamilyReadingStats)
+			}
+
+			// Reading level routes
+			readingLevels := protected.Group("/reading-levels")
+			{
+				readingLevels.GET("/convert", handlers.ConvertReadingLevel)
+				readingLevels.GET("/chart", handlers.GetReadingLevelChart)
+			}
+
+			// Export routes
+			exports := protected.Group("/exports")
+			{
+				exports.GET("/books.csv", handlers.ExportBooksCSV)
+				exports.GET("/books.xlsx", handlers.ExportBooksXLSX)
+			}
+
+			// Import routes
+			imports := protected.Group("/imports")
+			{
+				imports.POST("/books", handlers.ImportBooks)
+			}
+
+			// Backup routes
+			backup := protected.Group("/backup")
+			{
+				backup.GET("", handlers.ExportBackup)
+				backup.POST("/restore", handlers.RestoreBackup)
+			}
+		}
+	}
+
+	return api
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+)
+
+const (
+	defaultAuditLogLimit = 50
+	maxAuditLogLimit     = 200
+)
+
+// auditIgnoredFields are relations and bookkeeping fields left out of snapshots
+var auditIgnoredFields = []string{"child", "owner", "books", "permissions", "user", "sharedBook", "teacher", "students", "members", "children", "updatedAt"}
+
+// AuditLogFilter narrows an audit log query; zero values match everything
+type AuditLogFilter struct {
+	ActorID    uint
+	ChildID    uint
+	EntityType string
+	Limit      int
+	Offset     int
+}
+
+func init() {
+	RegisterChangeHook(recordAuditEntry)
+}
+
+// recordAuditEntry is the change hook that appends every change to the audit log
+func recordAuditEntry(event ChangeEvent) {
+	entry, err := buildAuditLog(event)
+	if err != nil {
+		log.Printf("Failed to build audit entry for %s %s %d: %v", event.Action, event.EntityType, event.EntityID, err)
+		return
+	}
+
+	if err := config.DB.Create(entry).Error; err != nil {
+		log.Printf("Failed to write audit entry for %s %s %d: %v", event.Action, event.EntityType, event.EntityID, err)
+	}
+}
+
+// buildAuditLog converts a change event into an audit log row with JSON snapshots and diff
+func buildAuditLog(event ChangeEvent) (*models.AuditLog, error) {
+	entry := &models.AuditLog{
+		ActorID:    event.Actor.UserID,
+		Action:     event.Action,
+		EntityType: event.EntityType,
+		EntityID:   event.EntityID,
+		ChildID:    event.ChildID,
+		IPAddress:  event.Actor.IP,
+	}
+
+	before, err := auditSnapshot(event.Before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := auditSnapshot(event.After)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.Before, err = marshalAuditJSON(before); err != nil {
+		return nil, err
+	}
+	if entry.After, err = marshalAuditJSON(after); err != nil {
+		return nil, err
+	}
+	if entry.Changes, err = marshalAuditJSON(diffAuditSnapshots(before, after)); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// auditSnapshot flattens a model into its JSON fields without relations
+func auditSnapshot(value interface{}) (map[string]interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot map[string]interface{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+
+	for _, field := range auditIgnoredFields {
+		delete(snapshot, field)
+	}
+	return snapshot, nil
+}
+
+// diffAuditSnapshots returns {"field": {"from": old, "to": new}} for every changed field
+func diffAuditSnapshots(before, after map[string]interface{}) map[string]interface{} {
+	changes := make(map[string]interface{})
+	for field, oldValue := range before {
+		newValue, exists := after[field]
+		if !exists || !reflect.DeepEqual(oldValue, newValue) {
+			changes[field] = map[string]interface{}{"from": oldValue, "to": newValue}
+		}
+	}
+	for field, newValue := range after {
+		if _, exists := before[field]; !exists {
+			changes[field] = map[string]interface{}{"from": nil, "to": newValue}
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+func marshalAuditJSON(value map[string]interface{}) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// GetAuditLogs returns audit entries matching a filter, newest first
+func GetAuditLogs(filter AuditLogFilter) ([]models.AuditLog, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAuditLogLimit
+	}
+	if limit > maxAuditLogLimit {
+		limit = maxAuditLogLimit
+	}
+
+	query := config.DB.Model(&models.AuditLog{})
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.ChildID != 0 {
+		query = query.Where("child_id = ?", filter.ChildID)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+
+	var entries []models.AuditLog
+	result := query.Order("created_at DESC, id DESC").Limit(limit).Offset(filter.Offset).Find(&entries)
+	return entries, result.Error
+}
+
+// GetAuditLogsByChild returns the audit trail of a single child
+func GetAuditLogsByChild(childID uint, limit, offset int) ([]models.AuditLog, error) {
+	return GetAuditLogs(AuditLogFilter{ChildID: childID, Limit: limit, Offset: offset})
+}
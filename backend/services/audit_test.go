@@ -0,0 +1,149 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type AuditServiceTestSuite struct {
+	suite.Suite
+	owner  *models.User
+	editor *models.User
+	child  *models.Child
+}
+
+func (suite *AuditServiceTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+	SharedPermissionCache().Clear()
+
+	owner, err := CreateUser(models.CreateUserRequest{
+		Email:     "owner@example.com",
+		Password:  "password123",
+		FirstName: "Owner",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	suite.owner = owner
+
+	editor, err := CreateUser(models.CreateUserRequest{
+		Email:     "editor@example.com",
+		Password:  "password123",
+		FirstName: "Editor",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	suite.editor = editor
+
+	child, err := CreateChild(models.CreateChildRequest{
+		FirstName: "Test",
+		LastName:  "Child",
+		Grade:     "3rd",
+	}, owner.ID)
+	assert.NoError(suite.T(), err)
+	suite.child = child
+}
+
+func (suite *AuditServiceTestSuite) TearDownTest() {
+	config.CleanupTestDatabase()
+}
+
+func (suite *AuditServiceTestSuite) TestUpdateBookRecordsDiff() {
+	book, err := CreateCustomBook(models.CreateCustomBookRequest{
+		Title:    "Old Title",
+		Author:   "Author",
+		DateRead: "2024-01-01",
+		ChildID:  suite.child.ID,
+	}, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+
+	_, err = UpdateBook(book.ID, models.UpdateBookRequest{
+		Title:    "New Title",
+		DateRead: "2024-01-01",
+	}, Actor{UserID: suite.editor.ID, IP: "10.0.0.1"})
+	assert.NoError(suite.T(), err)
+
+	entries, err := GetAuditLogs(AuditLogFilter{EntityType: EntityBook})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), entries, 2)
+
+	update := entries[0]
+	assert.Equal(suite.T(), ActionUpdate, update.Action)
+	assert.Equal(suite.T(), suite.editor.ID, update.ActorID)
+	assert.Equal(suite.T(), "10.0.0.1", update.IPAddress)
+	assert.Equal(suite.T(), suite.child.ID, update.ChildID)
+
+	var changes map[string]map[string]interface{}
+	assert.NoError(suite.T(), json.Unmarshal([]byte(update.Changes), &changes))
+	assert.Equal(suite.T(), map[string]interface{}{"from": "Old Title", "to": "New Title"}, changes["customTitle"])
+	assert.NotContains(suite.T(), changes, "dateRead")
+}
+
+func (suite *AuditServiceTestSuite) TestDeletionsAreRecorded() {
+	book, err := CreateCustomBook(models.CreateCustomBookRequest{
+		Title:    "Title",
+		Author:   "Author",
+		DateRead: "2024-01-01",
+		ChildID:  suite.child.ID,
+	}, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), DeleteBook(book.ID, Actor{UserID: suite.owner.ID}))
+
+	assert.NoError(suite.T(), CreatePermission(suite.editor.ID, suite.child.ID, "EDIT", Actor{UserID: suite.owner.ID}))
+	permission, err := GetPermissionsByChild(suite.child.ID)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), DeletePermissionByID(permission[0].ID, Actor{UserID: suite.owner.ID}))
+
+	entries, err := GetAuditLogsByChild(suite.child.ID, 0, 0)
+	assert.NoError(suite.T(), err)
+
+	var actions []string
+	for _, entry := range entries {
+		actions = append(actions, entry.EntityType+":"+entry.Action)
+		if entry.Action == ActionDelete {
+			assert.NotEmpty(suite.T(), entry.Before)
+			assert.Empty(suite.T(), entry.After)
+		}
+	}
+	assert.Equal(suite.T(), []string{
+		"permission:delete",
+		"permission:create",
+		"book:delete",
+		"book:create",
+		"child:create",
+	}, actions)
+}
+
+func (suite *AuditServiceTestSuite) TestFilterByActor() {
+	_, err := UpdateChild(suite.child.ID, models.UpdateChildRequest{
+		FirstName: "Renamed",
+		LastName:  "Child",
+		Grade:     "3rd",
+	}, Actor{UserID: suite.editor.ID})
+	assert.NoError(suite.T(), err)
+
+	entries, err := GetAuditLogs(AuditLogFilter{ActorID: suite.editor.ID})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), entries, 1)
+	assert.Equal(suite.T(), EntityChild, entries[0].EntityType)
+}
+
+func (suite *AuditServiceTestSuite) TestAuditLogIsAppendOnly() {
+	entries, err := GetAuditLogs(AuditLogFilter{})
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), entries)
+
+	entry := entries[0]
+	entry.Action = "tampered"
+	assert.Error(suite.T(), config.DB.Save(&entry).Error)
+	assert.Error(suite.T(), config.DB.Delete(&entry).Error)
+}
+
+func TestAuditServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(AuditServiceTestSuite))
+}
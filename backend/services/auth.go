@@ -111,13 +111,17 @@ func Login(loginReq models.LoginRequest) (*models.LoginResponse, error) {
 	}
 
 	userResponse := models.UserResponse{
-		ID:            user.ID,
-		Email:         user.Email,
-		FirstName:     user.FirstName,
-		LastName:      user.LastName,
-		IsAdmin:       user.IsAdmin,
-		EmailVerified: user.EmailVerified,
-		CreatedAt:     user.CreatedAt,
+		ID:                 user.ID,
+		Email:              user.Email,
+		FirstName:          user.FirstName,
+		LastName:           user.LastName,
+		IsAdmin:            user.IsAdmin,
+		EmailVerified:      user.EmailVerified,
+		OrganizationID:     user.OrganizationID,
+		OrgRole:            user.OrgRole,
+		Locale:             user.Locale,
+		ReadingLevelSystem: user.ReadingLevelSystem,
+		CreatedAt:          user.CreatedAt,
 	}
 
 	return &models.LoginResponse{
@@ -0,0 +1,449 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"gorm.io/gorm"
+)
+
+// BackupFormat identifies a backup archive
+const BackupFormat = "booktracker-backup"
+
+// BackupVersion is the archive version written by ExportBackup. RestoreBackup reads this and
+// every earlier version; bump it whenever a field changes meaning or a required field is added.
+const BackupVersion = 1
+
+var (
+	// ErrInvalidBackup is returned for files that are not a well-formed backup archive
+	ErrInvalidBackup = errors.New("file is not a valid backup archive")
+
+	// ErrUnsupportedBackupVersion is returned for archives written by a newer version of the app
+	ErrUnsupportedBackupVersion = fmt.Errorf("backup archive is newer than version %d", BackupVersion)
+)
+
+// BackupArchive is a portable copy of everything a user owns. IDs are the IDs on the instance
+// the archive came from and only link the records inside it; restoring assigns new ones.
+// Logins are stateless tokens, so there are no sessions to carry over.
+type BackupArchive struct {
+	Format      string             `json:"format"`
+	Version     int                `json:"version"`
+	CreatedAt   time.Time          `json:"createdAt"`
+	Account     BackupAccount      `json:"account"`
+	Children    []BackupChild      `json:"children"`
+	SharedBooks []BackupSharedBook `json:"sharedBooks"`
+	Books       []BackupBook       `json:"books"`
+	Permissions []BackupPermission `json:"permissions"`
+}
+
+// BackupAccount records whose data the archive holds; restoring never changes the account
+type BackupAccount struct {
+	Email     string `json:"email"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Locale    string `json:"locale"`
+}
+
+// BackupChild is a child owned by the account
+type BackupChild struct {
+	ID        uint      `json:"id"`
+	FirstName string    `json:"firstName"`
+	LastName  string    `json:"lastName"`
+	Grade     string    `json:"grade"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// BackupSharedBook is a catalog book referenced by one of the books. Restoring only uses its ISBN
+// to find the book in the catalog or on Open Library; the other fields are kept for people
+// reading the archive and to restore the book as a custom book when the ISBN is not found.
+type BackupSharedBook struct {
+	ID       uint   `json:"id"`
+	ISBN     string `json:"isbn"`
+	Title    string `json:"title"`
+	Author   string `json:"author"`
+	CoverURL string `json:"coverUrl,omitempty"`
+	Source   string `json:"source"`
+}
+
+// BackupBook is a reading record of one of the children
+type BackupBook struct {
+	ChildID        uint      `json:"childId"`
+	SharedBookID   *uint     `json:"sharedBookId,omitempty"`
+	CustomTitle    string    `json:"customTitle,omitempty"`
+	CustomAuthor   string    `json:"customAuthor,omitempty"`
+	CustomISBN     string    `json:"customIsbn,omitempty"`
+	DateRead       string    `json:"dateRead"`
+	LexileLevel    string    `json:"lexileLevel,omitempty"`
+	IsPartial      bool      `json:"isPartial"`
+	PartialComment string    `json:"partialComment,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	// ReadingLevels are the book's levels in systems other than Lexile
+	ReadingLevels []models.ReadingLevelInput `json:"readingLevels,omitempty"`
+}
+
+// BackupPermission is access to one of the children granted to another user, who is identified
+// by email because user IDs differ between instances
+type BackupPermission struct {
+	ChildID        uint   `json:"childId"`
+	Email          string `json:"email"`
+	PermissionType string `json:"permissionType"`
+}
+
+// RestoreResult counts what RestoreBackup added
+type RestoreResult struct {
+	Children          int `json:"children"`
+	Books             int `json:"books"`
+	SharedBooksAdded  int `json:"sharedBooksAdded"`
+	SharedBooksMerged int `json:"sharedBooksMerged"`
+	// CustomBooks are books whose shared book was not found, restored as custom books
+	CustomBooks int `json:"customBooks"`
+	Permissions int `json:"permissions"`
+	// SkippedPermissions are the emails of grantees without an account on this instance
+	SkippedPermissions []string `json:"skippedPermissions"`
+}
+
+// ExportBackup writes an archive of the children the user owns, with their books, the shared
+// books those reference and the permissions granted on them. Children shared with the user
+// belong to someone else's backup.
+func ExportBackup(w io.Writer, userID uint) error {
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	archive := BackupArchive{
+		Format:    BackupFormat,
+		Version:   BackupVersion,
+		CreatedAt: time.Now().UTC(),
+		Account: BackupAccount{
+			Email:     user.Email,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			Locale:    user.Locale,
+		},
+		Children:    []BackupChild{},
+		SharedBooks: []BackupSharedBook{},
+		Books:       []BackupBook{},
+		Permissions: []BackupPermission{},
+	}
+
+	children, err := GetChildrenByOwner(userID)
+	if err != nil {
+		return err
+	}
+	if len(children) > 0 {
+		childIDs := make([]uint, len(children))
+		for i, child := range children {
+			childIDs[i] = child.ID
+			archive.Children = append(archive.Children, BackupChild{
+				ID:        child.ID,
+				FirstName: child.FirstName,
+				LastName:  child.LastName,
+				Grade:     child.Grade,
+				CreatedAt: child.CreatedAt,
+			})
+		}
+
+		var books []models.Book
+		if err := config.DB.Preload("SharedBook").Preload("ReadingLevels").Where("child_id IN ?", childIDs).
+			Order("child_id, date_read, id").Find(&books).Error; err != nil {
+			return err
+		}
+		sharedBooks := make(map[uint]bool)
+		for _, book := range books {
+			if book.SharedBook != nil && !sharedBooks[book.SharedBook.ID] {
+				sharedBooks[book.SharedBook.ID] = true
+				archive.SharedBooks = append(archive.SharedBooks, BackupSharedBook{
+					ID:       book.SharedBook.ID,
+					ISBN:     book.SharedBook.ISBN,
+					Title:    book.SharedBook.Title,
+					Author:   book.SharedBook.Author,
+					CoverURL: book.SharedBook.CoverURL,
+					Source:   book.SharedBook.Source,
+				})
+			}
+			backupBook := BackupBook{
+				ChildID:        book.ChildID,
+				SharedBookID:   book.SharedBookID,
+				CustomTitle:    book.CustomTitle,
+				CustomAuthor:   book.CustomAuthor,
+				CustomISBN:     book.CustomISBN,
+				DateRead:       book.DateRead,
+				LexileLevel:    book.LexileLevel,
+				IsPartial:      book.IsPartial,
+				PartialComment: book.PartialComment,
+				CreatedAt:      book.CreatedAt,
+			}
+			for _, level := range book.ReadingLevels {
+				backupBook.ReadingLevels = append(backupBook.ReadingLevels, models.ReadingLevelInput{
+					System: level.System,
+					Level:  level.Level,
+				})
+			}
+			archive.Books = append(archive.Books, backupBook)
+		}
+
+		var permissions []models.Permission
+		if err := config.DB.Preload("User").Where("child_id IN ?", childIDs).
+			Order("child_id, id").Find(&permissions).Error; err != nil {
+			return err
+		}
+		for _, permission := range permissions {
+			archive.Permissions = append(archive.Permissions, BackupPermission{
+				ChildID:        permission.ChildID,
+				Email:          permission.User.Email,
+				PermissionType: permission.PermissionType,
+			})
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(archive)
+}
+
+// RestoreBackup adds the children in an archive to the user's account, with their books and
+// permissions, in one transaction. The archive's shared books are only looked up by ISBN, in this
+// instance's catalog and then on Open Library; the catalog never takes titles or covers from an
+// archive, so books whose ISBN is not found are restored as custom books. Permissions for people
+// without an account here are skipped and reported. Restoring an archive twice adds its children
+// twice.
+func RestoreBackup(r io.Reader, userID uint, actor Actor) (*RestoreResult, error) {
+	var archive BackupArchive
+	if err := json.NewDecoder(r).Decode(&archive); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	if err := validateBackup(&archive); err != nil {
+		return nil, err
+	}
+
+	result := &RestoreResult{SkippedPermissions: []string{}}
+	lookups := lookupBackupSharedBooks(archive.SharedBooks, result)
+	sharedBooks := make(map[uint]BackupSharedBook, len(archive.SharedBooks))
+	for _, backup := range archive.SharedBooks {
+		sharedBooks[backup.ID] = backup
+	}
+
+	var children []models.Child
+	var books []models.Book
+	var permissions []models.Permission
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		childIDs := make(map[uint]uint, len(archive.Children))
+		for _, backup := range archive.Children {
+			child := models.Child{
+				FirstName: backup.FirstName,
+				LastName:  backup.LastName,
+				Grade:     backup.Grade,
+				OwnerID:   userID,
+				CreatedAt: backup.CreatedAt,
+			}
+			if err := tx.Create(&child).Error; err != nil {
+				return err
+			}
+			childIDs[backup.ID] = child.ID
+			children = append(children, child)
+		}
+
+		for _, backup := range archive.Books {
+			book := models.Book{
+				ChildID:        childIDs[backup.ChildID],
+				CustomTitle:    backup.CustomTitle,
+				CustomAuthor:   backup.CustomAuthor,
+				CustomISBN:     backup.CustomISBN,
+				DateRead:       backup.DateRead,
+				LexileLevel:    backup.LexileLevel,
+				IsPartial:      backup.IsPartial,
+				PartialComment: backup.PartialComment,
+				CreatedAt:      backup.CreatedAt,
+			}
+			// Levels that do not parse are restored as they are, as the backfill leaves them
+			if err := applyLexile(&book); err != nil {
+				book.LexileLevel = backup.LexileLevel
+			}
+			if backup.SharedBookID != nil {
+				sharedBook := sharedBooks[*backup.SharedBookID]
+				if lookup := lookups[NormalizeISBN(sharedBook.ISBN)]; lookup != nil && lookup.SharedBook != nil {
+					book.SharedBookID = &lookup.SharedBook.ID
+				} else {
+					book.CustomTitle = sharedBook.Title
+					book.CustomAuthor = sharedBook.Author
+					book.CustomISBN = sharedBook.ISBN
+					result.CustomBooks++
+				}
+			}
+			if err := tx.Create(&book).Error; err != nil {
+				return err
+			}
+			// Reading levels were checked by validateBackup
+			levels, _ := readingLevelRows(&models.Book{}, backup.ReadingLevels)
+			if len(levels) > 0 {
+				if err := saveReadingLevels(tx, book.ID, levels); err != nil {
+					return err
+				}
+				book.ReadingLevels = levels
+			}
+			books = append(books, book)
+		}
+
+		for _, backup := range archive.Permissions {
+			var grantee models.User
+			if err := tx.Where("email = ?", backup.Email).First(&grantee).Error; err != nil {
+				if !errors.Is(err, gorm.ErrRecordNotFound) {
+					return err
+				}
+				result.SkippedPermissions = append(result.SkippedPermissions, backup.Email)
+				continue
+			}
+			if grantee.ID == userID {
+				continue // the user owns the restored children
+			}
+			permission := models.Permission{
+				UserID:         grantee.ID,
+				ChildID:        childIDs[backup.ChildID],
+				PermissionType: backup.PermissionType,
+			}
+			if err := tx.Create(&permission).Error; err != nil {
+				return err
+			}
+			permissions = append(permissions, permission)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateUserPermissions(userID)
+	for _, permission := range permissions {
+		invalidateUserPermissions(permission.UserID)
+	}
+
+	for _, child := range children {
+		fireChange(ChangeEvent{
+			Actor:      actor,
+			Action:     ActionCreate,
+			EntityType: EntityChild,
+			EntityID:   child.ID,
+			ChildID:    child.ID,
+			After:      child,
+		})
+	}
+	for _, book := range books {
+		fireChange(ChangeEvent{
+			Actor:      actor,
+			Action:     ActionImport,
+			EntityType: EntityBook,
+			EntityID:   book.ID,
+			ChildID:    book.ChildID,
+			After:      book,
+		})
+	}
+	for _, permission := range permissions {
+		fireChange(ChangeEvent{
+			Actor:      actor,
+			Action:     ActionCreate,
+			EntityType: EntityPermission,
+			EntityID:   permission.ID,
+			ChildID:    permission.ChildID,
+			After:      permission,
+		})
+	}
+
+	result.Children = len(children)
+	result.Books = len(books)
+	result.Permissions = len(permissions)
+	return result, nil
+}
+
+// lookupBackupSharedBooks resolves the archive's shared books by ISBN the way LookupSharedBook
+// does, counting the ones already in the catalog and the ones added from Open Library
+func lookupBackupSharedBooks(backups []BackupSharedBook, result *RestoreResult) map[string]*ISBNLookup {
+	lookups := make(map[string]*ISBNLookup)
+	var missing []string
+	for _, backup := range backups {
+		isbn := NormalizeISBN(backup.ISBN)
+		if _, ok := lookups[isbn]; ok || (len(isbn) != 10 && len(isbn) != 13) {
+			continue
+		}
+		lookups[isbn] = findSharedBookByISBN(isbn)
+		if lookups[isbn] == nil {
+			missing = append(missing, isbn)
+		} else {
+			result.SharedBooksMerged++
+		}
+	}
+	for isbn, lookup := range fetchSharedBooks(missing) {
+		lookups[isbn] = lookup
+		if lookup.SharedBook != nil {
+			result.SharedBooksAdded++
+		}
+	}
+	return lookups
+}
+
+// validateBackup checks the archive's version and that every reference points inside it, so a
+// restore never fails half way on a malformed file
+func validateBackup(archive *BackupArchive) error {
+	if archive.Format != BackupFormat || archive.Version < 1 {
+		return ErrInvalidBackup
+	}
+	if archive.Version > BackupVersion {
+		return ErrUnsupportedBackupVersion
+	}
+
+	children := make(map[uint]bool, len(archive.Children))
+	for _, child := range archive.Children {
+		if children[child.ID] || child.FirstName == "" {
+			return fmt.Errorf("%w: child %d is repeated or has no name", ErrInvalidBackup, child.ID)
+		}
+		children[child.ID] = true
+	}
+	sharedBooks := make(map[uint]bool, len(archive.SharedBooks))
+	for _, sharedBook := range archive.SharedBooks {
+		if sharedBooks[sharedBook.ID] || sharedBook.ISBN == "" || sharedBook.Title == "" {
+			return fmt.Errorf("%w: shared book %d is repeated or has no ISBN or title", ErrInvalidBackup, sharedBook.ID)
+		}
+		sharedBooks[sharedBook.ID] = true
+	}
+	for i, book := range archive.Books {
+		if !children[book.ChildID] {
+			return fmt.Errorf("%w: book %d belongs to a child that is not in the archive", ErrInvalidBackup, i+1)
+		}
+		if book.SharedBookID != nil && !sharedBooks[*book.SharedBookID] {
+			return fmt.Errorf("%w: book %d refers to a shared book that is not in the archive", ErrInvalidBackup, i+1)
+		}
+		if book.SharedBookID == nil && book.CustomTitle == "" {
+			return fmt.Errorf("%w: book %d has no title", ErrInvalidBackup, i+1)
+		}
+		if _, err := time.Parse("2006-01-02", book.DateRead); err != nil {
+			return fmt.Errorf("%w: book %d has an invalid date read", ErrInvalidBackup, i+1)
+		}
+		for _, level := range book.ReadingLevels {
+			if level.System == models.ReadingLevelLexile {
+				return fmt.Errorf("%w: book %d has its Lexile level among its other reading levels", ErrInvalidBackup, i+1)
+			}
+		}
+		if _, err := readingLevelRows(&models.Book{}, book.ReadingLevels); err != nil {
+			return fmt.Errorf("%w: book %d: %v", ErrInvalidBackup, i+1, err)
+		}
+	}
+	grants := make(map[string]bool, len(archive.Permissions))
+	for i, permission := range archive.Permissions {
+		grant := fmt.Sprintf("%d|%s", permission.ChildID, permission.Email)
+		if grants[grant] {
+			return fmt.Errorf("%w: permission %d is repeated", ErrInvalidBackup, i+1)
+		}
+		grants[grant] = true
+		if !children[permission.ChildID] {
+			return fmt.Errorf("%w: permission %d is for a child that is not in the archive", ErrInvalidBackup, i+1)
+		}
+		if permission.PermissionType != "VIEW" && permission.PermissionType != "EDIT" {
+			return fmt.Errorf("%w: permission %d has an unknown type", ErrInvalidBackup, i+1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type BackupTestSuite struct {
+	suite.Suite
+	owner       *models.User
+	coParent    *models.User
+	sam         *models.Child
+	openLibrary *httptest.Server
+	originalURL string
+}
+
+func (suite *BackupTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+	SharedPermissionCache().Clear()
+
+	// Open Library knows one book
+	suite.openLibrary = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("bibkeys") == "ISBN:9780439023481" {
+			w.Write([]byte(`{"ISBN:9780439023481": {"title": "The Hunger Games", "authors": [{"name": "Suzanne Collins"}]}}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	suite.originalURL = openLibraryBooksURL
+	openLibraryBooksURL = suite.openLibrary.URL
+
+	var err error
+	suite.owner, err = CreateUser(models.CreateUserRequest{
+		Email:     "owner@example.com",
+		Password:  "password123",
+		FirstName: "Owner",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	suite.coParent, err = CreateUser(models.CreateUserRequest{
+		Email:     "coparent@example.com",
+		Password:  "password123",
+		FirstName: "Co",
+		LastName:  "Parent",
+	})
+	assert.NoError(suite.T(), err)
+
+	suite.sam, err = CreateChild(models.CreateChildRequest{FirstName: "Sam", LastName: "Reader", Grade: "3"}, suite.owner.ID)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), CreatePermission(suite.coParent.ID, suite.sam.ID, "EDIT", SystemActor))
+
+	matilda := models.SharedBook{ISBN: "9780140328721", Title: "Matilda", Author: "Roald Dahl"}
+	assert.NoError(suite.T(), config.DB.Create(&matilda).Error)
+	assert.NoError(suite.T(), config.DB.Create(&models.Book{
+		ChildID: suite.sam.ID, SharedBookID: &matilda.ID, DateRead: "2024-09-10", LexileLevel: "840L",
+	}).Error)
+	assert.NoError(suite.T(), config.DB.Create(&models.Book{
+		ChildID: suite.sam.ID, CustomTitle: "Family Stories", CustomAuthor: "Grandma", DateRead: "2024-09-02",
+		IsPartial: true, PartialComment: "Chapter 1",
+	}).Error)
+
+	// Books of children shared with the owner are not part of the owner's backup
+	other, err := CreateChild(models.CreateChildRequest{FirstName: "Other", LastName: "Child"}, suite.coParent.ID)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), CreatePermission(suite.owner.ID, other.ID, "VIEW", SystemActor))
+}
+
+func (suite *BackupTestSuite) TearDownTest() {
+	openLibraryBooksURL = suite.originalURL
+	suite.openLibrary.Close()
+	config.CleanupTestDatabase()
+}
+
+func (suite *BackupTestSuite) export() BackupArchive {
+	var output bytes.Buffer
+	assert.NoError(suite.T(), ExportBackup(&output, suite.owner.ID))
+	var archive BackupArchive
+	assert.NoError(suite.T(), json.Unmarshal(output.Bytes(), &archive))
+	return archive
+}
+
+func (suite *BackupTestSuite) TestExportBackup() {
+	archive := suite.export()
+	assert.Equal(suite.T(), BackupFormat, archive.Format)
+	assert.Equal(suite.T(), BackupVersion, archive.Version)
+	assert.Equal(suite.T(), "owner@example.com", archive.Account.Email)
+	assert.Len(suite.T(), archive.Children, 1)
+	assert.Equal(suite.T(), "Sam", archive.Children[0].FirstName)
+	assert.Len(suite.T(), archive.Books, 2)
+	assert.Len(suite.T(), archive.SharedBooks, 1)
+	assert.Equal(suite.T(), []BackupPermission{
+		{ChildID: suite.sam.ID, Email: "coparent@example.com", PermissionType: "EDIT"},
+	}, archive.Permissions)
+}
+
+func (suite *BackupTestSuite) TestRestoreBackup() {
+	archive := suite.export()
+	archive.Permissions = append(archive.Permissions, BackupPermission{
+		ChildID: archive.Children[0].ID, Email: "grandma@example.com", PermissionType: "VIEW",
+	})
+	// A book whose shared book is not in this instance's catalog yet, with the archive's own
+	// details, which are not trusted
+	archive.SharedBooks = append(archive.SharedBooks, BackupSharedBook{
+		ID: 999, ISBN: "9780439023481", Title: "Planted Title", Author: "Someone", CoverURL: "https://evil.example.com/cover.jpg", Source: "openlibrary",
+	})
+	// And one that neither the catalog nor Open Library knows
+	archive.SharedBooks = append(archive.SharedBooks, BackupSharedBook{
+		ID: 1000, ISBN: "9781234567897", Title: "Grandpa's Tales", Author: "Grandpa", CoverURL: "https://evil.example.com/other.jpg",
+	})
+	hungerGamesID, talesID := uint(999), uint(1000)
+	archive.Books = append(archive.Books,
+		BackupBook{ChildID: archive.Children[0].ID, SharedBookID: &hungerGamesID, DateRead: "2024-09-20"},
+		BackupBook{ChildID: archive.Children[0].ID, SharedBookID: &talesID, DateRead: "2024-09-25"},
+	)
+	data, err := json.Marshal(archive)
+	assert.NoError(suite.T(), err)
+
+	// Restore into a new account, as if on another instance
+	newOwner, err := CreateUser(models.CreateUserRequest{
+		Email:     "owner@newhost.example.com",
+		Password:  "password123",
+		FirstName: "Owner",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	result, err := RestoreBackup(bytes.NewReader(data), newOwner.ID, Actor{UserID: newOwner.ID})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, result.Children)
+	assert.Equal(suite.T(), 4, result.Books)
+	assert.Equal(suite.T(), 1, result.SharedBooksMerged)
+	assert.Equal(suite.T(), 1, result.SharedBooksAdded)
+	assert.Equal(suite.T(), 1, result.CustomBooks)
+	assert.Equal(suite.T(), 1, result.Permissions)
+	assert.Equal(suite.T(), []string{"grandma@example.com"}, result.SkippedPermissions)
+
+	children, err := GetChildrenByOwner(newOwner.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), children, 1)
+	restored := children[0]
+	assert.NotEqual(suite.T(), suite.sam.ID, restored.ID)
+	assert.Equal(suite.T(), "3", restored.Grade)
+
+	books, err := GetBooksByChild(restored.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), books, 4)
+	titles := make(map[string]models.Book)
+	for _, book := range books {
+		if book.SharedBook != nil {
+			titles[book.SharedBook.Title] = book
+		} else {
+			titles[book.CustomTitle] = book
+		}
+	}
+	assert.Equal(suite.T(), "840L", titles["Matilda"].LexileLevel)
+	assert.Equal(suite.T(), "Chapter 1", titles["Family Stories"].PartialComment)
+	// The catalog entry comes from Open Library, not the archive
+	if assert.Contains(suite.T(), titles, "The Hunger Games") {
+		hungerGames := titles["The Hunger Games"].SharedBook
+		assert.Equal(suite.T(), "Suzanne Collins", hungerGames.Author)
+		assert.NotContains(suite.T(), hungerGames.CoverURL, "evil.example.com")
+	}
+	if assert.Contains(suite.T(), titles, "Grandpa's Tales") {
+		tales := titles["Grandpa's Tales"]
+		assert.Nil(suite.T(), tales.SharedBookID)
+		assert.Equal(suite.T(), "9781234567897", tales.CustomISBN)
+	}
+	assert.NotContains(suite.T(), titles, "Planted Title")
+	var planted int64
+	config.DB.Model(&models.SharedBook{}).Where("cover_url LIKE ?", "%evil.example.com%").Count(&planted)
+	assert.Zero(suite.T(), planted)
+
+	// Matilda was merged into the existing catalog entry by ISBN
+	var sharedBooks int64
+	config.DB.Model(&models.SharedBook{}).Where("isbn = ?", "9780140328721").Count(&sharedBooks)
+	assert.Equal(suite.T(), int64(1), sharedBooks)
+
+	hasPermission, err := HasChildPermission(suite.coParent.ID, restored.ID, "EDIT")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), hasPermission)
+}
+
+func (suite *BackupTestSuite) TestRestoreRejectsInvalidArchives() {
+	archive := suite.export()
+	before := suite.export()
+
+	archive.Version = BackupVersion + 1
+	data, _ := json.Marshal(archive)
+	_, err := RestoreBackup(bytes.NewReader(data), suite.owner.ID, SystemActor)
+	assert.ErrorIs(suite.T(), err, ErrUnsupportedBackupVersion)
+
+	archive.Version = BackupVersion
+	archive.Books[0].ChildID = 12345
+	data, _ = json.Marshal(archive)
+	_, err = RestoreBackup(bytes.NewReader(data), suite.owner.ID, SystemActor)
+	assert.ErrorIs(suite.T(), err, ErrInvalidBackup)
+
+	_, err = RestoreBackup(strings.NewReader(`{"children": [`), suite.owner.ID, SystemActor)
+	assert.ErrorIs(suite.T(), err, ErrInvalidBackup)
+
+	// Nothing was restored
+	assert.Equal(suite.T(), len(before.Children), len(suite.export().Children))
+}
+
+func TestBackupTestSuite(t *testing.T) {
+	suite.Run(t, new(BackupTestSuite))
+}
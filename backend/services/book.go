@@ -10,25 +10,32 @@ import (
 )
 
 // CreateBook creates a new book reading record
-func CreateBook(req models.CreateBookRequest) (*models.Book, error) {
+func CreateBook(req models.CreateBookRequest, actor Actor) (*models.Book, error) {
+	book, err := insertBook(config.DB, req)
+	if err != nil {
+		return nil, err
+	}
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionCreate,
+		EntityType: EntityBook,
+		EntityID:   book.ID,
+		ChildID:    book.ChildID,
+		After:      *book,
+	})
+
+	return book, nil
+}
+
+// insertBook saves a reading record through db, refusing a second full reading of the same book
+func insertBook(db *gorm.DB, req models.CreateBookRequest) (*models.Book, error) {
 	// For partial books, we allow duplicates since they represent different portions
 	if !req.IsPartial {
-		// Check for duplicate reading record for this child (only for non-partial books)
-		var existingBook models.Book
-		var duplicateQuery *gorm.DB
-		
-		if req.SharedBookID != nil {
-			// Check if child has already read this shared book (non-partial)
-			duplicateQuery = config.DB.Where("child_id = ? AND shared_book_id = ? AND is_partial = ?", req.ChildID, *req.SharedBookID, false)
-		} else if req.IsCustomBook {
-			// Check if child has already read this custom book (by title + author, non-partial)
-			duplicateQuery = config.DB.Where("child_id = ? AND custom_title = ? AND custom_author = ? AND is_partial = ?", 
-				req.ChildID, req.Title, req.Author, false)
-		} else {
+		if req.SharedBookID == nil && !req.IsCustomBook {
 			return nil, errors.New("invalid book request: must specify either shared book ID or custom book")
 		}
-		
-		if err := duplicateQuery.First(&existingBook).Error; err == nil {
+		if _, found := findDuplicateBook(db, req); found {
 			return nil, errors.New("child has already read this book")
 		}
 	}
@@ -48,19 +55,49 @@ func CreateBook(req models.CreateBookRequest) (*models.Book, error) {
 		book.CustomAuthor = req.Author
 		book.CustomISBN = req.ISBN
 	}
+	levels, err := readingLevelRows(&book, req.ReadingLevels)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyLexile(&book); err != nil {
+		return nil, err
+	}
 
-	result := config.DB.Create(&book)
+	result := db.Create(&book)
 	if result.Error != nil {
 		return nil, result.Error
 	}
-
+	if len(levels) > 0 {
+		if err := saveReadingLevels(db, book.ID, levels); err != nil {
+			return nil, err
+		}
+		book.ReadingLevels = levels
+	}
 	return &book, nil
 }
 
+// findDuplicateBook finds the child's full (non-partial) reading of the book in req
+func findDuplicateBook(db *gorm.DB, req models.CreateBookRequest) (*models.Book, bool) {
+	var existingBook models.Book
+	var duplicateQuery *gorm.DB
+	if req.SharedBookID != nil {
+		// Check if child has already read this shared book (non-partial)
+		duplicateQuery = db.Where("child_id = ? AND shared_book_id = ? AND is_partial = ?", req.ChildID, *req.SharedBookID, false)
+	} else {
+		// Check if child has already read this custom book (by title + author, non-partial)
+		duplicateQuery = db.Where("child_id = ? AND custom_title = ? AND custom_author = ? AND is_partial = ?",
+			req.ChildID, req.Title, req.Author, false)
+	}
+	if err := duplicateQuery.First(&existingBook).Error; err != nil {
+		return nil, false
+	}
+	return &existingBook, true
+}
+
 // GetBookByID gets a book by ID
 func GetBookByID(id uint) (*models.Book, error) {
 	var book models.Book
-	result := config.DB.Preload("Child").Preload("SharedBook").First(&book, id)
+	result := config.DB.Preload("Child").Preload("SharedBook").Preload("ReadingLevels").First(&book, id)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, errors.New("book not found")
@@ -73,7 +110,7 @@ func GetBookByID(id uint) (*models.Book, error) {
 // GetBooksByChild gets all books for a child
 func GetBooksByChild(childID uint) ([]models.Book, error) {
 	var books []models.Book
-	result := config.DB.Preload("SharedBook").Where("child_id = ?", childID).Order("date_read DESC").Find(&books)
+	result := config.DB.Preload("SharedBook").Preload("ReadingLevels").Where("child_id = ?", childID).Order("date_read DESC").Find(&books)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -84,14 +121,18 @@ func GetBooksByChild(childID uint) ([]models.Book, error) {
 func GetBooksForUser(userID uint) ([]models.Book, error) {
 	var books []models.Book
 	
-	// Get books for children owned by user or children user has permissions for
-	result := config.DB.Preload("SharedBook").Raw(`
+	// Get books for children owned by user, shared with the user, in one of the user's households or classrooms
+	result := config.DB.Preload("SharedBook").Preload("ReadingLevels").Raw(`
 		SELECT DISTINCT b.* FROM books b 
 		JOIN children c ON b.child_id = c.id 
-		LEFT JOIN permissions p ON c.id = p.child_id 
-		WHERE c.owner_id = ? OR p.user_id = ?
+		LEFT JOIN permissions p ON c.id = p.child_id AND p.deleted_at IS NULL AND p.user_id = ?
+		LEFT JOIN household_members hm ON c.household_id = hm.household_id AND hm.user_id = ?
+		LEFT JOIN classroom_students cs ON c.id = cs.child_id
+			AND cs.classroom_id IN (SELECT id FROM classrooms WHERE teacher_id = ?)
+		WHERE (c.owner_id = ? OR p.user_id IS NOT NULL OR hm.user_id IS NOT NULL OR cs.id IS NOT NULL)
+			AND b.deleted_at IS NULL AND c.deleted_at IS NULL
 		ORDER BY b.date_read DESC
-	`, userID, userID).Find(&books)
+	`, userID, userID, userID, userID).Find(&books)
 	
 	if result.Error != nil {
 		return nil, result.Error
@@ -100,19 +141,27 @@ func GetBooksForUser(userID uint) ([]models.Book, error) {
 }
 
 // UpdateBook updates a book reading record
-func UpdateBook(id uint, req models.UpdateBookRequest) (*models.Book, error) {
+func UpdateBook(id uint, req models.UpdateBookRequest, actor Actor) (*models.Book, error) {
 	var book models.Book
-	result := config.DB.Preload("SharedBook").First(&book, id)
+	result := config.DB.Preload("SharedBook").Preload("ReadingLevels").First(&book, id)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, errors.New("book not found")
 		}
 		return nil, result.Error
 	}
+	before := book
 
-	// Allow updating date read, lexile level, and partial info
+	// Allow updating date read, reading levels, and partial info
 	book.DateRead = req.DateRead
 	book.LexileLevel = req.LexileLevel
+	levels, err := readingLevelRows(&book, req.ReadingLevels)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyLexile(&book); err != nil {
+		return nil, err
+	}
 	book.IsPartial = req.IsPartial
 	book.PartialComment = req.PartialComment
 	
@@ -129,23 +178,64 @@ func UpdateBook(id uint, req models.UpdateBookRequest) (*models.Book, error) {
 		}
 	}
 
-	result = config.DB.Save(&book)
-	if result.Error != nil {
-		return nil, result.Error
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("ReadingLevels").Save(&book).Error; err != nil {
+			return err
+		}
+		// Without readingLevels the other systems are left as they are
+		if req.ReadingLevels == nil {
+			return nil
+		}
+		return saveReadingLevels(tx, book.ID, levels)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if req.ReadingLevels != nil {
+		book.ReadingLevels = levels
 	}
 
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionUpdate,
+		EntityType: EntityBook,
+		EntityID:   book.ID,
+		ChildID:    book.ChildID,
+		Before:     before,
+		After:      book,
+	})
+
 	return &book, nil
 }
 
-// DeleteBook deletes a book
-func DeleteBook(id uint) error {
-	result := config.DB.Delete(&models.Book{}, id)
+// DeleteBook moves a book to the trash
+func DeleteBook(id uint, actor Actor) error {
+	var book models.Book
+	result := config.DB.First(&book, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return errors.New("book not found")
+		}
+		return result.Error
+	}
+
+	result = config.DB.Delete(&models.Book{}, id)
 	if result.Error != nil {
 		return result.Error
 	}
 	if result.RowsAffected == 0 {
 		return errors.New("book not found")
 	}
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionDelete,
+		EntityType: EntityBook,
+		EntityID:   book.ID,
+		ChildID:    book.ChildID,
+		Before:     book,
+	})
+
 	return nil
 }
 
@@ -165,7 +255,7 @@ func GetBooksByChildAndMonth(childID uint, year int, month int) ([]models.Book,
 	}
 	endDate := fmt.Sprintf("%d-%02d-01", endYear, endMonth)
 	
-	result := config.DB.Preload("SharedBook").Where("child_id = ? AND date_read >= ? AND date_read < ?", 
+	result := config.DB.Preload("SharedBook").Preload("ReadingLevels").Where("child_id = ? AND date_read >= ? AND date_read < ?", 
 		childID, startDate, endDate).Order("date_read DESC").Find(&books)
 	
 	return books, result.Error
@@ -199,32 +289,22 @@ func GetDB() *gorm.DB {
 }
 
 // CreateCustomBook creates a custom book reading record
-func CreateCustomBook(req models.CreateCustomBookRequest) (*models.Book, error) {
-	// For partial books, we allow duplicates since they represent different portions
-	if !req.IsPartial {
-		// Check for duplicate custom book for this child (only for non-partial books)
-		var existingBook models.Book
-		if err := config.DB.Where("child_id = ? AND custom_title = ? AND custom_author = ? AND is_partial = ?", 
-			req.ChildID, req.Title, req.Author, false).First(&existingBook).Error; err == nil {
-			return nil, errors.New("child has already read this book")
-		}
-	}
+func CreateCustomBook(req models.CreateCustomBookRequest, actor Actor) (*models.Book, error) {
+	return CreateBook(customBookRequest(req), actor)
+}
 
-	book := models.Book{
+// customBookRequest is the CreateBookRequest equivalent of a custom book request
+func customBookRequest(req models.CreateCustomBookRequest) models.CreateBookRequest {
+	return models.CreateBookRequest{
+		ISBN:           req.ISBN,
+		Title:          req.Title,
+		Author:         req.Author,
+		LexileLevel:    req.LexileLevel,
 		DateRead:       req.DateRead,
 		ChildID:        req.ChildID,
-		CustomTitle:    req.Title,
-		CustomAuthor:   req.Author,
-		CustomISBN:     req.ISBN,
-		LexileLevel:    req.LexileLevel,
+		IsCustomBook:   true,
 		IsPartial:      req.IsPartial,
 		PartialComment: req.PartialComment,
+		ReadingLevels:  req.ReadingLevels,
 	}
-
-	result := config.DB.Create(&book)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-
-	return &book, nil
-}
\ No newline at end of file
+}
@@ -58,7 +58,7 @@ func (suite *BookServiceTestSuite) TestCreateBookSuccess() {
 		IsCustomBook: true,
 	}
 
-	book, err := CreateBook(req)
+	book, err := CreateBook(req, SystemActor)
 
 	assert.NoError(suite.T(), err)
 	assert.NotNil(suite.T(), book)
@@ -78,7 +78,7 @@ func (suite *BookServiceTestSuite) TestGetBookByIDSuccess() {
 		IsCustomBook: true,
 	}
 
-	createdBook, err := CreateBook(req)
+	createdBook, err := CreateBook(req, SystemActor)
 	assert.NoError(suite.T(), err)
 
 	// Get book by ID
@@ -119,8 +119,8 @@ func (suite *BookServiceTestSuite) TestGetBooksByChild() {
 		IsCustomBook: true,
 	}
 
-	_, err1 := CreateBook(book1Req)
-	_, err2 := CreateBook(book2Req)
+	_, err1 := CreateBook(book1Req, SystemActor)
+	_, err2 := CreateBook(book2Req, SystemActor)
 	assert.NoError(suite.T(), err1)
 	assert.NoError(suite.T(), err2)
 
@@ -145,7 +145,7 @@ func (suite *BookServiceTestSuite) TestGetBooksForUser() {
 		IsCustomBook: true,
 	}
 
-	_, err := CreateBook(bookReq)
+	_, err := CreateBook(bookReq, SystemActor)
 	assert.NoError(suite.T(), err)
 
 	// Get books for user (owner should see their child's books)
@@ -166,7 +166,7 @@ func (suite *BookServiceTestSuite) TestUpdateBookSuccess() {
 		IsCustomBook: true,
 	}
 
-	createdBook, err := CreateBook(createReq)
+	createdBook, err := CreateBook(createReq, SystemActor)
 	assert.NoError(suite.T(), err)
 
 	// Update the book
@@ -176,7 +176,7 @@ func (suite *BookServiceTestSuite) TestUpdateBookSuccess() {
 		DateRead: "2023-10-02",
 	}
 
-	updatedBook, err := UpdateBook(createdBook.ID, updateReq)
+	updatedBook, err := UpdateBook(createdBook.ID, updateReq, SystemActor)
 
 	assert.NoError(suite.T(), err)
 	assert.NotNil(suite.T(), updatedBook)
@@ -194,7 +194,7 @@ func (suite *BookServiceTestSuite) TestUpdateBookNotFound() {
 		DateRead: "2023-10-02",
 	}
 
-	updatedBook, err := UpdateBook(999, updateReq)
+	updatedBook, err := UpdateBook(999, updateReq, SystemActor)
 
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), updatedBook)
@@ -211,11 +211,11 @@ func (suite *BookServiceTestSuite) TestDeleteBookSuccess() {
 		IsCustomBook: true,
 	}
 
-	createdBook, err := CreateBook(req)
+	createdBook, err := CreateBook(req, SystemActor)
 	assert.NoError(suite.T(), err)
 
 	// Delete the book
-	err = DeleteBook(createdBook.ID)
+	err = DeleteBook(createdBook.ID, SystemActor)
 	assert.NoError(suite.T(), err)
 
 	// Verify book is deleted
@@ -225,7 +225,7 @@ func (suite *BookServiceTestSuite) TestDeleteBookSuccess() {
 }
 
 func (suite *BookServiceTestSuite) TestDeleteBookNotFound() {
-	err := DeleteBook(999)
+	err := DeleteBook(999, SystemActor)
 
 	assert.Error(suite.T(), err)
 	assert.Equal(suite.T(), "book not found", err.Error())
@@ -259,8 +259,8 @@ func (suite *BookServiceTestSuite) TestGetBooksForUserWithMultipleChildren() {
 		IsCustomBook: true,
 	}
 
-	_, err1 := CreateBook(book1Req)
-	_, err2 := CreateBook(book2Req)
+	_, err1 := CreateBook(book1Req, SystemActor)
+	_, err2 := CreateBook(book2Req, SystemActor)
 	assert.NoError(suite.T(), err1)
 	assert.NoError(suite.T(), err2)
 
@@ -3,6 +3,7 @@ package services
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/booktracker/backend/config"
 	"github.com/booktracker/backend/models"
@@ -12,10 +13,11 @@ import (
 // CreateChild creates a new child
 func CreateChild(req models.CreateChildRequest, ownerID uint) (*models.Child, error) {
 	child := models.Child{
-		FirstName: req.FirstName,
-		LastName:  req.LastName,
-		Grade:     req.Grade,
-		OwnerID:   ownerID,
+		FirstName:   req.FirstName,
+		LastName:    req.LastName,
+		Grade:       req.Grade,
+		OwnerID:     ownerID,
+		HouseholdID: req.HouseholdID,
 	}
 
 	result := config.DB.Create(&child)
@@ -23,6 +25,21 @@ func CreateChild(req models.CreateChildRequest, ownerID uint) (*models.Child, er
 		return nil, result.Error
 	}
 
+	// The owner's (and household's) cached grants do not include the new child yet
+	invalidateUserPermissions(ownerID)
+	if child.HouseholdID != nil {
+		invalidateHouseholdPermissions(*child.HouseholdID)
+	}
+
+	fireChange(ChangeEvent{
+		Actor:      Actor{UserID: ownerID},
+		Action:     ActionCreate,
+		EntityType: EntityChild,
+		EntityID:   child.ID,
+		ChildID:    child.ID,
+		After:      child,
+	})
+
 	return &child, nil
 }
 
@@ -53,12 +70,16 @@ func GetChildrenByOwner(ownerID uint) ([]models.Child, error) {
 func GetChildrenWithPermission(userID uint) ([]models.Child, error) {
 	var children []models.Child
 	
-	// Get children owned by user or children user has permissions for
+	// Get children owned by user, shared with the user, in one of the user's households or classrooms
 	result := config.DB.Raw(`
 		SELECT DISTINCT c.* FROM children c 
-		LEFT JOIN permissions p ON c.id = p.child_id 
-		WHERE c.owner_id = ? OR p.user_id = ?
-	`, userID, userID).Scan(&children)
+		LEFT JOIN permissions p ON c.id = p.child_id AND p.deleted_at IS NULL AND p.user_id = ?
+		LEFT JOIN household_members hm ON c.household_id = hm.household_id AND hm.user_id = ?
+		LEFT JOIN classroom_students cs ON c.id = cs.child_id
+			AND cs.classroom_id IN (SELECT id FROM classrooms WHERE teacher_id = ?)
+		WHERE (c.owner_id = ? OR p.user_id IS NOT NULL OR hm.user_id IS NOT NULL OR cs.id IS NOT NULL)
+			AND c.deleted_at IS NULL
+	`, userID, userID, userID, userID).Scan(&children)
 	
 	if result.Error != nil {
 		return nil, result.Error
@@ -67,7 +88,7 @@ func GetChildrenWithPermission(userID uint) ([]models.Child, error) {
 }
 
 // UpdateChild updates a child
-func UpdateChild(id uint, req models.UpdateChildRequest) (*models.Child, error) {
+func UpdateChild(id uint, req models.UpdateChildRequest, actor Actor) (*models.Child, error) {
 	var child models.Child
 	result := config.DB.First(&child, id)
 	if result.Error != nil {
@@ -76,6 +97,7 @@ func UpdateChild(id uint, req models.UpdateChildRequest) (*models.Child, error)
 		}
 		return nil, result.Error
 	}
+	before := child
 
 	child.FirstName = req.FirstName
 	child.LastName = req.LastName
@@ -86,21 +108,123 @@ func UpdateChild(id uint, req models.UpdateChildRequest) (*models.Child, error)
 		return nil, result.Error
 	}
 
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionUpdate,
+		EntityType: EntityChild,
+		EntityID:   child.ID,
+		ChildID:    child.ID,
+		Before:     before,
+		After:      child,
+	})
+
 	return &child, nil
 }
 
-// DeleteChild deletes a child
-func DeleteChild(id uint) error {
-	result := config.DB.Delete(&models.Child{}, id)
+// DeleteChild moves a child, its books and its permissions to the trash
+func DeleteChild(id uint, actor Actor) error {
+	var child models.Child
+	result := config.DB.First(&child, id)
 	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return errors.New("child not found")
+		}
 		return result.Error
 	}
-	if result.RowsAffected == 0 {
-		return errors.New("child not found")
+
+	// Soft-delete the child with its books and permissions under one timestamp
+	// so RestoreChild can bring back exactly what was removed together
+	deletedAt := time.Now()
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Book{}).Where("child_id = ?", id).Update("deleted_at", deletedAt).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Permission{}).Where("child_id = ?", id).Update("deleted_at", deletedAt).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&models.Child{}).Where("id = ?", id).Update("deleted_at", deletedAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("child not found")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	invalidateChildPermissions(id)
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionDelete,
+		EntityType: EntityChild,
+		EntityID:   child.ID,
+		ChildID:    child.ID,
+		Before:     child,
+	})
+
 	return nil
 }
 
+// TransferChildOwnership makes another user the owner of a child.
+// The previous owner keeps EDIT access so the transfer cannot lock them out.
+func TransferChildOwnership(childID, newOwnerID uint, actor Actor) (*models.Child, error) {
+	var child models.Child
+	result := config.DB.First(&child, childID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("child not found")
+		}
+		return nil, result.Error
+	}
+
+	before := child
+	previousOwnerID := child.OwnerID
+	if previousOwnerID == newOwnerID {
+		return nil, errors.New("user already owns this child")
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&child).Update("owner_id", newOwnerID).Error; err != nil {
+			return err
+		}
+
+		// Neither owner needs an explicit grant row from before the transfer
+		if err := tx.Unscoped().Where("user_id IN ? AND child_id = ?", []uint{newOwnerID, previousOwnerID}, childID).Delete(&models.Permission{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.Permission{
+			UserID:         previousOwnerID,
+			ChildID:        childID,
+			PermissionType: "EDIT",
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateChildPermissions(childID)
+	invalidateUserPermissions(newOwnerID)
+	invalidateUserPermissions(previousOwnerID)
+
+	child.OwnerID = newOwnerID
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionTransfer,
+		EntityType: EntityChild,
+		EntityID:   child.ID,
+		ChildID:    child.ID,
+		Before:     before,
+		After:      child,
+	})
+
+	return &child, nil
+}
+
 // CheckChildPermission checks if a user has permission to access a child
 func CheckChildPermission(userID, childID uint, permissionType string) (bool, error) {
 	var child models.Child
@@ -114,6 +238,32 @@ func CheckChildPermission(userID, childID uint, permissionType string) (bool, er
 		return true, nil
 	}
 
+	// Household members have access by role
+	if child.HouseholdID != nil {
+		role, err := GetHouseholdRole(*child.HouseholdID, userID)
+		if err != nil {
+			return false, err
+		}
+		if role != "" && accessLevelSatisfies(HouseholdRoleAccess(role), permissionType) {
+			return true, nil
+		}
+	}
+
+	// Teachers can view the students linked to their classrooms
+	if permissionType == "VIEW" {
+		var linked int64
+		err := config.DB.Model(&models.ClassroomStudent{}).
+			Joins("JOIN classrooms ON classrooms.id = classroom_students.classroom_id").
+			Where("classroom_students.child_id = ? AND classrooms.teacher_id = ?", childID, userID).
+			Count(&linked).Error
+		if err != nil {
+			return false, err
+		}
+		if linked > 0 {
+			return true, nil
+		}
+	}
+
 	// Check explicit permissions
 	var permission models.Permission
 	result = config.DB.Where("user_id = ? AND child_id = ? AND permission_type = ?", userID, childID, permissionType).First(&permission)
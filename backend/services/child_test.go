@@ -157,7 +157,7 @@ func (suite *ChildServiceTestSuite) TestUpdateChildSuccess() {
 		Grade:     "3rd",
 	}
 
-	updatedChild, err := UpdateChild(createdChild.ID, updateReq)
+	updatedChild, err := UpdateChild(createdChild.ID, updateReq, SystemActor)
 
 	assert.NoError(suite.T(), err)
 	assert.NotNil(suite.T(), updatedChild)
@@ -174,7 +174,7 @@ func (suite *ChildServiceTestSuite) TestUpdateChildNotFound() {
 		Grade:     "3rd",
 	}
 
-	updatedChild, err := UpdateChild(999, updateReq)
+	updatedChild, err := UpdateChild(999, updateReq, SystemActor)
 
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), updatedChild)
@@ -193,7 +193,7 @@ func (suite *ChildServiceTestSuite) TestDeleteChildSuccess() {
 	assert.NoError(suite.T(), err)
 
 	// Delete the child
-	err = DeleteChild(createdChild.ID)
+	err = DeleteChild(createdChild.ID, SystemActor)
 	assert.NoError(suite.T(), err)
 
 	// Verify child is deleted
@@ -203,7 +203,7 @@ func (suite *ChildServiceTestSuite) TestDeleteChildSuccess() {
 }
 
 func (suite *ChildServiceTestSuite) TestDeleteChildNotFound() {
-	err := DeleteChild(999)
+	err := DeleteChild(999, SystemActor)
 
 	assert.Error(suite.T(), err)
 	assert.Equal(suite.T(), "child not found", err.Error())
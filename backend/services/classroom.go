@@ -0,0 +1,388 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"gorm.io/gorm"
+)
+
+// Entity types of classroom changes
+const (
+	EntityClassroom        = "classroom"
+	EntityClassroomStudent = "classroom_student"
+)
+
+// ErrClassroomOutsideOrganization is returned when a child's family and the classroom are in different organizations
+var ErrClassroomOutsideOrganization = errors.New("classroom belongs to a different organization")
+
+// joinCodeAlphabet leaves out characters that are easy to misread (0/O, 1/I/L)
+const joinCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// joinCodeLength gives 31^8 possible codes
+const joinCodeLength = 8
+
+// generateJoinCode generates a short code parents can type in
+func generateJoinCode() (string, error) {
+	code := make([]byte, joinCodeLength)
+	max := big.NewInt(int64(len(joinCodeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = joinCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// NormalizeJoinCode makes join codes case-insensitive and tolerant of spaces and dashes
+func NormalizeJoinCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// CreateClassroom creates a classroom taught by the acting user, inside the teacher's organization if they have one
+func CreateClassroom(req models.CreateClassroomRequest, actor Actor) (*models.Classroom, error) {
+	joinCode, err := generateJoinCode()
+	if err != nil {
+		return nil, err
+	}
+
+	teacher, err := GetUserByID(actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	classroom := models.Classroom{
+		Name:           req.Name,
+		TeacherID:      actor.UserID,
+		JoinCode:       joinCode,
+		MonthlyGoal:    req.MonthlyGoal,
+		OrganizationID: teacher.OrganizationID,
+	}
+
+	result := config.DB.Create(&classroom)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionCreate,
+		EntityType: EntityClassroom,
+		EntityID:   classroom.ID,
+		After:      classroom,
+	})
+
+	return &classroom, nil
+}
+
+// GetClassroomByID gets a classroom by ID
+func GetClassroomByID(id uint) (*models.Classroom, error) {
+	var classroom models.Classroom
+	result := config.DB.First(&classroom, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("classroom not found")
+		}
+		return nil, result.Error
+	}
+	return &classroom, nil
+}
+
+// GetClassroomsByTeacher gets every classroom a user teaches
+func GetClassroomsByTeacher(teacherID uint) ([]models.Classroom, error) {
+	var classrooms []models.Classroom
+	result := config.DB.Where("teacher_id = ?", teacherID).Order("name").Find(&classrooms)
+	return classrooms, result.Error
+}
+
+// GetClassroomsByChild gets the classrooms a child has been linked to
+func GetClassroomsByChild(childID uint) ([]models.Classroom, error) {
+	var classrooms []models.Classroom
+	result := config.DB.
+		Where("id IN (?)", config.DB.Model(&models.ClassroomStudent{}).Select("classroom_id").Where("child_id = ?", childID)).
+		Order("name").
+		Find(&classrooms)
+	return classrooms, result.Error
+}
+
+// GetChildMonthlyGoal is the highest monthly goal of the child's classrooms, 0 when none sets one
+func GetChildMonthlyGoal(childID uint) (int, error) {
+	classrooms, err := GetClassroomsByChild(childID)
+	if err != nil {
+		return 0, err
+	}
+	goal := 0
+	for _, classroom := range classrooms {
+		if classroom.MonthlyGoal > goal {
+			goal = classroom.MonthlyGoal
+		}
+	}
+	return goal, nil
+}
+
+// UpdateClassroom updates a classroom's name and monthly goal
+func UpdateClassroom(id uint, req models.UpdateClassroomRequest, actor Actor) (*models.Classroom, error) {
+	classroom, err := GetClassroomByID(id)
+	if err != nil {
+		return nil, err
+	}
+	before := *classroom
+
+	classroom.Name = req.Name
+	classroom.MonthlyGoal = req.MonthlyGoal
+	if err := config.DB.Save(classroom).Error; err != nil {
+		return nil, err
+	}
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionUpdate,
+		EntityType: EntityClassroom,
+		EntityID:   classroom.ID,
+		Before:     before,
+		After:      *classroom,
+	})
+
+	return classroom, nil
+}
+
+// RegenerateJoinCode replaces a classroom's join code; students already linked stay
+func RegenerateJoinCode(id uint, actor Actor) (*models.Classroom, error) {
+	classroom, err := GetClassroomByID(id)
+	if err != nil {
+		return nil, err
+	}
+	before := *classroom
+
+	joinCode, err := generateJoinCode()
+	if err != nil {
+		return nil, err
+	}
+	if err := config.DB.Model(classroom).Update("join_code", joinCode).Error; err != nil {
+		return nil, err
+	}
+	classroom.JoinCode = joinCode
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionUpdate,
+		EntityType: EntityClassroom,
+		EntityID:   classroom.ID,
+		Before:     before,
+		After:      *classroom,
+	})
+
+	return classroom, nil
+}
+
+// DeleteClassroom removes a classroom and unlinks its students
+func DeleteClassroom(id uint, actor Actor) error {
+	classroom, err := GetClassroomByID(id)
+	if err != nil {
+		return err
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("classroom_id = ?", id).Delete(&models.ClassroomStudent{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(classroom).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	invalidateUserPermissions(classroom.TeacherID)
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionDelete,
+		EntityType: EntityClassroom,
+		EntityID:   classroom.ID,
+		Before:     *classroom,
+	})
+
+	return nil
+}
+
+// JoinClassroom links a child to the classroom with the given join code
+func JoinClassroom(joinCode string, childID uint, actor Actor) (*models.Classroom, error) {
+	var classroom models.Classroom
+	result := config.DB.Where("join_code = ?", NormalizeJoinCode(joinCode)).First(&classroom)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid join code")
+		}
+		return nil, result.Error
+	}
+
+	// Children of an organization's families only join that organization's classrooms
+	child, err := GetChildByID(childID)
+	if err != nil {
+		return nil, err
+	}
+	owner := child.Owner
+	if owner.OrganizationID != nil &&
+		(classroom.OrganizationID == nil || *classroom.OrganizationID != *owner.OrganizationID) {
+		return nil, ErrClassroomOutsideOrganization
+	}
+
+	var existing int64
+	if err := config.DB.Model(&models.ClassroomStudent{}).
+		Where("classroom_id = ? AND child_id = ?", classroom.ID, childID).
+		Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, errors.New("child is already in this classroom")
+	}
+
+	student := models.ClassroomStudent{
+		ClassroomID: classroom.ID,
+		ChildID:     childID,
+		AddedByID:   actor.UserID,
+	}
+	if err := config.DB.Create(&student).Error; err != nil {
+		return nil, err
+	}
+
+	invalidateUserPermissions(classroom.TeacherID)
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionCreate,
+		EntityType: EntityClassroomStudent,
+		EntityID:   student.ID,
+		ChildID:    childID,
+		After:      student,
+	})
+
+	return &classroom, nil
+}
+
+// RemoveClassroomStudent unlinks a child from a classroom
+func RemoveClassroomStudent(classroomID, childID uint, actor Actor) error {
+	classroom, err := GetClassroomByID(classroomID)
+	if err != nil {
+		return err
+	}
+
+	var student models.ClassroomStudent
+	result := config.DB.Where("classroom_id = ? AND child_id = ?", classroomID, childID).First(&student)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return errors.New("child is not in this classroom")
+		}
+		return result.Error
+	}
+
+	if err := config.DB.Delete(&student).Error; err != nil {
+		return err
+	}
+
+	invalidateUserPermissions(classroom.TeacherID)
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionDelete,
+		EntityType: EntityClassroomStudent,
+		EntityID:   student.ID,
+		ChildID:    childID,
+		Before:     student,
+	})
+
+	return nil
+}
+
+// GetClassroomRoster gets the students of a classroom with their owners, leaving out trashed children
+func GetClassroomRoster(classroomID uint) ([]models.ClassroomStudent, error) {
+	var students []models.ClassroomStudent
+	result := config.DB.
+		InnerJoins("Child").
+		Where("classroom_students.classroom_id = ?", classroomID).
+		Order("Child.last_name, Child.first_name").
+		Find(&students)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	ownerIDs := make([]uint, len(students))
+	for i, student := range students {
+		ownerIDs[i] = student.Child.OwnerID
+	}
+	owners, err := GetUsersByIDs(ownerIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range students {
+		students[i].Child.Owner = owners[students[i].Child.OwnerID]
+	}
+
+	return students, nil
+}
+
+// CountClassroomStudents counts the live students of a classroom
+func CountClassroomStudents(classroomID uint) (int, error) {
+	var count int64
+	result := config.DB.Model(&models.ClassroomStudent{}).
+		Joins("JOIN children ON children.id = classroom_students.child_id AND children.deleted_at IS NULL").
+		Where("classroom_students.classroom_id = ?", classroomID).
+		Count(&count)
+	return int(count), result.Error
+}
+
+// GetClassroomStats counts each student's books for a month and flags those below the class goal
+func GetClassroomStats(classroomID uint, year int, month int) (*models.ClassroomStatsResponse, error) {
+	classroom, err := GetClassroomByID(classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	students, err := GetClassroomRoster(classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.ClassroomStatsResponse{
+		ClassroomID:       classroom.ID,
+		Year:              year,
+		Month:             month,
+		MonthlyGoal:       classroom.MonthlyGoal,
+		Students:          make([]models.ClassroomStudentStats, 0, len(students)),
+		StudentsBelowGoal: []models.ClassroomStudentStats{},
+	}
+
+	for _, student := range students {
+		count, err := GetBookCountByChildAndMonth(student.ChildID, year, month)
+		if err != nil {
+			return nil, err
+		}
+
+		studentStats := models.ClassroomStudentStats{
+			ChildID:   student.ChildID,
+			FirstName: student.Child.FirstName,
+			LastName:  student.Child.LastName,
+			BookCount: count,
+			BelowGoal: classroom.MonthlyGoal > 0 && count < classroom.MonthlyGoal,
+		}
+		stats.Students = append(stats.Students, studentStats)
+		stats.TotalBooks += count
+		if studentStats.BelowGoal {
+			stats.StudentsBelowGoal = append(stats.StudentsBelowGoal, studentStats)
+		}
+	}
+
+	if len(stats.Students) > 0 {
+		stats.AverageBooks = float64(stats.TotalBooks) / float64(len(stats.Students))
+	}
+
+	return stats, nil
+}
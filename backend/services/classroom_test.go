@@ -0,0 +1,174 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ClassroomServiceTestSuite struct {
+	suite.Suite
+	teacher   *models.User
+	parent    *models.User
+	classroom *models.Classroom
+}
+
+func (suite *ClassroomServiceTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+	SharedPermissionCache().Clear()
+
+	suite.teacher = suite.createUser("teacher@example.com")
+	suite.parent = suite.createUser("parent@example.com")
+
+	classroom, err := CreateClassroom(models.CreateClassroomRequest{Name: "3B", MonthlyGoal: 2}, Actor{UserID: suite.teacher.ID})
+	assert.NoError(suite.T(), err)
+	suite.classroom = classroom
+}
+
+func (suite *ClassroomServiceTestSuite) TearDownTest() {
+	config.CleanupTestDatabase()
+}
+
+func (suite *ClassroomServiceTestSuite) createUser(email string) *models.User {
+	user, err := CreateUser(models.CreateUserRequest{
+		Email:     email,
+		Password:  "password123",
+		FirstName: "Test",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	return user
+}
+
+func (suite *ClassroomServiceTestSuite) createStudent(firstName string, booksInMarch int) *models.Child {
+	child, err := CreateChild(models.CreateChildRequest{
+		FirstName: firstName,
+		LastName:  "Student",
+		Grade:     "3rd",
+	}, suite.parent.ID)
+	assert.NoError(suite.T(), err)
+
+	for i := 0; i < booksInMarch; i++ {
+		_, err := CreateCustomBook(models.CreateCustomBookRequest{
+			Title:    fmt.Sprintf("Title %d", i),
+			Author:   "Author",
+			DateRead: "2024-03-10",
+			ChildID:  child.ID,
+		}, SystemActor)
+		assert.NoError(suite.T(), err)
+	}
+
+	_, err = JoinClassroom(suite.classroom.JoinCode, child.ID, Actor{UserID: suite.parent.ID})
+	assert.NoError(suite.T(), err)
+	return child
+}
+
+func (suite *ClassroomServiceTestSuite) TestJoinCodeFormat() {
+	assert.Len(suite.T(), suite.classroom.JoinCode, joinCodeLength)
+	for _, r := range suite.classroom.JoinCode {
+		assert.True(suite.T(), strings.ContainsRune(joinCodeAlphabet, r))
+	}
+	assert.Equal(suite.T(), "ABCD2345", NormalizeJoinCode("abcd-2345 "))
+}
+
+func (suite *ClassroomServiceTestSuite) TestJoiningGrantsTeacherViewAccess() {
+	child, err := CreateChild(models.CreateChildRequest{
+		FirstName: "Test",
+		LastName:  "Student",
+		Grade:     "3rd",
+	}, suite.parent.ID)
+	assert.NoError(suite.T(), err)
+
+	hasPermission, err := HasChildPermission(suite.teacher.ID, child.ID, "VIEW")
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), hasPermission)
+
+	// Codes are accepted in lower case
+	_, err = JoinClassroom(strings.ToLower(suite.classroom.JoinCode), child.ID, Actor{UserID: suite.parent.ID})
+	assert.NoError(suite.T(), err)
+
+	hasPermission, err = HasChildPermission(suite.teacher.ID, child.ID, "VIEW")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), hasPermission)
+	hasPermission, err = HasChildPermission(suite.teacher.ID, child.ID, "EDIT")
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), hasPermission)
+
+	hasPermission, err = CheckChildPermission(suite.teacher.ID, child.ID, "VIEW")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), hasPermission)
+
+	children, err := GetChildrenWithPermission(suite.teacher.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), children, 1)
+
+	_, err = JoinClassroom(suite.classroom.JoinCode, child.ID, Actor{UserID: suite.parent.ID})
+	assert.Error(suite.T(), err)
+
+	assert.NoError(suite.T(), RemoveClassroomStudent(suite.classroom.ID, child.ID, Actor{UserID: suite.parent.ID}))
+	hasPermission, err = HasChildPermission(suite.teacher.ID, child.ID, "VIEW")
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), hasPermission)
+}
+
+func (suite *ClassroomServiceTestSuite) TestInvalidJoinCode() {
+	child, err := CreateChild(models.CreateChildRequest{
+		FirstName: "Test",
+		LastName:  "Student",
+		Grade:     "3rd",
+	}, suite.parent.ID)
+	assert.NoError(suite.T(), err)
+
+	_, err = JoinClassroom("NOPE", child.ID, Actor{UserID: suite.parent.ID})
+	assert.Error(suite.T(), err)
+
+	oldCode := suite.classroom.JoinCode
+	_, err = RegenerateJoinCode(suite.classroom.ID, Actor{UserID: suite.teacher.ID})
+	assert.NoError(suite.T(), err)
+	_, err = JoinClassroom(oldCode, child.ID, Actor{UserID: suite.parent.ID})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ClassroomServiceTestSuite) TestRosterAndStats() {
+	suite.createStudent("Ada", 3)
+	below := suite.createStudent("Ben", 1)
+	trashed := suite.createStudent("Cy", 0)
+	assert.NoError(suite.T(), DeleteChild(trashed.ID, SystemActor))
+
+	roster, err := GetClassroomRoster(suite.classroom.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), roster, 2)
+	assert.Equal(suite.T(), "Ada", roster[0].Child.FirstName)
+	assert.Equal(suite.T(), suite.parent.Email, roster[0].Child.Owner.Email)
+
+	stats, err := GetClassroomStats(suite.classroom.ID, 2024, 3)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 4, stats.TotalBooks)
+	assert.Equal(suite.T(), 2.0, stats.AverageBooks)
+	assert.Len(suite.T(), stats.Students, 2)
+	assert.Len(suite.T(), stats.StudentsBelowGoal, 1)
+	assert.Equal(suite.T(), below.ID, stats.StudentsBelowGoal[0].ChildID)
+
+	stats, err = GetClassroomStats(suite.classroom.ID, 2024, 4)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), stats.StudentsBelowGoal, 2)
+}
+
+func (suite *ClassroomServiceTestSuite) TestDeleteClassroomRevokesAccess() {
+	child := suite.createStudent("Ada", 0)
+	assert.NoError(suite.T(), DeleteClassroom(suite.classroom.ID, Actor{UserID: suite.teacher.ID}))
+
+	hasPermission, err := HasChildPermission(suite.teacher.ID, child.ID, "VIEW")
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), hasPermission)
+}
+
+func TestClassroomServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(ClassroomServiceTestSuite))
+}
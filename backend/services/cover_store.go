@@ -0,0 +1,382 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	xdraw "golang.org/x/image/draw"
+
+	// Decoders for the other formats book sources serve covers in
+	_ "golang.org/x/image/webp"
+	_ "image/gif"
+)
+
+// Cover sizes kept for every shared book
+const (
+	CoverSizeSmall  = "small"
+	CoverSizeMedium = "medium"
+	CoverSizeLarge  = "large"
+)
+
+// coverSizes is the box each size is scaled down to fit; smaller covers are not enlarged
+var coverSizes = map[string]image.Point{
+	CoverSizeSmall:  {X: 60, Y: 90},
+	CoverSizeMedium: {X: 180, Y: 270},
+	CoverSizeLarge:  {X: 360, Y: 540},
+}
+
+// maxCoverPixels refuses images whose dimensions would take too much memory to decode
+const maxCoverPixels = 40_000_000
+
+var (
+	// ErrCoverNotFound is returned for shared books without a cover, or sizes that are not stored
+	ErrCoverNotFound = errors.New("cover not found")
+
+	// ErrInvalidCoverSize is returned for sizes other than small, medium and large
+	ErrInvalidCoverSize = errors.New("cover size must be small, medium or large")
+)
+
+// Cover is a normalized cover image
+type Cover struct {
+	Data        []byte
+	ContentType string // image/jpeg, or image/png for covers with transparency
+}
+
+// CoverStore keeps the normalized covers of shared books. DiskCoverStore suits a single
+// instance; an implementation backed by object storage can be installed with SetCoverStore.
+type CoverStore interface {
+	// Get returns one size of a cover, or ErrCoverNotFound
+	Get(sharedBookID uint, size string) (*Cover, error)
+	// Put stores one size of a cover, replacing what was there
+	Put(sharedBookID uint, size string, cover *Cover) error
+	// Delete removes every size of a cover
+	Delete(sharedBookID uint) error
+}
+
+// DiskCoverStore keeps covers as files under Dir, one directory per shared book
+type DiskCoverStore struct {
+	Dir string
+}
+
+// NewDiskCoverStore creates a store rooted at dir
+func NewDiskCoverStore(dir string) *DiskCoverStore {
+	return &DiskCoverStore{Dir: dir}
+}
+
+func (s *DiskCoverStore) Get(sharedBookID uint, size string) (*Cover, error) {
+	for _, cover := range []struct{ extension, contentType string }{
+		{".jpg", "image/jpeg"},
+		{".png", "image/png"},
+	} {
+		data, err := os.ReadFile(s.path(sharedBookID, size, cover.extension))
+		if err == nil {
+			return &Cover{Data: data, ContentType: cover.contentType}, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, ErrCoverNotFound
+}
+
+func (s *DiskCoverStore) Put(sharedBookID uint, size string, cover *Cover) error {
+	extension, stale := ".jpg", ".png"
+	if cover.ContentType == "image/png" {
+		extension, stale = ".png", ".jpg"
+	}
+
+	dir := filepath.Join(s.Dir, fmt.Sprint(sharedBookID))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename it into place, so readers never see half a cover
+	file, err := os.CreateTemp(dir, size+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(cover.Data); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	if err := os.Rename(file.Name(), s.path(sharedBookID, size, extension)); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+
+	os.Remove(s.path(sharedBookID, size, stale))
+	return nil
+}
+
+func (s *DiskCoverStore) Delete(sharedBookID uint) error {
+	return os.RemoveAll(filepath.Join(s.Dir, fmt.Sprint(sharedBookID)))
+}
+
+func (s *DiskCoverStore) path(sharedBookID uint, size, extension string) string {
+	return filepath.Join(s.Dir, fmt.Sprint(sharedBookID), size+extension)
+}
+
+var (
+	sharedCoverStore      CoverStore
+	sharedCoverStoreReady bool
+	sharedCoverStoreMutex sync.Mutex
+)
+
+// SharedCoverStore returns the process-wide cover store: a DiskCoverStore in COVER_STORE_DIR
+// (default covers) unless SetCoverStore was called first. It is nil when the directory cannot be
+// written, as on Vercel's read-only filesystem; covers are then not cached.
+func SharedCoverStore() CoverStore {
+	sharedCoverStoreMutex.Lock()
+	defer sharedCoverStoreMutex.Unlock()
+	if !sharedCoverStoreReady {
+		sharedCoverStore = defaultCoverStore()
+		sharedCoverStoreReady = true
+	}
+	return sharedCoverStore
+}
+
+// SetCoverStore replaces the process-wide cover store; nil goes back to the default
+func SetCoverStore(store CoverStore) {
+	sharedCoverStoreMutex.Lock()
+	defer sharedCoverStoreMutex.Unlock()
+	sharedCoverStore = store
+	sharedCoverStoreReady = store != nil
+}
+
+// defaultCoverStore opens the disk store if its directory can be written
+func defaultCoverStore() CoverStore {
+	dir := os.Getenv("COVER_STORE_DIR")
+	if dir == "" {
+		dir = "covers"
+	}
+	_, err := os.Stat(dir)
+	created := errors.Is(err, os.ErrNotExist)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Printf("Covers are not cached, %s cannot be created: %v", dir, err)
+		return nil
+	}
+	file, err := os.CreateTemp(dir, "probe-*.tmp")
+	if err == nil {
+		file.Close()
+		os.Remove(file.Name())
+	}
+	// Put creates the directory again with the first cover
+	if created {
+		os.Remove(dir)
+	}
+	if err != nil {
+		log.Printf("Covers are not cached, %s cannot be written: %v", dir, err)
+		return nil
+	}
+	return NewDiskCoverStore(dir)
+}
+
+// SharedBookCoverURL is where clients load a shared book's cover from, or "" when it has none.
+// Without a cover store it is the original cover URL, which spares a download per view.
+func SharedBookCoverURL(sharedBook *models.SharedBook) string {
+	if sharedBook == nil || sharedBook.CoverURL == "" {
+		return ""
+	}
+	if SharedCoverStore() == nil {
+		return sharedBook.CoverURL
+	}
+	return fmt.Sprintf("%s/api/covers/%d", BackendURL(), sharedBook.ID)
+}
+
+// NormalizeCover decodes a JPEG, PNG, GIF or WebP cover and encodes it at every cover size
+func NormalizeCover(data []byte) (map[string]*Cover, error) {
+	header, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported cover image: %w", err)
+	}
+	if header.Width <= 0 || header.Height <= 0 || header.Width*header.Height > maxCoverPixels {
+		return nil, errors.New("cover image dimensions are out of range")
+	}
+
+	source, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported cover image: %w", err)
+	}
+
+	covers := make(map[string]*Cover, len(coverSizes))
+	for size, box := range coverSizes {
+		cover, err := encodeCover(scaleToFit(source, box))
+		if err != nil {
+			return nil, err
+		}
+		covers[size] = cover
+	}
+	return covers, nil
+}
+
+// scaleToFit shrinks an image to fit the box, keeping its aspect ratio
+func scaleToFit(source image.Image, box image.Point) image.Image {
+	bounds := source.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= box.X && height <= box.Y {
+		return source
+	}
+
+	if width*box.Y > height*box.X {
+		height = max(1, height*box.X/width)
+		width = box.X
+	} else {
+		width = max(1, width*box.Y/height)
+		height = box.Y
+	}
+
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), source, bounds, xdraw.Src, nil)
+	return scaled
+}
+
+// encodeCover writes opaque covers as JPEG and keeps PNG for covers with transparency
+func encodeCover(img image.Image) (*Cover, error) {
+	var buffer bytes.Buffer
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && !opaque.Opaque() {
+		if err := png.Encode(&buffer, img); err != nil {
+			return nil, err
+		}
+		return &Cover{Data: buffer.Bytes(), ContentType: "image/png"}, nil
+	}
+
+	if err := jpeg.Encode(&buffer, img, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return &Cover{Data: buffer.Bytes(), ContentType: "image/jpeg"}, nil
+}
+
+// CacheSharedBookCover downloads a shared book's cover and stores every size, unless it is already
+// stored or there is no cover store
+func CacheSharedBookCover(sharedBook *models.SharedBook) error {
+	store := SharedCoverStore()
+	if sharedBook.CoverURL == "" || store == nil {
+		return nil
+	}
+	if _, err := store.Get(sharedBook.ID, CoverSizeLarge); err == nil {
+		return nil
+	}
+
+	covers, err := fetchCovers(sharedBook)
+	if err != nil {
+		return err
+	}
+	for size, cover := range covers {
+		if err := store.Put(sharedBook.ID, size, cover); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QueueSharedBookCover caches a new shared book's cover in the background
+func QueueSharedBookCover(sharedBook models.SharedBook) {
+	if sharedBook.CoverURL == "" || SharedCoverStore() == nil {
+		return
+	}
+	go func() {
+		if err := CacheSharedBookCover(&sharedBook); err != nil {
+			log.Printf("Failed to cache cover of shared book %d: %v", sharedBook.ID, err)
+		}
+	}()
+}
+
+// GetSharedBookCover returns one size of a shared book's cover, downloading it first if it was never
+// cached. Without a cover store it is downloaded every time.
+func GetSharedBookCover(sharedBookID uint, size string) (*Cover, error) {
+	if _, ok := coverSizes[size]; !ok {
+		return nil, ErrInvalidCoverSize
+	}
+
+	store := SharedCoverStore()
+	if store != nil {
+		cover, err := store.Get(sharedBookID, size)
+		if !errors.Is(err, ErrCoverNotFound) {
+			return cover, err
+		}
+	}
+
+	var sharedBook models.SharedBook
+	if err := config.DB.First(&sharedBook, sharedBookID).Error; err != nil || sharedBook.CoverURL == "" {
+		return nil, ErrCoverNotFound
+	}
+	if store == nil {
+		covers, err := fetchCovers(&sharedBook)
+		if err != nil {
+			return nil, err
+		}
+		return covers[size], nil
+	}
+	if err := CacheSharedBookCover(&sharedBook); err != nil {
+		return nil, err
+	}
+	return store.Get(sharedBookID, size)
+}
+
+// fetchCovers downloads a shared book's original cover and normalizes it to every size
+func fetchCovers(sharedBook *models.SharedBook) (map[string]*Cover, error) {
+	data, err := downloadCover(sharedBook.CoverURL)
+	if err != nil {
+		return nil, err
+	}
+	return NormalizeCover(data)
+}
+
+// coverClient downloads covers. Cover URLs come from book sources and users, so like webhooks it
+// refuses private addresses (WEBHOOK_ALLOW_PRIVATE_NETWORKS=true lifts this for both) and ignores
+// proxy settings. Redirects are followed, and each one is checked as it connects.
+var coverClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Control: checkPublicAddress,
+		}).DialContext,
+	},
+}
+
+// downloadCover fetches an original cover, bounded in time and size
+func downloadCover(url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), coverFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := coverClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCoverImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxCoverImageSize {
+		return nil, errors.New("image is too large")
+	}
+	return data, nil
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// webpCover is a 1x1 lossless WebP image
+const webpCover = "UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA=="
+
+type CoverStoreTestSuite struct {
+	suite.Suite
+	store *DiskCoverStore
+}
+
+func (suite *CoverStoreTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+	suite.store = NewDiskCoverStore(suite.T().TempDir())
+	SetCoverStore(suite.store)
+	// Covers are served by local test servers
+	suite.T().Setenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS", "true")
+}
+
+func (suite *CoverStoreTestSuite) TearDownTest() {
+	SetCoverStore(nil)
+	config.CleanupTestDatabase()
+}
+
+func opaqueJPEG(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 100, B: 50, A: 255})
+		}
+	}
+	var buffer bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buffer, img, nil))
+	return buffer.Bytes()
+}
+
+func (suite *CoverStoreTestSuite) TestNormalizeScalesToEverySize() {
+	covers, err := NormalizeCover(opaqueJPEG(suite.T(), 400, 600))
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), covers, 3)
+
+	for size, box := range coverSizes {
+		cover := covers[size]
+		assert.Equal(suite.T(), "image/jpeg", cover.ContentType)
+		header, format, err := image.DecodeConfig(bytes.NewReader(cover.Data))
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), "jpeg", format)
+		assert.Equal(suite.T(), box.X, header.Width, size)
+		assert.Equal(suite.T(), box.Y, header.Height, size)
+	}
+}
+
+func (suite *CoverStoreTestSuite) TestNormalizeKeepsTransparencyAndReadsWebP() {
+	var buffer bytes.Buffer
+	assert.NoError(suite.T(), png.Encode(&buffer, image.NewNRGBA(image.Rect(0, 0, 30, 40))))
+	covers, err := NormalizeCover(buffer.Bytes())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "image/png", covers[CoverSizeLarge].ContentType)
+
+	// Small covers are not enlarged
+	header, _, err := image.DecodeConfig(bytes.NewReader(covers[CoverSizeLarge].Data))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 30, header.Width)
+
+	webp, err := base64.StdEncoding.DecodeString(webpCover)
+	assert.NoError(suite.T(), err)
+	covers, err = NormalizeCover(webp)
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), []string{"image/jpeg", "image/png"}, covers[CoverSizeSmall].ContentType)
+
+	_, err = NormalizeCover([]byte("not an image"))
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CoverStoreTestSuite) TestDiskStoreReplacesAndDeletes() {
+	_, err := suite.store.Get(7, CoverSizeSmall)
+	assert.ErrorIs(suite.T(), err, ErrCoverNotFound)
+
+	assert.NoError(suite.T(), suite.store.Put(7, CoverSizeSmall, &Cover{Data: []byte("jpeg"), ContentType: "image/jpeg"}))
+	assert.NoError(suite.T(), suite.store.Put(7, CoverSizeSmall, &Cover{Data: []byte("png"), ContentType: "image/png"}))
+	cover, err := suite.store.Get(7, CoverSizeSmall)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "image/png", cover.ContentType)
+	assert.Equal(suite.T(), []byte("png"), cover.Data)
+
+	assert.NoError(suite.T(), suite.store.Delete(7))
+	_, err = suite.store.Get(7, CoverSizeSmall)
+	assert.ErrorIs(suite.T(), err, ErrCoverNotFound)
+}
+
+func (suite *CoverStoreTestSuite) TestCoversAreDownloadedOnce() {
+	var requests int32
+	source := opaqueJPEG(suite.T(), 500, 750)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write(source)
+	}))
+	defer server.Close()
+
+	sharedBook := models.SharedBook{ISBN: "9780000000001", Title: "Cached", Author: "Author", CoverURL: server.URL + "/cover.jpg"}
+	assert.NoError(suite.T(), config.DB.Create(&sharedBook).Error)
+	assert.Equal(suite.T(), "http://localhost:8080/api/covers/1", SharedBookCoverURL(&sharedBook))
+
+	for _, size := range []string{CoverSizeMedium, CoverSizeSmall, CoverSizeMedium} {
+		cover, err := GetSharedBookCover(sharedBook.ID, size)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), "image/jpeg", cover.ContentType)
+	}
+	assert.Equal(suite.T(), int32(1), atomic.LoadInt32(&requests))
+	assert.NoError(suite.T(), CacheSharedBookCover(&sharedBook))
+	assert.Equal(suite.T(), int32(1), atomic.LoadInt32(&requests))
+
+	_, err := GetSharedBookCover(sharedBook.ID, "huge")
+	assert.ErrorIs(suite.T(), err, ErrInvalidCoverSize)
+
+	noCover := models.SharedBook{ISBN: "9780000000002", Title: "No Cover", Author: "Author"}
+	assert.NoError(suite.T(), config.DB.Create(&noCover).Error)
+	_, err = GetSharedBookCover(noCover.ID, CoverSizeMedium)
+	assert.ErrorIs(suite.T(), err, ErrCoverNotFound)
+	assert.Empty(suite.T(), SharedBookCoverURL(&noCover))
+}
+
+func (suite *CoverStoreTestSuite) TestWithoutAWritableStore() {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write(opaqueJPEG(suite.T(), 500, 750))
+	}))
+	defer server.Close()
+
+	// A directory that cannot be created, like the read-only filesystem of a serverless function
+	blocker := filepath.Join(suite.T().TempDir(), "file")
+	assert.NoError(suite.T(), os.WriteFile(blocker, nil, 0o644))
+	suite.T().Setenv("COVER_STORE_DIR", filepath.Join(blocker, "covers"))
+	SetCoverStore(nil)
+	assert.Nil(suite.T(), SharedCoverStore())
+
+	// Books link to the original cover, and reports download it when they need it
+	sharedBook := models.SharedBook{ISBN: "9780000000001", Title: "Uncached", Author: "Author", CoverURL: server.URL + "/cover.jpg"}
+	assert.NoError(suite.T(), config.DB.Create(&sharedBook).Error)
+	assert.Equal(suite.T(), server.URL+"/cover.jpg", SharedBookCoverURL(&sharedBook))
+	assert.NoError(suite.T(), CacheSharedBookCover(&sharedBook))
+	assert.Equal(suite.T(), int32(0), atomic.LoadInt32(&requests))
+
+	cover, err := GetSharedBookCover(sharedBook.ID, CoverSizeSmall)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "image/jpeg", cover.ContentType)
+	assert.Equal(suite.T(), int32(1), atomic.LoadInt32(&requests))
+}
+
+func (suite *CoverStoreTestSuite) TestRefusesPrivateAddresses() {
+	suite.T().Setenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS", "")
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+	}))
+	defer server.Close()
+
+	sharedBook := models.SharedBook{ISBN: "9780000000001", Title: "Internal", Author: "Author", CoverURL: server.URL + "/cover.jpg"}
+	assert.NoError(suite.T(), config.DB.Create(&sharedBook).Error)
+	_, err := GetSharedBookCover(sharedBook.ID, CoverSizeMedium)
+	assert.ErrorIs(suite.T(), err, errPrivateAddress)
+	assert.Equal(suite.T(), int32(0), atomic.LoadInt32(&requests))
+}
+
+func TestCoverStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(CoverStoreTestSuite))
+}
@@ -0,0 +1,350 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"gorm.io/gorm"
+)
+
+// Digest frequencies
+const (
+	DigestWeekly  = "WEEKLY"
+	DigestMonthly = "MONTHLY"
+)
+
+// ErrDigestTokenInvalid is returned for forged or expired digest links
+var ErrDigestTokenInvalid = errors.New("digest link is no longer valid")
+
+// DigestReportLinkTTL is how long the report links in a digest keep working after its period ends
+const DigestReportLinkTTL = 30 * 24 * time.Hour
+
+// DigestReportGrant is what a report link in a digest opens: one child's report for one month
+type DigestReportGrant struct {
+	UserID  uint
+	ChildID uint
+	Year    int
+	Month   int
+}
+
+// DigestEmailData is the data of the digest email template
+type DigestEmailData struct {
+	RecipientName string
+	Frequency     string
+	PeriodStart   string // First day covered, YYYY-MM-DD
+	PeriodEnd     string // Last day covered, YYYY-MM-DD
+	Children      []DigestChild
+}
+
+// DigestChild summarizes one child's reading in a digest
+type DigestChild struct {
+	Name      string
+	Books     []DigestBook
+	Goal      int // Highest monthly goal of the child's classrooms, 0 for none
+	GoalCount int // Books read in the goal's month so far
+	GoalMonth string
+	ReportURL string
+}
+
+// GoalPercent is the progress toward the goal, capped at 100
+func (c DigestChild) GoalPercent() int {
+	if c.Goal <= 0 {
+		return 0
+	}
+	if c.GoalCount >= c.Goal {
+		return 100
+	}
+	return c.GoalCount * 100 / c.Goal
+}
+
+// DigestBook is one finished book in a digest
+type DigestBook struct {
+	Title     string
+	Author    string
+	CoverURL  string
+	DateRead  string
+	IsPartial bool
+}
+
+// GetDigestSubscription gets a user's digest subscription, or nil when they have not opted in
+func GetDigestSubscription(userID uint) (*models.DigestSubscription, error) {
+	var subscription models.DigestSubscription
+	result := config.DB.Where("user_id = ?", userID).First(&subscription)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &subscription, nil
+}
+
+// SubscribeToDigest opts a user into digests or changes their frequency.
+// Opting in also turns digest notifications back on if the user had unsubscribed from them.
+func SubscribeToDigest(userID uint, frequency string) (*models.DigestSubscription, error) {
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := SetNotificationEnabled(user.Email, NotificationDigests, true); err != nil {
+		return nil, err
+	}
+
+	subscription, err := GetDigestSubscription(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if subscription != nil {
+		subscription.Frequency = frequency
+		if err := config.DB.Save(subscription).Error; err != nil {
+			return nil, err
+		}
+		return subscription, nil
+	}
+
+	subscription = &models.DigestSubscription{
+		UserID:    userID,
+		Frequency: frequency,
+	}
+	if err := config.DB.Create(subscription).Error; err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// UnsubscribeFromDigest opts a user out of digests
+func UnsubscribeFromDigest(userID uint) error {
+	return config.DB.Where("user_id = ?", userID).Delete(&models.DigestSubscription{}).Error
+}
+
+// DigestPeriod returns the last complete period before now: the previous Monday-to-Sunday week
+// or the previous calendar month. The end is exclusive.
+func DigestPeriod(frequency string, now time.Time) (time.Time, time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if frequency == DigestWeekly {
+		daysSinceMonday := (int(today.Weekday()) + 6) % 7
+		end := today.AddDate(0, 0, -daysSinceMonday)
+		return end.AddDate(0, 0, -7), end
+	}
+	end := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	return end.AddDate(0, -1, 0), end
+}
+
+// SendDueDigests queues a digest for every subscription whose last complete period has not been sent yet.
+// Subscribers with no books read in the period are skipped until the next one.
+func SendDueDigests(now time.Time) (int, error) {
+	var subscriptions []models.DigestSubscription
+	if err := config.DB.Find(&subscriptions).Error; err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+		start, end := DigestPeriod(subscription.Frequency, now)
+		if subscription.LastSentAt != nil && !subscription.LastSentAt.Before(end) {
+			continue
+		}
+
+		queued, err := sendDigest(subscription, start, end)
+		if err != nil {
+			log.Printf("Digest for user %d failed: %v", subscription.UserID, err)
+			continue
+		}
+		if err := config.DB.Model(subscription).Update("last_sent_at", now).Error; err != nil {
+			return sent, err
+		}
+		if queued {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// sendDigest builds and queues one digest, reporting whether there was anything to send
+func sendDigest(subscription *models.DigestSubscription, start, end time.Time) (bool, error) {
+	user, err := GetUserByID(subscription.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	data, err := BuildDigest(user.ID, subscription.Frequency, start, end)
+	if err != nil {
+		return false, err
+	}
+	data.RecipientName = user.FirstName
+	if len(data.Children) == 0 {
+		return false, nil
+	}
+
+	return true, NewEmailService().ForLocale(user.Locale).SendDigestEmail(user.Email, *data)
+}
+
+// BuildDigest collects the books finished in [start, end) by every child the user can view
+func BuildDigest(userID uint, frequency string, start, end time.Time) (*DigestEmailData, error) {
+	children, err := GetChildrenWithPermission(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	last := end.AddDate(0, 0, -1)
+	data := &DigestEmailData{
+		Frequency:   frequency,
+		PeriodStart: start.Format("2006-01-02"),
+		PeriodEnd:   last.Format("2006-01-02"),
+	}
+
+	for _, child := range children {
+		books, err := getBooksInPeriod(child.ID, start, end)
+		if err != nil {
+			return nil, err
+		}
+		if len(books) == 0 {
+			continue
+		}
+
+		digestChild := DigestChild{
+			Name:      child.FirstName,
+			GoalMonth: last.Format("2006-01"),
+			ReportURL: BackendURL() + "/api/public/digest/report?token=" + url.QueryEscape(DigestReportToken(DigestReportGrant{
+				UserID: userID, ChildID: child.ID, Year: last.Year(), Month: int(last.Month()),
+			}, end.Add(DigestReportLinkTTL))),
+		}
+		for _, book := range books {
+			digestChild.Books = append(digestChild.Books, digestBook(&book))
+		}
+
+		digestChild.Goal, err = GetChildMonthlyGoal(child.ID)
+		if err != nil {
+			return nil, err
+		}
+		if digestChild.Goal > 0 {
+			digestChild.GoalCount, err = GetBookCountByChildAndMonth(child.ID, last.Year(), int(last.Month()))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		data.Children = append(data.Children, digestChild)
+	}
+
+	return data, nil
+}
+
+// getBooksInPeriod gets a child's books read in [start, end), which may span two months
+func getBooksInPeriod(childID uint, start, end time.Time) ([]models.Book, error) {
+	from, to := start.Format("2006-01-02"), end.Format("2006-01-02")
+
+	var books []models.Book
+	for month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location()); month.Before(end); month = month.AddDate(0, 1, 0) {
+		monthBooks, err := GetBooksByChildAndMonth(childID, month.Year(), int(month.Month()))
+		if err != nil {
+			return nil, err
+		}
+		for _, book := range monthBooks {
+			if len(book.DateRead) >= 10 && book.DateRead[:10] >= from && book.DateRead[:10] < to {
+				books = append(books, book)
+			}
+		}
+	}
+
+	sort.SliceStable(books, func(i, j int) bool {
+		return books[i].DateRead > books[j].DateRead
+	})
+	return books, nil
+}
+
+func digestBook(book *models.Book) DigestBook {
+	digest := DigestBook{
+		Title:     book.CustomTitle,
+		Author:    book.CustomAuthor,
+		DateRead:  book.DateRead,
+		IsPartial: book.IsPartial,
+	}
+	if book.SharedBook != nil {
+		digest.Title = book.SharedBook.Title
+		digest.Author = book.SharedBook.Author
+		digest.CoverURL = SharedBookCoverURL(book.SharedBook)
+	}
+	if len(digest.DateRead) > 10 {
+		digest.DateRead = digest.DateRead[:10]
+	}
+	return digest
+}
+
+// DigestReportToken signs a report link for one recipient, child and month that stops working at expiresAt
+func DigestReportToken(grant DigestReportGrant, expiresAt time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d:%d:%d:%d",
+		grant.UserID, grant.ChildID, grant.Year, grant.Month, expiresAt.Unix())))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(digestReportSignature(payload))
+}
+
+func digestReportSignature(payload string) []byte {
+	mac := hmac.New(sha256.New, jwtSecret)
+	mac.Write([]byte("digest-report:" + payload))
+	return mac.Sum(nil)
+}
+
+// ParseDigestReportToken verifies a report link that has not expired by now and returns what it opens
+func ParseDigestReportToken(token string, now time.Time) (*DigestReportGrant, error) {
+	payload, signature, found := strings.Cut(token, ".")
+	if !found {
+		return nil, ErrDigestTokenInvalid
+	}
+	decodedSignature, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(decodedSignature, digestReportSignature(payload)) {
+		return nil, ErrDigestTokenInvalid
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrDigestTokenInvalid
+	}
+
+	var grant DigestReportGrant
+	var expiresAt int64
+	if _, err := fmt.Sscanf(string(decoded), "%d:%d:%d:%d:%d",
+		&grant.UserID, &grant.ChildID, &grant.Year, &grant.Month, &expiresAt); err != nil {
+		return nil, ErrDigestTokenInvalid
+	}
+	if !now.Before(time.Unix(expiresAt, 0)) {
+		return nil, ErrDigestTokenInvalid
+	}
+	return &grant, nil
+}
+
+// StartDigestJob periodically queues the digests that are due
+func StartDigestJob(interval time.Duration) chan<- struct{} {
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			sent, err := SendDueDigests(time.Now())
+			if err != nil {
+				log.Printf("Digest job failed: %v", err)
+			} else if sent > 0 {
+				log.Printf("Digest job queued %d digests", sent)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return stop
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type DigestServiceTestSuite struct {
+	suite.Suite
+	teacher *models.User
+	parent  *models.User
+	child   *models.Child
+}
+
+func (suite *DigestServiceTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+	SharedPermissionCache().Clear()
+
+	suite.teacher = suite.createUser("teacher@example.com", "Terry")
+	suite.parent = suite.createUser("parent@example.com", "Pat")
+
+	child, err := CreateChild(models.CreateChildRequest{
+		FirstName: "Sam",
+		LastName:  "Reader",
+		Grade:     "3rd",
+	}, suite.parent.ID)
+	assert.NoError(suite.T(), err)
+	suite.child = child
+
+	classroom, err := CreateClassroom(models.CreateClassroomRequest{Name: "3B", MonthlyGoal: 4}, Actor{UserID: suite.teacher.ID})
+	assert.NoError(suite.T(), err)
+	_, err = JoinClassroom(classroom.JoinCode, child.ID, Actor{UserID: suite.parent.ID})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *DigestServiceTestSuite) TearDownTest() {
+	config.CleanupTestDatabase()
+}
+
+func (suite *DigestServiceTestSuite) createUser(email, firstName string) *models.User {
+	user, err := CreateUser(models.CreateUserRequest{
+		Email:     email,
+		Password:  "password123",
+		FirstName: firstName,
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	return user
+}
+
+func (suite *DigestServiceTestSuite) readBook(title, dateRead string) {
+	_, err := CreateCustomBook(models.CreateCustomBookRequest{
+		Title:    title,
+		Author:   "Author",
+		DateRead: dateRead,
+		ChildID:  suite.child.ID,
+	}, SystemActor)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *DigestServiceTestSuite) TestDigestPeriod() {
+	// Wednesday 2024-03-13
+	now := time.Date(2024, 3, 13, 9, 30, 0, 0, time.UTC)
+
+	start, end := DigestPeriod(DigestWeekly, now)
+	assert.Equal(suite.T(), time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(suite.T(), time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), end)
+
+	// On a Monday the week that just ended is reported
+	start, _ = DigestPeriod(DigestWeekly, time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC))
+	assert.Equal(suite.T(), time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), start)
+
+	start, end = DigestPeriod(DigestMonthly, now)
+	assert.Equal(suite.T(), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(suite.T(), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), end)
+}
+
+func (suite *DigestServiceTestSuite) TestBuildDigestCoversOnlyThePeriod() {
+	suite.readBook("Before", "2024-02-26")
+	suite.readBook("Across The Month", "2024-02-29")
+	suite.readBook("Inside", "2024-03-03")
+	suite.readBook("After", "2024-03-04")
+
+	// Week of Monday 2024-02-26, which spans two months
+	start := time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC)
+	data, err := BuildDigest(suite.parent.ID, DigestWeekly, start, start.AddDate(0, 0, 7))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "2024-02-26", data.PeriodStart)
+	assert.Equal(suite.T(), "2024-03-03", data.PeriodEnd)
+
+	assert.Len(suite.T(), data.Children, 1)
+	child := data.Children[0]
+	assert.Equal(suite.T(), "Sam", child.Name)
+	titles := make([]string, len(child.Books))
+	for i, book := range child.Books {
+		titles[i] = book.Title
+	}
+	assert.Equal(suite.T(), []string{"Inside", "Across The Month", "Before"}, titles)
+
+	// Goal progress is for the month the period ends in
+	assert.Equal(suite.T(), 4, child.Goal)
+	assert.Equal(suite.T(), 2, child.GoalCount)
+	assert.Equal(suite.T(), "2024-03", child.GoalMonth)
+	assert.Equal(suite.T(), 50, child.GoalPercent())
+	reportURL, err := url.Parse(child.ReportURL)
+	assert.NoError(suite.T(), err)
+	grant, err := ParseDigestReportToken(reportURL.Query().Get("token"), start)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), DigestReportGrant{UserID: suite.parent.ID, ChildID: suite.child.ID, Year: 2024, Month: 3}, *grant)
+
+	// The teacher sees the child through the classroom
+	data, err = BuildDigest(suite.teacher.ID, DigestWeekly, start, start.AddDate(0, 0, 7))
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), data.Children, 1)
+}
+
+func TestDigestReportToken(t *testing.T) {
+	grant := DigestReportGrant{UserID: 1, ChildID: 2, Year: 2024, Month: 3}
+	now := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	token := DigestReportToken(grant, now.Add(DigestReportLinkTTL))
+
+	parsed, err := ParseDigestReportToken(token, now)
+	assert.NoError(t, err)
+	assert.Equal(t, grant, *parsed)
+
+	// Links expire
+	_, err = ParseDigestReportToken(token, now.Add(DigestReportLinkTTL))
+	assert.ErrorIs(t, err, ErrDigestTokenInvalid)
+
+	// Another child or month cannot be substituted
+	_, signature, _ := strings.Cut(token, ".")
+	other, _, _ := strings.Cut(DigestReportToken(DigestReportGrant{UserID: 1, ChildID: 3, Year: 2024, Month: 3}, now.Add(DigestReportLinkTTL)), ".")
+	_, err = ParseDigestReportToken(other+"."+signature, now)
+	assert.ErrorIs(t, err, ErrDigestTokenInvalid)
+
+	// Unsubscribe tokens are not report links
+	_, err = ParseDigestReportToken(UnsubscribeToken("parent@example.com", NotificationDigests), now)
+	assert.ErrorIs(t, err, ErrDigestTokenInvalid)
+}
+
+func (suite *DigestServiceTestSuite) TestSendDueDigestsQueuesOncePerPeriod() {
+	suite.readBook("Charlotte's Web", "2024-03-05")
+
+	_, err := SubscribeToDigest(suite.parent.ID, DigestWeekly)
+	assert.NoError(suite.T(), err)
+
+	// The teacher has no books to report for the month and gets nothing
+	_, err = SubscribeToDigest(suite.teacher.ID, DigestMonthly)
+	assert.NoError(suite.T(), err)
+
+	now := time.Date(2024, 3, 13, 9, 0, 0, 0, time.UTC)
+	sent, err := SendDueDigests(now)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, sent)
+
+	var emails []models.EmailOutbox
+	assert.NoError(suite.T(), config.DB.Find(&emails).Error)
+	assert.Len(suite.T(), emails, 1)
+	assert.Equal(suite.T(), "parent@example.com", emails[0].ToAddress)
+	assert.Equal(suite.T(), "Your weekly reading digest", emails[0].Subject)
+	assert.Contains(suite.T(), emails[0].TextBody, "Charlotte's Web")
+	assert.Contains(suite.T(), emails[0].TextBody, "Class goal for 2024-03: 1 of 4 books (25%)")
+
+	// Running again in the same week does not send a second digest
+	sent, err = SendDueDigests(now.Add(time.Hour))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, sent)
+
+	// The next week is due again but had no books
+	sent, err = SendDueDigests(now.AddDate(0, 0, 7))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, sent)
+
+	subscription, err := GetDigestSubscription(suite.parent.ID)
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), subscription.LastSentAt)
+}
+
+func (suite *DigestServiceTestSuite) TestUnsubscribeLinkStopsDigests() {
+	suite.readBook("Charlotte's Web", "2024-03-05")
+	_, err := SubscribeToDigest(suite.parent.ID, DigestMonthly)
+	assert.NoError(suite.T(), err)
+
+	// Changing the frequency keeps the subscription
+	updated, err := SubscribeToDigest(suite.parent.ID, DigestWeekly)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), DigestWeekly, updated.Frequency)
+
+	// Digests carry the signed per-category unsubscribe link
+	category, err := UnsubscribeByToken(UnsubscribeToken("parent@example.com", NotificationDigests))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), NotificationDigests, category)
+
+	_, err = SendDueDigests(time.Date(2024, 3, 13, 9, 0, 0, 0, time.UTC))
+	assert.NoError(suite.T(), err)
+	var count int64
+	assert.NoError(suite.T(), config.DB.Model(&models.EmailOutbox{}).Count(&count).Error)
+	assert.Zero(suite.T(), count)
+}
+
+func (suite *DigestServiceTestSuite) TestDigestEmailRendersCoversAndLinks() {
+	rendered, err := PreviewEmail(EmailTemplateDigest, "es")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Tu resumen semanal de lectura", rendered.Subject)
+	assert.Contains(suite.T(), rendered.HTMLBody, `<img src="https://covers.openlibrary.org/b/id/8231856-M.jpg"`)
+	assert.Contains(suite.T(), rendered.HTMLBody, "width: 60%")
+	assert.Contains(suite.T(), rendered.TextBody, "/api/public/unsubscribe?token=sample")
+}
+
+func TestDigestServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(DigestServiceTestSuite))
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+)
+
+// Outbox statuses
+const (
+	OutboxStatusPending = "PENDING"
+	OutboxStatusSent    = "SENT"
+	OutboxStatusFailed  = "FAILED"
+)
+
+// MaxEmailAttempts is how many deliveries are tried before an email is marked failed
+const MaxEmailAttempts = 8
+
+// outboxBatchSize bounds how many emails one worker pass delivers
+const outboxBatchSize = 50
+
+// outboxLease keeps other workers away from an email while it is being delivered
+const outboxLease = 5 * time.Minute
+
+// EnqueueEmail stores an email in the outbox; the outbox worker delivers it, or the
+// request itself when deliveries are inline
+func EnqueueEmail(msg EmailMessage) (*models.EmailOutbox, error) {
+	if msg.From == "" {
+		msg.From = emailFrom()
+	}
+
+	var headers string
+	if len(msg.Headers) > 0 {
+		encoded, err := json.Marshal(msg.Headers)
+		if err != nil {
+			return nil, err
+		}
+		headers = string(encoded)
+	}
+
+	email := models.EmailOutbox{
+		FromAddress:   msg.From,
+		ToAddress:     msg.To,
+		Subject:       msg.Subject,
+		HTMLBody:      msg.HTMLBody,
+		TextBody:      msg.TextBody,
+		Headers:       headers,
+		Status:        OutboxStatusPending,
+		NextAttemptAt: time.Now(),
+	}
+	if err := config.DB.Create(&email).Error; err != nil {
+		return nil, err
+	}
+
+	// A failed attempt stays in the outbox for the scheduled jobs to retry
+	if inlineDelivery.Load() {
+		if _, err := deliverOutboxEmail(&email, time.Now()); err != nil {
+			log.Printf("Email %d to %s could not be delivered inline: %v", email.ID, email.ToAddress, err)
+		}
+	}
+	return &email, nil
+}
+
+// ProcessEmailOutbox delivers the pending emails that are due and returns how many were sent.
+// Failed deliveries are retried with exponential backoff until MaxEmailAttempts.
+func ProcessEmailOutbox(now time.Time) (int, error) {
+	var due []models.EmailOutbox
+	result := config.DB.
+		Where("status = ? AND next_attempt_at <= ?", OutboxStatusPending, now).
+		Order("next_attempt_at").
+		Limit(outboxBatchSize).
+		Find(&due)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	sent := 0
+	for i := range due {
+		delivered, err := deliverOutboxEmail(&due[i], now)
+		if err != nil {
+			return sent, err
+		}
+		if delivered {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// deliverOutboxEmail makes one delivery attempt and reports whether the email was sent.
+// Errors are only returned when the outbox itself cannot be updated.
+func deliverOutboxEmail(email *models.EmailOutbox, now time.Time) (bool, error) {
+	// Claim the email by counting the attempt, so a second worker does not deliver it too
+	attempts := email.Attempts + 1
+	claim := config.DB.Model(&models.EmailOutbox{}).
+		Where("id = ? AND status = ? AND attempts = ?", email.ID, OutboxStatusPending, email.Attempts).
+		Updates(map[string]interface{}{"attempts": attempts, "next_attempt_at": now.Add(outboxLease)})
+	if claim.Error != nil {
+		return false, claim.Error
+	}
+	if claim.RowsAffected == 0 {
+		return false, nil
+	}
+
+	msg, sendErr := outboxMessage(email)
+	if sendErr == nil {
+		sendErr = GetEmailSender().Send(msg)
+	}
+
+	updates := map[string]interface{}{}
+	if sendErr == nil {
+		updates["status"] = OutboxStatusSent
+		updates["sent_at"] = now
+		updates["last_error"] = ""
+	} else {
+		log.Printf("Email %d to %s failed (attempt %d): %v", email.ID, email.ToAddress, attempts, sendErr)
+		updates["last_error"] = sendErr.Error()
+		if attempts >= MaxEmailAttempts {
+			updates["status"] = OutboxStatusFailed
+		} else {
+			updates["next_attempt_at"] = now.Add(outboxBackoff(attempts))
+		}
+	}
+
+	if err := config.DB.Model(email).Updates(updates).Error; err != nil {
+		return false, err
+	}
+	return sendErr == nil, nil
+}
+
+// outboxBackoff doubles the wait after each failed attempt: 1m, 2m, 4m ... capped at 6h
+func outboxBackoff(attempts int) time.Duration {
+	delay := time.Minute << (attempts - 1)
+	if attempts > 10 || delay > 6*time.Hour {
+		return 6 * time.Hour
+	}
+	return delay
+}
+
+func outboxMessage(email *models.EmailOutbox) (EmailMessage, error) {
+	msg := EmailMessage{
+		From:     email.FromAddress,
+		To:       email.ToAddress,
+		Subject:  email.Subject,
+		HTMLBody: email.HTMLBody,
+		TextBody: email.TextBody,
+	}
+	if email.Headers != "" {
+		if err := json.Unmarshal([]byte(email.Headers), &msg.Headers); err != nil {
+			return msg, err
+		}
+	}
+	return msg, nil
+}
+
+// StartEmailOutboxWorker periodically delivers due emails from the outbox
+func StartEmailOutboxWorker(interval time.Duration) chan<- struct{} {
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := ProcessEmailOutbox(time.Now()); err != nil {
+				log.Printf("Email outbox delivery failed: %v", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return stop
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeSMTPServer is a minimal SMTP stand-in that accepts every message
+type fakeSMTPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	messages []string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := &fakeSMTPServer{listener: listener}
+	go server.serve()
+	return server
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		conn.Write([]byte(line + "\r\n"))
+	}
+
+	reply("220 localhost fake SMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case command == "DATA":
+			reply("354 end with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, data.String())
+			s.mu.Unlock()
+			reply("250 queued")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+// flakySender fails a number of times before delivering
+type flakySender struct {
+	failures  int
+	delivered []EmailMessage
+}
+
+func (s *flakySender) Send(msg EmailMessage) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("provider unavailable")
+	}
+	s.delivered = append(s.delivered, msg)
+	return nil
+}
+
+type EmailOutboxTestSuite struct {
+	suite.Suite
+}
+
+func (suite *EmailOutboxTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+}
+
+func (suite *EmailOutboxTestSuite) TearDownTest() {
+	SetEmailSender(LogSender{})
+	config.CleanupTestDatabase()
+}
+
+func (suite *EmailOutboxTestSuite) TestDeliversThroughSMTP() {
+	server := newFakeSMTPServer(suite.T())
+	defer server.listener.Close()
+	SetEmailSender(&SMTPSender{Addr: server.listener.Addr().String()})
+
+	err := NewEmailService().SendPasswordResetEmail("parent@example.com", "Pat", "reset-token")
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), server.received(), "emails are only delivered by the outbox worker")
+
+	sent, err := ProcessEmailOutbox(time.Now())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, sent)
+
+	messages := server.received()
+	assert.Len(suite.T(), messages, 1)
+	assert.Contains(suite.T(), messages[0], "To: parent@example.com")
+	assert.Contains(suite.T(), messages[0], "Subject: Reset your password")
+	assert.Contains(suite.T(), messages[0], "reset-token")
+
+	var email models.EmailOutbox
+	assert.NoError(suite.T(), config.DB.First(&email).Error)
+	assert.Equal(suite.T(), OutboxStatusSent, email.Status)
+	assert.NotNil(suite.T(), email.SentAt)
+}
+
+func (suite *EmailOutboxTestSuite) TestRetriesWithBackoff() {
+	sender := &flakySender{failures: 2}
+	SetEmailSender(sender)
+
+	_, err := EnqueueEmail(EmailMessage{To: "parent@example.com", Subject: "Hello", HTMLBody: "<p>Hi</p>"})
+	assert.NoError(suite.T(), err)
+
+	now := time.Now()
+	sent, err := ProcessEmailOutbox(now)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, sent)
+
+	var email models.EmailOutbox
+	assert.NoError(suite.T(), config.DB.First(&email).Error)
+	assert.Equal(suite.T(), OutboxStatusPending, email.Status)
+	assert.Equal(suite.T(), 1, email.Attempts)
+	assert.Equal(suite.T(), "provider unavailable", email.LastError)
+
+	// Not due again until the backoff has passed
+	sent, err = ProcessEmailOutbox(now.Add(30 * time.Second))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, sent)
+	assert.Equal(suite.T(), 1, sender.failures)
+
+	sent, err = ProcessEmailOutbox(now.Add(outboxBackoff(1)))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, sent)
+
+	sent, err = ProcessEmailOutbox(now.Add(outboxBackoff(1) + outboxBackoff(2)))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, sent)
+	assert.Len(suite.T(), sender.delivered, 1)
+	assert.Equal(suite.T(), DefaultEmailFrom, sender.delivered[0].From)
+
+	assert.NoError(suite.T(), config.DB.First(&email).Error)
+	assert.Equal(suite.T(), OutboxStatusSent, email.Status)
+	assert.Equal(suite.T(), 3, email.Attempts)
+	assert.Empty(suite.T(), email.LastError)
+}
+
+func (suite *EmailOutboxTestSuite) TestInlineDelivery() {
+	sender := &flakySender{failures: 1}
+	SetEmailSender(sender)
+	SetInlineDelivery(true)
+	defer SetInlineDelivery(false)
+
+	// The first email fails inline and is left for the scheduled jobs
+	_, err := EnqueueEmail(EmailMessage{To: "parent@example.com", Subject: "Hello", HTMLBody: "<p>Hi</p>"})
+	assert.NoError(suite.T(), err)
+	_, err = EnqueueEmail(EmailMessage{To: "teacher@example.com", Subject: "Hello", HTMLBody: "<p>Hi</p>"})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), sender.delivered, 1)
+	assert.Equal(suite.T(), "teacher@example.com", sender.delivered[0].To)
+
+	result, err := RunScheduledJobs(time.Now().Add(outboxBackoff(1)))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, result.EmailsSent)
+	assert.Len(suite.T(), sender.delivered, 2)
+}
+
+func (suite *EmailOutboxTestSuite) TestGivesUpAfterMaxAttempts() {
+	SetEmailSender(&flakySender{failures: MaxEmailAttempts})
+
+	_, err := EnqueueEmail(EmailMessage{To: "parent@example.com", Subject: "Hello", HTMLBody: "<p>Hi</p>"})
+	assert.NoError(suite.T(), err)
+
+	now := time.Now()
+	for i := 0; i < MaxEmailAttempts; i++ {
+		now = now.Add(7 * time.Hour)
+		_, err := ProcessEmailOutbox(now)
+		assert.NoError(suite.T(), err)
+	}
+
+	var email models.EmailOutbox
+	assert.NoError(suite.T(), config.DB.First(&email).Error)
+	assert.Equal(suite.T(), OutboxStatusFailed, email.Status)
+	assert.Equal(suite.T(), MaxEmailAttempts, email.Attempts)
+}
+
+func (suite *EmailOutboxTestSuite) TestFileSenderWritesMailbox() {
+	dir := suite.T().TempDir()
+	SetEmailSender(&FileSender{Dir: dir})
+
+	_, err := EnqueueEmail(EmailMessage{
+		To:       "parent@example.com",
+		Subject:  "Hello",
+		HTMLBody: "<p>Hi</p>",
+		TextBody: "Hi",
+		Headers:  map[string]string{"X-Test": "yes"},
+	})
+	assert.NoError(suite.T(), err)
+	_, err = ProcessEmailOutbox(time.Now())
+	assert.NoError(suite.T(), err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), files, 1)
+
+	data, err := os.ReadFile(files[0])
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), string(data), "X-Test: yes")
+	assert.Contains(suite.T(), string(data), "multipart/alternative")
+}
+
+func TestEmailOutboxTestSuite(t *testing.T) {
+	suite.Run(t, new(EmailOutboxTestSuite))
+}
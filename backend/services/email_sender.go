@@ -0,0 +1,268 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/resend/resend-go/v2"
+)
+
+// DefaultEmailFrom is the sender address when EMAIL_FROM is not set
+const DefaultEmailFrom = "Book Tracker <noreply@booktracker.rustyphillips.net>"
+
+// EmailMessage is a single outgoing email, independent of the transport
+type EmailMessage struct {
+	From     string
+	To       string
+	Subject  string
+	HTMLBody string
+	TextBody string
+	Headers  map[string]string
+}
+
+// EmailSender delivers an email through one transport
+type EmailSender interface {
+	Send(msg EmailMessage) error
+}
+
+// ResendSender delivers through the Resend API
+type ResendSender struct {
+	client *resend.Client
+}
+
+// NewResendSender creates a Resend sender for an API key
+func NewResendSender(apiKey string) *ResendSender {
+	return &ResendSender{client: resend.NewClient(apiKey)}
+}
+
+// Send implements EmailSender
+func (s *ResendSender) Send(msg EmailMessage) error {
+	_, err := s.client.Emails.Send(&resend.SendEmailRequest{
+		From:    msg.From,
+		To:      []string{msg.To},
+		Subject: msg.Subject,
+		Html:    msg.HTMLBody,
+		Text:    msg.TextBody,
+		Headers: msg.Headers,
+	})
+	return err
+}
+
+// SMTPSender delivers through an SMTP relay. STARTTLS is used when the server offers it.
+type SMTPSender struct {
+	Addr     string // host:port
+	Username string // empty for relays without authentication
+	Password string
+}
+
+// Send implements EmailSender
+func (s *SMTPSender) Send(msg EmailMessage) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, err := net.SplitHostPort(s.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+
+	data, err := msg.MIME()
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(s.Addr, auth, emailAddress(msg.From), []string{emailAddress(msg.To)}, data)
+}
+
+// FileSender writes each email as an .eml file into a directory, for development and tests
+type FileSender struct {
+	Dir string
+}
+
+// Send implements EmailSender
+func (s *FileSender) Send(msg EmailMessage) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+
+	data, err := msg.MIME()
+	if err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+	return os.WriteFile(filepath.Join(s.Dir, name), data, 0o644)
+}
+
+// LogSender prints emails to stdout; the default in development when no transport is configured
+type LogSender struct{}
+
+// Send implements EmailSender
+func (LogSender) Send(msg EmailMessage) error {
+	fmt.Printf("📧 [DEV] Email for %s:\n", msg.To)
+	fmt.Printf("   Subject: %s\n", msg.Subject)
+	if msg.TextBody != "" {
+		fmt.Printf("%s\n", msg.TextBody)
+	} else {
+		fmt.Printf("%s\n", msg.HTMLBody)
+	}
+	return nil
+}
+
+// MIME renders the message as an RFC 5322 email, with a text and an HTML part when both are set
+func (m EmailMessage) MIME() ([]byte, error) {
+	var buf bytes.Buffer
+
+	writeHeader := func(name, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+	}
+	writeHeader("From", m.From)
+	writeHeader("To", m.To)
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	writeHeader("Date", time.Now().Format(time.RFC1123Z))
+	writeHeader("MIME-Version", "1.0")
+
+	names := make([]string, 0, len(m.Headers))
+	for name := range m.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeHeader(name, m.Headers[name])
+	}
+
+	if m.TextBody == "" || m.HTMLBody == "" {
+		contentType, body := "text/html", m.HTMLBody
+		if m.HTMLBody == "" {
+			contentType, body = "text/plain", m.TextBody
+		}
+		writeHeader("Content-Type", contentType+"; charset=utf-8")
+		writeHeader("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	boundaryBytes := make([]byte, 12)
+	if _, err := rand.Read(boundaryBytes); err != nil {
+		return nil, err
+	}
+	boundary := "booktracker-" + hex.EncodeToString(boundaryBytes)
+	writeHeader("Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", boundary))
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", m.TextBody},
+		{"text/html", m.HTMLBody},
+	} {
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		writeHeader("Content-Type", part.contentType+"; charset=utf-8")
+		writeHeader("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, part.body); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+
+	return buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(buf *bytes.Buffer, body string) error {
+	writer := quotedprintable.NewWriter(buf)
+	if _, err := writer.Write([]byte(body)); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// emailAddress extracts the bare address from "Name <address>"
+func emailAddress(value string) string {
+	if start := strings.LastIndex(value, "<"); start >= 0 {
+		if end := strings.LastIndex(value, ">"); end > start {
+			return value[start+1 : end]
+		}
+	}
+	return strings.TrimSpace(value)
+}
+
+// NewEmailSenderFromEnv picks the transport from EMAIL_TRANSPORT ("resend", "smtp", "file" or "log").
+// Without EMAIL_TRANSPORT, Resend is used when RESEND_API_KEY is set and emails are logged otherwise.
+func NewEmailSenderFromEnv() EmailSender {
+	transport := strings.ToLower(os.Getenv("EMAIL_TRANSPORT"))
+	if transport == "" && os.Getenv("RESEND_API_KEY") != "" {
+		transport = "resend"
+	}
+
+	switch transport {
+	case "resend":
+		return NewResendSender(os.Getenv("RESEND_API_KEY"))
+	case "smtp":
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return &SMTPSender{
+			Addr:     net.JoinHostPort(os.Getenv("SMTP_HOST"), port),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		}
+	case "file":
+		dir := os.Getenv("EMAIL_FILE_DIR")
+		if dir == "" {
+			dir = "mail"
+		}
+		return &FileSender{Dir: dir}
+	default:
+		return LogSender{}
+	}
+}
+
+var (
+	emailSenderMu sync.Mutex
+	emailSender   EmailSender
+)
+
+// SetEmailSender replaces the transport the outbox delivers through
+func SetEmailSender(sender EmailSender) {
+	emailSenderMu.Lock()
+	defer emailSenderMu.Unlock()
+	emailSender = sender
+}
+
+// GetEmailSender returns the transport the outbox delivers through.
+// It is read from the environment on first use, after main has loaded .env.
+func GetEmailSender() EmailSender {
+	emailSenderMu.Lock()
+	defer emailSenderMu.Unlock()
+	if emailSender == nil {
+		emailSender = NewEmailSenderFromEnv()
+	}
+	return emailSender
+}
+
+// emailFrom returns the configured sender address (EMAIL_FROM)
+func emailFrom() string {
+	if from := os.Getenv("EMAIL_FROM"); from != "" {
+		return from
+	}
+	return DefaultEmailFrom
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Email templates
+const (
+	EmailTemplateVerification     = "verification"
+	EmailTemplateInvitation       = "invitation"
+	EmailTemplatePasswordReset    = "password_reset"
+	EmailTemplateSystemInvitation = "system_invitation"
+	EmailTemplateDigest           = "digest"
+)
+
+// emailTemplateCategories maps the templates users can opt out of to their notification category
+var emailTemplateCategories = map[string]string{
+	EmailTemplateInvitation:       NotificationInvitations,
+	EmailTemplateSystemInvitation: NotificationInvitations,
+	EmailTemplateDigest:           NotificationDigests,
+}
+
+// DefaultLocale is used for users without a locale and for unsupported locales
+const DefaultLocale = "en"
+
+// SupportedLocales lists the languages every email template is translated into
+var SupportedLocales = []string{"en", "es"}
+
+//go:embed email_templates
+var emailTemplateFS embed.FS
+
+// ActionEmailData is the data of emails built around a single link
+type ActionEmailData struct {
+	RecipientName string
+	InviterName   string
+	ChildName     string
+	ActionURL     string
+}
+
+// RenderedEmail is a template rendered for one locale
+type RenderedEmail struct {
+	Subject  string `json:"subject"`
+	HTMLBody string `json:"html"`
+	TextBody string `json:"text"`
+}
+
+// emailView is what the templates see: the shared layout uses Locale, Subject and UnsubscribeURL, the email uses Data
+type emailView struct {
+	Locale         string
+	Subject        string
+	UnsubscribeURL string
+	Data           interface{}
+}
+
+type emailButton struct {
+	URL   string
+	Label string
+}
+
+type parsedEmailTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// emailTemplateSamples is the sample data admins preview each template with
+var emailTemplateSamples = map[string]interface{}{
+	EmailTemplateVerification: ActionEmailData{
+		RecipientName: "Alex",
+		ActionURL:     "https://example.com/verify-email?token=sample",
+	},
+	EmailTemplateInvitation: ActionEmailData{
+		InviterName: "Alex Rivera",
+		ChildName:   "Sam Rivera",
+		ActionURL:   "https://example.com/accept-invitation?token=sample",
+	},
+	EmailTemplatePasswordReset: ActionEmailData{
+		RecipientName: "Alex",
+		ActionURL:     "https://example.com/reset-password?token=sample",
+	},
+	EmailTemplateSystemInvitation: ActionEmailData{
+		InviterName: "Alex Rivera",
+		ActionURL:   "https://example.com/accept-invitation?token=sample",
+	},
+	EmailTemplateDigest: DigestEmailData{
+		RecipientName: "Alex",
+		Frequency:     DigestWeekly,
+		PeriodStart:   "2024-03-04",
+		PeriodEnd:     "2024-03-10",
+		Children: []DigestChild{
+			{
+				Name: "Sam",
+				Books: []DigestBook{
+					{Title: "Charlotte's Web", Author: "E. B. White", CoverURL: "https://covers.openlibrary.org/b/id/8231856-M.jpg", DateRead: "2024-03-09"},
+					{Title: "Frog and Toad", Author: "Arnold Lobel", DateRead: "2024-03-05", IsPartial: true},
+				},
+				Goal:      10,
+				GoalCount: 6,
+				GoalMonth: "2024-03",
+				ReportURL: "https://example.com/api/public/digest/report?token=sample",
+			},
+		},
+	},
+}
+
+// emailTemplates holds every template parsed for every locale, keyed by locale then name
+var emailTemplates = mustParseEmailTemplates()
+
+func mustParseEmailTemplates() map[string]map[string]parsedEmailTemplate {
+	funcs := map[string]interface{}{
+		"button": func(url, label string) emailButton {
+			return emailButton{URL: url, Label: label}
+		},
+	}
+
+	templates := make(map[string]map[string]parsedEmailTemplate, len(SupportedLocales))
+	for _, locale := range SupportedLocales {
+		templates[locale] = make(map[string]parsedEmailTemplate, len(emailTemplateSamples))
+		for _, name := range EmailTemplateNames() {
+			html := htmltemplate.Must(htmltemplate.New("layout.html").Funcs(funcs).ParseFS(emailTemplateFS,
+				"email_templates/layout.html",
+				"email_templates/"+locale+"/common.html",
+				"email_templates/"+locale+"/"+name+".html",
+			))
+			text := texttemplate.Must(texttemplate.New("layout.txt").Funcs(funcs).ParseFS(emailTemplateFS,
+				"email_templates/layout.txt",
+				"email_templates/"+locale+"/common.txt",
+				"email_templates/"+locale+"/"+name+".txt",
+			))
+			templates[locale][name] = parsedEmailTemplate{html: html, text: text}
+		}
+	}
+	return templates
+}
+
+// EmailTemplateNames lists the available email templates
+func EmailTemplateNames() []string {
+	return []string{
+		EmailTemplateVerification,
+		EmailTemplateInvitation,
+		EmailTemplatePasswordReset,
+		EmailTemplateSystemInvitation,
+		EmailTemplateDigest,
+	}
+}
+
+// NormalizeLocale maps a locale such as "es-MX" to a supported language, falling back to English
+func NormalizeLocale(locale string) string {
+	language := strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(language, "-_"); i >= 0 {
+		language = language[:i]
+	}
+	for _, supported := range SupportedLocales {
+		if language == supported {
+			return supported
+		}
+	}
+	return DefaultLocale
+}
+
+// RenderEmail renders the subject, HTML and plain-text bodies of a template in the given locale
+func RenderEmail(name, locale string, data interface{}) (*RenderedEmail, error) {
+	return renderEmail(name, locale, data, "")
+}
+
+// renderEmail renders a template, adding an unsubscribe footer when unsubscribeURL is set
+func renderEmail(name, locale string, data interface{}, unsubscribeURL string) (*RenderedEmail, error) {
+	locale = NormalizeLocale(locale)
+	tmpl, ok := emailTemplates[locale][name]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template %q", name)
+	}
+
+	view := emailView{Locale: locale, UnsubscribeURL: unsubscribeURL, Data: data}
+
+	var subject bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", view); err != nil {
+		return nil, err
+	}
+	view.Subject = strings.TrimSpace(subject.String())
+
+	var text bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&text, "layout.txt", view); err != nil {
+		return nil, err
+	}
+
+	var html bytes.Buffer
+	if err := tmpl.html.ExecuteTemplate(&html, "layout.html", view); err != nil {
+		return nil, err
+	}
+
+	return &RenderedEmail{
+		Subject:  view.Subject,
+		HTMLBody: html.String(),
+		TextBody: strings.TrimSpace(text.String()) + "\n",
+	}, nil
+}
+
+// PreviewEmail renders a template with its sample data
+func PreviewEmail(name, locale string) (*RenderedEmail, error) {
+	data, ok := emailTemplateSamples[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template %q", name)
+	}
+	unsubscribeURL := ""
+	if _, ok := emailTemplateCategories[name]; ok {
+		unsubscribeURL = "https://example.com/api/public/unsubscribe?token=sample"
+	}
+	return renderEmail(name, locale, data, unsubscribeURL)
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"html"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEveryTemplateRendersInEveryLocale(t *testing.T) {
+	for _, locale := range SupportedLocales {
+		for _, name := range EmailTemplateNames() {
+			rendered, err := PreviewEmail(name, locale)
+			if !assert.NoError(t, err, "%s/%s", locale, name) {
+				continue
+			}
+			assert.NotEmpty(t, rendered.Subject, "%s/%s", locale, name)
+			assert.Contains(t, rendered.HTMLBody, `<html lang="`+locale+`">`)
+			assert.Contains(t, rendered.HTMLBody, "<title>"+html.EscapeString(rendered.Subject)+"</title>")
+			assert.Contains(t, rendered.TextBody, "https://example.com/")
+			assert.NotContains(t, rendered.TextBody, "<p>")
+			assert.NotContains(t, rendered.HTMLBody, "no value")
+			assert.NotContains(t, rendered.TextBody, "no value")
+		}
+	}
+}
+
+func TestRenderEmailUsesLocaleAndEscapesHTML(t *testing.T) {
+	data := ActionEmailData{
+		InviterName: "Ana <b>López</b>",
+		ChildName:   "Sam",
+		ActionURL:   "https://example.com/accept-invitation?token=abc",
+	}
+
+	rendered, err := RenderEmail(EmailTemplateInvitation, "es-MX", data)
+	assert.NoError(t, err)
+	assert.Equal(t, "Ana <b>López</b> te ha invitado a seguir el progreso de lectura de Sam", rendered.Subject)
+	assert.Contains(t, rendered.HTMLBody, "Ana &lt;b&gt;López&lt;/b&gt;")
+	assert.Contains(t, rendered.TextBody, "Abre el enlace de abajo")
+
+	rendered, err = RenderEmail(EmailTemplateInvitation, "fr", data)
+	assert.NoError(t, err)
+	assert.Contains(t, rendered.TextBody, "has invited you")
+
+	_, err = RenderEmail("missing", "en", data)
+	assert.Error(t, err)
+}
+
+func TestNormalizeLocale(t *testing.T) {
+	assert.Equal(t, "es", NormalizeLocale("ES_es"))
+	assert.Equal(t, "en", NormalizeLocale(""))
+	assert.Equal(t, "en", NormalizeLocale("de"))
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"sync"
+)
+
+// Actor identifies who performed a change and where the request came from
+type Actor struct {
+	UserID uint
+	IP     string
+}
+
+// SystemActor is used for changes made by background jobs
+var SystemActor = Actor{}
+
+// Change actions
+const (
+	ActionCreate   = "create"
+	ActionUpdate   = "update"
+	ActionDelete   = "delete"
+	ActionTransfer = "transfer"
+	ActionRestore  = "restore"
+	// ActionImport creates a book from an imported reading log. Hooks treat it as a creation,
+	// except that followers are not notified once per imported row.
+	ActionImport = "import"
+)
+
+// Entity types
+const (
+	EntityBook       = "book"
+	EntityChild      = "child"
+	EntityPermission = "permission"
+)
+
+// ChangeEvent describes a mutation performed by a service function
+type ChangeEvent struct {
+	Actor      Actor
+	Action     string
+	EntityType string
+	EntityID   uint
+	ChildID    uint
+	Before     interface{} // nil for creations
+	After      interface{} // nil for deletions
+}
+
+// ChangeHook is called synchronously after a change has been committed
+type ChangeHook func(event ChangeEvent)
+
+var (
+	changeHooks      []ChangeHook
+	changeHooksMutex sync.RWMutex
+)
+
+// RegisterChangeHook subscribes a hook to every change made through the services
+func RegisterChangeHook(hook ChangeHook) {
+	changeHooksMutex.Lock()
+	defer changeHooksMutex.Unlock()
+	changeHooks = append(changeHooks, hook)
+}
+
+// fireChange passes an event to every registered hook
+func fireChange(event ChangeEvent) {
+	changeHooksMutex.RLock()
+	hooks := make([]ChangeHook, len(changeHooks))
+	copy(hooks, changeHooks)
+	changeHooksMutex.RUnlock()
+
+	for _, hook := range hooks {
+		hook(event)
+	}
+}
@@ -0,0 +1,469 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+)
+
+// Export formats
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+)
+
+// Export columns, in their default order
+const (
+	ExportColumnChild   = "child"
+	ExportColumnDate    = "date"
+	ExportColumnTitle   = "title"
+	ExportColumnAuthor  = "author"
+	ExportColumnISBN    = "isbn"
+	ExportColumnLexile  = "lexile"
+	ExportColumnLevel   = "level"
+	ExportColumnPartial = "partial"
+	ExportColumnNote    = "note"
+	ExportColumnSource  = "source"
+)
+
+// ExportColumns are every export column, which is also what an export includes by default
+var ExportColumns = []string{
+	ExportColumnChild,
+	ExportColumnDate,
+	ExportColumnTitle,
+	ExportColumnAuthor,
+	ExportColumnISBN,
+	ExportColumnLexile,
+	ExportColumnLevel,
+	ExportColumnPartial,
+	ExportColumnNote,
+	ExportColumnSource,
+}
+
+var exportColumnHeadings = map[string]string{
+	ExportColumnChild:   "Child",
+	ExportColumnDate:    "Date Read",
+	ExportColumnTitle:   "Title",
+	ExportColumnAuthor:  "Author",
+	ExportColumnISBN:    "ISBN",
+	ExportColumnLexile:  "Lexile",
+	ExportColumnLevel:   "Level", // Named after the LevelSystem of the export
+	ExportColumnPartial: "Partial",
+	ExportColumnNote:    "Partial Note",
+	ExportColumnSource:  "Source",
+}
+
+var (
+	// ErrInvalidExportFormat is returned for formats other than csv and xlsx
+	ErrInvalidExportFormat = errors.New("format must be csv or xlsx")
+
+	// ErrInvalidExportColumn is returned for column names that are not in ExportColumns
+	ErrInvalidExportColumn = errors.New("unknown export column")
+)
+
+// ParseExportColumns reads a comma-separated column list; an empty list selects every column
+func ParseExportColumns(list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return ExportColumns, nil
+	}
+	var columns []string
+	seen := make(map[string]bool)
+	for _, column := range strings.Split(list, ",") {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if _, ok := exportColumnHeadings[column]; !ok {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidExportColumn, column)
+		}
+		if !seen[column] {
+			seen[column] = true
+			columns = append(columns, column)
+		}
+	}
+	return columns, nil
+}
+
+// BookExport selects what ExportBooks writes
+type BookExport struct {
+	Children []*models.Child
+	From     time.Time // First day included; zero for no lower bound
+	To       time.Time // Last day included; zero for no upper bound
+	Columns  []string
+	// LevelSystem is the reading-level system of the level column; levels converted from another
+	// system are marked with ~
+	LevelSystem string
+}
+
+// exportRow is a book joined with its shared book, as read from the database
+type exportRow struct {
+	ChildID        uint
+	DateRead       string
+	CustomTitle    string
+	CustomAuthor   string
+	CustomISBN     string
+	LexileLevel    string
+	LexileMeasure  *int
+	IsPartial      bool
+	PartialComment string
+	SharedBookID   *uint
+	SharedTitle    string
+	SharedAuthor   string
+	SharedISBN     string
+
+	// Levels in the other systems, with their grade equivalents
+	ATOSLevel          string
+	ATOSGrade          float64
+	GuidedReadingLevel string
+	GuidedReadingGrade float64
+	GradeLevel         string
+	GradeLevelGrade    float64
+}
+
+// book is the row's book, with as much as exportValue needs of its reading levels
+func (row exportRow) book() *models.Book {
+	book := &models.Book{LexileLevel: row.LexileLevel, LexileMeasure: row.LexileMeasure}
+	for _, level := range []models.BookReadingLevel{
+		{System: models.ReadingLevelATOS, Level: row.ATOSLevel, GradeEquivalent: row.ATOSGrade},
+		{System: models.ReadingLevelGuidedReading, Level: row.GuidedReadingLevel, GradeEquivalent: row.GuidedReadingGrade},
+		{System: models.ReadingLevelGrade, Level: row.GradeLevel, GradeEquivalent: row.GradeLevelGrade},
+	} {
+		if level.Level != "" {
+			book.ReadingLevels = append(book.ReadingLevels, level)
+		}
+	}
+	return book
+}
+
+// ExportBooks writes the children's books, a heading row and then one row per book ordered by
+// child and date, as CSV or XLSX. Rows are read from the database and written one at a time,
+// so exports of any size use constant memory.
+func ExportBooks(w io.Writer, format string, export BookExport) error {
+	var writer exportWriter
+	switch format {
+	case ExportFormatCSV:
+		writer = newCSVExportWriter(w)
+	case ExportFormatXLSX:
+		writer = newXLSXExportWriter(w)
+	default:
+		return ErrInvalidExportFormat
+	}
+
+	if export.LevelSystem == "" {
+		export.LevelSystem = models.ReadingLevelLexile
+	}
+	headings := make([]exportCell, len(export.Columns))
+	for i, column := range export.Columns {
+		headings[i] = exportCell{text: exportColumnHeadings[column]}
+		if column == ExportColumnLevel {
+			headings[i].text = readingLevelNames[export.LevelSystem] + " Level"
+		}
+	}
+	if err := writer.writeRow(headings); err != nil {
+		return err
+	}
+
+	if len(export.Children) > 0 {
+		if err := writeExportRows(writer, export); err != nil {
+			return err
+		}
+	}
+	return writer.close()
+}
+
+func writeExportRows(writer exportWriter, export BookExport) error {
+	childNames := make(map[uint]string, len(export.Children))
+	childIDs := make([]uint, len(export.Children))
+	for i, child := range export.Children {
+		childIDs[i] = child.ID
+		childNames[child.ID] = strings.TrimSpace(child.FirstName + " " + child.LastName)
+	}
+
+	query := config.DB.Model(&models.Book{}).
+		Select("books.child_id, books.date_read, books.custom_title, books.custom_author, books.custom_isbn, "+
+			"books.lexile_level, books.lexile_measure, books.is_partial, books.partial_comment, books.shared_book_id, "+
+			"shared_books.title AS shared_title, shared_books.author AS shared_author, shared_books.isbn AS shared_isbn, "+
+			"atos.level AS atos_level, atos.grade_equivalent AS atos_grade, "+
+			"guided.level AS guided_reading_level, guided.grade_equivalent AS guided_reading_grade, "+
+			"grade.level AS grade_level, grade.grade_equivalent AS grade_level_grade").
+		Joins("LEFT JOIN shared_books ON shared_books.id = books.shared_book_id").
+		Joins("LEFT JOIN book_reading_levels atos ON atos.book_id = books.id AND atos.system = ?", models.ReadingLevelATOS).
+		Joins("LEFT JOIN book_reading_levels guided ON guided.book_id = books.id AND guided.system = ?", models.ReadingLevelGuidedReading).
+		Joins("LEFT JOIN book_reading_levels grade ON grade.book_id = books.id AND grade.system = ?", models.ReadingLevelGrade).
+		Where("books.child_id IN ?", childIDs)
+	if !export.From.IsZero() {
+		query = query.Where("books.date_read >= ?", export.From.Format("2006-01-02"))
+	}
+	if !export.To.IsZero() {
+		query = query.Where("books.date_read < ?", export.To.AddDate(0, 0, 1).Format("2006-01-02"))
+	}
+
+	rows, err := query.Order("books.child_id ASC, books.date_read ASC, books.id ASC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	cells := make([]exportCell, len(export.Columns))
+	for rows.Next() {
+		var row exportRow
+		if err := config.DB.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		for i, column := range export.Columns {
+			cells[i] = exportValue(row, column, childNames, export.LevelSystem)
+		}
+		if err := writer.writeRow(cells); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// exportValue is one column of a book's row
+func exportValue(row exportRow, column string, childNames map[uint]string, levelSystem string) exportCell {
+	shared := row.SharedBookID != nil
+	switch column {
+	case ExportColumnChild:
+		return exportCell{text: childNames[row.ChildID]}
+	case ExportColumnDate:
+		if date, err := time.Parse("2006-01-02", firstN(row.DateRead, 10)); err == nil {
+			return exportCell{text: date.Format("2006-01-02"), date: date}
+		}
+		return exportCell{text: row.DateRead}
+	case ExportColumnTitle:
+		if shared {
+			return exportCell{text: row.SharedTitle}
+		}
+		return exportCell{text: row.CustomTitle}
+	case ExportColumnAuthor:
+		if shared {
+			return exportCell{text: row.SharedAuthor}
+		}
+		return exportCell{text: row.CustomAuthor}
+	case ExportColumnISBN:
+		if shared {
+			return exportCell{text: row.SharedISBN}
+		}
+		return exportCell{text: row.CustomISBN}
+	case ExportColumnLexile:
+		return exportCell{text: row.LexileLevel}
+	case ExportColumnLevel:
+		level := BookReadingLevelIn(row.book(), levelSystem)
+		if level == nil {
+			return exportCell{}
+		}
+		if level.Approximate {
+			return exportCell{text: "~" + level.Level}
+		}
+		return exportCell{text: level.Level}
+	case ExportColumnPartial:
+		if row.IsPartial {
+			return exportCell{text: "Yes"}
+		}
+		return exportCell{text: "No"}
+	case ExportColumnNote:
+		return exportCell{text: row.PartialComment}
+	case ExportColumnSource:
+		if shared {
+			return exportCell{text: "Shared"}
+		}
+		return exportCell{text: "Custom"}
+	}
+	return exportCell{}
+}
+
+// firstN returns the first n bytes of s, or all of it when shorter
+func firstN(s string, n int) string {
+	if len(s) < n {
+		return s
+	}
+	return s[:n]
+}
+
+// exportCell is a value to export; date is set for dates, which spreadsheets store as numbers
+type exportCell struct {
+	text string
+	date time.Time
+}
+
+// exportWriter writes the rows of an export in one format
+type exportWriter interface {
+	writeRow(cells []exportCell) error
+	close() error
+}
+
+// csvExportWriter writes UTF-8 CSV with a byte order mark, which Excel needs to read it as UTF-8
+type csvExportWriter struct {
+	w       io.Writer
+	csv     *csv.Writer
+	started bool
+	record  []string
+}
+
+func newCSVExportWriter(w io.Writer) *csvExportWriter {
+	return &csvExportWriter{w: w, csv: csv.NewWriter(w)}
+}
+
+func (c *csvExportWriter) writeRow(cells []exportCell) error {
+	if !c.started {
+		c.started = true
+		if _, err := io.WriteString(c.w, "\uFEFF"); err != nil {
+			return err
+		}
+	}
+	c.record = c.record[:0]
+	for _, cell := range cells {
+		c.record = append(c.record, escapeCSVFormula(cell.text))
+	}
+	return c.csv.Write(c.record)
+}
+
+func (c *csvExportWriter) close() error {
+	c.csv.Flush()
+	return c.csv.Error()
+}
+
+// escapeCSVFormula keeps spreadsheets from running titles that start like a formula
+func escapeCSVFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// xlsxExportWriter writes a single-sheet workbook. The sheet is the last entry in the zip and
+// is written as rows arrive, with strings inline rather than in a shared table, so nothing is
+// held back until the end.
+type xlsxExportWriter struct {
+	zip   *zip.Writer
+	sheet io.Writer
+	row   int
+	err   error
+}
+
+func newXLSXExportWriter(w io.Writer) *xlsxExportWriter {
+	return &xlsxExportWriter{zip: zip.NewWriter(w)}
+}
+
+// xlsxParts are the fixed parts of the workbook. Style 1 is the bold heading row and
+// style 2 formats dates.
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Books" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`},
+	{"xl/styles.xml", xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+		`<xf numFmtId="14" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>` +
+		`</styleSheet>`},
+}
+
+// xlsxEpoch is day zero of spreadsheet date serial numbers
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+func (x *xlsxExportWriter) writeRow(cells []exportCell) error {
+	if x.err != nil {
+		return x.err
+	}
+	if x.sheet == nil {
+		x.err = x.start()
+		if x.err != nil {
+			return x.err
+		}
+	}
+
+	x.row++
+	var b strings.Builder
+	fmt.Fprintf(&b, `<row r="%d">`, x.row)
+	for i, cell := range cells {
+		ref := xlsxColumn(i) + fmt.Sprint(x.row)
+		switch {
+		case x.row == 1:
+			fmt.Fprintf(&b, `<c r="%s" s="1" t="inlineStr"><is><t>%s</t></is></c>`, ref, xmlText(cell.text))
+		case !cell.date.IsZero():
+			fmt.Fprintf(&b, `<c r="%s" s="2"><v>%d</v></c>`, ref, int(cell.date.Sub(xlsxEpoch).Hours()/24))
+		case cell.text != "":
+			fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xmlText(cell.text))
+		}
+	}
+	b.WriteString("</row>")
+	_, x.err = io.WriteString(x.sheet, b.String())
+	return x.err
+}
+
+// start writes the fixed parts and opens the sheet
+func (x *xlsxExportWriter) start() error {
+	for _, part := range xlsxParts {
+		w, err := x.zip.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, part.content); err != nil {
+			return err
+		}
+	}
+	sheet, err := x.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	x.sheet = sheet
+	_, err = io.WriteString(sheet, xml.Header+`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`+
+		`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`+
+		`<sheetData>`)
+	return err
+}
+
+func (x *xlsxExportWriter) close() error {
+	if x.err != nil {
+		return x.err
+	}
+	if x.sheet == nil {
+		if err := x.start(); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(x.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
+
+// xlsxColumn is the letter name of a zero-based column: A to Z, then AA onwards
+func xlsxColumn(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// xmlText escapes text for an XML element; characters XML cannot hold become U+FFFD
+func xmlText(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
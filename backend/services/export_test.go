@@ -0,0 +1,170 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ExportTestSuite struct {
+	suite.Suite
+	owner *models.User
+	sam   *models.Child
+	alex  *models.Child
+}
+
+func (suite *ExportTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+	SharedPermissionCache().Clear()
+
+	owner, err := CreateUser(models.CreateUserRequest{
+		Email:     "owner@example.com",
+		Password:  "password123",
+		FirstName: "Owner",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	suite.owner = owner
+
+	suite.sam, err = CreateChild(models.CreateChildRequest{FirstName: "Sam", LastName: "Reader"}, owner.ID)
+	assert.NoError(suite.T(), err)
+	suite.alex, err = CreateChild(models.CreateChildRequest{FirstName: "Alex", LastName: "Reader"}, owner.ID)
+	assert.NoError(suite.T(), err)
+
+	// A shared book, a partly read custom book and a custom book outside the range
+	sharedBook := models.SharedBook{ISBN: "9780140328721", Title: "Matilda", Author: "Roald Dahl"}
+	assert.NoError(suite.T(), config.DB.Create(&sharedBook).Error)
+	assert.NoError(suite.T(), config.DB.Create(&models.Book{
+		ChildID: suite.sam.ID, SharedBookID: &sharedBook.ID, DateRead: "2024-09-10", LexileLevel: "840L",
+	}).Error)
+	assert.NoError(suite.T(), config.DB.Create(&models.Book{
+		ChildID: suite.sam.ID, CustomTitle: "=HYPERLINK(\"x\")", CustomAuthor: "Días Autor", DateRead: "2024-09-02",
+		IsPartial: true, PartialComment: "Chapters 1-3",
+	}).Error)
+	assert.NoError(suite.T(), config.DB.Create(&models.Book{
+		ChildID: suite.alex.ID, CustomTitle: "Summer Book", CustomAuthor: "Author", DateRead: "2024-07-30",
+	}).Error)
+}
+
+func (suite *ExportTestSuite) TearDownTest() {
+	config.CleanupTestDatabase()
+}
+
+func (suite *ExportTestSuite) TestCSVExport() {
+	var output bytes.Buffer
+	err := ExportBooks(&output, ExportFormatCSV, BookExport{
+		Children: []*models.Child{suite.sam, suite.alex},
+		From:     time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2024, 9, 30, 0, 0, 0, 0, time.UTC),
+		Columns:  ExportColumns,
+	})
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), strings.HasPrefix(output.String(), "\uFEFF"))
+
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(output.String(), "\uFEFF"))).ReadAll()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), [][]string{
+		{"Child", "Date Read", "Title", "Author", "ISBN", "Lexile", "Lexile Level", "Partial", "Partial Note", "Source"},
+		{"Sam Reader", "2024-09-02", "'=HYPERLINK(\"x\")", "Días Autor", "", "", "", "Yes", "Chapters 1-3", "Custom"},
+		{"Sam Reader", "2024-09-10", "Matilda", "Roald Dahl", "9780140328721", "840L", "840L", "No", "", "Shared"},
+	}, records)
+
+	// Without a range every book is exported, in the requested columns
+	columns, err := ParseExportColumns("title, date,title")
+	assert.NoError(suite.T(), err)
+	output.Reset()
+	assert.NoError(suite.T(), ExportBooks(&output, ExportFormatCSV, BookExport{
+		Children: []*models.Child{suite.alex},
+		Columns:  columns,
+	}))
+	assert.Equal(suite.T(), "\uFEFFTitle,Date Read\nSummer Book,2024-07-30\n", output.String())
+
+	_, err = ParseExportColumns("title,minutes")
+	assert.ErrorIs(suite.T(), err, ErrInvalidExportColumn)
+	assert.ErrorIs(suite.T(), ExportBooks(io.Discard, "pdf", BookExport{}), ErrInvalidExportFormat)
+}
+
+func (suite *ExportTestSuite) TestLevelColumn() {
+	// Matilda's Lexile level gets its measure from the backfill
+	_, err := BackfillLexileMeasures()
+	assert.NoError(suite.T(), err)
+	var summer models.Book
+	assert.NoError(suite.T(), config.DB.Where("custom_title = ?", "Summer Book").First(&summer).Error)
+	assert.NoError(suite.T(), saveReadingLevels(config.DB, summer.ID, []models.BookReadingLevel{
+		{System: models.ReadingLevelGuidedReading, Level: "M", GradeEquivalent: 3},
+	}))
+
+	var output bytes.Buffer
+	assert.NoError(suite.T(), ExportBooks(&output, ExportFormatCSV, BookExport{
+		Children:    []*models.Child{suite.sam, suite.alex},
+		Columns:     []string{ExportColumnTitle, ExportColumnLevel},
+		LevelSystem: models.ReadingLevelGuidedReading,
+	}))
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(output.String(), "\uFEFF"))).ReadAll()
+	assert.NoError(suite.T(), err)
+	// Levels converted from another system are marked
+	assert.Equal(suite.T(), [][]string{
+		{"Title", "Guided Reading Level"},
+		{"'=HYPERLINK(\"x\")", ""},
+		{"Matilda", "~S"},
+		{"Summer Book", "M"},
+	}, records)
+}
+
+func (suite *ExportTestSuite) TestXLSXExport() {
+	var output bytes.Buffer
+	err := ExportBooks(&output, ExportFormatXLSX, BookExport{
+		Children: []*models.Child{suite.sam},
+		Columns:  []string{ExportColumnDate, ExportColumnTitle, ExportColumnAuthor},
+	})
+	assert.NoError(suite.T(), err)
+
+	archive, err := zip.NewReader(bytes.NewReader(output.Bytes()), int64(output.Len()))
+	assert.NoError(suite.T(), err)
+	parts := make(map[string]string)
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		assert.NoError(suite.T(), err)
+		content, err := io.ReadAll(reader)
+		assert.NoError(suite.T(), err)
+		parts[file.Name] = string(content)
+
+		// Every part is well-formed XML
+		decoder := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			if _, err := decoder.Token(); err != nil {
+				assert.ErrorIs(suite.T(), err, io.EOF, file.Name)
+				break
+			}
+		}
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		assert.Contains(suite.T(), parts, name)
+	}
+
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	assert.Contains(suite.T(), sheet, `<c r="A1" s="1" t="inlineStr"><is><t>Date Read</t></is></c>`)
+	// Dates are serial numbers with a date format; 2024-09-02 is day 45537
+	assert.Contains(suite.T(), sheet, `<c r="A2" s="2"><v>45537</v></c>`)
+	assert.Contains(suite.T(), sheet, `<t xml:space="preserve">=HYPERLINK(&#34;x&#34;)</t>`)
+	assert.Contains(suite.T(), sheet, `<t xml:space="preserve">Días Autor</t>`)
+	assert.Contains(suite.T(), sheet, `<c r="B3" t="inlineStr"><is><t xml:space="preserve">Matilda</t></is></c>`)
+	assert.True(suite.T(), strings.HasSuffix(sheet, "</sheetData></worksheet>"))
+
+	assert.Equal(suite.T(), "AB", xlsxColumn(27))
+}
+
+func TestExportTestSuite(t *testing.T) {
+	suite.Run(t, new(ExportTestSuite))
+}
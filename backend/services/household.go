@@ -0,0 +1,349 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"gorm.io/gorm"
+)
+
+// Household member roles
+const (
+	HouseholdRoleOwner  = "OWNER"
+	HouseholdRoleEditor = "EDITOR"
+	HouseholdRoleViewer = "VIEWER"
+)
+
+// Entity types of household changes
+const (
+	EntityHousehold       = "household"
+	EntityHouseholdMember = "household_member"
+)
+
+// householdRoleAccessSQL maps a member role to the child access level it grants
+const householdRoleAccessSQL = `CASE hm.role WHEN 'VIEWER' THEN 'VIEW' ELSE 'EDIT' END`
+
+// HouseholdRoleAccess returns the child access level granted by a household role
+func HouseholdRoleAccess(role string) string {
+	if role == HouseholdRoleViewer {
+		return "VIEW"
+	}
+	return "EDIT"
+}
+
+// CreateHousehold creates a household with its creator as the first owner
+func CreateHousehold(req models.CreateHouseholdRequest, actor Actor) (*models.Household, error) {
+	household := models.Household{
+		Name:        req.Name,
+		CreatedByID: actor.UserID,
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&household).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.HouseholdMember{
+			HouseholdID: household.ID,
+			UserID:      actor.UserID,
+			Role:        HouseholdRoleOwner,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionCreate,
+		EntityType: EntityHousehold,
+		EntityID:   household.ID,
+		After:      household,
+	})
+
+	return &household, nil
+}
+
+// GetHouseholdByID gets a household with its members and children
+func GetHouseholdByID(id uint) (*models.Household, error) {
+	var household models.Household
+	result := config.DB.Preload("Members.User").Preload("Children").First(&household, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("household not found")
+		}
+		return nil, result.Error
+	}
+	return &household, nil
+}
+
+// GetHouseholdsForUser gets every household the user is a member of
+func GetHouseholdsForUser(userID uint) ([]models.Household, error) {
+	var households []models.Household
+	result := config.DB.Preload("Members.User").Preload("Children").
+		Where("id IN (?)", config.DB.Model(&models.HouseholdMember{}).Select("household_id").Where("user_id = ?", userID)).
+		Order("name").
+		Find(&households)
+	return households, result.Error
+}
+
+// GetHouseholdRole returns the user's role in a household, or "" if they are not a member
+func GetHouseholdRole(householdID, userID uint) (string, error) {
+	var member models.HouseholdMember
+	result := config.DB.Where("household_id = ? AND user_id = ?", householdID, userID).First(&member)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", result.Error
+	}
+	return member.Role, nil
+}
+
+// UpdateHousehold renames a household
+func UpdateHousehold(id uint, req models.UpdateHouseholdRequest, actor Actor) (*models.Household, error) {
+	var household models.Household
+	result := config.DB.First(&household, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("household not found")
+		}
+		return nil, result.Error
+	}
+	before := household
+
+	household.Name = req.Name
+	if err := config.DB.Save(&household).Error; err != nil {
+		return nil, err
+	}
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionUpdate,
+		EntityType: EntityHousehold,
+		EntityID:   household.ID,
+		Before:     before,
+		After:      household,
+	})
+
+	return &household, nil
+}
+
+// DeleteHousehold removes a household; its children stay with their owners
+func DeleteHousehold(id uint, actor Actor) error {
+	var household models.Household
+	result := config.DB.First(&household, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return errors.New("household not found")
+		}
+		return result.Error
+	}
+
+	var memberIDs []uint
+	if err := config.DB.Model(&models.HouseholdMember{}).Where("household_id = ?", id).Pluck("user_id", &memberIDs).Error; err != nil {
+		return err
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Child{}).Where("household_id = ?", id).Update("household_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("household_id = ?", id).Delete(&models.HouseholdMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&household).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, memberID := range memberIDs {
+		invalidateUserPermissions(memberID)
+	}
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionDelete,
+		EntityType: EntityHousehold,
+		EntityID:   household.ID,
+		Before:     household,
+	})
+
+	return nil
+}
+
+// AddHouseholdMember adds an existing user to a household
+func AddHouseholdMember(householdID, userID uint, role string, actor Actor) (*models.HouseholdMember, error) {
+	existingRole, err := GetHouseholdRole(householdID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existingRole != "" {
+		return nil, errors.New("user is already a member of this household")
+	}
+
+	member := models.HouseholdMember{
+		HouseholdID: householdID,
+		UserID:      userID,
+		Role:        role,
+	}
+	if err := config.DB.Create(&member).Error; err != nil {
+		return nil, err
+	}
+
+	invalidateUserPermissions(userID)
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionCreate,
+		EntityType: EntityHouseholdMember,
+		EntityID:   member.ID,
+		After:      member,
+	})
+
+	return &member, nil
+}
+
+// UpdateHouseholdMember changes a member's role, keeping at least one owner
+func UpdateHouseholdMember(householdID, userID uint, role string, actor Actor) (*models.HouseholdMember, error) {
+	var member models.HouseholdMember
+	result := config.DB.Where("household_id = ? AND user_id = ?", householdID, userID).First(&member)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("household member not found")
+		}
+		return nil, result.Error
+	}
+	before := member
+
+	if member.Role == HouseholdRoleOwner && role != HouseholdRoleOwner {
+		if err := ensureAnotherHouseholdOwner(householdID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	member.Role = role
+	if err := config.DB.Save(&member).Error; err != nil {
+		return nil, err
+	}
+
+	invalidateUserPermissions(userID)
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionUpdate,
+		EntityType: EntityHouseholdMember,
+		EntityID:   member.ID,
+		Before:     before,
+		After:      member,
+	})
+
+	return &member, nil
+}
+
+// RemoveHouseholdMember removes a user from a household, keeping at least one owner
+func RemoveHouseholdMember(householdID, userID uint, actor Actor) error {
+	var member models.HouseholdMember
+	result := config.DB.Where("household_id = ? AND user_id = ?", householdID, userID).First(&member)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return errors.New("household member not found")
+		}
+		return result.Error
+	}
+
+	if member.Role == HouseholdRoleOwner {
+		if err := ensureAnotherHouseholdOwner(householdID, userID); err != nil {
+			return err
+		}
+	}
+
+	if err := config.DB.Delete(&member).Error; err != nil {
+		return err
+	}
+
+	invalidateUserPermissions(userID)
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionDelete,
+		EntityType: EntityHouseholdMember,
+		EntityID:   member.ID,
+		Before:     member,
+	})
+
+	return nil
+}
+
+// SetChildHousehold moves a child into a household, or out of it when householdID is nil
+func SetChildHousehold(childID uint, householdID *uint, actor Actor) (*models.Child, error) {
+	var child models.Child
+	result := config.DB.First(&child, childID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("child not found")
+		}
+		return nil, result.Error
+	}
+	before := child
+
+	if householdID != nil {
+		var household models.Household
+		if err := config.DB.First(&household, *householdID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("household not found")
+			}
+			return nil, err
+		}
+	}
+
+	if err := config.DB.Model(&child).Update("household_id", householdID).Error; err != nil {
+		return nil, err
+	}
+	child.HouseholdID = householdID
+
+	// Members of both the old and the new household gain or lose the child
+	invalidateChildPermissions(childID)
+	for _, id := range []*uint{before.HouseholdID, householdID} {
+		if id != nil {
+			invalidateHouseholdPermissions(*id)
+		}
+	}
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionUpdate,
+		EntityType: EntityChild,
+		EntityID:   child.ID,
+		ChildID:    child.ID,
+		Before:     before,
+		After:      child,
+	})
+
+	return &child, nil
+}
+
+// ensureAnotherHouseholdOwner fails if userID is the household's last owner
+func ensureAnotherHouseholdOwner(householdID, userID uint) error {
+	var owners int64
+	err := config.DB.Model(&models.HouseholdMember{}).
+		Where("household_id = ? AND role = ? AND user_id <> ?", householdID, HouseholdRoleOwner, userID).
+		Count(&owners).Error
+	if err != nil {
+		return err
+	}
+	if owners == 0 {
+		return errors.New("a household needs at least one owner")
+	}
+	return nil
+}
+
+// invalidateHouseholdPermissions drops the cached grants of every household member
+func invalidateHouseholdPermissions(householdID uint) {
+	var memberIDs []uint
+	config.DB.Model(&models.HouseholdMember{}).Where("household_id = ?", householdID).Pluck("user_id", &memberIDs)
+	for _, memberID := range memberIDs {
+		invalidateUserPermissions(memberID)
+	}
+}
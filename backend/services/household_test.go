@@ -0,0 +1,189 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type HouseholdServiceTestSuite struct {
+	suite.Suite
+	parent      *models.User
+	grandparent *models.User
+	outsider    *models.User
+	household   *models.Household
+}
+
+func (suite *HouseholdServiceTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+	SharedPermissionCache().Clear()
+
+	suite.parent = suite.createUser("parent@example.com")
+	suite.grandparent = suite.createUser("grandparent@example.com")
+	suite.outsider = suite.createUser("outsider@example.com")
+
+	household, err := CreateHousehold(models.CreateHouseholdRequest{Name: "Family"}, Actor{UserID: suite.parent.ID})
+	assert.NoError(suite.T(), err)
+	suite.household = household
+}
+
+func (suite *HouseholdServiceTestSuite) TearDownTest() {
+	config.CleanupTestDatabase()
+}
+
+func (suite *HouseholdServiceTestSuite) createUser(email string) *models.User {
+	user, err := CreateUser(models.CreateUserRequest{
+		Email:     email,
+		Password:  "password123",
+		FirstName: "Test",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	return user
+}
+
+func (suite *HouseholdServiceTestSuite) createChild(householdID *uint) *models.Child {
+	child, err := CreateChild(models.CreateChildRequest{
+		FirstName:   "Test",
+		LastName:    "Child",
+		Grade:       "3rd",
+		HouseholdID: householdID,
+	}, suite.parent.ID)
+	assert.NoError(suite.T(), err)
+	return child
+}
+
+func (suite *HouseholdServiceTestSuite) TestCreatorIsOwner() {
+	role, err := GetHouseholdRole(suite.household.ID, suite.parent.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), HouseholdRoleOwner, role)
+
+	role, err = GetHouseholdRole(suite.household.ID, suite.outsider.ID)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), role)
+}
+
+func (suite *HouseholdServiceTestSuite) TestNewMemberReachesExistingChildren() {
+	child := suite.createChild(&suite.household.ID)
+
+	// Warm the cache before the member is added
+	hasPermission, err := HasChildPermission(suite.grandparent.ID, child.ID, "VIEW")
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), hasPermission)
+
+	_, err = AddHouseholdMember(suite.household.ID, suite.grandparent.ID, HouseholdRoleViewer, SystemActor)
+	assert.NoError(suite.T(), err)
+
+	hasPermission, err = HasChildPermission(suite.grandparent.ID, child.ID, "VIEW")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), hasPermission)
+	hasPermission, err = HasChildPermission(suite.grandparent.ID, child.ID, "EDIT")
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), hasPermission)
+
+	hasPermission, err = CheckChildPermission(suite.grandparent.ID, child.ID, "VIEW")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), hasPermission)
+
+	children, err := GetChildrenWithPermission(suite.grandparent.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), children, 1)
+}
+
+func (suite *HouseholdServiceTestSuite) TestNewChildReachesExistingMembers() {
+	_, err := AddHouseholdMember(suite.household.ID, suite.grandparent.ID, HouseholdRoleEditor, SystemActor)
+	assert.NoError(suite.T(), err)
+
+	_, err = HasChildPermission(suite.grandparent.ID, 0, "VIEW")
+	assert.NoError(suite.T(), err)
+
+	child := suite.createChild(&suite.household.ID)
+	hasPermission, err := HasChildPermission(suite.grandparent.ID, child.ID, "EDIT")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), hasPermission)
+
+	_, err = CreateCustomBook(models.CreateCustomBookRequest{
+		Title:    "Title",
+		Author:   "Author",
+		DateRead: "2024-01-01",
+		ChildID:  child.ID,
+	}, SystemActor)
+	assert.NoError(suite.T(), err)
+	books, err := GetBooksForUser(suite.grandparent.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), books, 1)
+}
+
+func (suite *HouseholdServiceTestSuite) TestHouseholdAndPermissionAreBothResolved() {
+	householdChild := suite.createChild(&suite.household.ID)
+	sharedChild := suite.createChild(nil)
+
+	_, err := AddHouseholdMember(suite.household.ID, suite.grandparent.ID, HouseholdRoleViewer, SystemActor)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), CreatePermission(suite.grandparent.ID, sharedChild.ID, "EDIT", SystemActor))
+	// A one-off grant can raise access above the household role
+	assert.NoError(suite.T(), CreatePermission(suite.grandparent.ID, householdChild.ID, "EDIT", SystemActor))
+
+	children, err := GetChildrenWithPermission(suite.grandparent.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), children, 2)
+
+	grants, err := LoadUserGrants(suite.grandparent.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), map[uint]string{householdChild.ID: "EDIT", sharedChild.ID: "EDIT"}, grants)
+}
+
+func (suite *HouseholdServiceTestSuite) TestRemovingMemberOrChildRevokesAccess() {
+	child := suite.createChild(&suite.household.ID)
+	_, err := AddHouseholdMember(suite.household.ID, suite.grandparent.ID, HouseholdRoleEditor, SystemActor)
+	assert.NoError(suite.T(), err)
+
+	_, err = SetChildHousehold(child.ID, nil, SystemActor)
+	assert.NoError(suite.T(), err)
+	hasPermission, err := HasChildPermission(suite.grandparent.ID, child.ID, "VIEW")
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), hasPermission)
+
+	_, err = SetChildHousehold(child.ID, &suite.household.ID, SystemActor)
+	assert.NoError(suite.T(), err)
+	hasPermission, err = HasChildPermission(suite.grandparent.ID, child.ID, "VIEW")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), hasPermission)
+
+	assert.NoError(suite.T(), RemoveHouseholdMember(suite.household.ID, suite.grandparent.ID, SystemActor))
+	hasPermission, err = HasChildPermission(suite.grandparent.ID, child.ID, "VIEW")
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), hasPermission)
+}
+
+func (suite *HouseholdServiceTestSuite) TestLastOwnerCannotLeave() {
+	assert.Error(suite.T(), RemoveHouseholdMember(suite.household.ID, suite.parent.ID, SystemActor))
+	_, err := UpdateHouseholdMember(suite.household.ID, suite.parent.ID, HouseholdRoleViewer, SystemActor)
+	assert.Error(suite.T(), err)
+
+	_, err = AddHouseholdMember(suite.household.ID, suite.grandparent.ID, HouseholdRoleOwner, SystemActor)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), RemoveHouseholdMember(suite.household.ID, suite.parent.ID, SystemActor))
+}
+
+func (suite *HouseholdServiceTestSuite) TestDeleteHouseholdKeepsChildren() {
+	child := suite.createChild(&suite.household.ID)
+	assert.NoError(suite.T(), DeleteHousehold(suite.household.ID, SystemActor))
+
+	reloaded, err := GetChildByID(child.ID)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), reloaded.HouseholdID)
+	assert.Equal(suite.T(), suite.parent.ID, reloaded.OwnerID)
+
+	households, err := GetHouseholdsForUser(suite.parent.ID)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), households)
+}
+
+func TestHouseholdServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(HouseholdServiceTestSuite))
+}
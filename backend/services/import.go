@@ -0,0 +1,497 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"gorm.io/gorm"
+)
+
+// Import formats, recognised from the CSV header
+const (
+	ImportFormatBookTracker = "booktracker" // the CSV written by ExportBooks
+	ImportFormatGoodreads   = "goodreads"
+)
+
+// Import row statuses
+const (
+	ImportStatusReady     = "ready" // would be imported by a commit
+	ImportStatusImported  = "imported"
+	ImportStatusDuplicate = "duplicate"
+	ImportStatusSkipped   = "skipped"
+	ImportStatusError     = "error"
+)
+
+// maxImportRows keeps an import small enough to preview and commit in one request
+const maxImportRows = 5000
+
+// ISBNs missing from the database are looked up on a small pool to stay polite to Open Library
+const isbnLookupWorkers = 4
+
+// goodreadsReadShelf is the Goodreads shelf of finished books; books on other shelves are skipped
+const goodreadsReadShelf = "read"
+
+// importDateLayouts are the dates we write, the dates Goodreads writes, and the US dates a
+// spreadsheet writes when it saves one of our exports
+var importDateLayouts = []string{"2006-01-02", "2006/01/02", "1/2/2006"}
+
+var (
+	// ErrInvalidImportFile is returned for files that are not CSV exports in a known format
+	ErrInvalidImportFile = errors.New("file is not a book tracker or Goodreads CSV export")
+
+	// ErrImportTooLarge is returned for files with more than maxImportRows books
+	ErrImportTooLarge = fmt.Errorf("imports are limited to %d books", maxImportRows)
+
+	// ErrImportChildRequired is returned for Goodreads files when no child was chosen
+	ErrImportChildRequired = errors.New("a child must be chosen for files without a Child column")
+)
+
+// BookImport selects where ImportBooks puts the books it reads
+type BookImport struct {
+	// Children are the children the importer can edit; the Child column is matched against their names
+	Children []*models.Child
+	// ChildID receives the rows that do not name a child, which is every row of a Goodreads file
+	ChildID uint
+	// DryRun checks every row without saving anything
+	DryRun bool
+}
+
+// ImportRow is the outcome for one book in an imported file
+type ImportRow struct {
+	Line         int    `json:"line"`
+	Status       string `json:"status"`
+	Message      string `json:"message,omitempty"`
+	ChildID      uint   `json:"childId,omitempty"`
+	Title        string `json:"title,omitempty"`
+	Author       string `json:"author,omitempty"`
+	ISBN         string `json:"isbn,omitempty"`
+	DateRead     string `json:"dateRead,omitempty"`
+	IsPartial    bool   `json:"isPartial"`
+	SharedBookID *uint  `json:"sharedBookId,omitempty"`
+	BookID       uint   `json:"bookId,omitempty"` // set once imported
+
+	request models.CreateBookRequest
+}
+
+// ImportResult reports what an import did, or would do on a dry run, row by row
+type ImportResult struct {
+	Format     string      `json:"format"`
+	DryRun     bool        `json:"dryRun"`
+	Ready      int         `json:"ready"`
+	Imported   int         `json:"imported"`
+	Duplicates int         `json:"duplicates"`
+	Skipped    int         `json:"skipped"`
+	Errors     int         `json:"errors"`
+	Rows       []ImportRow `json:"rows"`
+}
+
+// importRecord is a CSV row with its cells keyed by heading
+type importRecord struct {
+	line  int
+	cells map[string]string
+}
+
+func (r importRecord) get(heading string) string {
+	return strings.TrimSpace(r.cells[heading])
+}
+
+// ImportBooks reads a reading log exported from the book tracker or Goodreads and logs its books.
+// ISBNs are resolved to shared books through LookupSharedBook; rows whose ISBN is unknown become
+// custom books. Rows with errors and books the child has already read are reported and left
+// out, and everything else is saved in a single transaction. A dry run reports the same rows
+// without saving any books, though books found on Open Library still join the shared catalog.
+func ImportBooks(r io.Reader, books BookImport, actor Actor) (*ImportResult, error) {
+	format, records, err := readImportRecords(r)
+	if err != nil {
+		return nil, err
+	}
+	if format == ImportFormatGoodreads && books.ChildID == 0 {
+		return nil, ErrImportChildRequired
+	}
+
+	result := &ImportResult{Format: format, DryRun: books.DryRun}
+	childrenByName := make(map[string][]*models.Child)
+	for _, child := range books.Children {
+		name := strings.ToLower(strings.TrimSpace(child.FirstName + " " + child.LastName))
+		childrenByName[name] = append(childrenByName[name], child)
+	}
+
+	rows := make([]*ImportRow, len(records))
+	for i, record := range records {
+		if format == ImportFormatGoodreads {
+			rows[i] = parseGoodreadsRecord(record, books.ChildID)
+		} else {
+			rows[i] = parseBookTrackerRecord(record, books.ChildID, childrenByName)
+		}
+	}
+
+	lookups := lookupImportISBNs(rows)
+	seen := make(map[string]int)
+	for _, row := range rows {
+		if row.Status != ImportStatusReady {
+			continue
+		}
+		resolveImportRow(row, lookups)
+		if row.Status != ImportStatusReady || row.IsPartial {
+			continue
+		}
+
+		// Partial readings can repeat; a full reading is logged once per child
+		key := fmt.Sprintf("%d|%s|%s", row.ChildID, strings.ToLower(row.Title), strings.ToLower(row.Author))
+		if row.SharedBookID != nil {
+			key = fmt.Sprintf("%d|%d", row.ChildID, *row.SharedBookID)
+		}
+		if line, ok := seen[key]; ok {
+			row.Status, row.Message = ImportStatusDuplicate, fmt.Sprintf("Repeats line %d", line)
+		} else if existing, found := findDuplicateBook(config.DB, row.request); found {
+			row.Status, row.Message = ImportStatusDuplicate, "Already logged as read on "+existing.DateRead
+		} else {
+			seen[key] = row.Line
+		}
+	}
+
+	if !books.DryRun {
+		if err := commitImportRows(rows, actor); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, row := range rows {
+		switch row.Status {
+		case ImportStatusReady:
+			result.Ready++
+		case ImportStatusImported:
+			result.Imported++
+		case ImportStatusDuplicate:
+			result.Duplicates++
+		case ImportStatusSkipped:
+			result.Skipped++
+		case ImportStatusError:
+			result.Errors++
+		}
+		result.Rows = append(result.Rows, *row)
+	}
+	return result, nil
+}
+
+// readImportRecords reads the header to recognise the format, then every row
+func readImportRecords(r io.Reader) (string, []importRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return "", nil, ErrInvalidImportFile
+	}
+	headings := make(map[string]bool, len(header))
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\uFEFF"))
+		headings[header[i]] = true
+	}
+
+	var format string
+	switch {
+	case headings["Book Id"] && headings["Exclusive Shelf"]:
+		format = ImportFormatGoodreads
+	case headings[exportColumnHeadings[ExportColumnTitle]] && headings[exportColumnHeadings[ExportColumnDate]]:
+		format = ImportFormatBookTracker
+	default:
+		return "", nil, ErrInvalidImportFile
+	}
+
+	var records []importRecord
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+		}
+		if len(records) == maxImportRows {
+			return "", nil, ErrImportTooLarge
+		}
+		line, _ := reader.FieldPos(0)
+		record := importRecord{line: line, cells: make(map[string]string, len(header))}
+		for i, value := range fields {
+			if i < len(header) {
+				record.cells[header[i]] = value
+			}
+		}
+		records = append(records, record)
+	}
+	return format, records, nil
+}
+
+// parseBookTrackerRecord reads a row of our own CSV export
+func parseBookTrackerRecord(record importRecord, childID uint, childrenByName map[string][]*models.Child) *ImportRow {
+	cell := func(column string) string {
+		return unescapeCSVFormula(record.get(exportColumnHeadings[column]))
+	}
+	row := &ImportRow{
+		Line:    record.line,
+		Status:  ImportStatusReady,
+		ChildID: childID,
+		Title:   cell(ExportColumnTitle),
+		Author:  cell(ExportColumnAuthor),
+		ISBN:    NormalizeISBN(cell(ExportColumnISBN)),
+	}
+	row.request.LexileLevel = cell(ExportColumnLexile)
+	if _, err := ParseLexile(row.request.LexileLevel); err != nil {
+		return row.fail("Lexile %q is not a level such as 840L or BR100L", row.request.LexileLevel)
+	}
+	row.request.PartialComment = cell(ExportColumnNote)
+	// The level column holds entered levels as they are and conversions marked with ~, which are
+	// left out; Lexile levels come from the Lexile column
+	for _, system := range ReadingLevelSystems {
+		level := record.get(readingLevelNames[system] + " Level")
+		if system == models.ReadingLevelLexile || level == "" || strings.HasPrefix(level, "~") {
+			continue
+		}
+		if _, err := ParseReadingLevel(system, level); err != nil {
+			return row.fail("%s level %q is not a level in that system", readingLevelNames[system], level)
+		}
+		row.request.ReadingLevels = append(row.request.ReadingLevels, models.ReadingLevelInput{System: system, Level: level})
+	}
+
+	if name := cell(ExportColumnChild); name != "" {
+		matches := childrenByName[strings.ToLower(name)]
+		switch len(matches) {
+		case 0:
+			return row.fail("No child named %q that you can edit", name)
+		case 1:
+			row.ChildID = matches[0].ID
+		default:
+			return row.fail("More than one child is named %q", name)
+		}
+	}
+	if row.ChildID == 0 {
+		return row.fail("No child given; choose a child for rows without one")
+	}
+
+	switch strings.ToLower(cell(ExportColumnPartial)) {
+	case "", "no", "false":
+	case "yes", "true":
+		row.IsPartial = true
+	default:
+		return row.fail("Partial must be Yes or No, not %q", cell(ExportColumnPartial))
+	}
+	return row.parseDate(cell(ExportColumnDate))
+}
+
+// parseGoodreadsRecord reads a row of a Goodreads library export. Goodreads writes ISBNs as
+// ="0439023483" so spreadsheets keep their leading zeros.
+func parseGoodreadsRecord(record importRecord, childID uint) *ImportRow {
+	row := &ImportRow{
+		Line:    record.line,
+		Status:  ImportStatusReady,
+		ChildID: childID,
+		Title:   record.get("Title"),
+		Author:  record.get("Author"),
+	}
+	for _, column := range []string{"ISBN13", "ISBN"} {
+		if isbn := NormalizeISBN(strings.Trim(record.get(column), `="`)); isbn != "" {
+			row.ISBN = isbn
+			break
+		}
+	}
+
+	if shelf := record.get("Exclusive Shelf"); shelf != goodreadsReadShelf {
+		row.Status, row.Message = ImportStatusSkipped, fmt.Sprintf("On the %q shelf, not %q", shelf, goodreadsReadShelf)
+		return row
+	}
+
+	// Goodreads leaves Date Read empty for books shelved without a date
+	date := record.get("Date Read")
+	if date == "" {
+		date = record.get("Date Added")
+	}
+	return row.parseDate(date)
+}
+
+func (row *ImportRow) parseDate(value string) *ImportRow {
+	if value == "" {
+		return row.fail("Date read is missing")
+	}
+	for _, layout := range importDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			row.DateRead = date.Format("2006-01-02")
+			return row
+		}
+	}
+	return row.fail("Date read %q is not a date like 2024-09-30", value)
+}
+
+func (row *ImportRow) fail(format string, args ...interface{}) *ImportRow {
+	row.Status, row.Message = ImportStatusError, fmt.Sprintf(format, args...)
+	return row
+}
+
+// unescapeCSVFormula undoes escapeCSVFormula
+func unescapeCSVFormula(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
+
+// lookupImportISBNs resolves the ready rows' ISBNs the way LookupSharedBook does, checking the
+// catalog before Open Library
+func lookupImportISBNs(rows []*ImportRow) map[string]*ISBNLookup {
+	lookups := make(map[string]*ISBNLookup)
+	var missing []string
+	for _, row := range rows {
+		if row.Status != ImportStatusReady || row.ISBN == "" {
+			continue
+		}
+		if len(row.ISBN) != 10 && len(row.ISBN) != 13 {
+			continue
+		}
+		if _, ok := lookups[row.ISBN]; ok {
+			continue
+		}
+		lookups[row.ISBN] = findSharedBookByISBN(row.ISBN)
+		if lookups[row.ISBN] == nil {
+			missing = append(missing, row.ISBN)
+		}
+	}
+	for isbn, lookup := range fetchSharedBooks(missing) {
+		lookups[isbn] = lookup
+	}
+	return lookups
+}
+
+// fetchSharedBooks looks ISBNs missing from the catalog up on Open Library with a small pool of
+// workers and saves the books found as shared books. The workers only make requests; the
+// database is written once they are done. ISBNs Open Library does not know are left out.
+func fetchSharedBooks(missing []string) map[string]*ISBNLookup {
+	found := make(map[string]*ISBNLookup)
+	if len(missing) == 0 {
+		return found
+	}
+
+	isbns := make(chan string)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	fetched := make(map[string]*ISBNLookup)
+	for i := 0; i < isbnLookupWorkers && i < len(missing); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for isbn := range isbns {
+				lookup := fetchOpenLibraryBook(isbn)
+				mu.Lock()
+				fetched[isbn] = lookup
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, isbn := range missing {
+		isbns <- isbn
+	}
+	close(isbns)
+	wg.Wait()
+
+	for _, isbn := range missing {
+		if lookup := fetched[isbn]; lookup != nil {
+			saveOpenLibraryBook(isbn, lookup)
+			found[isbn] = lookup
+		}
+	}
+	return found
+}
+
+// resolveImportRow turns a parsed row into the request that logs it: a shared book when its ISBN
+// was found, otherwise a custom book from the row's own title and author
+func resolveImportRow(row *ImportRow, lookups map[string]*ISBNLookup) {
+	lexile, levels, note := row.request.LexileLevel, row.request.ReadingLevels, row.request.PartialComment
+	if !row.IsPartial {
+		note = ""
+	}
+
+	if lookup := lookups[row.ISBN]; lookup != nil && lookup.SharedBook != nil {
+		row.Title, row.Author = lookup.Title, lookup.Author
+		row.SharedBookID = &lookup.SharedBook.ID
+		row.request = models.CreateBookRequest{
+			ISBN:           row.ISBN,
+			LexileLevel:    lexile,
+			DateRead:       row.DateRead,
+			ChildID:        row.ChildID,
+			SharedBookID:   row.SharedBookID,
+			IsPartial:      row.IsPartial,
+			PartialComment: note,
+			ReadingLevels:  levels,
+		}
+		return
+	}
+
+	switch {
+	case row.Title == "":
+		row.fail("Title is missing")
+	case row.Author == "":
+		row.fail("Author is missing")
+	default:
+		if len(row.ISBN) != 10 && len(row.ISBN) != 13 {
+			row.ISBN = ""
+		}
+		row.request = customBookRequest(models.CreateCustomBookRequest{
+			Title:          row.Title,
+			Author:         row.Author,
+			ISBN:           row.ISBN,
+			LexileLevel:    lexile,
+			DateRead:       row.DateRead,
+			ChildID:        row.ChildID,
+			IsPartial:      row.IsPartial,
+			PartialComment: note,
+			ReadingLevels:  levels,
+		})
+	}
+}
+
+// commitImportRows saves every ready row in one transaction, so a failure leaves nothing behind
+func commitImportRows(rows []*ImportRow, actor Actor) error {
+	var created []models.Book
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, row := range rows {
+			if row.Status != ImportStatusReady {
+				continue
+			}
+			book, err := insertBook(tx, row.request)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", row.Line, err)
+			}
+			created = append(created, *book)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	i := 0
+	for _, row := range rows {
+		if row.Status == ImportStatusReady {
+			row.Status, row.BookID = ImportStatusImported, created[i].ID
+			i++
+		}
+	}
+	for _, book := range created {
+		fireChange(ChangeEvent{
+			Actor:      actor,
+			Action:     ActionImport,
+			EntityType: EntityBook,
+			EntityID:   book.ID,
+			ChildID:    book.ChildID,
+			After:      book,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ImportTestSuite struct {
+	suite.Suite
+	owner       *models.User
+	sam         *models.Child
+	alex        *models.Child
+	matilda     models.SharedBook
+	openLibrary *httptest.Server
+	originalURL string
+}
+
+func (suite *ImportTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+	SharedPermissionCache().Clear()
+
+	// Open Library knows one book, without a cover
+	suite.openLibrary = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("bibkeys") == "ISBN:9780439023481" {
+			w.Write([]byte(`{"ISBN:9780439023481": {"title": "The Hunger Games", "authors": [{"name": "Suzanne Collins"}]}}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	suite.originalURL = openLibraryBooksURL
+	openLibraryBooksURL = suite.openLibrary.URL
+
+	owner, err := CreateUser(models.CreateUserRequest{
+		Email:     "owner@example.com",
+		Password:  "password123",
+		FirstName: "Owner",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	suite.owner = owner
+
+	suite.sam, err = CreateChild(models.CreateChildRequest{FirstName: "Sam", LastName: "Reader"}, owner.ID)
+	assert.NoError(suite.T(), err)
+	suite.alex, err = CreateChild(models.CreateChildRequest{FirstName: "Alex", LastName: "Reader"}, owner.ID)
+	assert.NoError(suite.T(), err)
+
+	suite.matilda = models.SharedBook{ISBN: "9780140328721", Title: "Matilda", Author: "Roald Dahl"}
+	assert.NoError(suite.T(), config.DB.Create(&suite.matilda).Error)
+	assert.NoError(suite.T(), config.DB.Create(&models.Book{
+		ChildID: suite.sam.ID, SharedBookID: &suite.matilda.ID, DateRead: "2024-09-10",
+	}).Error)
+}
+
+func (suite *ImportTestSuite) TearDownTest() {
+	openLibraryBooksURL = suite.originalURL
+	suite.openLibrary.Close()
+	config.CleanupTestDatabase()
+}
+
+func (suite *ImportTestSuite) countBooks() int64 {
+	var count int64
+	config.DB.Model(&models.Book{}).Count(&count)
+	return count
+}
+
+func (suite *ImportTestSuite) TestBookTrackerImport() {
+	file := "\uFEFFChild,Date Read,Title,Author,ISBN,Lexile,Partial,Partial Note,Source\n" +
+		"Sam Reader,2024-09-10,Matilda,Roald Dahl,9780140328721,840L,No,,Shared\n" +
+		"sam reader,2024-09-02,'=Plus,Días Autor,,,Yes,Chapters 1-3,Custom\n" +
+		"Alex Reader,9/14/2024,Hunger Games,Collins,978-0-439-02348-1,,No,,Shared\n" +
+		"Alex Reader,2024-09-20,The Hunger Games,Suzanne Collins,9780439023481,,No,,Shared\n" +
+		"Jamie Reader,2024-09-01,Holes,Louis Sachar,,,No,,Custom\n" +
+		"Alex Reader,last week,Holes,Louis Sachar,,,No,,Custom\n" +
+		"Alex Reader,2024-09-03,Unknown ISBN,,9999999999,,No,,Custom\n"
+
+	preview, err := ImportBooks(strings.NewReader(file), BookImport{
+		Children: []*models.Child{suite.sam, suite.alex},
+		DryRun:   true,
+	}, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), ImportFormatBookTracker, preview.Format)
+	assert.Equal(suite.T(), int64(1), suite.countBooks(), "a dry run saves no books")
+
+	statuses := make([]string, len(preview.Rows))
+	for i, row := range preview.Rows {
+		statuses[i] = row.Status
+	}
+	assert.Equal(suite.T(), []string{
+		ImportStatusDuplicate, ImportStatusReady, ImportStatusReady, ImportStatusDuplicate,
+		ImportStatusError, ImportStatusError, ImportStatusError,
+	}, statuses)
+	assert.Equal(suite.T(), 2, preview.Ready)
+	assert.Equal(suite.T(), 2, preview.Duplicates)
+	assert.Equal(suite.T(), 3, preview.Errors)
+
+	assert.Equal(suite.T(), 2, preview.Rows[0].Line)
+	assert.Equal(suite.T(), "Already logged as read on 2024-09-10", preview.Rows[0].Message)
+	assert.Equal(suite.T(), "=Plus", preview.Rows[1].Title)
+	assert.Equal(suite.T(), suite.sam.ID, preview.Rows[1].ChildID)
+	assert.True(suite.T(), preview.Rows[1].IsPartial)
+	// The ISBN is resolved through Open Library, and the title taken from it
+	assert.Equal(suite.T(), "2024-09-14", preview.Rows[2].DateRead)
+	assert.Equal(suite.T(), "The Hunger Games", preview.Rows[2].Title)
+	assert.NotNil(suite.T(), preview.Rows[2].SharedBookID)
+	assert.Equal(suite.T(), "Repeats line 4", preview.Rows[3].Message)
+	assert.Contains(suite.T(), preview.Rows[4].Message, "Jamie Reader")
+	assert.Contains(suite.T(), preview.Rows[5].Message, "last week")
+	assert.Equal(suite.T(), "Author is missing", preview.Rows[6].Message)
+
+	result, err := ImportBooks(strings.NewReader(file), BookImport{
+		Children: []*models.Child{suite.sam, suite.alex},
+	}, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, result.Imported)
+	assert.Equal(suite.T(), 0, result.Ready)
+	assert.Equal(suite.T(), int64(3), suite.countBooks())
+
+	var custom models.Book
+	assert.NoError(suite.T(), config.DB.First(&custom, result.Rows[1].BookID).Error)
+	assert.Equal(suite.T(), "=Plus", custom.CustomTitle)
+	assert.Equal(suite.T(), "Chapters 1-3", custom.PartialComment)
+	var shared models.Book
+	assert.NoError(suite.T(), config.DB.First(&shared, result.Rows[2].BookID).Error)
+	assert.Equal(suite.T(), *result.Rows[2].SharedBookID, *shared.SharedBookID)
+	assert.Equal(suite.T(), suite.alex.ID, shared.ChildID)
+
+	// Importing the same file again finds nothing new, except the partial reading
+	again, err := ImportBooks(strings.NewReader(file), BookImport{
+		Children: []*models.Child{suite.sam, suite.alex},
+		DryRun:   true,
+	}, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, again.Ready)
+	assert.Equal(suite.T(), 3, again.Duplicates)
+}
+
+func (suite *ImportTestSuite) TestGoodreadsImport() {
+	file := "Book Id,Title,Author,Author l-f,Additional Authors,ISBN,ISBN13,My Rating,Average Rating,Publisher,Binding,Number of Pages,Year Published,Original Publication Year,Date Read,Date Added,Bookshelves,Bookshelves with positions,Exclusive Shelf,My Review,Spoiler,Private Notes,Read Count,Owned Copies\n" +
+		`2767052,"The Hunger Games (The Hunger Games, #1)",Suzanne Collins,"Collins, Suzanne",,"=""0439023483""","=""9780439023481""",5,4.32,Scholastic Press,Hardcover,374,2008,2008,2024/08/30,2024/08/01,,,read,,,,1,0` + "\n" +
+		`6310,Charlie and the Chocolate Factory,Roald Dahl,"Dahl, Roald",,"=""""","=""""",4,4.14,Puffin,Paperback,155,1998,1964,,2024/07/15,,,read,,,,1,0` + "\n" +
+		`3636,The Giver,Lois Lowry,"Lowry, Lois",,"=""""","=""""",0,4.13,Ember,Paperback,208,2006,1993,,2024/09/01,to-read,to-read (#1),to-read,,,,0,0` + "\n"
+
+	_, err := ImportBooks(strings.NewReader(file), BookImport{}, Actor{UserID: suite.owner.ID})
+	assert.ErrorIs(suite.T(), err, ErrImportChildRequired)
+
+	result, err := ImportBooks(strings.NewReader(file), BookImport{
+		ChildID: suite.sam.ID,
+	}, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), ImportFormatGoodreads, result.Format)
+	assert.Equal(suite.T(), 2, result.Imported)
+	assert.Equal(suite.T(), 1, result.Skipped)
+
+	assert.Equal(suite.T(), "9780439023481", result.Rows[0].ISBN)
+	assert.Equal(suite.T(), "2024-08-30", result.Rows[0].DateRead)
+	assert.NotNil(suite.T(), result.Rows[0].SharedBookID)
+	// Without a Date Read, the date the book was shelved is used
+	assert.Equal(suite.T(), "2024-07-15", result.Rows[1].DateRead)
+	assert.Nil(suite.T(), result.Rows[1].SharedBookID)
+	assert.Equal(suite.T(), ImportStatusSkipped, result.Rows[2].Status)
+
+	var entries int64
+	config.DB.Model(&models.AuditLog{}).Where("action = ?", ActionImport).Count(&entries)
+	assert.Equal(suite.T(), int64(2), entries)
+
+	_, err = ImportBooks(strings.NewReader("Name,Pages\nHoles,233\n"), BookImport{ChildID: suite.sam.ID}, Actor{})
+	assert.ErrorIs(suite.T(), err, ErrInvalidImportFile)
+}
+
+func TestImportTestSuite(t *testing.T) {
+	suite.Run(t, new(ImportTestSuite))
+}
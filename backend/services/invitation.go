@@ -112,7 +112,7 @@ func ProcessInvitationRegistration(req models.CreateUserWithInvitationRequest) (
 	}
 
 	// Create the permission
-	err = CreatePermission(user.ID, invitation.ChildID, invitation.PermissionType)
+	err = CreatePermission(user.ID, invitation.ChildID, invitation.PermissionType, Actor{UserID: user.ID})
 	if err != nil {
 		// If permission creation fails, we should probably clean up the user
 		// But for simplicity, we'll just return the error
@@ -122,6 +122,8 @@ func ProcessInvitationRegistration(req models.CreateUserWithInvitationRequest) (
 	// Delete the pending invitation since it's been processed
 	config.DB.Delete(invitation)
 
+	notifyInvitationAccepted(invitation.InvitedByID, user, []uint{invitation.ChildID})
+
 	return user, nil
 }
 
@@ -222,7 +224,7 @@ func ProcessBulkInvitationRegistration(req models.CreateUserWithInvitationReques
 
 	// Create permissions for all children
 	for _, invitation := range invitations {
-		err = CreatePermission(user.ID, invitation.ChildID, invitation.PermissionType)
+		err = CreatePermission(user.ID, invitation.ChildID, invitation.PermissionType, Actor{UserID: user.ID})
 		if err != nil {
 			tx.Rollback()
 			return nil, err
@@ -240,6 +242,19 @@ func ProcessBulkInvitationRegistration(req models.CreateUserWithInvitationReques
 		return nil, err
 	}
 
+	// Tell each inviter once, about all the children they shared
+	childIDsByInviter := make(map[uint][]uint)
+	var inviterIDs []uint
+	for _, invitation := range invitations {
+		if _, ok := childIDsByInviter[invitation.InvitedByID]; !ok {
+			inviterIDs = append(inviterIDs, invitation.InvitedByID)
+		}
+		childIDsByInviter[invitation.InvitedByID] = append(childIDsByInviter[invitation.InvitedByID], invitation.ChildID)
+	}
+	for _, inviterID := range inviterIDs {
+		notifyInvitationAccepted(inviterID, user, childIDsByInviter[inviterID])
+	}
+
 	return user, nil
 }
 
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+)
+
+// openLibraryBooksURL is the Open Library books API; tests point it at a stub server
+var openLibraryBooksURL = "https://openlibrary.org/api/books"
+
+// ErrInvalidISBN is returned for ISBNs that are not 10 or 13 characters once cleaned
+var ErrInvalidISBN = errors.New("invalid ISBN format. Must be 10 or 13 digits")
+
+// OpenLibraryResponse represents the response from Open Library API
+type OpenLibraryResponse struct {
+	Title   string `json:"title"`
+	Authors []struct {
+		Name string `json:"name"`
+	} `json:"authors"`
+	ISBN10 []string `json:"isbn_10"`
+	ISBN13 []string `json:"isbn_13"`
+	Cover  struct {
+		Small  string `json:"small"`
+		Medium string `json:"medium"`
+		Large  string `json:"large"`
+	} `json:"cover"`
+}
+
+// ISBNLookup is a book found by LookupSharedBook
+type ISBNLookup struct {
+	ISBN     string // may be a related edition's ISBN when that edition has a cover
+	Title    string
+	Author   string
+	CoverURL string
+	// SharedBook is nil when a book found on Open Library could not be saved
+	SharedBook *models.SharedBook
+}
+
+// NormalizeISBN removes the hyphens and spaces people type into ISBNs
+func NormalizeISBN(isbn string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(isbn, "-", ""), " ", "")
+}
+
+// LookupSharedBook finds the shared book for an ISBN, checking the database before Open Library.
+// Books found on Open Library are saved as shared books and their covers queued for caching.
+// It returns nil without an error when no book has the ISBN.
+func LookupSharedBook(isbn string) (*ISBNLookup, error) {
+	isbn = NormalizeISBN(isbn)
+	if len(isbn) != 10 && len(isbn) != 13 {
+		return nil, ErrInvalidISBN
+	}
+
+	// Check database first for existing SharedBook
+	if lookup := findSharedBookByISBN(isbn); lookup != nil {
+		return lookup, nil
+	}
+
+	// Not in database, try API lookup
+	lookup := fetchOpenLibraryBook(isbn)
+	if lookup == nil {
+		return nil, nil
+	}
+	saveOpenLibraryBook(isbn, lookup)
+	return lookup, nil
+}
+
+// findSharedBookByISBN returns the saved shared book with the ISBN, or nil
+func findSharedBookByISBN(isbn string) *ISBNLookup {
+	var existingSharedBook models.SharedBook
+	if err := config.DB.Where("isbn = ?", isbn).First(&existingSharedBook).Error; err != nil {
+		return nil
+	}
+	return &ISBNLookup{
+		ISBN:       existingSharedBook.ISBN,
+		Title:      existingSharedBook.Title,
+		Author:     existingSharedBook.Author,
+		CoverURL:   SharedBookCoverURL(&existingSharedBook),
+		SharedBook: &existingSharedBook,
+	}
+}
+
+// fetchOpenLibraryBook looks the ISBN up on Open Library without touching the database
+func fetchOpenLibraryBook(isbn string) *ISBNLookup {
+	bookData, finalISBN, found := lookupSingleISBN(isbn)
+	if !found {
+		return nil
+	}
+
+	// If no cover image, try to find a related ISBN with better cover
+	if bookData.Cover.Small == "" && bookData.Cover.Medium == "" && bookData.Cover.Large == "" {
+		betterData, betterISBN, foundBetter := findISBNWithCover(bookData)
+		if foundBetter {
+			bookData = betterData
+			finalISBN = betterISBN
+		}
+	}
+
+	// Extract author name (take first author if multiple)
+	author := ""
+	if len(bookData.Authors) > 0 {
+		author = bookData.Authors[0].Name
+	}
+
+	// Extract cover URL (prefer medium size)
+	coverURL := ""
+	if bookData.Cover.Medium != "" {
+		coverURL = bookData.Cover.Medium
+	} else if bookData.Cover.Large != "" {
+		coverURL = bookData.Cover.Large
+	} else if bookData.Cover.Small != "" {
+		coverURL = bookData.Cover.Small
+	}
+
+	return &ISBNLookup{
+		ISBN:     finalISBN,
+		Title:    bookData.Title,
+		Author:   author,
+		CoverURL: coverURL,
+	}
+}
+
+// saveOpenLibraryBook saves a book fetched from Open Library as the shared book for isbn
+func saveOpenLibraryBook(isbn string, lookup *ISBNLookup) {
+	newSharedBook := models.SharedBook{
+		ISBN:     isbn,
+		Title:    lookup.Title,
+		Author:   lookup.Author,
+		CoverURL: lookup.CoverURL,
+		Source:   "openlibrary",
+	}
+	if err := config.DB.Create(&newSharedBook).Error; err == nil {
+		lookup.SharedBook = &newSharedBook
+		// Fetch the cover once now, so reports and the cover endpoint never wait on Open Library
+		QueueSharedBookCover(newSharedBook)
+		lookup.CoverURL = SharedBookCoverURL(&newSharedBook)
+	}
+}
+
+// lookupSingleISBN performs a single ISBN lookup
+func lookupSingleISBN(isbn string) (OpenLibraryResponse, string, bool) {
+	query := url.Values{
+		"bibkeys": {"ISBN:" + isbn},
+		"format":  {"json"},
+		"jscmd":   {"data"},
+	}
+	resp, err := http.Get(openLibraryBooksURL + "?" + query.Encode())
+	if err != nil {
+		return OpenLibraryResponse{}, isbn, false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return OpenLibraryResponse{}, isbn, false
+	}
+
+	var apiResponse map[string]OpenLibraryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+		return OpenLibraryResponse{}, isbn, false
+	}
+
+	key := fmt.Sprintf("ISBN:%s", isbn)
+	bookData, found := apiResponse[key]
+
+	if !found || bookData.Title == "" {
+		return OpenLibraryResponse{}, isbn, false
+	}
+
+	return bookData, isbn, true
+}
+
+// findISBNWithCover tries related ISBNs to find one with a cover image
+func findISBNWithCover(originalData OpenLibraryResponse) (OpenLibraryResponse, string, bool) {
+	// Collect all related ISBNs from the original response
+	var relatedISBNs []string
+	relatedISBNs = append(relatedISBNs, originalData.ISBN10...)
+	relatedISBNs = append(relatedISBNs, originalData.ISBN13...)
+
+	// Try each related ISBN until we find one with a cover
+	for _, relatedISBN := range relatedISBNs {
+		if relatedISBN == "" {
+			continue
+		}
+
+		bookData, isbn, found := lookupSingleISBN(relatedISBN)
+		if !found {
+			continue
+		}
+
+		// Check if this one has a cover image
+		if bookData.Cover.Small != "" || bookData.Cover.Medium != "" || bookData.Cover.Large != "" {
+			// Found one with cover! Stop here and return it
+			return bookData, isbn, true
+		}
+	}
+
+	// No ISBN with cover found
+	return OpenLibraryResponse{}, "", false
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// inlineDelivery sends emails and webhook events during the request that queues them
+var inlineDelivery atomic.Bool
+
+// SetInlineDelivery makes queued emails and webhook events go out during the request that queued them.
+// Deployments without the long-running workers (the serverless function) turn it on
+// and call RunScheduledJobs periodically to retry what failed.
+func SetInlineDelivery(enabled bool) {
+	inlineDelivery.Store(enabled)
+}
+
+// ScheduledJobsResult counts what one RunScheduledJobs pass did
+type ScheduledJobsResult struct {
+	EmailsSent        int   `json:"emailsSent"`
+	DigestsQueued     int   `json:"digestsQueued"`
+	WebhooksDelivered int   `json:"webhooksDelivered"`
+	RecordsPurged     int64 `json:"recordsPurged"`
+}
+
+// RunScheduledJobs does one pass of the work the background workers do on a long-running server:
+// retrying queued emails and webhook events, queueing due digests and purging old trash. Every job runs even if
+// an earlier one fails; the first error is returned.
+func RunScheduledJobs(now time.Time) (ScheduledJobsResult, error) {
+	var result ScheduledJobsResult
+	var firstErr error
+	record := func(job string, err error) {
+		if err != nil {
+			log.Printf("Scheduled %s failed: %v", job, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	// Digests are queued before the outbox is drained so they go out in the same pass
+	var err error
+	result.DigestsQueued, err = SendDueDigests(now)
+	record("digest job", err)
+	result.EmailsSent, err = ProcessEmailOutbox(now)
+	record("email delivery", err)
+	result.WebhooksDelivered, err = ProcessWebhookDeliveries(now)
+	record("webhook delivery", err)
+	result.RecordsPurged, err = PurgeDeletedRecords(now.Add(-TrashRetention()))
+	record("trash purge", err)
+
+	return result, firstErr
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"gorm.io/gorm"
+)
+
+// lexileCodes are the two-letter codes that can come before a Lexile measure: Adult Directed,
+// Beginning Reader, Graphic Novel, High-Low, Illustrated Guide, Non-Conforming and Non-Prose
+var lexileCodes = map[string]bool{"AD": true, "BR": true, "GN": true, "HL": true, "IG": true, "NC": true, "NP": true}
+
+// lexileBeginningReader measures count down from zero, so BR100L is stored as -100
+const lexileBeginningReader = "BR"
+
+// maxLexileMeasure is above the hardest texts measured
+const maxLexileMeasure = 2000
+
+// lexileFlagMargin is how far outside the child's grade band a book has to be to be flagged
+const lexileFlagMargin = 100
+
+// Progression windows: the median at each month covers that month and the ones before it
+const (
+	DefaultLexileWindowMonths = 3
+	MaxLexileWindowMonths     = 12
+)
+
+var lexilePattern = regexp.MustCompile(`^([A-Z]{2})?([0-9]{1,4})?L?$`)
+
+var (
+	// ErrInvalidLexile is returned for Lexile levels that are not a measure with an optional code
+	ErrInvalidLexile = errors.New("lexile level must be a measure such as 840L, BR100L or HL600L")
+
+	// ErrInvalidLexileWindow is returned for progression windows outside 1 to MaxLexileWindowMonths
+	ErrInvalidLexileWindow = fmt.Errorf("window must be between 1 and %d months", MaxLexileWindowMonths)
+)
+
+// LexileLevel is a parsed Lexile level
+type LexileLevel struct {
+	Code    string // one of lexileCodes, or empty
+	Measure *int   // nil for a code without a measure, such as NP
+}
+
+// String formats the level the way publishers print it
+func (l LexileLevel) String() string {
+	if l.Measure == nil {
+		return l.Code
+	}
+	measure := *l.Measure
+	if l.Code == lexileBeginningReader {
+		measure = -measure
+	}
+	return fmt.Sprintf("%s%dL", l.Code, measure)
+}
+
+// ParseLexile reads a Lexile level such as 840L, 840, BR100L, HL600L or NP, ignoring case and
+// spaces. An empty level is nil.
+func ParseLexile(level string) (*LexileLevel, error) {
+	text := strings.ToUpper(strings.Join(strings.Fields(level), ""))
+	if text == "" {
+		return nil, nil
+	}
+	match := lexilePattern.FindStringSubmatch(text)
+	if match == nil || (match[1] == "" && match[2] == "") || (match[1] != "" && !lexileCodes[match[1]]) {
+		return nil, fmt.Errorf("%w, not %q", ErrInvalidLexile, level)
+	}
+	if match[2] == "" && text != match[1] {
+		return nil, fmt.Errorf("%w, not %q", ErrInvalidLexile, level) // a bare "L"
+	}
+
+	lexile := &LexileLevel{Code: match[1]}
+	if match[2] != "" {
+		measure, _ := strconv.Atoi(match[2])
+		if measure > maxLexileMeasure {
+			return nil, fmt.Errorf("%w, not %q", ErrInvalidLexile, level)
+		}
+		if lexile.Code == lexileBeginningReader {
+			measure = -measure
+		}
+		lexile.Measure = &measure
+	}
+	return lexile, nil
+}
+
+// applyLexile normalises a book's Lexile level and stores its numeric measure
+func applyLexile(book *models.Book) error {
+	book.LexileParsed = true
+	lexile, err := ParseLexile(book.LexileLevel)
+	if err != nil {
+		return err
+	}
+	book.LexileLevel, book.LexileMeasure = "", nil
+	if lexile != nil {
+		book.LexileLevel, book.LexileMeasure = lexile.String(), lexile.Measure
+	}
+	return nil
+}
+
+// BackfillLexileMeasures parses the Lexile levels of books saved before levels were parsed and
+// marks them, so each book is only looked at once. Levels that do not parse are left as they are.
+// It returns the number of books whose level was parsed.
+func BackfillLexileMeasures() (int, error) {
+	var books []models.Book
+	updated := 0
+	result := config.DB.Unscoped().Select("id", "lexile_level").
+		Where("lexile_level <> '' AND lexile_parsed = ?", false).
+		FindInBatches(&books, 500, func(tx *gorm.DB, batch int) error {
+			for _, book := range books {
+				updates := map[string]interface{}{"lexile_parsed": true}
+				lexile, err := ParseLexile(book.LexileLevel)
+				if err != nil {
+					log.Printf("Leaving unparsed Lexile level %q of book %d", book.LexileLevel, book.ID)
+				} else if lexile != nil {
+					updates["lexile_level"] = lexile.String()
+					updates["lexile_measure"] = lexile.Measure
+					updated++
+				}
+				if err := config.DB.Unscoped().Model(&models.Book{}).Where("id = ?", book.ID).
+					UpdateColumns(updates).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	return updated, result.Error
+}
+
+// lexileGradeBands are MetaMetrics' typical reader measures by grade: the middle half
+// (25th to 75th percentile) of students at mid-year
+var lexileGradeBands = map[int][2]int{
+	1:  {-120, 295},
+	2:  {170, 545},
+	3:  {415, 760},
+	4:  {635, 950},
+	5:  {770, 1080},
+	6:  {855, 1165},
+	7:  {925, 1235},
+	8:  {985, 1295},
+	9:  {1040, 1350},
+	10: {1085, 1400},
+	11: {1130, 1440},
+	12: {1130, 1440},
+}
+
+var gradeNumberPattern = regexp.MustCompile(`[0-9]+`)
+
+// ParseGrade reads a free-form grade such as "3", "3rd" or "Grade 3"; kindergarten is 0
+func ParseGrade(grade string) (int, bool) {
+	text := strings.ToLower(strings.TrimSpace(grade))
+	switch text {
+	case "k", "kg", "kindergarten":
+		return 0, true
+	}
+	number := gradeNumberPattern.FindString(text)
+	if number == "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil || n > 12 {
+		return 0, false
+	}
+	return n, true
+}
+
+// lexileGradeBand returns the published band for a child's grade, or nil when there is none
+func lexileGradeBand(grade string) *models.LexileBandResponse {
+	n, ok := ParseGrade(grade)
+	if !ok {
+		return nil
+	}
+	band, ok := lexileGradeBands[n]
+	if !ok {
+		return nil
+	}
+	return &models.LexileBandResponse{
+		Grade:    n,
+		Min:      band[0],
+		Max:      band[1],
+		MinLevel: lexileMeasureString(band[0]),
+		MaxLevel: lexileMeasureString(band[1]),
+	}
+}
+
+func lexileMeasureString(measure int) string {
+	code := ""
+	if measure < 0 {
+		code = lexileBeginningReader
+	}
+	return LexileLevel{Code: code, Measure: &measure}.String()
+}
+
+// lexileBandPosition places a measure below, within or above the band, and how far outside it
+// is; positions more than lexileFlagMargin outside are the ones flagged
+func lexileBandPosition(measure float64, band *models.LexileBandResponse) (string, float64) {
+	switch {
+	case measure < float64(band.Min):
+		return models.LexilePositionBelow, float64(band.Min) - measure
+	case measure > float64(band.Max):
+		return models.LexilePositionAbove, measure - float64(band.Max)
+	default:
+		return models.LexilePositionWithin, 0
+	}
+}
+
+// GetLexileProgression follows a child's rolling median Lexile measure month by month from the
+// month of start, against the band for the child's current grade, and flags the books read in
+// those months that are far outside the band
+func GetLexileProgression(childID uint, start time.Time, months, windowMonths int) (*models.LexileProgressionResponse, error) {
+	if months < 1 || months > MaxStatsMonths {
+		return nil, ErrInvalidStatsWindow
+	}
+	if windowMonths < 1 || windowMonths > MaxLexileWindowMonths {
+		return nil, ErrInvalidLexileWindow
+	}
+	child, err := GetChildByID(childID)
+	if err != nil {
+		return nil, err
+	}
+
+	start = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, months, 0)
+	windowStart := start.AddDate(0, 1-windowMonths, 0)
+
+	var books []struct {
+		ID            uint
+		Title         string
+		DateRead      string
+		LexileLevel   string
+		LexileMeasure int
+	}
+	if err := config.DB.Raw(`
+		SELECT b.id, COALESCE(sb.title, b.custom_title) AS title, b.date_read, b.lexile_level, b.lexile_measure
+		FROM books b
+		LEFT JOIN shared_books sb ON sb.id = b.shared_book_id
+		WHERE b.deleted_at IS NULL AND b.child_id = ? AND b.lexile_measure IS NOT NULL
+			AND b.date_read >= ? AND b.date_read < ?
+		ORDER BY b.date_read, b.id`,
+		childID, windowStart.Format("2006-01-02"), end.Format("2006-01-02")).Scan(&books).Error; err != nil {
+		return nil, err
+	}
+
+	progression := &models.LexileProgressionResponse{
+		ChildID:      childID,
+		Grade:        child.Grade,
+		Band:         lexileGradeBand(child.Grade),
+		WindowMonths: windowMonths,
+		FlagMargin:   lexileFlagMargin,
+		Points:       make([]models.LexileProgressionPoint, 0, months),
+		FlaggedBooks: []models.LexileFlaggedBook{},
+	}
+
+	for month := start; month.Before(end); month = month.AddDate(0, 1, 0) {
+		from := month.AddDate(0, 1-windowMonths, 0).Format("2006-01-02")
+		to := month.AddDate(0, 1, 0).Format("2006-01-02")
+		var measures []int
+		for _, book := range books {
+			if book.DateRead >= from && book.DateRead < to {
+				measures = append(measures, book.LexileMeasure)
+			}
+		}
+
+		point := models.LexileProgressionPoint{Month: month.Format("2006-01"), Books: len(measures)}
+		if len(measures) > 0 {
+			median := medianMeasure(measures)
+			point.Median = &median
+			if progression.Band != nil {
+				point.Position, _ = lexileBandPosition(median, progression.Band)
+			}
+		}
+		progression.Points = append(progression.Points, point)
+	}
+
+	if progression.Band != nil {
+		for _, book := range books {
+			if book.DateRead < start.Format("2006-01-02") {
+				continue
+			}
+			position, distance := lexileBandPosition(float64(book.LexileMeasure), progression.Band)
+			if distance > lexileFlagMargin {
+				progression.FlaggedBooks = append(progression.FlaggedBooks, models.LexileFlaggedBook{
+					BookID:        book.ID,
+					Title:         book.Title,
+					DateRead:      book.DateRead,
+					LexileLevel:   book.LexileLevel,
+					LexileMeasure: book.LexileMeasure,
+					Position:      position,
+					Distance:      int(distance),
+				})
+			}
+		}
+	}
+
+	return progression, nil
+}
+
+func medianMeasure(measures []int) float64 {
+	sorted := append([]int(nil), measures...)
+	sort.Ints(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return float64(sorted[middle])
+	}
+	return float64(sorted[middle-1]+sorted[middle]) / 2
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestParseLexile(t *testing.T) {
+	for level, want := range map[string]string{
+		"840L":     "840L",
+		"840":      "840L",
+		" 840l ":   "840L",
+		"BR100L":   "BR100L",
+		"br 100L":  "BR100L",
+		"HL600L":   "HL600L",
+		"AD620L":   "AD620L",
+		"GN":       "GN",
+		"NP":       "NP",
+		"2000L":    "2000L",
+		"IG 0550L": "IG550L",
+	} {
+		lexile, err := ParseLexile(level)
+		if assert.NoError(t, err, level) {
+			assert.Equal(t, want, lexile.String(), level)
+		}
+	}
+
+	lexile, _ := ParseLexile("BR100L")
+	assert.Equal(t, -100, *lexile.Measure)
+	lexile, _ = ParseLexile("NP")
+	assert.Nil(t, lexile.Measure)
+	lexile, err := ParseLexile("  ")
+	assert.NoError(t, err)
+	assert.Nil(t, lexile)
+
+	for _, level := range []string{"L", "XX500L", "2100L", "840L+", "level 4", "NPL", "84OL"} {
+		_, err := ParseLexile(level)
+		assert.ErrorIs(t, err, ErrInvalidLexile, level)
+	}
+}
+
+func TestParseGrade(t *testing.T) {
+	for grade, want := range map[string]int{"3": 3, "3rd": 3, "Grade 10": 10, "K": 0, "kindergarten": 0} {
+		n, ok := ParseGrade(grade)
+		assert.True(t, ok, grade)
+		assert.Equal(t, want, n, grade)
+	}
+	for _, grade := range []string{"", "Pre-K", "13", "college"} {
+		_, ok := ParseGrade(grade)
+		assert.False(t, ok, grade)
+	}
+}
+
+type LexileTestSuite struct {
+	suite.Suite
+	owner *models.User
+	sam   *models.Child
+}
+
+func (suite *LexileTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+	SharedPermissionCache().Clear()
+
+	var err error
+	suite.owner, err = CreateUser(models.CreateUserRequest{
+		Email:     "owner@example.com",
+		Password:  "password123",
+		FirstName: "Owner",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	suite.sam, err = CreateChild(models.CreateChildRequest{FirstName: "Sam", LastName: "Reader", Grade: "3rd"}, suite.owner.ID)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *LexileTestSuite) TearDownTest() {
+	config.CleanupTestDatabase()
+}
+
+func (suite *LexileTestSuite) createBook(title, dateRead, lexile string) *models.Book {
+	book, err := CreateCustomBook(models.CreateCustomBookRequest{
+		Title: title, Author: "Author", DateRead: dateRead, ChildID: suite.sam.ID, LexileLevel: lexile,
+	}, SystemActor)
+	assert.NoError(suite.T(), err)
+	return book
+}
+
+func (suite *LexileTestSuite) TestBooksStoreParsedLevels() {
+	book := suite.createBook("Frindle", "2024-09-01", "hl 830l")
+	assert.Equal(suite.T(), "HL830L", book.LexileLevel)
+	assert.Equal(suite.T(), 830, *book.LexileMeasure)
+
+	_, err := CreateCustomBook(models.CreateCustomBookRequest{
+		Title: "Bad", Author: "Author", DateRead: "2024-09-01", ChildID: suite.sam.ID, LexileLevel: "grade 3",
+	}, SystemActor)
+	assert.ErrorIs(suite.T(), err, ErrInvalidLexile)
+
+	updated, err := UpdateBook(book.ID, models.UpdateBookRequest{DateRead: "2024-09-01", LexileLevel: ""}, SystemActor)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), updated.LexileMeasure)
+	_, err = UpdateBook(book.ID, models.UpdateBookRequest{DateRead: "2024-09-01", LexileLevel: "hard"}, SystemActor)
+	assert.ErrorIs(suite.T(), err, ErrInvalidLexile)
+}
+
+func (suite *LexileTestSuite) TestBackfill() {
+	legacy := []models.Book{
+		{ChildID: suite.sam.ID, CustomTitle: "A", CustomAuthor: "X", DateRead: "2024-09-01", LexileLevel: "br50"},
+		{ChildID: suite.sam.ID, CustomTitle: "B", CustomAuthor: "X", DateRead: "2024-09-01", LexileLevel: "about 500"},
+		{ChildID: suite.sam.ID, CustomTitle: "C", CustomAuthor: "X", DateRead: "2024-09-01", LexileLevel: "NP"},
+	}
+	for i := range legacy {
+		assert.NoError(suite.T(), config.DB.Create(&legacy[i]).Error)
+	}
+
+	updated, err := BackfillLexileMeasures()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, updated)
+
+	var books []models.Book
+	assert.NoError(suite.T(), config.DB.Order("id").Find(&books).Error)
+	assert.Equal(suite.T(), "BR50L", books[0].LexileLevel)
+	assert.Equal(suite.T(), -50, *books[0].LexileMeasure)
+	// Levels that do not parse are kept as they were
+	assert.Equal(suite.T(), "about 500", books[1].LexileLevel)
+	assert.Nil(suite.T(), books[1].LexileMeasure)
+	assert.Nil(suite.T(), books[2].LexileMeasure)
+	for _, book := range books {
+		assert.True(suite.T(), book.LexileParsed)
+	}
+
+	// Every book is only looked at once, including the ones without a measure
+	var pending int64
+	assert.NoError(suite.T(), config.DB.Model(&models.Book{}).Where("lexile_parsed = ?", false).Count(&pending).Error)
+	assert.Zero(suite.T(), pending)
+	updated, err = BackfillLexileMeasures()
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), updated)
+}
+
+func (suite *LexileTestSuite) TestProgression() {
+	suite.createBook("June", "2024-06-10", "500L")
+	suite.createBook("July", "2024-07-10", "600L")
+	suite.createBook("Easy", "2024-08-05", "BR100L")
+	suite.createBook("August", "2024-08-20", "700L")
+	suite.createBook("Hard", "2024-09-15", "1000L")
+	suite.createBook("No measure", "2024-09-16", "NP")
+
+	progression, err := GetLexileProgression(suite.sam.ID, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), 4, 2)
+	assert.NoError(suite.T(), err)
+
+	// Grade 3 reads 415L to 760L
+	assert.Equal(suite.T(), &models.LexileBandResponse{Grade: 3, Min: 415, Max: 760, MinLevel: "415L", MaxLevel: "760L"}, progression.Band)
+
+	assert.Len(suite.T(), progression.Points, 4)
+	// July covers June and July
+	assert.Equal(suite.T(), 2, progression.Points[0].Books)
+	assert.Equal(suite.T(), 550.0, *progression.Points[0].Median)
+	assert.Equal(suite.T(), models.LexilePositionWithin, progression.Points[0].Position)
+	// August covers July and August: -100, 600, 700
+	assert.Equal(suite.T(), 600.0, *progression.Points[1].Median)
+	// September covers August and September: -100, 700, 1000
+	assert.Equal(suite.T(), 700.0, *progression.Points[2].Median)
+	// October covers September only
+	assert.Equal(suite.T(), 1000.0, *progression.Points[3].Median)
+	assert.Equal(suite.T(), models.LexilePositionAbove, progression.Points[3].Position)
+
+	// June is before the window, so only the easy and hard books are flagged
+	assert.Len(suite.T(), progression.FlaggedBooks, 2)
+	assert.Equal(suite.T(), "Easy", progression.FlaggedBooks[0].Title)
+	assert.Equal(suite.T(), models.LexilePositionBelow, progression.FlaggedBooks[0].Position)
+	assert.Equal(suite.T(), 515, progression.FlaggedBooks[0].Distance)
+	assert.Equal(suite.T(), "Hard", progression.FlaggedBooks[1].Title)
+	assert.Equal(suite.T(), 240, progression.FlaggedBooks[1].Distance)
+
+	// Without a published band there is nothing to compare against
+	_, err = UpdateChild(suite.sam.ID, models.UpdateChildRequest{FirstName: "Sam", LastName: "Reader", Grade: "K"}, SystemActor)
+	assert.NoError(suite.T(), err)
+	progression, err = GetLexileProgression(suite.sam.ID, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), 4, 2)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), progression.Band)
+	assert.Empty(suite.T(), progression.Points[3].Position)
+	assert.Empty(suite.T(), progression.FlaggedBooks)
+}
+
+func TestLexileTestSuite(t *testing.T) {
+	suite.Run(t, new(LexileTestSuite))
+}
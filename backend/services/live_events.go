@@ -0,0 +1,256 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/booktracker/backend/models"
+)
+
+// Live event types pushed to dashboards
+const (
+	LiveEventBookCreated       = "book.created"
+	LiveEventBookUpdated       = "book.updated"
+	LiveEventBookDeleted       = "book.deleted"
+	LiveEventPermissionChanged = "permission.changed"
+)
+
+// EventStreamTokenTTL is how long a stream token can open the event stream. It only has to last
+// until the client connects, so a token that ends up in an access log is soon useless.
+const EventStreamTokenTTL = time.Minute
+
+// ErrEventStreamTokenInvalid is returned for forged or expired stream tokens
+var ErrEventStreamTokenInvalid = errors.New("event stream token is no longer valid")
+
+// liveSubscriptionBuffer is how many events a slow client may fall behind before events are dropped
+const liveSubscriptionBuffer = 32
+
+// LiveEvent is a change pushed to the users who can view the child it concerns
+type LiveEvent struct {
+	Type     string    `json:"type"`
+	ChildID  uint      `json:"childId"`
+	EntityID uint      `json:"entityId"`
+	UserID   uint      `json:"userId,omitempty"` // For permission changes, the user whose access changed
+	ActorID  uint      `json:"actorId,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// EventFanout carries live events between hubs. The in-process LocalFanout suits a single
+// instance; an implementation backed by a message broker can span several.
+type EventFanout interface {
+	// Publish sends an event to every hub, including the one that published it
+	Publish(event LiveEvent) error
+	// Subscribe registers the function a hub delivers received events with
+	Subscribe(deliver func(LiveEvent))
+}
+
+// LocalFanout delivers events to the hubs of this process
+type LocalFanout struct {
+	mu         sync.RWMutex
+	deliverers []func(LiveEvent)
+}
+
+func (f *LocalFanout) Publish(event LiveEvent) error {
+	f.mu.RLock()
+	deliverers := make([]func(LiveEvent), len(f.deliverers))
+	copy(deliverers, f.deliverers)
+	f.mu.RUnlock()
+
+	for _, deliver := range deliverers {
+		deliver(event)
+	}
+	return nil
+}
+
+func (f *LocalFanout) Subscribe(deliver func(LiveEvent)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deliverers = append(f.deliverers, deliver)
+}
+
+// LiveSubscription is one connected client
+type LiveSubscription struct {
+	UserID uint
+	Events <-chan LiveEvent
+
+	events chan LiveEvent
+}
+
+// EventHub tracks connected clients and forwards each event to those allowed to see it
+type EventHub struct {
+	fanout EventFanout
+
+	mu            sync.RWMutex
+	subscriptions map[*LiveSubscription]struct{}
+}
+
+// NewEventHub creates a hub that publishes and receives through the given fan-out
+func NewEventHub(fanout EventFanout) *EventHub {
+	hub := &EventHub{
+		fanout:        fanout,
+		subscriptions: make(map[*LiveSubscription]struct{}),
+	}
+	fanout.Subscribe(hub.deliver)
+	return hub
+}
+
+// Subscribe connects a client; call Unsubscribe when it goes away
+func (h *EventHub) Subscribe(userID uint) *LiveSubscription {
+	events := make(chan LiveEvent, liveSubscriptionBuffer)
+	subscription := &LiveSubscription{UserID: userID, Events: events, events: events}
+
+	h.mu.Lock()
+	h.subscriptions[subscription] = struct{}{}
+	h.mu.Unlock()
+	return subscription
+}
+
+// Unsubscribe disconnects a client and closes its event channel
+func (h *EventHub) Unsubscribe(subscription *LiveSubscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscriptions[subscription]; ok {
+		delete(h.subscriptions, subscription)
+		close(subscription.events)
+	}
+}
+
+// Publish sends an event through the fan-out
+func (h *EventHub) Publish(event LiveEvent) error {
+	return h.fanout.Publish(event)
+}
+
+// deliver hands an event to every local client that can view its child.
+// The user whose permission changed is always told, even when they just lost access.
+func (h *EventHub) deliver(event LiveEvent) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for subscription := range h.subscriptions {
+		if !canReceiveLiveEvent(subscription.UserID, event) {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+		default:
+			log.Printf("Dropping %s event for user %d: client is not keeping up", event.Type, subscription.UserID)
+		}
+	}
+}
+
+func canReceiveLiveEvent(userID uint, event LiveEvent) bool {
+	if event.Type == LiveEventPermissionChanged && event.UserID == userID {
+		return true
+	}
+	allowed, err := HasChildPermission(userID, event.ChildID, "VIEW")
+	return err == nil && allowed
+}
+
+var (
+	sharedEventHub      *EventHub
+	sharedEventHubMutex sync.Mutex
+)
+
+// SharedEventHub returns the process-wide hub, using a LocalFanout unless SetEventFanout was called first
+func SharedEventHub() *EventHub {
+	sharedEventHubMutex.Lock()
+	defer sharedEventHubMutex.Unlock()
+	if sharedEventHub == nil {
+		sharedEventHub = NewEventHub(&LocalFanout{})
+	}
+	return sharedEventHub
+}
+
+// SetEventFanout replaces the process-wide hub with one that uses the given fan-out
+func SetEventFanout(fanout EventFanout) {
+	sharedEventHubMutex.Lock()
+	defer sharedEventHubMutex.Unlock()
+	sharedEventHub = NewEventHub(fanout)
+}
+
+func init() {
+	RegisterChangeHook(publishLiveEvent)
+}
+
+// publishLiveEvent is the change hook that turns book and permission changes into live events
+func publishLiveEvent(event ChangeEvent) {
+	live := LiveEvent{
+		ChildID:  event.ChildID,
+		EntityID: event.EntityID,
+		ActorID:  event.Actor.UserID,
+		At:       time.Now(),
+	}
+
+	switch event.EntityType {
+	case EntityBook:
+		switch event.Action {
+		case ActionCreate, ActionRestore, ActionImport:
+			live.Type = LiveEventBookCreated
+		case ActionUpdate:
+			live.Type = LiveEventBookUpdated
+		case ActionDelete:
+			live.Type = LiveEventBookDeleted
+		default:
+			return
+		}
+	case EntityPermission:
+		live.Type = LiveEventPermissionChanged
+		if permission, ok := event.After.(models.Permission); ok {
+			live.UserID = permission.UserID
+		} else if permission, ok := event.Before.(models.Permission); ok {
+			live.UserID = permission.UserID
+		}
+	default:
+		return
+	}
+
+	if err := SharedEventHub().Publish(live); err != nil {
+		log.Printf("Failed to publish %s event for child %d: %v", live.Type, live.ChildID, err)
+	}
+}
+
+// EventStreamToken signs a token that only opens the user's event stream, until expiresAt.
+// EventSource cannot send headers, so it goes in the URL instead of the login token.
+func EventStreamToken(userID uint, expiresAt time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", userID, expiresAt.Unix())))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(eventStreamSignature(payload))
+}
+
+func eventStreamSignature(payload string) []byte {
+	mac := hmac.New(sha256.New, jwtSecret)
+	mac.Write([]byte("event-stream:" + payload))
+	return mac.Sum(nil)
+}
+
+// ParseEventStreamToken verifies a stream token that has not expired by now and returns its user
+func ParseEventStreamToken(token string, now time.Time) (uint, error) {
+	payload, signature, found := strings.Cut(token, ".")
+	if !found {
+		return 0, ErrEventStreamTokenInvalid
+	}
+	decodedSignature, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(decodedSignature, eventStreamSignature(payload)) {
+		return 0, ErrEventStreamTokenInvalid
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return 0, ErrEventStreamTokenInvalid
+	}
+
+	var userID uint
+	var expiresAt int64
+	if _, err := fmt.Sscanf(string(decoded), "%d:%d", &userID, &expiresAt); err != nil {
+		return 0, ErrEventStreamTokenInvalid
+	}
+	if !now.Before(time.Unix(expiresAt, 0)) {
+		return 0, ErrEventStreamTokenInvalid
+	}
+	return userID, nil
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// recordingFanout stands in for a broker: it records what was published and delivers it back
+type recordingFanout struct {
+	LocalFanout
+	published []LiveEvent
+}
+
+func (f *recordingFanout) Publish(event LiveEvent) error {
+	f.published = append(f.published, event)
+	return f.LocalFanout.Publish(event)
+}
+
+type LiveEventsTestSuite struct {
+	suite.Suite
+	fanout   *recordingFanout
+	owner    *models.User
+	coParent *models.User
+	stranger *models.User
+	child    *models.Child
+}
+
+func (suite *LiveEventsTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+	SharedPermissionCache().Clear()
+
+	suite.fanout = &recordingFanout{}
+	SetEventFanout(suite.fanout)
+
+	suite.owner = suite.createUser("owner@example.com")
+	suite.coParent = suite.createUser("coparent@example.com")
+	suite.stranger = suite.createUser("stranger@example.com")
+
+	child, err := CreateChild(models.CreateChildRequest{
+		FirstName: "Sam",
+		LastName:  "Reader",
+		Grade:     "3rd",
+	}, suite.owner.ID)
+	assert.NoError(suite.T(), err)
+	suite.child = child
+	assert.NoError(suite.T(), CreatePermission(suite.coParent.ID, child.ID, "EDIT", Actor{UserID: suite.owner.ID}))
+}
+
+func (suite *LiveEventsTestSuite) TearDownTest() {
+	SetEventFanout(&LocalFanout{})
+	config.CleanupTestDatabase()
+}
+
+func (suite *LiveEventsTestSuite) createUser(email string) *models.User {
+	user, err := CreateUser(models.CreateUserRequest{
+		Email:     email,
+		Password:  "password123",
+		FirstName: "Test",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	return user
+}
+
+// received drains the events already waiting for a subscription
+func received(subscription *LiveSubscription) []LiveEvent {
+	var events []LiveEvent
+	for {
+		select {
+		case event := <-subscription.Events:
+			events = append(events, event)
+		case <-time.After(10 * time.Millisecond):
+			return events
+		}
+	}
+}
+
+func (suite *LiveEventsTestSuite) TestBookEventsReachOnlyViewers() {
+	suite.fanout.published = nil
+	hub := SharedEventHub()
+	owner := hub.Subscribe(suite.owner.ID)
+	coParent := hub.Subscribe(suite.coParent.ID)
+	stranger := hub.Subscribe(suite.stranger.ID)
+	defer hub.Unsubscribe(owner)
+	defer hub.Unsubscribe(coParent)
+	defer hub.Unsubscribe(stranger)
+
+	book, err := CreateCustomBook(models.CreateCustomBookRequest{
+		Title:    "Frog and Toad",
+		DateRead: "2024-03-10",
+		ChildID:  suite.child.ID,
+	}, Actor{UserID: suite.coParent.ID})
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), DeleteBook(book.ID, Actor{UserID: suite.owner.ID}))
+
+	for _, subscription := range []*LiveSubscription{owner, coParent} {
+		events := received(subscription)
+		if assert.Len(suite.T(), events, 2) {
+			assert.Equal(suite.T(), LiveEventBookCreated, events[0].Type)
+			assert.Equal(suite.T(), suite.child.ID, events[0].ChildID)
+			assert.Equal(suite.T(), book.ID, events[0].EntityID)
+			assert.Equal(suite.T(), suite.coParent.ID, events[0].ActorID)
+			assert.Equal(suite.T(), LiveEventBookDeleted, events[1].Type)
+		}
+	}
+	assert.Empty(suite.T(), received(stranger))
+
+	// Every event went through the pluggable fan-out
+	assert.Len(suite.T(), suite.fanout.published, 2)
+}
+
+func (suite *LiveEventsTestSuite) TestPermissionChangesReachTheAffectedUser() {
+	hub := SharedEventHub()
+	stranger := hub.Subscribe(suite.stranger.ID)
+	defer hub.Unsubscribe(stranger)
+
+	assert.NoError(suite.T(), CreatePermission(suite.stranger.ID, suite.child.ID, "VIEW", Actor{UserID: suite.owner.ID}))
+	events := received(stranger)
+	if assert.Len(suite.T(), events, 1) {
+		assert.Equal(suite.T(), LiveEventPermissionChanged, events[0].Type)
+		assert.Equal(suite.T(), suite.stranger.ID, events[0].UserID)
+	}
+
+	// Losing access is announced too, but later book events are not
+	assert.NoError(suite.T(), DeletePermission(suite.stranger.ID, suite.child.ID, Actor{UserID: suite.owner.ID}))
+	_, err := CreateCustomBook(models.CreateCustomBookRequest{
+		Title:    "Private",
+		DateRead: "2024-03-11",
+		ChildID:  suite.child.ID,
+	}, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+
+	events = received(stranger)
+	if assert.Len(suite.T(), events, 1) {
+		assert.Equal(suite.T(), LiveEventPermissionChanged, events[0].Type)
+	}
+}
+
+func (suite *LiveEventsTestSuite) TestSlowClientsDoNotBlockPublishers() {
+	hub := SharedEventHub()
+	subscription := hub.Subscribe(suite.owner.ID)
+
+	for i := 0; i < liveSubscriptionBuffer+5; i++ {
+		assert.NoError(suite.T(), hub.Publish(LiveEvent{Type: LiveEventBookUpdated, ChildID: suite.child.ID}))
+	}
+	assert.Len(suite.T(), received(subscription), liveSubscriptionBuffer)
+
+	hub.Unsubscribe(subscription)
+	_, open := <-subscription.Events
+	assert.False(suite.T(), open)
+	hub.Unsubscribe(subscription)
+}
+
+func TestLiveEventsTestSuite(t *testing.T) {
+	suite.Run(t, new(LiveEventsTestSuite))
+}
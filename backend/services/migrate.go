@@ -0,0 +1,24 @@
+package services
+
+import (
+	"log"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+)
+
+// MigrateDatabase migrates the schema and brings existing rows up to date. The server and the
+// serverless function both run it at startup.
+func MigrateDatabase() error {
+	if err := models.AutoMigrate(config.GetDB()); err != nil {
+		return err
+	}
+
+	// Parse the Lexile levels of books saved before levels were parsed
+	if updated, err := BackfillLexileMeasures(); err != nil {
+		log.Printf("Failed to backfill Lexile measures: %v", err)
+	} else if updated > 0 {
+		log.Printf("Backfilled Lexile measures of %d books", updated)
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+)
+
+// Notification types
+const (
+	NotificationTypeBookLogged             = "BOOK_LOGGED"
+	NotificationTypeInvitationAccepted     = "INVITATION_ACCEPTED"
+	NotificationTypeOrganizationInvitation = "ORGANIZATION_INVITATION"
+)
+
+// NotificationTypes lists the notification types users can mute
+var NotificationTypes = []string{
+	NotificationTypeBookLogged,
+	NotificationTypeInvitationAccepted,
+	NotificationTypeOrganizationInvitation,
+}
+
+const (
+	defaultNotificationLimit = 50
+	maxNotificationLimit     = 200
+)
+
+// ErrNotificationNotFound is returned for notifications that do not exist or belong to someone else
+var ErrNotificationNotFound = errors.New("notification not found")
+
+func init() {
+	RegisterChangeHook(publishChangeNotifications)
+}
+
+func isNotificationType(notificationType string) bool {
+	for _, t := range NotificationTypes {
+		if t == notificationType {
+			return true
+		}
+	}
+	return false
+}
+
+// PublishNotification adds a notification to the feed of every recipient who has not muted its type
+func PublishNotification(recipientIDs []uint, notification models.Notification) error {
+	if len(recipientIDs) == 0 {
+		return nil
+	}
+
+	var mutedIDs []uint
+	err := config.DB.Model(&models.NotificationMute{}).
+		Where("type = ? AND user_id IN ?", notification.Type, recipientIDs).
+		Pluck("user_id", &mutedIDs).Error
+	if err != nil {
+		return err
+	}
+	muted := make(map[uint]bool, len(mutedIDs))
+	for _, id := range mutedIDs {
+		muted[id] = true
+	}
+
+	var notifications []models.Notification
+	seen := make(map[uint]bool, len(recipientIDs))
+	for _, userID := range recipientIDs {
+		if muted[userID] || seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		n := notification
+		n.UserID = userID
+		notifications = append(notifications, n)
+	}
+	if len(notifications) == 0 {
+		return nil
+	}
+	return config.DB.Create(&notifications).Error
+}
+
+// GetNotifications lists a user's notifications, newest first
+func GetNotifications(userID uint, unreadOnly bool, limit, offset int) ([]models.Notification, error) {
+	if limit <= 0 {
+		limit = defaultNotificationLimit
+	}
+	if limit > maxNotificationLimit {
+		limit = maxNotificationLimit
+	}
+
+	query := config.DB.Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	result := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&notifications)
+	return notifications, result.Error
+}
+
+// CountUnreadNotifications counts the notifications a user has not read yet
+func CountUnreadNotifications(userID uint) (int64, error) {
+	var count int64
+	err := config.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// MarkNotificationRead marks one of the user's notifications as read
+func MarkNotificationRead(userID, notificationID uint) (*models.Notification, error) {
+	var notification models.Notification
+	if err := config.DB.Where("id = ? AND user_id = ?", notificationID, userID).First(&notification).Error; err != nil {
+		return nil, ErrNotificationNotFound
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		if err := config.DB.Model(&notification).Update("read_at", now).Error; err != nil {
+			return nil, err
+		}
+		notification.ReadAt = &now
+	}
+	return &notification, nil
+}
+
+// MarkAllNotificationsRead marks every unread notification of a user as read and returns how many changed
+func MarkAllNotificationsRead(userID uint) (int64, error) {
+	result := config.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// GetNotificationMutes lists the notification types a user has muted
+func GetNotificationMutes(userID uint) ([]string, error) {
+	types := []string{}
+	err := config.DB.Model(&models.NotificationMute{}).Where("user_id = ?", userID).Order("type").Pluck("type", &types).Error
+	return types, err
+}
+
+// SetNotificationMuted mutes or unmutes a notification type for a user
+func SetNotificationMuted(userID uint, notificationType string, muted bool) error {
+	if !isNotificationType(notificationType) {
+		return errors.New("unknown notification type")
+	}
+
+	if !muted {
+		return config.DB.Where("user_id = ? AND type = ?", userID, notificationType).Delete(&models.NotificationMute{}).Error
+	}
+
+	var count int64
+	if err := config.DB.Model(&models.NotificationMute{}).Where("user_id = ? AND type = ?", userID, notificationType).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return config.DB.Create(&models.NotificationMute{UserID: userID, Type: notificationType}).Error
+}
+
+// publishChangeNotifications is the change hook that tells a child's family when someone logs a book
+func publishChangeNotifications(event ChangeEvent) {
+	if event.EntityType != EntityBook || event.Action != ActionCreate || event.Actor.UserID == 0 {
+		return
+	}
+	book, ok := event.After.(models.Book)
+	if !ok {
+		return
+	}
+
+	if err := notifyBookLogged(event.Actor.UserID, &book); err != nil {
+		log.Printf("Failed to publish notifications for book %d: %v", book.ID, err)
+	}
+}
+
+func notifyBookLogged(actorID uint, book *models.Book) error {
+	actor, err := GetUserByID(actorID)
+	if err != nil {
+		return err
+	}
+	child, err := GetChildByID(book.ChildID)
+	if err != nil {
+		return err
+	}
+
+	followerIDs, err := childFollowerIDs(child)
+	if err != nil {
+		return err
+	}
+	var recipientIDs []uint
+	for _, id := range followerIDs {
+		if id != actorID {
+			recipientIDs = append(recipientIDs, id)
+		}
+	}
+
+	title := book.CustomTitle
+	if book.SharedBookID != nil {
+		var sharedBook models.SharedBook
+		if err := config.DB.First(&sharedBook, *book.SharedBookID).Error; err == nil {
+			title = sharedBook.Title
+		}
+	}
+
+	return PublishNotification(recipientIDs, models.Notification{
+		Type:       NotificationTypeBookLogged,
+		Message:    fmt.Sprintf("%s logged \"%s\" for %s", actor.FirstName, title, child.FirstName),
+		ActorID:    &actorID,
+		ChildID:    &child.ID,
+		EntityType: EntityBook,
+		EntityID:   book.ID,
+	})
+}
+
+// childFollowerIDs returns the owner, household members and users granted access to a child.
+// Classroom teachers are left out so a class does not flood their feed.
+func childFollowerIDs(child *models.Child) ([]uint, error) {
+	ids := []uint{child.OwnerID}
+
+	if child.HouseholdID != nil {
+		var memberIDs []uint
+		if err := config.DB.Model(&models.HouseholdMember{}).Where("household_id = ?", *child.HouseholdID).Pluck("user_id", &memberIDs).Error; err != nil {
+			return nil, err
+		}
+		ids = append(ids, memberIDs...)
+	}
+
+	var grantedIDs []uint
+	if err := config.DB.Model(&models.Permission{}).Where("child_id = ?", child.ID).Pluck("user_id", &grantedIDs).Error; err != nil {
+		return nil, err
+	}
+	return append(ids, grantedIDs...), nil
+}
+
+// notifyInvitationAccepted tells an inviter that the person they invited has joined
+func notifyInvitationAccepted(inviterID uint, user *models.User, childIDs []uint) {
+	notification := models.Notification{
+		Type:       NotificationTypeInvitationAccepted,
+		ActorID:    &user.ID,
+		EntityType: EntityPermission,
+	}
+
+	if len(childIDs) == 1 {
+		child, err := GetChildByID(childIDs[0])
+		if err != nil {
+			log.Printf("Failed to publish invitation notification for user %d: %v", user.ID, err)
+			return
+		}
+		notification.ChildID = &child.ID
+		notification.Message = fmt.Sprintf("%s %s accepted your invitation to follow %s's reading", user.FirstName, user.LastName, child.FirstName)
+	} else {
+		notification.Message = fmt.Sprintf("%s %s accepted your invitation to follow %d children's reading", user.FirstName, user.LastName, len(childIDs))
+	}
+
+	if err := PublishNotification([]uint{inviterID}, notification); err != nil {
+		log.Printf("Failed to publish invitation notification for user %d: %v", user.ID, err)
+	}
+}
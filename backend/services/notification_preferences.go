@@ -0,0 +1,151 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"gorm.io/gorm/clause"
+)
+
+// Notification categories. Emails the user asked for themselves, such as
+// verification and password reset, belong to no category and are always sent.
+const (
+	NotificationInvitations    = "INVITATIONS"
+	NotificationDigests        = "DIGESTS"
+	NotificationReminders      = "REMINDERS"
+	NotificationSecurityAlerts = "SECURITY_ALERTS"
+)
+
+// NotificationCategories lists the categories users can turn off
+var NotificationCategories = []string{
+	NotificationInvitations,
+	NotificationDigests,
+	NotificationReminders,
+	NotificationSecurityAlerts,
+}
+
+// ErrUnsubscribeLinkInvalid is returned for unsubscribe tokens that were not signed by us
+var ErrUnsubscribeLinkInvalid = errors.New("unsubscribe link is not valid")
+
+// normalizeEmail makes opt-outs match however the address was capitalized
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func isNotificationCategory(category string) bool {
+	for _, c := range NotificationCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// GetNotificationPreferences reports which categories an address receives; everything is on by default
+func GetNotificationPreferences(email string) (map[string]bool, error) {
+	var optOuts []models.EmailOptOut
+	if err := config.DB.Where("email = ?", normalizeEmail(email)).Find(&optOuts).Error; err != nil {
+		return nil, err
+	}
+
+	preferences := make(map[string]bool, len(NotificationCategories))
+	for _, category := range NotificationCategories {
+		preferences[category] = true
+	}
+	for _, optOut := range optOuts {
+		preferences[optOut.Category] = false
+	}
+	return preferences, nil
+}
+
+// IsNotificationEnabled reports whether an address still receives a category
+func IsNotificationEnabled(email, category string) (bool, error) {
+	var count int64
+	err := config.DB.Model(&models.EmailOptOut{}).
+		Where("email = ? AND category = ?", normalizeEmail(email), category).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count == 0, nil
+}
+
+// SetNotificationEnabled turns a category on or off for an address
+func SetNotificationEnabled(email, category string, enabled bool) error {
+	if !isNotificationCategory(category) {
+		return errors.New("unknown notification category")
+	}
+
+	email = normalizeEmail(email)
+	if enabled {
+		return config.DB.Where("email = ? AND category = ?", email, category).Delete(&models.EmailOptOut{}).Error
+	}
+	return config.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.EmailOptOut{Email: email, Category: category}).Error
+}
+
+// moveNotificationPreferences carries opt-outs over when a user changes their email address
+func moveNotificationPreferences(oldEmail, newEmail string) error {
+	oldEmail, newEmail = normalizeEmail(oldEmail), normalizeEmail(newEmail)
+	if oldEmail == newEmail {
+		return nil
+	}
+	if err := config.DB.Where("email = ?", newEmail).Delete(&models.EmailOptOut{}).Error; err != nil {
+		return err
+	}
+	return config.DB.Model(&models.EmailOptOut{}).Where("email = ?", oldEmail).Update("email", newEmail).Error
+}
+
+// UnsubscribeToken signs an address and category so the link works without logging in and cannot be forged
+func UnsubscribeToken(email, category string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(normalizeEmail(email) + "\n" + category))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(unsubscribeSignature(payload))
+}
+
+// UnsubscribeURL is the one-click unsubscribe link for an address and category
+func UnsubscribeURL(email, category string) string {
+	return BackendURL() + "/api/public/unsubscribe?token=" + url.QueryEscape(UnsubscribeToken(email, category))
+}
+
+func unsubscribeSignature(payload string) []byte {
+	mac := hmac.New(sha256.New, jwtSecret)
+	mac.Write([]byte("unsubscribe:" + payload))
+	return mac.Sum(nil)
+}
+
+// ParseUnsubscribeToken verifies a token and returns the address and category it was issued for
+func ParseUnsubscribeToken(token string) (string, string, error) {
+	payload, signature, found := strings.Cut(token, ".")
+	if !found {
+		return "", "", ErrUnsubscribeLinkInvalid
+	}
+	decodedSignature, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(decodedSignature, unsubscribeSignature(payload)) {
+		return "", "", ErrUnsubscribeLinkInvalid
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", "", ErrUnsubscribeLinkInvalid
+	}
+	email, category, found := strings.Cut(string(decoded), "\n")
+	if !found || !isNotificationCategory(category) {
+		return "", "", ErrUnsubscribeLinkInvalid
+	}
+	return email, category, nil
+}
+
+// UnsubscribeByToken turns off the category an unsubscribe link was issued for
+func UnsubscribeByToken(token string) (string, error) {
+	email, category, err := ParseUnsubscribeToken(token)
+	if err != nil {
+		return "", err
+	}
+	return category, SetNotificationEnabled(email, category, false)
+}
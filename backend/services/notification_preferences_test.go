@@ -0,0 +1,151 @@
+package services
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type NotificationPreferencesTestSuite struct {
+	suite.Suite
+	user *models.User
+}
+
+func (suite *NotificationPreferencesTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+
+	user, err := CreateUser(models.CreateUserRequest{
+		Email:     "parent@example.com",
+		Password:  "password123",
+		FirstName: "Pat",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	suite.user = user
+}
+
+func (suite *NotificationPreferencesTestSuite) TearDownTest() {
+	config.CleanupTestDatabase()
+}
+
+func (suite *NotificationPreferencesTestSuite) queuedEmails() []models.EmailOutbox {
+	var emails []models.EmailOutbox
+	assert.NoError(suite.T(), config.DB.Order("id").Find(&emails).Error)
+	return emails
+}
+
+func (suite *NotificationPreferencesTestSuite) TestEverythingIsOnByDefault() {
+	preferences, err := GetNotificationPreferences(suite.user.Email)
+	assert.NoError(suite.T(), err)
+	for _, category := range NotificationCategories {
+		assert.True(suite.T(), preferences[category], category)
+	}
+
+	assert.NoError(suite.T(), SetNotificationEnabled("Parent@Example.com", NotificationReminders, false))
+	// Turning a category off twice is not an error
+	assert.NoError(suite.T(), SetNotificationEnabled(suite.user.Email, NotificationReminders, false))
+
+	preferences, err = GetNotificationPreferences(suite.user.Email)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), preferences[NotificationReminders])
+	assert.True(suite.T(), preferences[NotificationInvitations])
+
+	assert.Error(suite.T(), SetNotificationEnabled(suite.user.Email, "NEWSLETTER", false))
+}
+
+func (suite *NotificationPreferencesTestSuite) TestUnsubscribeToken() {
+	token := UnsubscribeToken("Invitee@Example.com", NotificationInvitations)
+
+	email, category, err := ParseUnsubscribeToken(token)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "invitee@example.com", email)
+	assert.Equal(suite.T(), NotificationInvitations, category)
+
+	// A token re-signed for another address or category does not verify
+	payload, signature, _ := strings.Cut(token, ".")
+	forged := UnsubscribeToken("someone@example.com", NotificationInvitations)
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+	_, _, err = ParseUnsubscribeToken(forgedPayload + "." + signature)
+	assert.ErrorIs(suite.T(), err, ErrUnsubscribeLinkInvalid)
+	_, _, err = ParseUnsubscribeToken(payload)
+	assert.ErrorIs(suite.T(), err, ErrUnsubscribeLinkInvalid)
+	_, _, err = ParseUnsubscribeToken("garbage.garbage")
+	assert.ErrorIs(suite.T(), err, ErrUnsubscribeLinkInvalid)
+
+	category, err = UnsubscribeByToken(token)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), NotificationInvitations, category)
+	enabled, err := IsNotificationEnabled("invitee@example.com", NotificationInvitations)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), enabled)
+}
+
+func (suite *NotificationPreferencesTestSuite) TestOptedOutEmailsAreNotQueued() {
+	err := SendSystemInvitationEmail("invitee@example.com", "invite-token", suite.user)
+	assert.NoError(suite.T(), err)
+
+	emails := suite.queuedEmails()
+	assert.Len(suite.T(), emails, 1)
+	var headers map[string]string
+	assert.NoError(suite.T(), json.Unmarshal([]byte(emails[0].Headers), &headers))
+	assert.Equal(suite.T(), "List-Unsubscribe=One-Click", headers["List-Unsubscribe-Post"])
+	assert.Contains(suite.T(), headers["List-Unsubscribe"], "/api/public/unsubscribe?token=")
+	assert.Contains(suite.T(), emails[0].TextBody, "Unsubscribe: ")
+
+	// The invitee has no account but can still unsubscribe through the link
+	link, err := url.Parse(strings.Trim(headers["List-Unsubscribe"], "<>"))
+	assert.NoError(suite.T(), err)
+	_, err = UnsubscribeByToken(link.Query().Get("token"))
+	assert.NoError(suite.T(), err)
+
+	err = SendSystemInvitationEmail("invitee@example.com", "invite-token-2", suite.user)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), suite.queuedEmails(), 1)
+}
+
+func (suite *NotificationPreferencesTestSuite) TestTransactionalEmailsIgnorePreferences() {
+	for _, category := range NotificationCategories {
+		assert.NoError(suite.T(), SetNotificationEnabled(suite.user.Email, category, false))
+	}
+
+	err := NewEmailService().SendPasswordResetEmail(suite.user.Email, suite.user.FirstName, "reset-token")
+	assert.NoError(suite.T(), err)
+
+	emails := suite.queuedEmails()
+	assert.Len(suite.T(), emails, 1)
+	assert.Empty(suite.T(), emails[0].Headers)
+	assert.NotContains(suite.T(), emails[0].TextBody, "Unsubscribe")
+}
+
+func (suite *NotificationPreferencesTestSuite) TestPreferencesFollowEmailChanges() {
+	assert.NoError(suite.T(), SetNotificationEnabled(suite.user.Email, NotificationDigests, false))
+
+	_, err := UpdateUser(suite.user.ID, models.UpdateUserRequest{
+		Email:     "new@example.com",
+		FirstName: suite.user.FirstName,
+		LastName:  suite.user.LastName,
+	})
+	assert.NoError(suite.T(), err)
+
+	enabled, err := IsNotificationEnabled("new@example.com", NotificationDigests)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), enabled)
+
+	// Opting into digests again turns them back on
+	_, err = SubscribeToDigest(suite.user.ID, DigestWeekly)
+	assert.NoError(suite.T(), err)
+	enabled, err = IsNotificationEnabled("new@example.com", NotificationDigests)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), enabled)
+}
+
+func TestNotificationPreferencesTestSuite(t *testing.T) {
+	suite.Run(t, new(NotificationPreferencesTestSuite))
+}
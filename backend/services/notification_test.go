@@ -0,0 +1,175 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type NotificationServiceTestSuite struct {
+	suite.Suite
+	owner    *models.User
+	coParent *models.User
+	teacher  *models.User
+	child    *models.Child
+}
+
+func (suite *NotificationServiceTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+	SharedPermissionCache().Clear()
+
+	suite.owner = suite.createUser("owner@example.com", "Olivia")
+	suite.coParent = suite.createUser("coparent@example.com", "Chris")
+	suite.teacher = suite.createUser("teacher@example.com", "Terry")
+
+	child, err := CreateChild(models.CreateChildRequest{
+		FirstName: "Sam",
+		LastName:  "Reader",
+		Grade:     "3rd",
+	}, suite.owner.ID)
+	assert.NoError(suite.T(), err)
+	suite.child = child
+	assert.NoError(suite.T(), CreatePermission(suite.coParent.ID, child.ID, "EDIT", Actor{UserID: suite.owner.ID}))
+
+	classroom, err := CreateClassroom(models.CreateClassroomRequest{Name: "3B"}, Actor{UserID: suite.teacher.ID})
+	assert.NoError(suite.T(), err)
+	_, err = JoinClassroom(classroom.JoinCode, child.ID, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *NotificationServiceTestSuite) TearDownTest() {
+	config.CleanupTestDatabase()
+}
+
+func (suite *NotificationServiceTestSuite) createUser(email, firstName string) *models.User {
+	user, err := CreateUser(models.CreateUserRequest{
+		Email:     email,
+		Password:  "password123",
+		FirstName: firstName,
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	return user
+}
+
+func (suite *NotificationServiceTestSuite) logBook(title string, actor *models.User) *models.Book {
+	book, err := CreateCustomBook(models.CreateCustomBookRequest{
+		Title:    title,
+		Author:   "Author",
+		DateRead: "2024-03-10",
+		ChildID:  suite.child.ID,
+	}, Actor{UserID: actor.ID})
+	assert.NoError(suite.T(), err)
+	return book
+}
+
+func (suite *NotificationServiceTestSuite) TestBookLoggedNotifiesTheFamily() {
+	book := suite.logBook("Frog and Toad", suite.coParent)
+
+	notifications, err := GetNotifications(suite.owner.ID, false, 0, 0)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), notifications, 1)
+	assert.Equal(suite.T(), NotificationTypeBookLogged, notifications[0].Type)
+	assert.Equal(suite.T(), `Chris logged "Frog and Toad" for Sam`, notifications[0].Message)
+	assert.Equal(suite.T(), suite.coParent.ID, *notifications[0].ActorID)
+	assert.Equal(suite.T(), suite.child.ID, *notifications[0].ChildID)
+	assert.Equal(suite.T(), book.ID, notifications[0].EntityID)
+
+	// Neither the person who logged it nor the classroom teacher is notified
+	count, err := CountUnreadNotifications(suite.coParent.ID)
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), count)
+	count, err = CountUnreadNotifications(suite.teacher.ID)
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), count)
+
+	// Background jobs do not notify anyone
+	_, err = CreateCustomBook(models.CreateCustomBookRequest{
+		Title:    "Imported",
+		DateRead: "2024-03-11",
+		ChildID:  suite.child.ID,
+	}, SystemActor)
+	assert.NoError(suite.T(), err)
+	count, err = CountUnreadNotifications(suite.owner.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), count)
+}
+
+func (suite *NotificationServiceTestSuite) TestMarkRead() {
+	suite.logBook("First", suite.coParent)
+	suite.logBook("Second", suite.coParent)
+	suite.logBook("Third", suite.coParent)
+
+	notifications, err := GetNotifications(suite.owner.ID, false, 0, 0)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), notifications, 3)
+	assert.Contains(suite.T(), notifications[0].Message, "Third")
+
+	read, err := MarkNotificationRead(suite.owner.ID, notifications[0].ID)
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), read.ReadAt)
+
+	// Another user's notification cannot be marked
+	_, err = MarkNotificationRead(suite.coParent.ID, notifications[1].ID)
+	assert.ErrorIs(suite.T(), err, ErrNotificationNotFound)
+
+	unread, err := GetNotifications(suite.owner.ID, true, 0, 0)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), unread, 2)
+
+	updated, err := MarkAllNotificationsRead(suite.owner.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(2), updated)
+	count, err := CountUnreadNotifications(suite.owner.ID)
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), count)
+}
+
+func (suite *NotificationServiceTestSuite) TestMutedTypesAreNotPublished() {
+	assert.NoError(suite.T(), SetNotificationMuted(suite.owner.ID, NotificationTypeBookLogged, true))
+	assert.NoError(suite.T(), SetNotificationMuted(suite.owner.ID, NotificationTypeBookLogged, true))
+	assert.Error(suite.T(), SetNotificationMuted(suite.owner.ID, "BADGE", true))
+
+	muted, err := GetNotificationMutes(suite.owner.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{NotificationTypeBookLogged}, muted)
+
+	suite.logBook("Muted", suite.coParent)
+	count, err := CountUnreadNotifications(suite.owner.ID)
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), count)
+
+	assert.NoError(suite.T(), SetNotificationMuted(suite.owner.ID, NotificationTypeBookLogged, false))
+	suite.logBook("Unmuted", suite.coParent)
+	count, err = CountUnreadNotifications(suite.owner.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), count)
+}
+
+func (suite *NotificationServiceTestSuite) TestInvitationAcceptedNotifiesInviter() {
+	invitation, err := CreatePendingInvitation("grandma@example.com", suite.child.ID, "VIEW", suite.owner.ID)
+	assert.NoError(suite.T(), err)
+
+	_, err = ProcessInvitationRegistration(models.CreateUserWithInvitationRequest{
+		Email:           "grandma@example.com",
+		Password:        "password123",
+		FirstName:       "Grace",
+		LastName:        "Reader",
+		InvitationToken: invitation.Token,
+	})
+	assert.NoError(suite.T(), err)
+
+	notifications, err := GetNotifications(suite.owner.ID, false, 0, 0)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), notifications, 1)
+	assert.Equal(suite.T(), NotificationTypeInvitationAccepted, notifications[0].Type)
+	assert.Equal(suite.T(), "Grace Reader accepted your invitation to follow Sam's reading", notifications[0].Message)
+}
+
+func TestNotificationServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(NotificationServiceTestSuite))
+}
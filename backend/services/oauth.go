@@ -26,22 +26,26 @@ type GoogleUserInfo struct {
 	Picture       string `json:"picture"`
 }
 
+// BackendURL returns the public URL of this API, auto-detected from the hosting environment
+func BackendURL() string {
+	backendURL := os.Getenv("RENDER_EXTERNAL_URL") // Render.com provides this
+	if backendURL == "" {
+		vercelURL := os.Getenv("NEXT_PUBLIC_VERCEL_PROJECT_PRODUCTION_URL") // Vercel provides this
+		if vercelURL != "" {
+			backendURL = "https://" + vercelURL
+		} else {
+			// Local development fallback
+			backendURL = "http://localhost:8080"
+		}
+	}
+	return backendURL
+}
+
 func NewOAuthService() *OAuthService {
 	// Determine the redirect URL based on environment
 	redirectURL := os.Getenv("GOOGLE_REDIRECT_URL")
 	if redirectURL == "" {
-		// Auto-detect based on the current environment
-		backendURL := os.Getenv("RENDER_EXTERNAL_URL") // Render.com provides this
-		if backendURL == "" {
-			vercelURL := os.Getenv("NEXT_PUBLIC_VERCEL_PROJECT_PRODUCTION_URL") // Vercel provides this
-			if vercelURL != "" {
-				backendURL = "https://" + vercelURL
-			} else {
-				// Local development fallback
-				backendURL = "http://localhost:8080"
-			}
-		}
-		redirectURL = backendURL + "/api/auth/google/callback"
+		redirectURL = BackendURL() + "/api/auth/google/callback"
 	}
 
 	config := &oauth2.Config{
@@ -0,0 +1,390 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"gorm.io/gorm"
+)
+
+// Organization roles
+const (
+	OrgRoleAdmin   = "ADMIN"
+	OrgRoleTeacher = "TEACHER"
+	OrgRoleMember  = "MEMBER"
+)
+
+// Entity types of organization changes
+const (
+	EntityOrganization           = "organization"
+	EntityOrganizationMember     = "organization_member"
+	EntityOrganizationInvitation = "organization_invitation"
+)
+
+var (
+	// ErrOrganizationInvitationNotFound is returned for invitations that do not exist or are for someone else
+	ErrOrganizationInvitationNotFound = errors.New("organization invitation not found")
+	// ErrProtectedOrganizationUser stops organization admins from deleting admins
+	ErrProtectedOrganizationUser = errors.New("admins can only be deleted by a global admin")
+)
+
+// CreateOrganization creates an organization (global admin only)
+func CreateOrganization(req models.CreateOrganizationRequest, actor Actor) (*models.Organization, error) {
+	organization := models.Organization{Name: req.Name}
+
+	result := config.DB.Create(&organization)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionCreate,
+		EntityType: EntityOrganization,
+		EntityID:   organization.ID,
+		After:      organization,
+	})
+
+	return &organization, nil
+}
+
+// GetOrganizationByID gets an organization by ID
+func GetOrganizationByID(id uint) (*models.Organization, error) {
+	var organization models.Organization
+	result := config.DB.First(&organization, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("organization not found")
+		}
+		return nil, result.Error
+	}
+	return &organization, nil
+}
+
+// GetAllOrganizations gets every organization
+func GetAllOrganizations() ([]models.Organization, error) {
+	var organizations []models.Organization
+	result := config.DB.Order("name").Find(&organizations)
+	return organizations, result.Error
+}
+
+// CountOrganizationMembers counts the users of an organization
+func CountOrganizationMembers(organizationID uint) (int, error) {
+	var count int64
+	result := config.DB.Model(&models.User{}).Where("organization_id = ?", organizationID).Count(&count)
+	return int(count), result.Error
+}
+
+// DeleteOrganization removes an organization; its users and classrooms are kept without one
+func DeleteOrganization(id uint, actor Actor) error {
+	organization, err := GetOrganizationByID(id)
+	if err != nil {
+		return err
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("organization_id = ?", id).
+			Updates(map[string]interface{}{"organization_id": nil, "org_role": ""}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Classroom{}).Where("organization_id = ?", id).
+			Update("organization_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("organization_id = ?", id).Delete(&models.OrganizationInvitation{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(organization).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionDelete,
+		EntityType: EntityOrganization,
+		EntityID:   organization.ID,
+		Before:     *organization,
+	})
+
+	return nil
+}
+
+// IsOrganizationAdmin reports whether a user may administer an organization; global admins may administer all of them
+func IsOrganizationAdmin(user *models.User, organizationID uint) bool {
+	if user.IsAdmin {
+		return true
+	}
+	return user.OrgRole == OrgRoleAdmin && user.OrganizationID != nil && *user.OrganizationID == organizationID
+}
+
+// GetUsersByOrganization gets the users of one organization
+func GetUsersByOrganization(organizationID uint) ([]models.User, error) {
+	var users []models.User
+	result := config.DB.Where("organization_id = ?", organizationID).Order("last_name, first_name").Find(&users)
+	return users, result.Error
+}
+
+// GetOrganizationUser gets a user only if they belong to the organization,
+// so org admins cannot reach users of other organizations
+func GetOrganizationUser(organizationID, userID uint) (*models.User, error) {
+	var user models.User
+	result := config.DB.Where("id = ? AND organization_id = ?", userID, organizationID).First(&user)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found in organization")
+		}
+		return nil, result.Error
+	}
+	return &user, nil
+}
+
+// SetUserOrganization assigns a user to an organization with a role, or removes them (global admin only)
+func SetUserOrganization(userID uint, organizationID *uint, role string, actor Actor) (*models.User, error) {
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if organizationID != nil {
+		if _, err := GetOrganizationByID(*organizationID); err != nil {
+			return nil, err
+		}
+		if role == "" {
+			role = OrgRoleMember
+		}
+	} else {
+		role = ""
+	}
+
+	return setOrganizationMembership(user, organizationID, role, actor)
+}
+
+// InviteOrganizationMember invites a user who is not in any organization yet; they join once they accept
+func InviteOrganizationMember(organizationID, userID uint, role string, actor Actor) (*models.OrganizationInvitation, error) {
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.OrganizationID != nil {
+		if *user.OrganizationID == organizationID {
+			return nil, errors.New("user is already a member of this organization")
+		}
+		return nil, errors.New("user belongs to another organization")
+	}
+	organization, err := GetOrganizationByID(organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Inviting again changes the role of the pending invitation
+	var invitation models.OrganizationInvitation
+	result := config.DB.Where("organization_id = ? AND user_id = ?", organizationID, userID).First(&invitation)
+	if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, result.Error
+	}
+	invitation.OrganizationID = organizationID
+	invitation.UserID = userID
+	invitation.Role = role
+	invitation.InvitedByID = actor.UserID
+	if err := config.DB.Save(&invitation).Error; err != nil {
+		return nil, err
+	}
+	invitation.Organization = *organization
+	invitation.User = *user
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionCreate,
+		EntityType: EntityOrganizationInvitation,
+		EntityID:   invitation.ID,
+		After:      organizationInvitationView(invitation),
+	})
+
+	notification := models.Notification{
+		Type:       NotificationTypeOrganizationInvitation,
+		EntityType: EntityOrganizationInvitation,
+		EntityID:   invitation.ID,
+		Message:    fmt.Sprintf("You were invited to join %s", organization.Name),
+	}
+	if actor.UserID != 0 {
+		notification.ActorID = &actor.UserID
+	}
+	if err := PublishNotification([]uint{userID}, notification); err != nil {
+		log.Printf("Failed to publish organization invitation notification for user %d: %v", userID, err)
+	}
+
+	return &invitation, nil
+}
+
+// GetOrganizationInvitations gets the pending invitations of an organization
+func GetOrganizationInvitations(organizationID uint) ([]models.OrganizationInvitation, error) {
+	var invitations []models.OrganizationInvitation
+	result := config.DB.Preload("Organization").Preload("User").
+		Where("organization_id = ?", organizationID).Order("created_at").Find(&invitations)
+	return invitations, result.Error
+}
+
+// GetOrganizationInvitationsForUser gets the invitations waiting for a user's answer
+func GetOrganizationInvitationsForUser(userID uint) ([]models.OrganizationInvitation, error) {
+	var invitations []models.OrganizationInvitation
+	result := config.DB.Preload("Organization").Preload("User").
+		Where("user_id = ?", userID).Order("created_at").Find(&invitations)
+	return invitations, result.Error
+}
+
+// AcceptOrganizationInvitation makes the invited user a member; their other invitations are dropped
+func AcceptOrganizationInvitation(invitationID, userID uint, actor Actor) (*models.User, error) {
+	invitation, err := getOrganizationInvitation(invitationID, "user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.OrganizationID != nil {
+		return nil, errors.New("user already belongs to an organization")
+	}
+
+	member, err := setOrganizationMembership(user, &invitation.OrganizationID, invitation.Role, actor)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.DB.Where("user_id = ?", userID).Delete(&models.OrganizationInvitation{}).Error; err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// DeclineOrganizationInvitation drops an invitation without joining
+func DeclineOrganizationInvitation(invitationID, userID uint, actor Actor) error {
+	invitation, err := getOrganizationInvitation(invitationID, "user_id = ?", userID)
+	if err != nil {
+		return err
+	}
+	return deleteOrganizationInvitation(invitation, actor)
+}
+
+// RevokeOrganizationInvitation withdraws an invitation of the organization
+func RevokeOrganizationInvitation(organizationID, invitationID uint, actor Actor) error {
+	invitation, err := getOrganizationInvitation(invitationID, "organization_id = ?", organizationID)
+	if err != nil {
+		return err
+	}
+	return deleteOrganizationInvitation(invitation, actor)
+}
+
+func getOrganizationInvitation(invitationID uint, scope string, scopeID uint) (*models.OrganizationInvitation, error) {
+	var invitation models.OrganizationInvitation
+	result := config.DB.Where(scope, scopeID).First(&invitation, invitationID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrOrganizationInvitationNotFound
+		}
+		return nil, result.Error
+	}
+	return &invitation, nil
+}
+
+func deleteOrganizationInvitation(invitation *models.OrganizationInvitation, actor Actor) error {
+	if err := config.DB.Delete(invitation).Error; err != nil {
+		return err
+	}
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionDelete,
+		EntityType: EntityOrganizationInvitation,
+		EntityID:   invitation.ID,
+		Before:     organizationInvitationView(*invitation),
+	})
+
+	return nil
+}
+
+// UpdateOrganizationMember changes the role of a member of the organization
+func UpdateOrganizationMember(organizationID, userID uint, role string, actor Actor) (*models.User, error) {
+	user, err := GetOrganizationUser(organizationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return setOrganizationMembership(user, &organizationID, role, actor)
+}
+
+// RemoveOrganizationMember takes a user out of the organization without deleting their account
+func RemoveOrganizationMember(organizationID, userID uint, actor Actor) (*models.User, error) {
+	user, err := GetOrganizationUser(organizationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return setOrganizationMembership(user, nil, "", actor)
+}
+
+// DeleteOrganizationUser deletes the account of a member of the organization. Members joined by
+// accepting an invitation; fellow organization admins and global admins are left to a global admin.
+func DeleteOrganizationUser(organizationID, userID uint) error {
+	user, err := GetOrganizationUser(organizationID, userID)
+	if err != nil {
+		return err
+	}
+	if user.IsAdmin || user.OrgRole == OrgRoleAdmin {
+		return ErrProtectedOrganizationUser
+	}
+	return DeleteUser(user.ID)
+}
+
+// GetClassroomsByOrganization gets the classrooms created by teachers of an organization
+func GetClassroomsByOrganization(organizationID uint) ([]models.Classroom, error) {
+	var classrooms []models.Classroom
+	result := config.DB.Where("organization_id = ?", organizationID).Order("name").Find(&classrooms)
+	return classrooms, result.Error
+}
+
+func setOrganizationMembership(user *models.User, organizationID *uint, role string, actor Actor) (*models.User, error) {
+	before := *user
+
+	result := config.DB.Model(user).Updates(map[string]interface{}{
+		"organization_id": organizationID,
+		"org_role":        role,
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	user.OrganizationID = organizationID
+	user.OrgRole = role
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionUpdate,
+		EntityType: EntityOrganizationMember,
+		EntityID:   user.ID,
+		Before:     organizationMembershipView(before),
+		After:      organizationMembershipView(*user),
+	})
+
+	return user, nil
+}
+
+// organizationMembershipView keeps credentials and profile data out of the audit log
+func organizationMembershipView(user models.User) map[string]interface{} {
+	return map[string]interface{}{
+		"userId":         user.ID,
+		"organizationId": user.OrganizationID,
+		"orgRole":        user.OrgRole,
+	}
+}
+
+// organizationInvitationView is the audit record of an invitation
+func organizationInvitationView(invitation models.OrganizationInvitation) map[string]interface{} {
+	return map[string]interface{}{
+		"invitationId":   invitation.ID,
+		"userId":         invitation.UserID,
+		"organizationId": invitation.OrganizationID,
+		"orgRole":        invitation.Role,
+	}
+}
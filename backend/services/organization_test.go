@@ -0,0 +1,217 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type OrganizationServiceTestSuite struct {
+	suite.Suite
+	school   *models.Organization
+	other    *models.Organization
+	orgAdmin *models.User
+	teacher  *models.User
+	outsider *models.User
+}
+
+func (suite *OrganizationServiceTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+	SharedPermissionCache().Clear()
+
+	var err error
+	suite.school, err = CreateOrganization(models.CreateOrganizationRequest{Name: "Lincoln Elementary"}, SystemActor)
+	assert.NoError(suite.T(), err)
+	suite.other, err = CreateOrganization(models.CreateOrganizationRequest{Name: "Washington Elementary"}, SystemActor)
+	assert.NoError(suite.T(), err)
+
+	// The first account becomes the global admin
+	suite.createUser("admin@example.com")
+	suite.orgAdmin = suite.createUser("principal@example.com")
+	suite.teacher = suite.createUser("teacher@example.com")
+	suite.outsider = suite.createUser("outsider@example.com")
+
+	suite.orgAdmin, err = SetUserOrganization(suite.orgAdmin.ID, &suite.school.ID, OrgRoleAdmin, SystemActor)
+	assert.NoError(suite.T(), err)
+	invitation, err := InviteOrganizationMember(suite.school.ID, suite.teacher.ID, OrgRoleTeacher, Actor{UserID: suite.orgAdmin.ID})
+	assert.NoError(suite.T(), err)
+	suite.teacher, err = AcceptOrganizationInvitation(invitation.ID, suite.teacher.ID, Actor{UserID: suite.teacher.ID})
+	assert.NoError(suite.T(), err)
+	_, err = SetUserOrganization(suite.outsider.ID, &suite.other.ID, "", SystemActor)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *OrganizationServiceTestSuite) TearDownTest() {
+	config.CleanupTestDatabase()
+}
+
+func (suite *OrganizationServiceTestSuite) createUser(email string) *models.User {
+	user, err := CreateUser(models.CreateUserRequest{
+		Email:     email,
+		Password:  "password123",
+		FirstName: "Test",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	return user
+}
+
+func (suite *OrganizationServiceTestSuite) TestIsOrganizationAdmin() {
+	assert.True(suite.T(), IsOrganizationAdmin(suite.orgAdmin, suite.school.ID))
+	assert.False(suite.T(), IsOrganizationAdmin(suite.orgAdmin, suite.other.ID))
+	assert.False(suite.T(), IsOrganizationAdmin(suite.teacher, suite.school.ID))
+
+	globalAdmin := &models.User{IsAdmin: true}
+	assert.True(suite.T(), IsOrganizationAdmin(globalAdmin, suite.other.ID))
+}
+
+func (suite *OrganizationServiceTestSuite) TestUsersAreIsolatedByOrganization() {
+	users, err := GetUsersByOrganization(suite.school.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), users, 2)
+
+	outsider, err := GetUserByID(suite.outsider.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), OrgRoleMember, outsider.OrgRole)
+
+	_, err = GetOrganizationUser(suite.school.ID, suite.outsider.ID)
+	assert.Error(suite.T(), err)
+
+	// Users of another organization cannot be taken over or deleted
+	_, err = InviteOrganizationMember(suite.school.ID, suite.outsider.ID, OrgRoleMember, SystemActor)
+	assert.Error(suite.T(), err)
+	_, err = UpdateOrganizationMember(suite.school.ID, suite.outsider.ID, OrgRoleAdmin, SystemActor)
+	assert.Error(suite.T(), err)
+	assert.Error(suite.T(), DeleteOrganizationUser(suite.school.ID, suite.outsider.ID))
+
+	_, err = GetUserByID(suite.outsider.ID)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *OrganizationServiceTestSuite) TestMembersJoinOnlyByAcceptingInvitations() {
+	parent := suite.createUser("parent@example.com")
+	invitation, err := InviteOrganizationMember(suite.school.ID, parent.ID, OrgRoleMember, Actor{UserID: suite.orgAdmin.ID})
+	assert.NoError(suite.T(), err)
+
+	// Inviting does not make the user a member
+	_, err = GetOrganizationUser(suite.school.ID, parent.ID)
+	assert.Error(suite.T(), err)
+	invitations, err := GetOrganizationInvitationsForUser(parent.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), invitations, 1)
+	assert.Equal(suite.T(), "Lincoln Elementary", invitations[0].Organization.Name)
+	notifications, err := GetNotifications(parent.ID, true, 0, 0)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), notifications, 1)
+
+	// Only the invited user can accept
+	_, err = AcceptOrganizationInvitation(invitation.ID, suite.outsider.ID, Actor{UserID: suite.outsider.ID})
+	assert.ErrorIs(suite.T(), err, ErrOrganizationInvitationNotFound)
+
+	assert.NoError(suite.T(), DeclineOrganizationInvitation(invitation.ID, parent.ID, Actor{UserID: parent.ID}))
+	_, err = AcceptOrganizationInvitation(invitation.ID, parent.ID, Actor{UserID: parent.ID})
+	assert.ErrorIs(suite.T(), err, ErrOrganizationInvitationNotFound)
+
+	invitation, err = InviteOrganizationMember(suite.school.ID, parent.ID, OrgRoleMember, Actor{UserID: suite.orgAdmin.ID})
+	assert.NoError(suite.T(), err)
+	member, err := AcceptOrganizationInvitation(invitation.ID, parent.ID, Actor{UserID: parent.ID})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.school.ID, *member.OrganizationID)
+	invitations, err = GetOrganizationInvitations(suite.school.ID)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), invitations)
+}
+
+func (suite *OrganizationServiceTestSuite) TestDeleteOrganizationUser() {
+	parent := suite.createUser("parent@example.com")
+	invitation, err := InviteOrganizationMember(suite.school.ID, parent.ID, OrgRoleMember, Actor{UserID: suite.orgAdmin.ID})
+	assert.NoError(suite.T(), err)
+
+	// Until the invitation is accepted the account is not the organization's
+	assert.Error(suite.T(), DeleteOrganizationUser(suite.school.ID, parent.ID))
+	_, err = AcceptOrganizationInvitation(invitation.ID, parent.ID, Actor{UserID: parent.ID})
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), DeleteOrganizationUser(suite.school.ID, parent.ID))
+	_, err = GetUserByID(parent.ID)
+	assert.Error(suite.T(), err)
+
+	// Members of other organizations are out of reach
+	assert.Error(suite.T(), DeleteOrganizationUser(suite.school.ID, suite.outsider.ID))
+
+	// Admins are left to a global admin
+	assert.ErrorIs(suite.T(), DeleteOrganizationUser(suite.school.ID, suite.orgAdmin.ID), ErrProtectedOrganizationUser)
+	_, err = UpdateOrganizationMember(suite.school.ID, suite.teacher.ID, OrgRoleAdmin, Actor{UserID: suite.orgAdmin.ID})
+	assert.NoError(suite.T(), err)
+	assert.ErrorIs(suite.T(), DeleteOrganizationUser(suite.school.ID, suite.teacher.ID), ErrProtectedOrganizationUser)
+	assert.NoError(suite.T(), config.DB.Model(&models.User{}).Where("id = ?", suite.teacher.ID).
+		Updates(map[string]interface{}{"is_admin": true, "org_role": OrgRoleTeacher}).Error)
+	assert.ErrorIs(suite.T(), DeleteOrganizationUser(suite.school.ID, suite.teacher.ID), ErrProtectedOrganizationUser)
+	_, err = GetUserByID(suite.teacher.ID)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *OrganizationServiceTestSuite) TestClassroomsAreIsolatedByOrganization() {
+	classroom, err := CreateClassroom(models.CreateClassroomRequest{Name: "3B"}, Actor{UserID: suite.teacher.ID})
+	assert.NoError(suite.T(), err)
+
+	outsiderChild, err := CreateChild(models.CreateChildRequest{FirstName: "Olive", LastName: "Out"}, suite.outsider.ID)
+	assert.NoError(suite.T(), err)
+	_, err = JoinClassroom(classroom.JoinCode, outsiderChild.ID, Actor{UserID: suite.outsider.ID})
+	assert.ErrorIs(suite.T(), err, ErrClassroomOutsideOrganization)
+
+	// Families outside any organization can still join
+	parent := suite.createUser("parent@example.com")
+	child, err := CreateChild(models.CreateChildRequest{FirstName: "Pat", LastName: "Parent"}, parent.ID)
+	assert.NoError(suite.T(), err)
+	_, err = JoinClassroom(classroom.JoinCode, child.ID, Actor{UserID: parent.ID})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *OrganizationServiceTestSuite) TestClassroomsBelongToTeacherOrganization() {
+	classroom, err := CreateClassroom(models.CreateClassroomRequest{Name: "3B"}, Actor{UserID: suite.teacher.ID})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.school.ID, *classroom.OrganizationID)
+
+	classrooms, err := GetClassroomsByOrganization(suite.school.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), classrooms, 1)
+
+	classrooms, err = GetClassroomsByOrganization(suite.other.ID)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), classrooms)
+}
+
+func (suite *OrganizationServiceTestSuite) TestRemoveMemberKeepsAccount() {
+	user, err := RemoveOrganizationMember(suite.school.ID, suite.teacher.ID, SystemActor)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), user.OrganizationID)
+	assert.Empty(suite.T(), user.OrgRole)
+
+	reloaded, err := GetUserByID(suite.teacher.ID)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), reloaded.OrganizationID)
+}
+
+func (suite *OrganizationServiceTestSuite) TestDeleteOrganizationKeepsUsersAndClassrooms() {
+	classroom, err := CreateClassroom(models.CreateClassroomRequest{Name: "3B"}, Actor{UserID: suite.teacher.ID})
+	assert.NoError(suite.T(), err)
+
+	assert.NoError(suite.T(), DeleteOrganization(suite.school.ID, SystemActor))
+
+	teacher, err := GetUserByID(suite.teacher.ID)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), teacher.OrganizationID)
+	assert.Empty(suite.T(), teacher.OrgRole)
+
+	reloaded, err := GetClassroomByID(classroom.ID)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), reloaded.OrganizationID)
+}
+
+func TestOrganizationServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(OrganizationServiceTestSuite))
+}
@@ -1,11 +1,13 @@
 package services
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
-	"os"
-	"path/filepath"
+	"log"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/booktracker/backend/config"
@@ -21,53 +23,220 @@ type BookForPDF struct {
 	LexileLevel   string
 	DateRead      time.Time
 	CoverURL      string
+	SharedBookID  uint // Covers come from the cover store by shared book
 	IsPartial     bool
 	PartialComment string
-	CoverImagePath string // Local path to downloaded cover
+	CoverImage     []byte // Downloaded cover, kept in memory
+	CoverImageType string // gofpdf image type of CoverImage: JPG or PNG
+}
+
+// BooksReport is a rendered PDF report, written out with Write
+type BooksReport struct {
+	Filename string
+
+	pdf       *gofpdf.Fpdf
+	text      []string // Text drawn on each page, for tests
+	uncovered string   // Characters drawn that no embedded font has a glyph for
+}
+
+// Write streams the PDF to w, typically the HTTP response
+func (r *BooksReport) Write(w io.Writer) error {
+	return r.pdf.Output(w)
+}
+
+// Report presets accepted by the report endpoints
+const (
+	ReportPresetMonth      = "month"
+	ReportPresetYear       = "year"
+	ReportPresetSchoolYear = "school-year"
+	ReportPresetSemester   = "semester"
+	ReportPresetCustom     = "custom"
+)
+
+// Semester terms
+const (
+	SemesterFall   = "fall"
+	SemesterSpring = "spring"
+)
+
+// SchoolYearStartMonth is the month the school year and its fall semester begin
+const SchoolYearStartMonth = time.August
+
+// maxReportMonths keeps custom ranges to a printable size
+const maxReportMonths = 36
+
+// booksPerPage is the 4 x 8 grid of book cells on a report page
+const booksPerPage = 32
+
+// Covers load on a small pool so a long report of uncached covers cannot flood the cover hosts
+const (
+	coverFetchWorkers = 4
+	maxCoverImageSize = 5 << 20
+)
+
+// coverFetchTimeout bounds each cover download; a slow cover is left out rather than holding up the report
+var coverFetchTimeout = 10 * time.Second
+
+// ReportRange is the period a report covers, from Start up to but not including End
+type ReportRange struct {
+	Start time.Time
+	End   time.Time
+	Label string
+}
+
+// MonthRange covers one calendar month
+func MonthRange(year int, month int) ReportRange {
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	return ReportRange{
+		Start: start,
+		End:   start.AddDate(0, 1, 0),
+		Label: fmt.Sprintf("%s %d", start.Month(), year),
+	}
+}
+
+// YearRange covers one calendar year
+func YearRange(year int) ReportRange {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return ReportRange{
+		Start: start,
+		End:   start.AddDate(1, 0, 0),
+		Label: fmt.Sprintf("%d", year),
+	}
+}
+
+// SchoolYearRange covers the school year that starts in August of startYear
+func SchoolYearRange(startYear int) ReportRange {
+	start := time.Date(startYear, SchoolYearStartMonth, 1, 0, 0, 0, 0, time.UTC)
+	return ReportRange{
+		Start: start,
+		End:   start.AddDate(1, 0, 0),
+		Label: fmt.Sprintf("%d-%d School Year", startYear, startYear+1),
+	}
+}
+
+// SemesterRange covers the fall (August through December) or spring (January through July)
+// semester of a calendar year, so the two semesters make up a school year
+func SemesterRange(year int, term string) (ReportRange, error) {
+	switch term {
+	case SemesterFall:
+		return ReportRange{
+			Start: time.Date(year, SchoolYearStartMonth, 1, 0, 0, 0, 0, time.UTC),
+			End:   time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.UTC),
+			Label: fmt.Sprintf("Fall %d Semester", year),
+		}, nil
+	case SemesterSpring:
+		return ReportRange{
+			Start: time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC),
+			End:   time.Date(year, SchoolYearStartMonth, 1, 0, 0, 0, 0, time.UTC),
+			Label: fmt.Sprintf("Spring %d Semester", year),
+		}, nil
+	}
+	return ReportRange{}, fmt.Errorf("term must be %s or %s", SemesterFall, SemesterSpring)
+}
+
+// DateRange covers from through to, both days included
+func DateRange(from, to time.Time) (ReportRange, error) {
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	last := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	if last.Before(start) {
+		return ReportRange{}, errors.New("end date is before start date")
+	}
+	end := last.AddDate(0, 0, 1)
+	if start.AddDate(0, maxReportMonths, 0).Before(end) {
+		return ReportRange{}, fmt.Errorf("date range is longer than %d months", maxReportMonths)
+	}
+	return ReportRange{
+		Start: start,
+		End:   end,
+		Label: fmt.Sprintf("%s to %s", start.Format("2006-01-02"), last.Format("2006-01-02")),
+	}, nil
+}
+
+// Months lists the first day of every month the range touches
+func (r ReportRange) Months() []time.Time {
+	var months []time.Time
+	for month := time.Date(r.Start.Year(), r.Start.Month(), 1, 0, 0, 0, 0, time.UTC); month.Before(r.End); month = month.AddDate(0, 1, 0) {
+		months = append(months, month)
+	}
+	return months
+}
+
+// ChildBooks is one child's part of a report
+type ChildBooks struct {
+	Child *models.Child
+	Books []*BookForPDF
+}
+
+// ReportSummary holds the totals printed on a report's summary page
+type ReportSummary struct {
+	Months   []time.Time
+	Children []ChildSummary
+}
+
+// ChildSummary is one child's totals; PerMonth lines up with ReportSummary.Months
+type ChildSummary struct {
+	Name     string
+	Total    int
+	Partial  int
+	PerMonth []int
 }
 
 // GenerateMonthlyBooksPDF creates a PDF report for a child's books in a specific month
-func GenerateMonthlyBooksPDF(childID uint, year int, month int) (string, error) {
+func GenerateMonthlyBooksPDF(childID uint, year int, month int) (*BooksReport, error) {
+	return GenerateBooksPDF(childID, MonthRange(year, month), ReportTemplateGrid)
+}
+
+// GenerateMonthlyBooksPDFForChild creates the report for an already loaded child,
+// using the child's names as given (share links may blank the last name)
+func GenerateMonthlyBooksPDFForChild(child *models.Child, year int, month int) (*BooksReport, error) {
+	return GenerateBooksPDFForChildren([]*models.Child{child}, MonthRange(year, month), ReportTemplateGrid)
+}
+
+// GenerateBooksPDF creates a PDF report for a child's books over a period, laid out by the named template
+func GenerateBooksPDF(childID uint, r ReportRange, templateName string) (*BooksReport, error) {
 	// Get child information
 	child, err := GetChildByID(childID)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// Get books for the month
-	books, err := getBooksForMonth(childID, year, month)
-	if err != nil {
-		return "", err
-	}
+	return GenerateBooksPDFForChildren([]*models.Child{child}, r, templateName)
+}
 
-	// Download cover images
-	err = downloadCoverImages(books)
-	if err != nil {
-		return "", err
+// GenerateBooksPDFForChildren creates one PDF covering each child's books over a period.
+// Grid and log reports spanning several months or children start with a summary page.
+// Nothing touches the disk, so concurrent reports cannot interfere.
+func GenerateBooksPDFForChildren(children []*models.Child, r ReportRange, templateName string) (*BooksReport, error) {
+	template, ok := reportTemplates[templateName]
+	if !ok {
+		return nil, ErrInvalidReportTemplate
 	}
 
-	// Generate PDF
-	pdfPath, err := createPDF(child, books, year, month)
-	if err != nil {
-		return "", err
+	sections := make([]ChildBooks, len(children))
+	var allBooks []*BookForPDF
+	for i, child := range children {
+		books, err := getBooksForRange(child.ID, r)
+		if err != nil {
+			return nil, err
+		}
+		sections[i] = ChildBooks{Child: child, Books: books}
+		allBooks = append(allBooks, books...)
 	}
 
-	// Clean up cover images
-	cleanupCoverImages(books)
+	if template.needsCovers() {
+		fetchCoverImages(allBooks)
+	}
 
-	return pdfPath, nil
+	return createPDF(sections, r, template)
 }
 
-// getBooksForMonth retrieves books for a specific month
-func getBooksForMonth(childID uint, year int, month int) ([]*BookForPDF, error) {
+// getBooksForRange retrieves a child's books read during the period, oldest first
+func getBooksForRange(childID uint, r ReportRange) ([]*BookForPDF, error) {
 	db := config.GetDB()
-	
-	// Create date range for the month
-	startDate := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
-	endDate := startDate.AddDate(0, 1, 0).Add(-time.Nanosecond)
-	
+
 	var dbBooks []models.Book
-	err := db.Where("child_id = ? AND date_read BETWEEN ? AND ?", childID, startDate, endDate).
+	err := db.Where("child_id = ? AND date_read >= ? AND date_read < ?", childID,
+		r.Start.Format("2006-01-02"), r.End.Format("2006-01-02")).
 		Preload("SharedBook").
 		Order("date_read ASC").
 		Find(&dbBooks).Error
@@ -102,6 +271,7 @@ func getBooksForMonth(childID uint, year int, month int) ([]*BookForPDF, error)
 			pdfBook.Author = book.SharedBook.Author
 			pdfBook.ISBN = book.SharedBook.ISBN
 			pdfBook.CoverURL = book.SharedBook.CoverURL
+			pdfBook.SharedBookID = book.SharedBook.ID
 		} else {
 			pdfBook.Title = book.CustomTitle
 			pdfBook.Author = book.CustomAuthor
@@ -115,116 +285,299 @@ func getBooksForMonth(childID uint, year int, month int) ([]*BookForPDF, error)
 	return books, nil
 }
 
-// downloadCoverImages downloads cover images to temp directory
-func downloadCoverImages(books []*BookForPDF) error {
-	tempDir := os.TempDir()
-	
-	for i, book := range books {
-		if book.CoverURL == "" {
-			continue
+// fetchCoverImages loads the books' large covers from the cover store on a bounded worker pool,
+// downloading covers that were never cached. Covers that fail or time out are left out.
+func fetchCoverImages(books []*BookForPDF) {
+	bySharedBook := make(map[uint][]*BookForPDF)
+	for _, book := range books {
+		if book.SharedBookID != 0 && book.CoverURL != "" {
+			bySharedBook[book.SharedBookID] = append(bySharedBook[book.SharedBookID], book)
 		}
+	}
+	if len(bySharedBook) == 0 {
+		return
+	}
 
-		// Create temp file path
-		filename := fmt.Sprintf("book_cover_%d.jpg", i)
-		filepath := filepath.Join(tempDir, filename)
-		
-		// Download image
-		resp, err := http.Get(book.CoverURL)
-		if err != nil {
-			continue // Skip if download fails
+	sharedBookIDs := make(chan uint)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for i := 0; i < coverFetchWorkers && i < len(bySharedBook); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for sharedBookID := range sharedBookIDs {
+				cover, err := GetSharedBookCover(sharedBookID, CoverSizeLarge)
+				if err != nil {
+					log.Printf("Skipping cover of shared book %d: %v", sharedBookID, err)
+					continue
+				}
+				imageType := "JPG"
+				if cover.ContentType == "image/png" {
+					imageType = "PNG"
+				}
+				mu.Lock()
+				for _, book := range bySharedBook[sharedBookID] {
+					book.CoverImage = cover.Data
+					book.CoverImageType = imageType
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for sharedBookID := range bySharedBook {
+		sharedBookIDs <- sharedBookID
+	}
+	close(sharedBookIDs)
+	wg.Wait()
+}
+
+// createPDF generates the actual PDF document: an optional summary page, then each child's
+// pages as the template lays them out, under a shared page header and numbered footer
+func createPDF(sections []ChildBooks, r ReportRange, template reportTemplate) (*BooksReport, error) {
+	doc := newReportDocument()
+	pdf := doc.pdf
+	title := reportTitle(sections, r)
+	setPageHeaderAndFooter(doc, title)
+
+	months := r.Months()
+	if template.showsSummary() && (len(sections) > 1 || len(months) > 1) {
+		addSummaryPage(doc, summarizeReport(sections, r), title, r)
+	}
+
+	for _, section := range sections {
+		template.drawChild(doc, section, r)
+	}
+
+	// Nothing was drawn: still produce the headed page
+	if pdf.PageNo() == 0 {
+		addSectionPage(doc, title)
+	}
+
+	// Closing draws the last footer; the page count is only known now
+	pdf.Close()
+	pageCount := fmt.Sprint(pdf.PageNo())
+	for i, text := range doc.text {
+		doc.text[i] = strings.ReplaceAll(text, pageCountAlias, pageCount)
+	}
+
+	if err := pdf.Error(); err != nil {
+		return nil, err
+	}
+
+	name := "Family"
+	if len(sections) == 1 {
+		name = strings.TrimSpace(sections[0].Child.FirstName + " " + sections[0].Child.LastName)
+	}
+	filename := fmt.Sprintf("%s_%s_%s.pdf", template.filePrefix(),
+		strings.ReplaceAll(name, " ", "_"), strings.ReplaceAll(r.Label, " ", "_"))
+	if doc.uncovered != "" {
+		log.Printf("Report %s prints %q as empty boxes; set PDF_FALLBACK_FONT to a font with these characters", filename, doc.uncovered)
+	}
+	return &BooksReport{
+		Filename:  filename,
+		pdf:       pdf,
+		text:      doc.text,
+		uncovered: doc.uncovered,
+	}, nil
+}
+
+// reportTitle names the report: the child and period, or the period for a family report
+func reportTitle(sections []ChildBooks, r ReportRange) string {
+	if len(sections) == 1 {
+		childName := strings.TrimSpace(sections[0].Child.FirstName + " " + sections[0].Child.LastName)
+		return fmt.Sprintf("%s - %s", childName, r.Label)
+	}
+	return "Family Reading Report - " + r.Label
+}
+
+// summarizeReport counts each child's books per month of the range
+func summarizeReport(sections []ChildBooks, r ReportRange) ReportSummary {
+	summary := ReportSummary{Months: r.Months()}
+	monthIndex := make(map[string]int, len(summary.Months))
+	for i, month := range summary.Months {
+		monthIndex[month.Format("2006-01")] = i
+	}
+
+	for _, section := range sections {
+		child := ChildSummary{
+			Name:     strings.TrimSpace(section.Child.FirstName + " " + section.Child.LastName),
+			PerMonth: make([]int, len(summary.Months)),
 		}
-		defer resp.Body.Close()
+		for _, book := range section.Books {
+			child.Total++
+			if book.IsPartial {
+				child.Partial++
+			}
+			if i, ok := monthIndex[book.DateRead.Format("2006-01")]; ok {
+				child.PerMonth[i]++
+			}
+		}
+		summary.Children = append(summary.Children, child)
+	}
+	return summary
+}
 
-		// Create file
-		file, err := os.Create(filepath)
-		if err != nil {
-			continue
+// addSummaryPage draws the totals and a books-per-month table with a column per child
+func addSummaryPage(doc *reportDocument, summary ReportSummary, title string, r ReportRange) {
+	pdf := doc.pdf
+	pdf.AddPage()
+	doc.setFont("B", 16)
+	doc.cell(0, 10, doc.fit(title, 0), "", 0, "", false)
+	pdf.Ln(8)
+
+	doc.setFont("", 10)
+	lastDay := r.End.AddDate(0, 0, -1)
+	doc.cell(0, 6, fmt.Sprintf("%s - %s", r.Start.Format("January 2, 2006"), lastDay.Format("January 2, 2006")), "", 0, "", false)
+	pdf.Ln(12)
+
+	// Totals
+	doc.setFont("B", 12)
+	doc.cell(0, 8, "Totals", "", 0, "", false)
+	pdf.Ln(8)
+	doc.setFont("", 10)
+	grandTotal := 0
+	for _, child := range summary.Children {
+		grandTotal += child.Total
+		line := fmt.Sprintf("%s: %d books", child.Name, child.Total)
+		if child.Partial > 0 {
+			line += fmt.Sprintf(" (%d partially read)", child.Partial)
 		}
-		defer file.Close()
+		doc.cell(0, 6, doc.fit(line, 0), "", 0, "", false)
+		pdf.Ln(6)
+	}
+	showTotal := len(summary.Children) > 1
+	if showTotal {
+		doc.setFont("B", 10)
+		doc.cell(0, 6, fmt.Sprintf("All children: %d books", grandTotal), "", 0, "", false)
+		pdf.Ln(6)
+	}
+	pdf.Ln(6)
+
+	// Books per month
+	doc.setFont("B", 12)
+	doc.cell(0, 8, "Books per Month", "", 0, "", false)
+	pdf.Ln(8)
+
+	pageWidth, _ := pdf.GetPageSize()
+	leftMargin, _, rightMargin, _ := pdf.GetMargins()
+	columns := len(summary.Children) + 1
+	if showTotal {
+		columns++
+	}
+	columnWidth := (pageWidth - leftMargin - rightMargin) / float64(columns)
+	rowHeight := 5.0
 
-		// Copy image data
-		_, err = io.Copy(file, resp.Body)
-		if err != nil {
-			os.Remove(filepath)
-			continue
+	doc.setFont("B", 9)
+	pdf.SetFillColor(230, 230, 230)
+	pdf.SetDrawColor(200, 200, 200)
+	doc.cell(columnWidth, rowHeight, "Month", "1", 0, "L", true)
+	for _, child := range summary.Children {
+		doc.cell(columnWidth, rowHeight, doc.fit(child.Name, columnWidth), "1", 0, "C", true)
+	}
+	if showTotal {
+		doc.cell(columnWidth, rowHeight, "Total", "1", 0, "C", true)
+	}
+	pdf.Ln(-1)
+
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottomMargin := pdf.GetMargins()
+	doc.setFont("", 9)
+	for i, month := range summary.Months {
+		if pdf.GetY()+rowHeight > pageHeight-bottomMargin {
+			pdf.AddPage()
 		}
+		doc.cell(columnWidth, rowHeight, month.Format("January 2006"), "1", 0, "L", false)
+		monthTotal := 0
+		for _, child := range summary.Children {
+			monthTotal += child.PerMonth[i]
+			doc.cell(columnWidth, rowHeight, fmt.Sprintf("%d", child.PerMonth[i]), "1", 0, "C", false)
+		}
+		if showTotal {
+			doc.cell(columnWidth, rowHeight, fmt.Sprintf("%d", monthTotal), "1", 0, "C", false)
+		}
+		pdf.Ln(-1)
+	}
 
-		book.CoverImagePath = filepath
+	doc.setFont("B", 9)
+	doc.cell(columnWidth, rowHeight, "Total", "1", 0, "L", true)
+	for _, child := range summary.Children {
+		doc.cell(columnWidth, rowHeight, fmt.Sprintf("%d", child.Total), "1", 0, "C", true)
 	}
-	
-	return nil
+	if showTotal {
+		doc.cell(columnWidth, rowHeight, fmt.Sprintf("%d", grandTotal), "1", 0, "C", true)
+	}
+	pdf.Ln(-1)
+}
+
+// monthOfBooks is the books of one month, in reading order
+type monthOfBooks struct {
+	month time.Time
+	books []*BookForPDF
 }
 
-// cleanupCoverImages removes downloaded cover images
-func cleanupCoverImages(books []*BookForPDF) {
+// groupBooksByMonth splits books sorted by date into their months, skipping months without books
+func groupBooksByMonth(books []*BookForPDF) []monthOfBooks {
+	var groups []monthOfBooks
 	for _, book := range books {
-		if book.CoverImagePath != "" {
-			os.Remove(book.CoverImagePath)
+		month := time.Date(book.DateRead.Year(), book.DateRead.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if len(groups) == 0 || !groups[len(groups)-1].month.Equal(month) {
+			groups = append(groups, monthOfBooks{month: month})
 		}
+		groups[len(groups)-1].books = append(groups[len(groups)-1].books, book)
 	}
+	return groups
 }
 
-// createPDF generates the actual PDF document
-func createPDF(child *models.Child, books []*BookForPDF, year int, month int) (string, error) {
-	// Create PDF
-	pdf := gofpdf.New("P", "mm", "A4", "")
-	pdf.AddPage()
-	pdf.SetFont("Arial", "B", 16)
+// addSectionPage starts a page with the section header
+func addSectionPage(doc *reportDocument, header string) {
+	doc.pdf.AddPage()
+	doc.setFont("B", 16)
+	doc.cell(0, 10, doc.fit(header, 0), "", 0, "", false)
+	doc.pdf.Ln(15)
+}
 
-	// Header
-	monthName := time.Month(month).String()
-	header := fmt.Sprintf("%s %s - %s %d", child.FirstName, child.LastName, monthName, year)
-	pdf.Cell(0, 10, header)
-	pdf.Ln(15)
+// drawBookGrid draws books in a grid under the header, continuing on new pages as needed
+func drawBookGrid(doc *reportDocument, header string, books []*BookForPDF) {
+	pdf := doc.pdf
+	addSectionPage(doc, header)
 
-	// Page dimensions
+	// Page dimensions, below the page and section headers
 	pageWidth, pageHeight := pdf.GetPageSize()
-	leftMargin, topMargin, rightMargin, bottomMargin := pdf.GetMargins()
+	leftMargin, _, rightMargin, bottomMargin := pdf.GetMargins()
+	gridTop := pdf.GetY()
 	usableWidth := pageWidth - leftMargin - rightMargin
-	usableHeight := pageHeight - topMargin - bottomMargin - 25 // Reserve space for header
+	usableHeight := pageHeight - gridTop - bottomMargin
 
 	// Calculate layout: 4 columns, 8 rows = 32 books per page
 	cols := 4
-	rows := 8
+	rows := booksPerPage / cols
 	cellWidth := usableWidth / float64(cols)
 	cellHeight := usableHeight / float64(rows)
 
 	// Draw books in grid
 	for i, book := range books {
-		if i > 0 && i%32 == 0 {
+		if i > 0 && i%booksPerPage == 0 {
 			// Add new page every 32 books
-			pdf.AddPage()
-			pdf.SetFont("Arial", "B", 16)
-			pdf.Cell(0, 10, header)
-			pdf.Ln(15)
+			addSectionPage(doc, header)
 		}
 
 		// Calculate position
-		bookIndex := i % 32
+		bookIndex := i % booksPerPage
 		col := bookIndex % cols
 		row := bookIndex / cols
 
 		x := leftMargin + float64(col)*cellWidth
-		y := topMargin + 25 + float64(row)*cellHeight // 25 for header space
-
-		drawBookCell(pdf, book, x, y, cellWidth, cellHeight)
-	}
+		y := gridTop + float64(row)*cellHeight
 
-	// Save PDF
-	tempDir := os.TempDir()
-	pdfPath := filepath.Join(tempDir, fmt.Sprintf("books_report_%s_%s_%s_%d.pdf", 
-		child.FirstName, child.LastName, monthName, year))
-	
-	err := pdf.OutputFileAndClose(pdfPath)
-	if err != nil {
-		return "", err
+		drawBookCell(doc, book, x, y, cellWidth, cellHeight)
 	}
-
-	return pdfPath, nil
 }
 
 // drawBookCell draws a single book in the PDF grid
-func drawBookCell(pdf *gofpdf.Fpdf, book *BookForPDF, x, y, width, height float64) {
+func drawBookCell(doc *reportDocument, book *BookForPDF, x, y, width, height float64) {
+	pdf := doc.pdf
+
 	// Set position
 	pdf.SetXY(x, y)
 	
@@ -239,70 +592,82 @@ func drawBookCell(pdf *gofpdf.Fpdf, book *BookForPDF, x, y, width, height float6
 	imageY := y + 5
 	
 	// Add cover image if available
-	if book.CoverImagePath != "" && fileExists(book.CoverImagePath) {
-		// Try to add image, but continue if it fails
-		pdf.ImageOptions(book.CoverImagePath, imageX, imageY, imageWidth, imageHeight, 
-			false, gofpdf.ImageOptions{ImageType: "JPG", ReadDpi: false}, 0, "")
+	if registerCoverImage(pdf, book) {
+		pdf.ImageOptions(coverImageName(book), imageX, imageY, imageWidth, imageHeight,
+			false, gofpdf.ImageOptions{ImageType: book.CoverImageType, ReadDpi: false}, 0, "")
 	} else {
 		// Draw placeholder rectangle
 		pdf.SetFillColor(240, 240, 240)
 		pdf.Rect(imageX, imageY, imageWidth, imageHeight, "F")
 		pdf.SetXY(imageX, imageY+imageHeight/2-2)
-		pdf.SetFont("Arial", "", 8)
-		pdf.CellFormat(imageWidth, 4, "No Cover", "0", 0, "C", false, 0, "")
+		doc.setFont("", 8)
+		doc.cell(imageWidth, 4, "No Cover", "0", 0, "C", false)
 	}
 	
 	// Text area (bottom 40% of cell)
 	textY := y + imageHeight + 10
+	textWidth := width - 4
 	
 	pdf.SetXY(x+2, textY)
-	pdf.SetFont("Arial", "B", 8)
+	doc.setFont("B", 8)
 	
-	// Title (truncated to fit)
-	title := truncateString(book.Title, 25)
-	pdf.CellFormat(width-4, 3, title, "0", 1, "L", false, 0, "")
+	// Title, wrapped onto a second line before it is shortened
+	for _, line := range doc.wrap(book.Title, textWidth, 2) {
+		pdf.SetX(x+2)
+		doc.cell(textWidth, 3, line, "0", 1, "L", false)
+	}
 	
 	// Author
 	pdf.SetX(x+2)
-	pdf.SetFont("Arial", "", 7)
-	author := truncateString(book.Author, 30)
-	pdf.CellFormat(width-4, 3, author, "0", 1, "L", false, 0, "")
+	doc.setFont("", 7)
+	doc.cell(textWidth, 3, doc.fit(book.Author, textWidth), "0", 1, "L", false)
 	
 	// Date read
 	pdf.SetX(x+2)
 	dateStr := book.DateRead.Format("1/2/2006")
-	pdf.CellFormat(width-4, 3, dateStr, "0", 1, "L", false, 0, "")
+	doc.cell(textWidth, 3, dateStr, "0", 1, "L", false)
 	
 	// Lexile level (if available)
 	if book.LexileLevel != "" {
 		pdf.SetX(x+2)
-		pdf.CellFormat(width-4, 3, "Lexile: "+book.LexileLevel, "0", 1, "L", false, 0, "")
+		doc.cell(textWidth, 3, doc.fit("Lexile: "+book.LexileLevel, textWidth), "0", 1, "L", false)
 	}
 	
 	// ISBN (if available)
 	if book.ISBN != "" {
 		pdf.SetX(x+2)
-		isbn := truncateString(book.ISBN, 15)
-		pdf.CellFormat(width-4, 3, "ISBN: "+isbn, "0", 1, "L", false, 0, "")
+		doc.cell(textWidth, 3, doc.fit("ISBN: "+book.ISBN, textWidth), "0", 1, "L", false)
 	}
 	
 	// Partial comment (if available)
 	if book.IsPartial && book.PartialComment != "" {
 		pdf.SetX(x+2)
-		comment := truncateString(book.PartialComment, 30)
-		pdf.CellFormat(width-4, 3, "Note: "+comment, "0", 1, "L", false, 0, "")
+		doc.cell(textWidth, 3, doc.fit("Note: "+book.PartialComment, textWidth), "0", 1, "L", false)
 	}
 }
 
-// Helper functions
-func fileExists(filename string) bool {
-	_, err := os.Stat(filename)
-	return err == nil
-}
+// registerCoverImage adds the book's cover to the document once per shared book.
+// A cover gofpdf cannot parse is dropped instead of failing the whole report.
+func registerCoverImage(pdf *gofpdf.Fpdf, book *BookForPDF) bool {
+	if len(book.CoverImage) == 0 {
+		return false
+	}
+	name := coverImageName(book)
+	if pdf.GetImageInfo(name) != nil {
+		return true
+	}
 
-func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
+	options := gofpdf.ImageOptions{ImageType: book.CoverImageType, ReadDpi: false}
+	pdf.RegisterImageOptionsReader(name, options, bytes.NewReader(book.CoverImage))
+	if !pdf.Ok() {
+		log.Printf("Skipping unreadable cover of shared book %d: %v", book.SharedBookID, pdf.Error())
+		pdf.ClearError()
+		book.CoverImage = nil
+		return false
 	}
-	return s[:maxLen-3] + "..."
-}
\ No newline at end of file
+	return true
+}
+
+func coverImageName(book *BookForPDF) string {
+	return fmt.Sprintf("cover-%d", book.SharedBookID)
+}
@@ -0,0 +1,320 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type PDFReportTestSuite struct {
+	suite.Suite
+	owner *models.User
+	sam   *models.Child
+	alex  *models.Child
+}
+
+func (suite *PDFReportTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+	SharedPermissionCache().Clear()
+	SetCoverStore(NewDiskCoverStore(suite.T().TempDir()))
+	// Covers are served by local test servers
+	suite.T().Setenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS", "true")
+
+	owner, err := CreateUser(models.CreateUserRequest{
+		Email:     "owner@example.com",
+		Password:  "password123",
+		FirstName: "Owner",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	suite.owner = owner
+
+	suite.sam = suite.createChild("Sam")
+	suite.alex = suite.createChild("Alex")
+}
+
+func (suite *PDFReportTestSuite) TearDownTest() {
+	SetCoverStore(nil)
+	config.CleanupTestDatabase()
+}
+
+func (suite *PDFReportTestSuite) createChild(firstName string) *models.Child {
+	child, err := CreateChild(models.CreateChildRequest{
+		FirstName: firstName,
+		LastName:  "Reader",
+		Grade:     "3rd",
+	}, suite.owner.ID)
+	assert.NoError(suite.T(), err)
+	return child
+}
+
+func (suite *PDFReportTestSuite) readBook(child *models.Child, title, dateRead string) {
+	_, err := CreateCustomBook(models.CreateCustomBookRequest{
+		Title:    title,
+		Author:   "Author",
+		DateRead: dateRead,
+		ChildID:  child.ID,
+	}, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *PDFReportTestSuite) TestPresetRanges() {
+	month := MonthRange(2024, 12)
+	assert.Equal(suite.T(), time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), month.Start)
+	assert.Equal(suite.T(), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), month.End)
+	assert.Equal(suite.T(), "December 2024", month.Label)
+
+	schoolYear := SchoolYearRange(2024)
+	assert.Equal(suite.T(), time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC), schoolYear.Start)
+	assert.Equal(suite.T(), time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), schoolYear.End)
+	assert.Len(suite.T(), schoolYear.Months(), 12)
+
+	// The two semesters make up the school year
+	fall, err := SemesterRange(2024, SemesterFall)
+	assert.NoError(suite.T(), err)
+	spring, err := SemesterRange(2025, SemesterSpring)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), schoolYear.Start, fall.Start)
+	assert.Equal(suite.T(), fall.End, spring.Start)
+	assert.Equal(suite.T(), schoolYear.End, spring.End)
+	_, err = SemesterRange(2024, "summer")
+	assert.Error(suite.T(), err)
+
+	custom, err := DateRange(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), custom.End)
+	assert.Len(suite.T(), custom.Months(), 3)
+
+	_, err = DateRange(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	assert.Error(suite.T(), err)
+	_, err = DateRange(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PDFReportTestSuite) TestSummaryCountsBooksPerMonth() {
+	suite.readBook(suite.sam, "September Book", "2024-09-03")
+	suite.readBook(suite.sam, "Another September Book", "2024-09-20")
+	suite.readBook(suite.sam, "January Book", "2025-01-10")
+	suite.readBook(suite.alex, "October Book", "2024-10-05")
+	suite.readBook(suite.alex, "Summer Book", "2024-07-30") // Before the school year
+
+	schoolYear := SchoolYearRange(2024)
+	var sections []ChildBooks
+	for _, child := range []*models.Child{suite.sam, suite.alex} {
+		books, err := getBooksForRange(child.ID, schoolYear)
+		assert.NoError(suite.T(), err)
+		sections = append(sections, ChildBooks{Child: child, Books: books})
+	}
+
+	summary := summarizeReport(sections, schoolYear)
+	assert.Len(suite.T(), summary.Months, 12)
+	if assert.Len(suite.T(), summary.Children, 2) {
+		sam := summary.Children[0]
+		assert.Equal(suite.T(), "Sam Reader", sam.Name)
+		assert.Equal(suite.T(), 3, sam.Total)
+		assert.Equal(suite.T(), 2, sam.PerMonth[1]) // September
+		assert.Equal(suite.T(), 1, sam.PerMonth[5]) // January
+		assert.Equal(suite.T(), 1, summary.Children[1].Total)
+	}
+
+	groups := groupBooksByMonth(sections[0].Books)
+	if assert.Len(suite.T(), groups, 2) {
+		assert.Equal(suite.T(), time.September, groups[0].month.Month())
+		assert.Len(suite.T(), groups[0].books, 2)
+	}
+}
+
+func (suite *PDFReportTestSuite) TestGenerateFamilyReport() {
+	suite.readBook(suite.sam, "Family Book One", "2024-09-03")
+	suite.readBook(suite.alex, "Family Book Two", "2024-11-05")
+
+	fall, err := SemesterRange(2024, SemesterFall)
+	assert.NoError(suite.T(), err)
+	report, err := GenerateBooksPDFForChildren([]*models.Child{suite.sam, suite.alex}, fall, ReportTemplateGrid)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "books_report_Family_Fall_2024_Semester.pdf", report.Filename)
+
+	var output bytes.Buffer
+	assert.NoError(suite.T(), report.Write(&output))
+	assert.True(suite.T(), bytes.HasPrefix(output.Bytes(), []byte("%PDF")))
+
+	// A single month keeps the original file name
+	report, err = GenerateMonthlyBooksPDF(suite.sam.ID, 2024, 9)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "books_report_Sam_Reader_September_2024.pdf", report.Filename)
+}
+
+func (suite *PDFReportTestSuite) TestTemplates() {
+	for day := 1; day <= 28; day++ {
+		suite.readBook(suite.sam, fmt.Sprintf("Log Book %d", day), fmt.Sprintf("2024-09-%02d", day))
+		suite.readBook(suite.sam, fmt.Sprintf("Second Log Book %d", day), fmt.Sprintf("2024-09-%02d", day))
+	}
+
+	// The log continues onto a second page under the same headings
+	report, err := GenerateBooksPDF(suite.sam.ID, MonthRange(2024, 9), ReportTemplateLog)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "reading_log_Sam_Reader_September_2024.pdf", report.Filename)
+	assert.Equal(suite.T(), 2, report.pdf.PageNo())
+	assert.Contains(suite.T(), report.text, "p2 Parent Initials")
+	assert.Contains(suite.T(), report.text, "p2 Page 2 of 2")
+	assert.Contains(suite.T(), report.text, "p2 Total: 56 books")
+
+	// Alex read nothing, so only Sam gets a certificate
+	report, err = GenerateBooksPDFForChildren([]*models.Child{suite.sam, suite.alex}, MonthRange(2024, 9), ReportTemplateCertificate)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "certificate_Family_September_2024.pdf", report.Filename)
+	assert.Equal(suite.T(), 1, report.pdf.PageNo())
+	assert.Contains(suite.T(), report.text, "p1 for reading 56 books")
+
+	_, err = GenerateBooksPDF(suite.sam.ID, MonthRange(2024, 9), "poster")
+	assert.ErrorIs(suite.T(), err, ErrInvalidReportTemplate)
+}
+
+// serveCovers starts a cover host whose /cover/N returns an N pixel wide PNG, tracking how many
+// requests it serves at once and in total. /slow never answers in time and /broken is not an image.
+func (suite *PDFReportTestSuite) serveCovers() (*httptest.Server, *int32, *int32) {
+	var active, maxActive, requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		current := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			seen := atomic.LoadInt32(&maxActive)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxActive, seen, current) {
+				break
+			}
+		}
+
+		switch {
+		case r.URL.Path == "/slow":
+			select {
+			case <-time.After(2 * time.Second):
+			case <-r.Context().Done():
+			}
+			return
+		case r.URL.Path == "/broken":
+			w.Write([]byte("not an image"))
+			return
+		}
+
+		width, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/cover/"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "image/png")
+		png.Encode(w, image.NewRGBA(image.Rect(0, 0, width, 4)))
+	}))
+	suite.T().Cleanup(server.Close)
+	return server, &maxActive, &requests
+}
+
+func (suite *PDFReportTestSuite) readBookWithCover(child *models.Child, isbn, coverURL, dateRead string) {
+	sharedBook := models.SharedBook{ISBN: isbn, Title: "Book " + isbn, Author: "Author", CoverURL: coverURL}
+	assert.NoError(suite.T(), config.DB.Create(&sharedBook).Error)
+	_, err := CreateBook(models.CreateBookRequest{
+		SharedBookID: &sharedBook.ID,
+		DateRead:     dateRead,
+		ChildID:      child.ID,
+	}, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *PDFReportTestSuite) TestCoversAreFetchedOnABoundedPool() {
+	server, maxActive, requests := suite.serveCovers()
+	previousTimeout := coverFetchTimeout
+	coverFetchTimeout = 200 * time.Millisecond
+	defer func() { coverFetchTimeout = previousTimeout }()
+
+	for i := 0; i < 12; i++ {
+		suite.readBookWithCover(suite.sam, fmt.Sprintf("97800000001%02d", i), fmt.Sprintf("%s/cover/%d", server.URL, 10+i), "2024-09-10")
+	}
+	// Slow and broken covers are left out
+	suite.readBookWithCover(suite.sam, "9780000000200", server.URL+"/slow", "2024-09-11")
+	suite.readBookWithCover(suite.sam, "9780000000201", server.URL+"/broken", "2024-09-12")
+
+	books, err := getBooksForRange(suite.sam.ID, MonthRange(2024, 9))
+	assert.NoError(suite.T(), err)
+	start := time.Now()
+	fetchCoverImages(books)
+	assert.Less(suite.T(), time.Since(start), time.Second)
+
+	for _, book := range books {
+		if strings.HasSuffix(book.CoverURL, "/slow") || strings.HasSuffix(book.CoverURL, "/broken") {
+			assert.Empty(suite.T(), book.CoverImage, book.CoverURL)
+			continue
+		}
+		assert.NotEmpty(suite.T(), book.CoverImage, book.CoverURL)
+		assert.Equal(suite.T(), "PNG", book.CoverImageType)
+	}
+	assert.LessOrEqual(suite.T(), atomic.LoadInt32(maxActive), int32(coverFetchWorkers))
+
+	// Cached covers are not downloaded again; only the failed ones are retried
+	fetched := atomic.LoadInt32(requests)
+	books, err = getBooksForRange(suite.sam.ID, MonthRange(2024, 9))
+	assert.NoError(suite.T(), err)
+	fetchCoverImages(books)
+	assert.Equal(suite.T(), fetched+2, atomic.LoadInt32(requests))
+}
+
+func (suite *PDFReportTestSuite) TestConcurrentReportsDoNotInterfere() {
+	server, _, _ := suite.serveCovers()
+
+	// Each child's cover has its own width, which the PDF's image dictionary records in the clear
+	children := make([]*models.Child, 6)
+	for i := range children {
+		children[i] = suite.createChild(fmt.Sprintf("Kid%d", i))
+		suite.readBookWithCover(children[i], fmt.Sprintf("978000000000%d", i),
+			fmt.Sprintf("%s/cover/%d", server.URL, 20+i), "2024-09-10")
+	}
+
+	outputs := make([]bytes.Buffer, len(children))
+	errs := make([]error, len(children))
+	var wg sync.WaitGroup
+	for i := range children {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			report, err := GenerateMonthlyBooksPDFForChild(children[i], 2024, 9)
+			if err == nil {
+				err = report.Write(&outputs[i])
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range children {
+		assert.NoError(suite.T(), errs[i])
+		output := outputs[i].String()
+		assert.True(suite.T(), strings.HasPrefix(output, "%PDF"))
+		for j := range children {
+			width := fmt.Sprintf("/Width %d", 20+j)
+			if i == j {
+				assert.Contains(suite.T(), output, width)
+			} else {
+				assert.NotContains(suite.T(), output, width)
+			}
+		}
+	}
+}
+
+func TestPDFReportTestSuite(t *testing.T) {
+	suite.Run(t, new(PDFReportTestSuite))
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Report templates, chosen with the template parameter of the report endpoints
+const (
+	ReportTemplateGrid        = "grid"        // Cover grid, a section per month
+	ReportTemplateLog         = "log"         // Reading log table with columns to fill in by hand
+	ReportTemplateCertificate = "certificate" // Certificate of achievement per child
+)
+
+// ErrInvalidReportTemplate is returned for template names other than grid, log and certificate
+var ErrInvalidReportTemplate = errors.New("template must be grid, log or certificate")
+
+// reportTemplate lays out each child's pages; createPDF draws the summary page and the shared
+// page header and footer around them
+type reportTemplate interface {
+	// drawChild adds the pages for one child's books over the period
+	drawChild(doc *reportDocument, section ChildBooks, r ReportRange)
+	// showsSummary is whether reports over several months or children start with a summary page
+	showsSummary() bool
+	// needsCovers is whether cover images are loaded before drawing
+	needsCovers() bool
+	// filePrefix starts the report's download filename
+	filePrefix() string
+}
+
+var reportTemplates = map[string]reportTemplate{
+	ReportTemplateGrid:        gridTemplate{},
+	ReportTemplateLog:         logTemplate{},
+	ReportTemplateCertificate: certificateTemplate{},
+}
+
+// IsReportTemplate reports whether name is one of the report templates
+func IsReportTemplate(name string) bool {
+	_, ok := reportTemplates[name]
+	return ok
+}
+
+// setPageHeaderAndFooter draws the report title above and the page number below every page
+func setPageHeaderAndFooter(doc *reportDocument, title string) {
+	pdf := doc.pdf
+	pdf.SetHeaderFunc(func() {
+		// gofpdf restores its own font after the header; the document's copy is restored here
+		style, size := doc.style, doc.size
+		defer func() { doc.style, doc.size = style, size }()
+
+		pageWidth, _ := pdf.GetPageSize()
+		leftMargin, topMargin, rightMargin, _ := pdf.GetMargins()
+		pdf.SetTextColor(120, 120, 120)
+		doc.setFont("", 8)
+		doc.cell(0, 5, doc.fit(title, 0), "", 0, "L", false)
+		pdf.SetDrawColor(200, 200, 200)
+		pdf.Line(leftMargin, topMargin+6, pageWidth-rightMargin, topMargin+6)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetY(topMargin + 10)
+	})
+	pdf.SetFooterFunc(func() {
+		style, size := doc.style, doc.size
+		defer func() { doc.style, doc.size = style, size }()
+
+		pdf.SetY(-15)
+		pdf.SetTextColor(120, 120, 120)
+		doc.setFont("", 8)
+		doc.cell(0, 5, fmt.Sprintf("Page %d of %s", pdf.PageNo(), pageCountAlias), "", 0, "C", false)
+		pdf.SetTextColor(0, 0, 0)
+	})
+}
+
+// gridTemplate is the cover grid: a section per month with books, headed by the child and month
+type gridTemplate struct{}
+
+func (gridTemplate) drawChild(doc *reportDocument, section ChildBooks, r ReportRange) {
+	childName := strings.TrimSpace(section.Child.FirstName + " " + section.Child.LastName)
+	for _, group := range groupBooksByMonth(section.Books) {
+		header := fmt.Sprintf("%s - %s %d", childName, group.month.Month(), group.month.Year())
+		drawBookGrid(doc, header, group.books)
+	}
+}
+
+func (gridTemplate) showsSummary() bool { return true }
+func (gridTemplate) needsCovers() bool  { return true }
+func (gridTemplate) filePrefix() string { return "books_report" }
+
+// logTemplate is a reading log table, as schools ask for. Minutes and parent initials are not
+// tracked, so those columns are left blank to fill in by hand.
+type logTemplate struct{}
+
+// logColumns are the log's column headings and widths, filling the 190mm between the margins
+var logColumns = []struct {
+	heading string
+	width   float64
+}{
+	{"Date", 22},
+	{"Title", 70},
+	{"Author", 48},
+	{"Minutes", 20},
+	{"Parent Initials", 30},
+}
+
+const logLineHeight = 4.5
+
+func (logTemplate) drawChild(doc *reportDocument, section ChildBooks, r ReportRange) {
+	pdf := doc.pdf
+	childName := strings.TrimSpace(section.Child.FirstName + " " + section.Child.LastName)
+	header := fmt.Sprintf("%s - Reading Log", childName)
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottomMargin := pdf.GetMargins()
+
+	addSectionPage(doc, header)
+	drawLogHeadings(doc)
+
+	if len(section.Books) == 0 {
+		doc.setFont("", 9)
+		doc.cell(0, 8, "No books read in "+r.Label, "1", 1, "C", false)
+		return
+	}
+
+	for _, book := range section.Books {
+		title := book.Title
+		if book.IsPartial {
+			title += " (partial)"
+		}
+
+		doc.setFont("", 9)
+		cells := [][]string{
+			{book.DateRead.Format("1/2/2006")},
+			doc.wrap(title, logColumns[1].width, 2),
+			doc.wrap(book.Author, logColumns[2].width, 2),
+			nil,
+			nil,
+		}
+		lines := 1
+		for _, cell := range cells {
+			lines = max(lines, len(cell))
+		}
+		rowHeight := float64(lines)*logLineHeight + 2
+
+		// Continue the table on a new page, headings and all
+		if pdf.GetY()+rowHeight > pageHeight-bottomMargin {
+			addSectionPage(doc, header)
+			drawLogHeadings(doc)
+			doc.setFont("", 9)
+		}
+
+		left, y := pdf.GetXY()
+		x := left
+		for i, column := range logColumns {
+			pdf.Rect(x, y, column.width, rowHeight, "D")
+			for j, line := range cells[i] {
+				pdf.SetXY(x, y+1+float64(j)*logLineHeight)
+				doc.cell(column.width, logLineHeight, line, "", 0, "L", false)
+			}
+			x += column.width
+		}
+		pdf.SetXY(left, y+rowHeight)
+	}
+
+	pdf.Ln(4)
+	doc.setFont("B", 10)
+	doc.cell(0, 6, fmt.Sprintf("Total: %s", bookCount(len(section.Books))), "", 1, "L", false)
+}
+
+// drawLogHeadings draws the log's heading row at the current position
+func drawLogHeadings(doc *reportDocument) {
+	doc.setFont("B", 9)
+	doc.pdf.SetFillColor(230, 230, 230)
+	doc.pdf.SetDrawColor(200, 200, 200)
+	for _, column := range logColumns {
+		doc.cell(column.width, 7, column.heading, "1", 0, "L", true)
+	}
+	doc.pdf.Ln(-1)
+}
+
+func (logTemplate) showsSummary() bool { return true }
+func (logTemplate) needsCovers() bool  { return false }
+func (logTemplate) filePrefix() string { return "reading_log" }
+
+// certificateTemplate is a landscape certificate of achievement for each child who read
+// something in the period
+type certificateTemplate struct{}
+
+func (certificateTemplate) drawChild(doc *reportDocument, section ChildBooks, r ReportRange) {
+	if len(section.Books) == 0 {
+		return
+	}
+	pdf := doc.pdf
+	pdf.AddPageFormat("L", pdf.GetPageSizeStr("A4"))
+	pageWidth, pageHeight := pdf.GetPageSize()
+
+	// Double border
+	pdf.SetDrawColor(180, 140, 40)
+	pdf.SetLineWidth(1.2)
+	pdf.Rect(14, 20, pageWidth-28, pageHeight-40, "D")
+	pdf.SetLineWidth(0.4)
+	pdf.Rect(18, 24, pageWidth-36, pageHeight-48, "D")
+	pdf.SetLineWidth(0.2)
+
+	centered := func(y float64, style string, size float64, text string) {
+		pdf.SetXY(24, y)
+		doc.setFont(style, size)
+		doc.cell(pageWidth-48, size/2, doc.fit(text, pageWidth-48), "", 0, "C", false)
+	}
+
+	childName := strings.TrimSpace(section.Child.FirstName + " " + section.Child.LastName)
+	partial := 0
+	for _, book := range section.Books {
+		if book.IsPartial {
+			partial++
+		}
+	}
+	achievement := "for reading " + bookCount(len(section.Books))
+	if partial > 0 {
+		achievement += fmt.Sprintf(" (%d partially)", partial)
+	}
+
+	pdf.SetTextColor(120, 90, 20)
+	centered(42, "B", 34, "Certificate of Achievement")
+	pdf.SetTextColor(0, 0, 0)
+	centered(70, "", 14, "This certificate is proudly presented to")
+	centered(85, "B", 30, childName)
+	pdf.SetDrawColor(180, 140, 40)
+	pdf.Line(pageWidth/2-70, 103, pageWidth/2+70, 103)
+	centered(112, "", 16, achievement)
+	centered(122, "", 14, r.Label)
+
+	// Date and signature lines
+	lineY := pageHeight - 48
+	lastDay := r.End.AddDate(0, 0, -1)
+	for i, label := range []string{"Date", "Signature"} {
+		x := 50 + float64(i)*(pageWidth-180)
+		pdf.SetDrawColor(0, 0, 0)
+		pdf.Line(x, lineY, x+80, lineY)
+		if i == 0 {
+			pdf.SetXY(x, lineY-7)
+			doc.setFont("", 11)
+			doc.cell(80, 6, lastDay.Format("January 2, 2006"), "", 0, "C", false)
+		}
+		pdf.SetXY(x, lineY+1)
+		doc.setFont("", 10)
+		doc.cell(80, 5, label, "", 0, "C", false)
+	}
+}
+
+func (certificateTemplate) showsSummary() bool { return false }
+func (certificateTemplate) needsCovers() bool  { return false }
+func (certificateTemplate) filePrefix() string { return "certificate" }
+
+// bookCount is "1 book" or "n books"
+func bookCount(n int) string {
+	if n == 1 {
+		return "1 book"
+	}
+	return fmt.Sprintf("%d books", n)
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"embed"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jung-kurt/gofpdf"
+	"golang.org/x/image/font/sfnt"
+)
+
+// Reports draw text in DejaVu Sans Condensed, a UTF-8 TrueType font embedded in the PDF, so
+// accented, Cyrillic and Greek titles print as written. It has no CJK glyphs: a TrueType font
+// that does, such as Noto Sans CJK in TTF form, can be set in PDF_FALLBACK_FONT and is used
+// for the characters DejaVu lacks. Without one, those characters print as empty boxes, and
+// each report logs which characters they were.
+const (
+	reportFontFamily   = "DejaVu"
+	fallbackFontFamily = "Fallback"
+	ellipsis           = "…"
+	pageCountAlias     = "{nb}" // Replaced with the number of pages when the PDF is written
+)
+
+//go:embed fonts/DejaVuSansCondensed.ttf fonts/DejaVuSansCondensed-Bold.ttf
+var reportFontFS embed.FS
+
+// reportFont is a TrueType font and the glyph table used to tell which characters it covers
+type reportFont struct {
+	data   []byte
+	glyphs *sfnt.Font
+}
+
+// bytes returns a copy of the font file for one document: gofpdf writes into the slice it is
+// given, so documents built concurrently must not share it
+func (f *reportFont) bytes() []byte {
+	return append([]byte(nil), f.data...)
+}
+
+func (f *reportFont) covers(r rune) bool {
+	var buffer sfnt.Buffer
+	index, err := f.glyphs.GlyphIndex(&buffer, r)
+	return err == nil && index != 0
+}
+
+var (
+	reportFonts     map[string]*reportFont // by style: "" and "B"
+	fallbackFont    *reportFont
+	reportFontsOnce sync.Once
+)
+
+// loadReportFonts parses the embedded fonts and the optional fallback font once per process
+func loadReportFonts() {
+	reportFontsOnce.Do(func() {
+		reportFonts = make(map[string]*reportFont, 2)
+		for style, name := range map[string]string{"": "DejaVuSansCondensed.ttf", "B": "DejaVuSansCondensed-Bold.ttf"} {
+			font, err := parseReportFont(reportFontFS.ReadFile("fonts/" + name))
+			if err != nil {
+				// The fonts are compiled in, so this only happens to a broken build
+				panic(fmt.Sprintf("embedded report font %s: %v", name, err))
+			}
+			reportFonts[style] = font
+		}
+
+		if path := os.Getenv("PDF_FALLBACK_FONT"); path != "" {
+			font, err := parseReportFont(os.ReadFile(path))
+			if err != nil {
+				log.Printf("Ignoring PDF_FALLBACK_FONT %s: %v", path, err)
+				return
+			}
+			fallbackFont = font
+		}
+	})
+}
+
+func parseReportFont(data []byte, err error) (*reportFont, error) {
+	if err != nil {
+		return nil, err
+	}
+	glyphs, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return &reportFont{data: data, glyphs: glyphs}, nil
+}
+
+// reportDocument wraps the PDF with Unicode-aware text drawing. Every string drawn is also
+// recorded by page, which is what the golden-file tests compare.
+type reportDocument struct {
+	pdf   *gofpdf.Fpdf
+	style string
+	size  float64
+	text  []string
+
+	fallbackAdded bool
+	uncovered     string // Characters drawn that neither font covers, once each
+}
+
+func newReportDocument() *reportDocument {
+	loadReportFonts()
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	// Layouts start new pages themselves; gofpdf breaking a row in half is never wanted
+	pdf.SetAutoPageBreak(false, 20)
+	// Set before the fonts are added, so the alias's characters are kept in their subsets
+	pdf.AliasNbPages(pageCountAlias)
+	for style, font := range reportFonts {
+		pdf.AddUTF8FontFromBytes(reportFontFamily, style, font.bytes())
+	}
+	return &reportDocument{pdf: pdf}
+}
+
+// setFont selects the report font in the given style ("" or "B") and size in points
+func (d *reportDocument) setFont(style string, size float64) {
+	d.style, d.size = style, size
+	d.pdf.SetFont(reportFontFamily, style, size)
+}
+
+// textRun is a stretch of text drawn in a single font family
+type textRun struct {
+	family string
+	text   string
+}
+
+// runs splits text into the stretches the report font covers and the ones left to the fallback
+func (d *reportDocument) runs(text string) []textRun {
+	var runs []textRun
+	for _, r := range text {
+		family := reportFontFamily
+		if fallbackFont != nil && !unicode.IsSpace(r) && !reportFonts[d.style].covers(r) && fallbackFont.covers(r) {
+			family = fallbackFontFamily
+		}
+		if len(runs) > 0 && runs[len(runs)-1].family == family {
+			runs[len(runs)-1].text += string(r)
+		} else {
+			runs = append(runs, textRun{family: family, text: string(r)})
+		}
+	}
+	return runs
+}
+
+// useFamily switches between the report font and the fallback font at the current style and size
+func (d *reportDocument) useFamily(family string) {
+	if family == fallbackFontFamily && !d.fallbackAdded {
+		// Only reports that need it carry the (usually large) fallback font
+		d.pdf.AddUTF8FontFromBytes(fallbackFontFamily, "", fallbackFont.bytes())
+		d.pdf.AddUTF8FontFromBytes(fallbackFontFamily, "B", fallbackFont.bytes())
+		d.fallbackAdded = true
+	}
+	d.pdf.SetFont(family, d.style, d.size)
+}
+
+// textWidth is the width of text in the current font, in millimetres
+func (d *reportDocument) textWidth(text string) float64 {
+	text = cleanPDFText(text)
+	width := 0.0
+	for _, run := range d.runs(text) {
+		d.useFamily(run.family)
+		width += d.pdf.GetStringWidth(run.text)
+	}
+	d.useFamily(reportFontFamily)
+	return width
+}
+
+// cell draws text like gofpdf's CellFormat; a width of 0 extends the cell to the right margin.
+// Text is not clipped, so callers fit or wrap it first.
+func (d *reportDocument) cell(w, h float64, text, border string, ln int, align string, fill bool) {
+	text = cleanPDFText(text)
+	if text != "" {
+		d.text = append(d.text, fmt.Sprintf("p%d %s", d.pdf.PageNo(), text))
+	}
+	d.noteUncovered(text)
+
+	runs := d.runs(text)
+	if len(runs) <= 1 {
+		d.pdf.CellFormat(w, h, text, border, ln, align, fill, 0, "")
+		return
+	}
+
+	// Mixed fonts: draw the empty cell for its border, fill and cursor movement, then each
+	// run at the baseline CellFormat would have used
+	x, y := d.pdf.GetXY()
+	w = d.cellWidth(w)
+	width := d.textWidth(text)
+	d.pdf.CellFormat(w, h, "", border, ln, align, fill, 0, "")
+	afterX, afterY := d.pdf.GetXY()
+
+	margin := d.pdf.GetCellMargin()
+	textX := x + margin
+	switch {
+	case strings.Contains(align, "C"):
+		textX = x + (w-width)/2
+	case strings.Contains(align, "R"):
+		textX = x + w - margin - width
+	}
+	_, fontSize := d.pdf.GetFontSize()
+	baseline := y + h/2 + 0.3*fontSize
+	for _, run := range runs {
+		d.useFamily(run.family)
+		d.pdf.Text(textX, baseline, run.text)
+		textX += d.pdf.GetStringWidth(run.text)
+	}
+	d.useFamily(reportFontFamily)
+	d.pdf.SetXY(afterX, afterY)
+}
+
+// noteUncovered records the characters of text that no embedded font has a glyph for
+func (d *reportDocument) noteUncovered(text string) {
+	for _, r := range text {
+		if unicode.IsSpace(r) || reportFonts[d.style].covers(r) || (fallbackFont != nil && fallbackFont.covers(r)) {
+			continue
+		}
+		if !strings.ContainsRune(d.uncovered, r) {
+			d.uncovered += string(r)
+		}
+	}
+}
+
+// cellWidth resolves a cell width of 0 to the space left before the right margin
+func (d *reportDocument) cellWidth(w float64) float64 {
+	if w == 0 {
+		pageWidth, _ := d.pdf.GetPageSize()
+		_, _, rightMargin, _ := d.pdf.GetMargins()
+		w = pageWidth - rightMargin - d.pdf.GetX()
+	}
+	return w
+}
+
+// fit shortens text with an ellipsis until it fits a cell of width w (0 for the rest of the line),
+// cutting between characters rather than bytes
+func (d *reportDocument) fit(text string, w float64) string {
+	text = cleanPDFText(text)
+	available := d.cellWidth(w) - 2*d.pdf.GetCellMargin()
+	if d.textWidth(text) <= available {
+		return text
+	}
+	runes := []rune(text)
+	for n := len(runes) - 1; n > 0; n-- {
+		shortened := strings.TrimRightFunc(string(runes[:n]), unicode.IsSpace) + ellipsis
+		if d.textWidth(shortened) <= available {
+			return shortened
+		}
+	}
+	return ellipsis
+}
+
+// wrap breaks text into at most maxLines lines that fit a cell of width w. Lines break between
+// words where possible and between characters otherwise, which is how CJK text without spaces
+// wraps; text left over after the last line is cut with an ellipsis.
+func (d *reportDocument) wrap(text string, w float64, maxLines int) []string {
+	available := d.cellWidth(w) - 2*d.pdf.GetCellMargin()
+	var lines []string
+	line := ""
+	rest := strings.Fields(cleanPDFText(text))
+	for len(rest) > 0 {
+		candidate := rest[0]
+		if line != "" {
+			candidate = line + " " + rest[0]
+		}
+		if d.textWidth(candidate) <= available {
+			line = candidate
+			rest = rest[1:]
+			continue
+		}
+
+		if maxLines > 0 && len(lines) == maxLines-1 {
+			return append(lines, d.fit(strings.TrimSpace(line+" "+strings.Join(rest, " ")), w))
+		}
+		if line == "" {
+			// A single word wider than the line is split between characters
+			var head string
+			head, rest[0] = d.splitToWidth(rest[0], available)
+			lines = append(lines, head)
+		} else {
+			lines = append(lines, line)
+			line = ""
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitToWidth returns the longest prefix of word that fits, at least one character, and the rest
+func (d *reportDocument) splitToWidth(word string, available float64) (string, string) {
+	runes := []rune(word)
+	n := 1
+	for n < len(runes) && d.textWidth(string(runes[:n+1])) <= available {
+		n++
+	}
+	return string(runes[:n]), string(runes[n:])
+}
+
+// cleanPDFText replaces what the PDF fonts cannot encode: invalid UTF-8 and characters outside
+// the Basic Multilingual Plane, such as emoji, become U+FFFD and control characters become spaces
+func cleanPDFText(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == utf8.RuneError || r > 0xFFFF:
+			return '\uFFFD'
+		case unicode.IsControl(r):
+			return ' '
+		}
+		return r
+	}, text)
+}
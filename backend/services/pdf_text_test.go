@@ -0,0 +1,236 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/booktracker/backend/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenReportBooks are the fixture books of the golden-file tests, with titles in several scripts
+func goldenReportBooks() []*BookForPDF {
+	date := func(day int) time.Time { return time.Date(2024, 9, day, 0, 0, 0, 0, time.UTC) }
+	return []*BookForPDF{
+		{Title: "Días de Perros", Author: "Alberto Sánchez Piñol", ISBN: "9788498381498", DateRead: date(2)},
+		{Title: "Приключения Незнайки и его друзей", Author: "Николай Носов", DateRead: date(5)},
+		{Title: "Η Οδύσσεια για παιδιά", Author: "Όμηρος", LexileLevel: "720L", DateRead: date(9)},
+		{Title: "はらぺこあおむし", Author: "エリック・カール", DateRead: date(12)},
+		{Title: "ぐりとぐらのおきゃくさまとクリスマスのえほんシリーズ", Author: "なかがわりえこ", DateRead: date(14)},
+		{Title: "The Extraordinarily Long and Winding Title of a Book That Does Not Fit", Author: "Gabriel García Márquez y Compañía Editorial", DateRead: date(20)},
+		{Title: "Le Petit Prince", Author: "Antoine de Saint-Exupéry", IsPartial: true, PartialComment: "Stopped at the fox 🦊, will finish", DateRead: date(28)},
+	}
+}
+
+// testFallbackFont builds a minimal TrueType font with a full-width square for each character
+// of text, standing in for a CJK font set in PDF_FALLBACK_FONT. Only BMP characters are mapped.
+func testFallbackFont(text string) []byte {
+	seen := make(map[rune]bool)
+	var runes []int
+	for _, r := range text {
+		if r < 0xFFFF && !seen[r] {
+			seen[r] = true
+			runes = append(runes, int(r))
+		}
+	}
+	sort.Ints(runes)
+
+	table := func(values ...interface{}) []byte {
+		var buffer bytes.Buffer
+		for _, value := range values {
+			binary.Write(&buffer, binary.BigEndian, value)
+		}
+		return buffer.Bytes()
+	}
+	const em = 1000
+
+	// Glyph 0 (.notdef) is empty; glyph 1 is one square contour
+	square := table(int16(1), int16(50), int16(-100), int16(950), int16(800), uint16(3), uint16(0),
+		[]uint8{1, 1, 1, 1}, []int16{50, 900, 0, -900}, []int16{-100, 0, 900, 0})
+
+	// cmap format 4: one segment per character, shifted onto glyph 1, then the closing segment
+	segments := len(runes) + 1
+	var ends, starts, deltas []uint16
+	for _, r := range runes {
+		ends, starts, deltas = append(ends, uint16(r)), append(starts, uint16(r)), append(deltas, uint16(1-r))
+	}
+	ends, starts, deltas = append(ends, 0xFFFF), append(starts, 0xFFFF), append(deltas, 1)
+	searchRange := 2
+	for searchRange*2 <= segments*2 {
+		searchRange *= 2
+	}
+	subtable := table(uint16(4), uint16(16+8*segments), uint16(0), uint16(2*segments), uint16(searchRange),
+		uint16(0), uint16(2*segments-searchRange), ends, uint16(0), starts, deltas, make([]uint16, segments))
+	for entrySelector := searchRange / 2; entrySelector > 1; entrySelector /= 2 {
+		subtable[9]++
+	}
+
+	tables := map[string][]byte{
+		"cmap": append(table(uint16(0), uint16(1), uint16(3), uint16(1), uint32(12)), subtable...),
+		"glyf": square,
+		"head": table(uint32(0x10000), uint32(0x10000), uint32(0), uint32(0x5F0F3CF5), uint16(0), uint16(em),
+			int64(0), int64(0), int16(50), int16(-100), int16(950), int16(800), uint16(0), uint16(8),
+			int16(2), int16(1), int16(0)),
+		"hhea": table(uint32(0x10000), int16(880), int16(-120), int16(0), uint16(em), int16(50), int16(50),
+			int16(950), int16(1), int16(0), int16(0), [4]int16{}, int16(0), uint16(2)),
+		"hmtx": table(uint16(em), int16(0), uint16(em), int16(50)),
+		"loca": table(uint32(0), uint32(0), uint32(len(square))),
+		"maxp": table(uint32(0x10000), uint16(2), uint16(4), uint16(1), [10]uint16{}, uint16(0)),
+		"name": table(uint16(0), uint16(0), uint16(6)),
+		"post": table(uint32(0x30000), uint32(0), int16(-100), int16(50), [5]uint32{}),
+	}
+
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	font := table(uint32(0x10000), uint16(len(tags)), uint16(128), uint16(3), uint16(16*len(tags)-128))
+	offset := len(font) + 16*len(tags)
+	var data []byte
+	for _, tag := range tags {
+		contents := tables[tag]
+		for len(contents)%4 != 0 {
+			contents = append(contents, 0)
+		}
+		var checksum uint32
+		for i := 0; i < len(contents); i += 4 {
+			checksum += binary.BigEndian.Uint32(contents[i:])
+		}
+		font = append(font, table([]byte(tag), checksum, uint32(offset+len(data)), uint32(len(tables[tag])))...)
+		data = append(data, contents...)
+	}
+	return append(font, data...)
+}
+
+// useTestFallbackFont sets the fallback font to one covering the CJK characters of the fixtures
+// for the rest of the test
+func useTestFallbackFont(t *testing.T) {
+	loadReportFonts()
+	text := "ゆきたなか"
+	for _, book := range goldenReportBooks() {
+		text += book.Title + book.Author
+	}
+	var cjk strings.Builder
+	for _, r := range text {
+		if !reportFonts[""].covers(r) {
+			cjk.WriteRune(r)
+		}
+	}
+
+	font, err := parseReportFont(testFallbackFont(cjk.String()), nil)
+	assert.NoError(t, err)
+	previous := fallbackFont
+	fallbackFont = font
+	t.Cleanup(func() { fallbackFont = previous })
+}
+
+func goldenReport(t *testing.T, sections []ChildBooks, r ReportRange, template, name string) {
+	useTestFallbackFont(t)
+	report, err := createPDF(sections, r, reportTemplates[template])
+	assert.NoError(t, err)
+	// Every character drawn has a glyph in one of the embedded fonts, rather than printing as a box
+	assert.Empty(t, report.uncovered)
+
+	var output bytes.Buffer
+	assert.NoError(t, report.Write(&output))
+	// The text is set in the embedded TrueType font, not a core PDF font
+	assert.Contains(t, output.String(), "/FontFile2")
+	assert.Contains(t, output.String(), "/CIDFontType2")
+	assert.NotContains(t, output.String(), "/Helvetica")
+
+	got := strings.Join(report.text, "\n") + "\n"
+	path := filepath.Join("testdata", name+".golden")
+	if *updateGolden {
+		assert.NoError(t, os.MkdirAll("testdata", 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+	}
+	want, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, string(want), got, "run go test ./services -run Golden -update after an intended change")
+}
+
+func TestGoldenChildReport(t *testing.T) {
+	child := &models.Child{FirstName: "Zoë", LastName: "Ñúñez"}
+	goldenReport(t, []ChildBooks{{Child: child, Books: goldenReportBooks()}}, MonthRange(2024, 9), ReportTemplateGrid, "pdf_report_child")
+}
+
+func TestGoldenFamilyReport(t *testing.T) {
+	zoe := &models.Child{FirstName: "Zoë", LastName: "Ñúñez"}
+	yuki := &models.Child{FirstName: "ゆき", LastName: "たなか"}
+	books := goldenReportBooks()
+	goldenReport(t, []ChildBooks{
+		{Child: zoe, Books: books[:3]},
+		{Child: yuki, Books: books[3:]},
+	}, SchoolYearRange(2024), ReportTemplateGrid, "pdf_report_family")
+}
+
+func TestGoldenLogReport(t *testing.T) {
+	child := &models.Child{FirstName: "Zoë", LastName: "Ñúñez"}
+	goldenReport(t, []ChildBooks{{Child: child, Books: goldenReportBooks()}}, MonthRange(2024, 9), ReportTemplateLog, "pdf_report_log")
+}
+
+func TestGoldenCertificateReport(t *testing.T) {
+	zoe := &models.Child{FirstName: "Zoë", LastName: "Ñúñez"}
+	yuki := &models.Child{FirstName: "ゆき", LastName: "たなか"}
+	idle := &models.Child{FirstName: "Idle", LastName: "Reader"}
+	fall, err := SemesterRange(2024, SemesterFall)
+	assert.NoError(t, err)
+	books := goldenReportBooks()
+	goldenReport(t, []ChildBooks{
+		{Child: zoe, Books: books[:1]},
+		{Child: yuki, Books: books[1:]},
+		{Child: idle}, // Nothing read, so no certificate
+	}, fall, ReportTemplateCertificate, "pdf_report_certificate")
+}
+
+func TestFitAndWrapMeasureWidthNotBytes(t *testing.T) {
+	doc := newReportDocument()
+	doc.pdf.AddPage()
+	doc.setFont("B", 8)
+
+	// Multi-byte titles that fit are left alone
+	assert.Equal(t, "Días de Perros", doc.fit("Días de Perros", 40))
+
+	// Cut titles stay valid UTF-8 and fit the cell
+	for _, title := range []string{
+		"Приключения Незнайки и его друзей",
+		"ぐりとぐらのおきゃくさまとクリスマスのえほんシリーズ",
+		"ÁÉÍÓÚÁÉÍÓÚÁÉÍÓÚÁÉÍÓÚÁÉÍÓÚÁÉÍÓÚ",
+	} {
+		fitted := doc.fit(title, 30)
+		assert.True(t, utf8.ValidString(fitted), fitted)
+		assert.True(t, strings.HasSuffix(fitted, ellipsis), fitted)
+		assert.LessOrEqual(t, doc.textWidth(fitted), 30-2*doc.pdf.GetCellMargin())
+	}
+
+	// Narrow letters fit more characters than wide ones in the same width
+	narrow := []rune(doc.fit(strings.Repeat("i", 80), 30))
+	wide := []rune(doc.fit(strings.Repeat("W", 80), 30))
+	assert.Greater(t, len(narrow), len(wide))
+
+	// Words wrap whole; text without spaces breaks between characters
+	lines := doc.wrap("The Extraordinarily Long and Winding Title", 30, 3)
+	assert.Greater(t, len(lines), 1)
+	for _, line := range lines {
+		assert.Equal(t, strings.TrimSpace(line), line)
+		assert.LessOrEqual(t, doc.textWidth(line), 30-2*doc.pdf.GetCellMargin())
+	}
+	lines = doc.wrap("ぐりとぐらのおきゃくさまとクリスマスのえほんシリーズ", 20, 2)
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasSuffix(lines[1], ellipsis))
+	assert.Equal(t, []string{"Short"}, doc.wrap("Short", 30, 2))
+
+	// Emoji and control characters cannot be encoded in the PDF fonts
+	assert.Equal(t, "Fox � here", cleanPDFText("Fox 🦊\nhere"))
+}
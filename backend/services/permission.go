@@ -1,20 +1,43 @@
 package services
 
 import (
+	"errors"
+
 	"github.com/booktracker/backend/config"
 	"github.com/booktracker/backend/models"
+	"gorm.io/gorm"
 )
 
 // CreatePermission creates a new permission for a user to access a child's data
-func CreatePermission(userID, childID uint, permissionType string) error {
+func CreatePermission(userID, childID uint, permissionType string, actor Actor) error {
 	// Check if permission already exists
 	var existingPermission models.Permission
 	result := config.DB.Where("user_id = ? AND child_id = ?", userID, childID).First(&existingPermission)
 	
 	if result.Error == nil {
 		// Permission exists, update it
+		before := existingPermission
 		existingPermission.PermissionType = permissionType
-		return config.DB.Save(&existingPermission).Error
+		if err := config.DB.Save(&existingPermission).Error; err != nil {
+			return err
+		}
+		invalidateUserPermissions(userID)
+
+		fireChange(ChangeEvent{
+			Actor:      actor,
+			Action:     ActionUpdate,
+			EntityType: EntityPermission,
+			EntityID:   existingPermission.ID,
+			ChildID:    childID,
+			Before:     before,
+			After:      existingPermission,
+		})
+		return nil
+	}
+
+	// Drop a trashed grant for the same pair so the unique index allows a fresh one
+	if err := config.DB.Unscoped().Where("user_id = ? AND child_id = ? AND deleted_at IS NOT NULL", userID, childID).Delete(&models.Permission{}).Error; err != nil {
+		return err
 	}
 
 	// Create new permission
@@ -24,7 +47,20 @@ func CreatePermission(userID, childID uint, permissionType string) error {
 		PermissionType: permissionType,
 	}
 
-	return config.DB.Create(&permission).Error
+	if err := config.DB.Create(&permission).Error; err != nil {
+		return err
+	}
+	invalidateUserPermissions(userID)
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionCreate,
+		EntityType: EntityPermission,
+		EntityID:   permission.ID,
+		ChildID:    childID,
+		After:      permission,
+	})
+	return nil
 }
 
 // GetPermissionsByUser gets all permissions for a specific user
@@ -43,8 +79,16 @@ func GetPermissionsByChild(childID uint) ([]models.Permission, error) {
 
 
 // DeletePermission removes a permission
-func DeletePermission(userID, childID uint) error {
-	return config.DB.Where("user_id = ? AND child_id = ?", userID, childID).Delete(&models.Permission{}).Error
+func DeletePermission(userID, childID uint, actor Actor) error {
+	var permission models.Permission
+	if err := config.DB.Where("user_id = ? AND child_id = ?", userID, childID).First(&permission).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return DeletePermissionByID(permission.ID, actor)
 }
 
 // GetPermissionByID gets a permission by ID
@@ -58,11 +102,29 @@ func GetPermissionByID(permissionID uint) (*models.Permission, error) {
 }
 
 // DeletePermissionByID removes a permission by ID
-func DeletePermissionByID(permissionID uint) error {
-	return config.DB.Delete(&models.Permission{}, permissionID).Error
+func DeletePermissionByID(permissionID uint, actor Actor) error {
+	permission, err := GetPermissionByID(permissionID)
+	if err != nil {
+		return err
+	}
+
+	if err := config.DB.Delete(&models.Permission{}, permissionID).Error; err != nil {
+		return err
+	}
+	invalidateUserPermissions(permission.UserID)
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionDelete,
+		EntityType: EntityPermission,
+		EntityID:   permission.ID,
+		ChildID:    permission.ChildID,
+		Before:     *permission,
+	})
+	return nil
 }
 
 // CreateOrUpdatePermission is an alias for CreatePermission which already handles updates
-func CreateOrUpdatePermission(userID, childID uint, permissionType string) error {
-	return CreatePermission(userID, childID, permissionType)
+func CreateOrUpdatePermission(userID, childID uint, permissionType string, actor Actor) error {
+	return CreatePermission(userID, childID, permissionType, actor)
 }
\ No newline at end of file
@@ -1,67 +1,265 @@
 package services
 
 import (
+	"container/list"
 	"context"
-	"fmt"
 	"sync"
 	"time"
+
+	"github.com/booktracker/backend/config"
 )
 
-// PermissionCacheEntry represents a cached permission result
+const (
+	// DefaultPermissionCacheTTL is how long a user's grants stay cached
+	DefaultPermissionCacheTTL = 5 * time.Minute
+	// DefaultPermissionCacheMaxUsers bounds how many users' grants are kept in memory
+	DefaultPermissionCacheMaxUsers = 10000
+
+	// accessLevelOwner is the implicit level of a child's owner
+	accessLevelOwner = "OWNER"
+)
+
+// PermissionCacheEntry holds every child a user can access, keyed by child ID
 type PermissionCacheEntry struct {
-	HasPermission bool
-	ExpiresAt     time.Time
+	UserID    uint
+	Grants    map[uint]string // childID -> OWNER, EDIT or VIEW
+	ExpiresAt time.Time
 }
 
-// PermissionCache provides request-scoped permission caching
+// PermissionCache is a process-wide, size-bounded cache of user grants.
+// Entries are loaded per user in a single query and evicted least-recently-used.
 type PermissionCache struct {
-	cache map[string]PermissionCacheEntry
-	mutex sync.RWMutex
-	ttl   time.Duration
+	entries  map[uint]*list.Element
+	lru      *list.List
+	mutex    sync.Mutex
+	ttl      time.Duration
+	maxUsers int
+	load     func(userID uint) (map[uint]string, error)
+
+	// Invalidations that land while grants are loading make the load stale.
+	// generations counts per-user invalidations and epoch counts the ones that
+	// reach many users; both only matter while a load is in flight.
+	generations map[uint]uint64
+	epoch       uint64
+	loading     int
 }
 
 // NewPermissionCache creates a new permission cache with TTL in milliseconds
 func NewPermissionCache(ttlMs int64) *PermissionCache {
+	return NewPermissionCacheWithLimit(time.Duration(ttlMs)*time.Millisecond, DefaultPermissionCacheMaxUsers)
+}
+
+// NewPermissionCacheWithLimit creates a permission cache holding at most maxUsers entries
+func NewPermissionCacheWithLimit(ttl time.Duration, maxUsers int) *PermissionCache {
+	if maxUsers <= 0 {
+		maxUsers = DefaultPermissionCacheMaxUsers
+	}
 	return &PermissionCache{
-		cache: make(map[string]PermissionCacheEntry),
-		ttl:   time.Duration(ttlMs) * time.Millisecond,
+		entries:     make(map[uint]*list.Element),
+		lru:         list.New(),
+		ttl:         ttl,
+		maxUsers:    maxUsers,
+		load:        LoadUserGrants,
+		generations: make(map[uint]uint64),
 	}
 }
 
-// GetOrCheck gets permission from cache or checks and caches the result
+// GetOrCheck gets permission from cache or loads the user's grants and caches them
 func (pc *PermissionCache) GetOrCheck(userID uint, childID uint, permissionType string) (bool, error) {
-	key := fmt.Sprintf("%d:%d:%s", userID, childID, permissionType)
-	
-	// Try to get from cache first
-	pc.mutex.RLock()
-	if entry, exists := pc.cache[key]; exists && time.Now().Before(entry.ExpiresAt) {
-		pc.mutex.RUnlock()
-		return entry.HasPermission, nil
-	}
-	pc.mutex.RUnlock()
-	
-	// Not in cache or expired, check permission
-	hasPermission, err := CheckChildPermission(userID, childID, permissionType)
+	grants, err := pc.GetGrants(userID)
 	if err != nil {
 		return false, err
 	}
-	
-	// Cache the result
+	return accessLevelSatisfies(grants[childID], permissionType), nil
+}
+
+// GetGrants returns the user's access level for every child they can reach.
+// The map is the caller's own copy.
+func (pc *PermissionCache) GetGrants(userID uint) (map[uint]string, error) {
 	pc.mutex.Lock()
-	pc.cache[key] = PermissionCacheEntry{
-		HasPermission: hasPermission,
-		ExpiresAt:     time.Now().Add(pc.ttl),
+	if element, exists := pc.entries[userID]; exists {
+		entry := element.Value.(*PermissionCacheEntry)
+		if time.Now().Before(entry.ExpiresAt) {
+			pc.lru.MoveToFront(element)
+			pc.mutex.Unlock()
+			return copyGrants(entry.Grants), nil
+		}
+		pc.removeElement(element)
 	}
+	generation, epoch := pc.generations[userID], pc.epoch
+	pc.loading++
 	pc.mutex.Unlock()
-	
-	return hasPermission, nil
+
+	// Not in cache or expired, load every grant for the user at once
+	grants, err := pc.load(userID)
+
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+	stale := pc.generations[userID] != generation || pc.epoch != epoch
+	pc.loading--
+	if pc.loading == 0 {
+		pc.generations = make(map[uint]uint64)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Grants invalidated during the load may already be out of date, so they
+	// are returned but not cached
+	if stale {
+		return grants, nil
+	}
+
+	if element, exists := pc.entries[userID]; exists {
+		pc.removeElement(element)
+	}
+	element := pc.lru.PushFront(&PermissionCacheEntry{
+		UserID:    userID,
+		Grants:    grants,
+		ExpiresAt: time.Now().Add(pc.ttl),
+	})
+	pc.entries[userID] = element
+	for pc.lru.Len() > pc.maxUsers {
+		pc.removeElement(pc.lru.Back())
+	}
+
+	return copyGrants(grants), nil
+}
+
+// InvalidateUser drops the cached grants of a single user
+func (pc *PermissionCache) InvalidateUser(userID uint) {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+	if pc.loading > 0 {
+		pc.generations[userID]++
+	}
+	if element, exists := pc.entries[userID]; exists {
+		pc.removeElement(element)
+	}
+}
+
+// InvalidateChild drops the cached grants of every user who can reach a child
+func (pc *PermissionCache) InvalidateChild(childID uint) {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+	// Users still loading are not in the cache yet, so any of them may reach the child
+	pc.epoch++
+	for element := pc.lru.Front(); element != nil; {
+		next := element.Next()
+		if _, exists := element.Value.(*PermissionCacheEntry).Grants[childID]; exists {
+			pc.removeElement(element)
+		}
+		element = next
+	}
+}
+
+// Len returns the number of users currently cached
+func (pc *PermissionCache) Len() int {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+	return pc.lru.Len()
 }
 
 // Clear removes all cached entries
 func (pc *PermissionCache) Clear() {
 	pc.mutex.Lock()
 	defer pc.mutex.Unlock()
-	pc.cache = make(map[string]PermissionCacheEntry)
+	pc.entries = make(map[uint]*list.Element)
+	pc.lru.Init()
+	pc.epoch++
+}
+
+func (pc *PermissionCache) removeElement(element *list.Element) {
+	entry := pc.lru.Remove(element).(*PermissionCacheEntry)
+	delete(pc.entries, entry.UserID)
+}
+
+func copyGrants(grants map[uint]string) map[uint]string {
+	copied := make(map[uint]string, len(grants))
+	for childID, level := range grants {
+		copied[childID] = level
+	}
+	return copied
+}
+
+// LoadUserGrants loads every child a user owns, was granted, or reaches through a household or classroom in one query
+func LoadUserGrants(userID uint) (map[uint]string, error) {
+	var rows []struct {
+		ChildID        uint
+		PermissionType string
+	}
+	err := config.DB.Raw(`
+		SELECT c.id AS child_id, ? AS permission_type FROM children c
+		WHERE c.owner_id = ? AND c.deleted_at IS NULL
+		UNION ALL
+		SELECT p.child_id, p.permission_type FROM permissions p
+		JOIN children c ON c.id = p.child_id
+		WHERE p.user_id = ? AND p.deleted_at IS NULL AND c.deleted_at IS NULL
+		UNION ALL
+		SELECT c.id, `+householdRoleAccessSQL+` FROM children c
+		JOIN household_members hm ON hm.household_id = c.household_id
+		WHERE hm.user_id = ? AND c.deleted_at IS NULL
+		UNION ALL
+		SELECT c.id, 'VIEW' FROM children c
+		JOIN classroom_students cs ON cs.child_id = c.id
+		JOIN classrooms cl ON cl.id = cs.classroom_id
+		WHERE cl.teacher_id = ? AND c.deleted_at IS NULL
+	`, accessLevelOwner, userID, userID, userID, userID).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	grants := make(map[uint]string, len(rows))
+	for _, row := range rows {
+		if accessLevelRank(row.PermissionType) > accessLevelRank(grants[row.ChildID]) {
+			grants[row.ChildID] = row.PermissionType
+		}
+	}
+	return grants, nil
+}
+
+// accessLevelRank orders access levels so the strongest grant wins
+func accessLevelRank(level string) int {
+	switch level {
+	case accessLevelOwner:
+		return 3
+	case "EDIT":
+		return 2
+	case "VIEW":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// accessLevelSatisfies reports whether a granted level covers the requested permission type
+func accessLevelSatisfies(granted, permissionType string) bool {
+	if granted == "" {
+		return false
+	}
+	return accessLevelRank(granted) >= accessLevelRank(permissionType)
+}
+
+// sharedPermissionCache is the process-wide cache used by handlers
+var sharedPermissionCache = NewPermissionCacheWithLimit(DefaultPermissionCacheTTL, DefaultPermissionCacheMaxUsers)
+
+// SharedPermissionCache returns the process-wide permission cache
+func SharedPermissionCache() *PermissionCache {
+	return sharedPermissionCache
+}
+
+// HasChildPermission checks a permission through the process-wide cache
+func HasChildPermission(userID, childID uint, permissionType string) (bool, error) {
+	return sharedPermissionCache.GetOrCheck(userID, childID, permissionType)
+}
+
+// invalidateUserPermissions is called whenever a user's grants change
+func invalidateUserPermissions(userID uint) {
+	sharedPermissionCache.InvalidateUser(userID)
+}
+
+// invalidateChildPermissions is called whenever the set of users who can reach a child changes
+func invalidateChildPermissions(childID uint) {
+	sharedPermissionCache.InvalidateChild(childID)
 }
 
 // Context key for permission cache
@@ -69,16 +267,15 @@ type contextKey string
 
 const permissionCacheKey contextKey = "permissionCache"
 
-// GetPermissionCacheFromContext gets or creates permission cache from context
+// GetPermissionCacheFromContext gets the permission cache from context, falling back to the shared cache
 func GetPermissionCacheFromContext(ctx context.Context) *PermissionCache {
 	if cache, ok := ctx.Value(permissionCacheKey).(*PermissionCache); ok {
 		return cache
 	}
-	// Create new cache with 5 minute TTL for request duration
-	return NewPermissionCache(5 * 60 * 1000)
+	return sharedPermissionCache
 }
 
 // SetPermissionCacheInContext sets permission cache in context
 func SetPermissionCacheInContext(ctx context.Context, cache *PermissionCache) context.Context {
 	return context.WithValue(ctx, permissionCacheKey, cache)
-}
\ No newline at end of file
+}
@@ -22,6 +22,9 @@ func (suite *PermissionCacheTestSuite) SetupTest() {
 	
 	// Create a new cache for each test
 	suite.cache = NewPermissionCache(5000) // 5 seconds TTL
+
+	// The shared cache outlives the per-test database
+	SharedPermissionCache().Clear()
 }
 
 func (suite *PermissionCacheTestSuite) TearDownTest() {
@@ -231,6 +234,190 @@ func (suite *PermissionCacheTestSuite) TestPermissionCacheClear() {
 	assert.True(suite.T(), duration >= 0, "Query duration should be non-negative")
 }
 
+func (suite *PermissionCacheTestSuite) createUser(email string) models.User {
+	user := models.User{
+		Email:         email,
+		PasswordHash:  "hashedpassword",
+		FirstName:     "Test",
+		LastName:      "User",
+		EmailVerified: true,
+	}
+	config.DB.Create(&user)
+	return user
+}
+
+func (suite *PermissionCacheTestSuite) TestLoadUserGrants() {
+	owner := suite.createUser("owner@example.com")
+	viewer := suite.createUser("viewer@example.com")
+
+	ownChild := models.Child{FirstName: "Own", LastName: "Child", Grade: "1st", OwnerID: viewer.ID}
+	config.DB.Create(&ownChild)
+	sharedChild := models.Child{FirstName: "Shared", LastName: "Child", Grade: "2nd", OwnerID: owner.ID}
+	config.DB.Create(&sharedChild)
+	config.DB.Create(&models.Permission{UserID: viewer.ID, ChildID: sharedChild.ID, PermissionType: "VIEW"})
+
+	grants, err := LoadUserGrants(viewer.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), map[uint]string{ownChild.ID: "OWNER", sharedChild.ID: "VIEW"}, grants)
+}
+
+func (suite *PermissionCacheTestSuite) TestSharedCacheInvalidatedOnCreatePermission() {
+	owner := suite.createUser("owner@example.com")
+	other := suite.createUser("other@example.com")
+	child := models.Child{FirstName: "Test", LastName: "Child", Grade: "1st", OwnerID: owner.ID}
+	config.DB.Create(&child)
+
+	hasPermission, err := HasChildPermission(other.ID, child.ID, "VIEW")
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), hasPermission)
+
+	assert.NoError(suite.T(), CreatePermission(other.ID, child.ID, "VIEW", SystemActor))
+
+	hasPermission, err = HasChildPermission(other.ID, child.ID, "VIEW")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), hasPermission)
+
+	hasPermission, err = HasChildPermission(other.ID, child.ID, "EDIT")
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), hasPermission)
+
+	// Upgrading an existing permission also invalidates
+	assert.NoError(suite.T(), CreatePermission(other.ID, child.ID, "EDIT", SystemActor))
+	hasPermission, err = HasChildPermission(other.ID, child.ID, "EDIT")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), hasPermission)
+}
+
+func (suite *PermissionCacheTestSuite) TestSharedCacheInvalidatedOnDeletePermission() {
+	owner := suite.createUser("owner@example.com")
+	other := suite.createUser("other@example.com")
+	child := models.Child{FirstName: "Test", LastName: "Child", Grade: "1st", OwnerID: owner.ID}
+	config.DB.Create(&child)
+	permission := models.Permission{UserID: other.ID, ChildID: child.ID, PermissionType: "EDIT"}
+	config.DB.Create(&permission)
+
+	hasPermission, err := HasChildPermission(other.ID, child.ID, "VIEW")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), hasPermission)
+
+	assert.NoError(suite.T(), DeletePermissionByID(permission.ID, SystemActor))
+
+	hasPermission, err = HasChildPermission(other.ID, child.ID, "VIEW")
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), hasPermission)
+}
+
+func (suite *PermissionCacheTestSuite) TestSharedCacheInvalidatedOnDeleteChild() {
+	owner := suite.createUser("owner@example.com")
+	other := suite.createUser("other@example.com")
+	child := models.Child{FirstName: "Test", LastName: "Child", Grade: "1st", OwnerID: owner.ID}
+	config.DB.Create(&child)
+	config.DB.Create(&models.Permission{UserID: other.ID, ChildID: child.ID, PermissionType: "VIEW"})
+
+	_, err := HasChildPermission(owner.ID, child.ID, "EDIT")
+	assert.NoError(suite.T(), err)
+	_, err = HasChildPermission(other.ID, child.ID, "VIEW")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, SharedPermissionCache().Len())
+
+	config.DB.Where("child_id = ?", child.ID).Delete(&models.Permission{})
+	assert.NoError(suite.T(), DeleteChild(child.ID, SystemActor))
+	assert.Equal(suite.T(), 0, SharedPermissionCache().Len())
+
+	hasPermission, err := HasChildPermission(owner.ID, child.ID, "VIEW")
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), hasPermission)
+}
+
+func (suite *PermissionCacheTestSuite) TestSharedCacheInvalidatedOnOwnershipTransfer() {
+	owner := suite.createUser("owner@example.com")
+	newOwner := suite.createUser("new@example.com")
+	child := models.Child{FirstName: "Test", LastName: "Child", Grade: "1st", OwnerID: owner.ID}
+	config.DB.Create(&child)
+
+	hasPermission, err := HasChildPermission(newOwner.ID, child.ID, "EDIT")
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), hasPermission)
+
+	_, err = TransferChildOwnership(child.ID, newOwner.ID, SystemActor)
+	assert.NoError(suite.T(), err)
+
+	grants, err := SharedPermissionCache().GetGrants(newOwner.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "OWNER", grants[child.ID])
+
+	grants, err = SharedPermissionCache().GetGrants(owner.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "EDIT", grants[child.ID])
+}
+
+func (suite *PermissionCacheTestSuite) TestPermissionCacheBoundedSize() {
+	boundedCache := NewPermissionCacheWithLimit(time.Minute, 2)
+
+	user1 := suite.createUser("user1@example.com")
+	user2 := suite.createUser("user2@example.com")
+	user3 := suite.createUser("user3@example.com")
+
+	for _, user := range []models.User{user1, user2, user3} {
+		_, err := boundedCache.GetGrants(user.ID)
+		assert.NoError(suite.T(), err)
+	}
+
+	// The least recently used user is evicted
+	assert.Equal(suite.T(), 2, boundedCache.Len())
+	boundedCache.mutex.Lock()
+	_, user1Cached := boundedCache.entries[user1.ID]
+	_, user3Cached := boundedCache.entries[user3.ID]
+	boundedCache.mutex.Unlock()
+	assert.False(suite.T(), user1Cached)
+	assert.True(suite.T(), user3Cached)
+}
+
+func (suite *PermissionCacheTestSuite) TestInvalidationDuringLoadIsNotOverwritten() {
+	user := suite.createUser("user@example.com")
+
+	// Grants revoked while they load must not be cached afterwards
+	suite.cache.load = func(userID uint) (map[uint]string, error) {
+		suite.cache.InvalidateUser(userID)
+		return map[uint]string{1: "EDIT"}, nil
+	}
+	grants, err := suite.cache.GetGrants(user.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "EDIT", grants[1])
+	assert.Equal(suite.T(), 0, suite.cache.Len())
+
+	suite.cache.load = func(userID uint) (map[uint]string, error) {
+		suite.cache.InvalidateChild(1)
+		return map[uint]string{1: "EDIT"}, nil
+	}
+	_, err = suite.cache.GetGrants(user.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, suite.cache.Len())
+
+	suite.cache.load = func(userID uint) (map[uint]string, error) {
+		return map[uint]string{1: "EDIT"}, nil
+	}
+	_, err = suite.cache.GetGrants(user.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, suite.cache.Len())
+}
+
+func (suite *PermissionCacheTestSuite) TestGrantsAreCopied() {
+	user := suite.createUser("user@example.com")
+	suite.cache.load = func(userID uint) (map[uint]string, error) {
+		return map[uint]string{1: "VIEW"}, nil
+	}
+
+	grants, err := suite.cache.GetGrants(user.ID)
+	assert.NoError(suite.T(), err)
+	grants[1] = accessLevelOwner
+	grants[2] = "EDIT"
+
+	grants, err = suite.cache.GetGrants(user.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), map[uint]string{1: "VIEW"}, grants)
+}
+
 func TestPermissionCacheTestSuite(t *testing.T) {
 	suite.Run(t, new(PermissionCacheTestSuite))
 }
\ No newline at end of file
@@ -0,0 +1,388 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/booktracker/backend/models"
+	"gorm.io/gorm"
+)
+
+// ReadingLevelSystems are the reading-level systems a book can be levelled in, from the most to
+// the least precise; a level missing in one system is converted from the first one entered
+var ReadingLevelSystems = []string{
+	models.ReadingLevelLexile,
+	models.ReadingLevelATOS,
+	models.ReadingLevelGuidedReading,
+	models.ReadingLevelGrade,
+}
+
+var readingLevelNames = map[string]string{
+	models.ReadingLevelLexile:        "Lexile",
+	models.ReadingLevelATOS:          "ATOS",
+	models.ReadingLevelGuidedReading: "Guided Reading",
+	models.ReadingLevelGrade:         "Grade",
+}
+
+// maxATOSLevel is above the hardest books Accelerated Reader levels
+const maxATOSLevel = 15.0
+
+var (
+	// ErrInvalidReadingLevel is returned for levels that are not written the way their system writes them
+	ErrInvalidReadingLevel = errors.New("invalid reading level")
+
+	// ErrInvalidReadingLevelSystem is returned for systems that are not in ReadingLevelSystems
+	ErrInvalidReadingLevelSystem = fmt.Errorf("reading level system must be one of %s", strings.Join(ReadingLevelSystems, ", "))
+)
+
+var guidedReadingPattern = regexp.MustCompile(`^(?:LEVEL)?([A-Z])$`)
+
+// readingLevelChart places the systems side by side at the start of each grade, K to 13 (the
+// end of grade 12). ATOS book levels are grade equivalents already. Guided Reading letters are
+// the Fountas & Pinnell end-of-year benchmarks of the grade before, and Lexile measures are
+// interpolated from the midpoints of lexileGradeBands. Conversions interpolate between rows, so
+// they are approximate.
+var readingLevelChart = [][3]float64{
+	// grade, Guided Reading letter (A is 1), Lexile
+	{0, 1, -300},
+	{1, 4, -50},
+	{2, 10, 225},
+	{3, 13, 475},
+	{4, 16, 690},
+	{5, 19, 860},
+	{6, 22, 970},
+	{7, 25, 1045},
+	{8, 26, 1110},
+	{9, 26, 1170},
+	{10, 26, 1220},
+	{11, 26, 1265},
+	{12, 26, 1300},
+	{13, 26, 1350},
+}
+
+// Columns of readingLevelChart
+const (
+	chartGrade = iota
+	chartGuidedReading
+	chartLexile
+)
+
+// chartLookup maps x in one column of readingLevelChart onto another, interpolating between rows
+// and clamping at the ends. Where a column levels off (Z covers grades 8 to 12) the first row
+// with the value is used.
+func chartLookup(x float64, from, to int) float64 {
+	rows := readingLevelChart
+	if x <= rows[0][from] {
+		return rows[0][to]
+	}
+	for i := 1; i < len(rows); i++ {
+		low, high := rows[i-1], rows[i]
+		if x <= high[from] && high[from] > low[from] {
+			return low[to] + (x-low[from])/(high[from]-low[from])*(high[to]-low[to])
+		}
+	}
+	return rows[len(rows)-1][to]
+}
+
+// ReadingLevel is a level in one reading-level system, placed on the grade-equivalent scale the
+// systems are converted through
+type ReadingLevel struct {
+	System          string
+	Level           string   // in the system's usual spelling: 840L, 4.5, M or K
+	GradeEquivalent *float64 // nil for Lexile codes without a measure, such as NP
+	Approximate     bool     // converted from a level in another system
+
+	value float64 // the level within its system, for comparing levels of the same system
+}
+
+// Response is the level as returned by the API
+func (l ReadingLevel) Response() models.ReadingLevelResponse {
+	return models.ReadingLevelResponse{System: l.System, Level: l.Level, Approximate: l.Approximate}
+}
+
+// ParseReadingLevelSystem reads a system name such as ATOS or guided_reading
+func ParseReadingLevelSystem(system string) (string, error) {
+	system = strings.ToUpper(strings.TrimSpace(system))
+	if _, ok := readingLevelNames[system]; !ok {
+		return "", ErrInvalidReadingLevelSystem
+	}
+	return system, nil
+}
+
+// ParseReadingLevel reads a level in one of the systems: a Lexile level such as 840L or BR100L,
+// an ATOS book level from 0.0 to 15.0, a Guided Reading letter, or a grade from K to 12
+func ParseReadingLevel(system, level string) (*ReadingLevel, error) {
+	text := strings.TrimSpace(level)
+	if text == "" {
+		return nil, fmt.Errorf("%w: the %s level is empty", ErrInvalidReadingLevel, readingLevelNames[system])
+	}
+
+	switch system {
+	case models.ReadingLevelLexile:
+		lexile, err := ParseLexile(text)
+		if err != nil {
+			return nil, err
+		}
+		parsed := &ReadingLevel{System: system, Level: lexile.String()}
+		if lexile.Measure != nil {
+			parsed.value = float64(*lexile.Measure)
+			grade := chartLookup(parsed.value, chartLexile, chartGrade)
+			parsed.GradeEquivalent = &grade
+		}
+		return parsed, nil
+
+	case models.ReadingLevelATOS:
+		atos, err := strconv.ParseFloat(text, 64)
+		if err != nil || atos < 0 || atos > maxATOSLevel {
+			return nil, fmt.Errorf("%w: ATOS book levels are numbers from 0.0 to %.1f, not %q", ErrInvalidReadingLevel, maxATOSLevel, level)
+		}
+		atos = math.Round(atos*10) / 10
+		return &ReadingLevel{System: system, Level: formatATOS(atos), GradeEquivalent: &atos, value: atos}, nil
+
+	case models.ReadingLevelGuidedReading:
+		match := guidedReadingPattern.FindStringSubmatch(strings.ToUpper(strings.Join(strings.Fields(text), "")))
+		if match == nil {
+			return nil, fmt.Errorf("%w: Guided Reading levels are letters A to Z, not %q", ErrInvalidReadingLevel, level)
+		}
+		letter := float64(match[1][0]-'A') + 1
+		grade := chartLookup(letter, chartGuidedReading, chartGrade)
+		return &ReadingLevel{System: system, Level: match[1], GradeEquivalent: &grade, value: letter}, nil
+
+	case models.ReadingLevelGrade:
+		// Fractions of a grade are ATOS levels
+		grade, ok := ParseGrade(text)
+		if !ok || strings.Contains(text, ".") {
+			return nil, fmt.Errorf("%w: grade levels are K or 1 to 12, not %q", ErrInvalidReadingLevel, level)
+		}
+		// A book at a grade level suits the middle of that grade
+		middle := float64(grade) + 0.5
+		return &ReadingLevel{System: system, Level: formatGrade(grade), GradeEquivalent: &middle, value: float64(grade)}, nil
+	}
+	return nil, ErrInvalidReadingLevelSystem
+}
+
+// Convert gives the approximate level in another system, or nil when the level has no grade
+// equivalent to convert from. A level converts to itself unchanged.
+func (l ReadingLevel) Convert(system string) *ReadingLevel {
+	if l.System == system {
+		return &l
+	}
+	if l.GradeEquivalent == nil {
+		return nil
+	}
+	grade := *l.GradeEquivalent
+	converted := &ReadingLevel{System: system, GradeEquivalent: &grade, Approximate: true}
+
+	switch system {
+	case models.ReadingLevelLexile:
+		measure := int(math.Round(chartLookup(grade, chartGrade, chartLexile)/10) * 10)
+		converted.Level = lexileMeasureString(measure)
+		converted.value = float64(measure)
+	case models.ReadingLevelATOS:
+		atos := math.Round(grade*10) / 10
+		converted.Level = formatATOS(atos)
+		converted.value = atos
+	case models.ReadingLevelGuidedReading:
+		letter := math.Max(1, math.Min(26, math.Round(chartLookup(grade, chartGrade, chartGuidedReading))))
+		converted.Level = string(rune('A' + int(letter) - 1))
+		converted.value = letter
+	case models.ReadingLevelGrade:
+		whole := int(math.Max(0, math.Min(12, math.Floor(grade))))
+		converted.Level = formatGrade(whole)
+		converted.value = float64(whole)
+	default:
+		return nil
+	}
+	return converted
+}
+
+func formatATOS(atos float64) string {
+	return strconv.FormatFloat(atos, 'f', 1, 64)
+}
+
+func formatGrade(grade int) string {
+	if grade == 0 {
+		return "K"
+	}
+	return strconv.Itoa(grade)
+}
+
+// BookReadingLevels are the levels entered for a book, in ReadingLevelSystems order. A Lexile
+// level saved before levels were parsed is listed as it is, without a grade equivalent.
+func BookReadingLevels(book *models.Book) []ReadingLevel {
+	var levels []ReadingLevel
+	for _, system := range ReadingLevelSystems {
+		if system == models.ReadingLevelLexile {
+			if book.LexileLevel == "" {
+				continue
+			}
+			level := ReadingLevel{System: system, Level: book.LexileLevel}
+			if book.LexileMeasure != nil {
+				level.value = float64(*book.LexileMeasure)
+				grade := chartLookup(level.value, chartLexile, chartGrade)
+				level.GradeEquivalent = &grade
+			}
+			levels = append(levels, level)
+			continue
+		}
+		for _, stored := range book.ReadingLevels {
+			if stored.System != system {
+				continue
+			}
+			// The stored spelling is already normalised; parsing recovers the value within the system
+			level, err := ParseReadingLevel(stored.System, stored.Level)
+			if err != nil {
+				grade := stored.GradeEquivalent
+				level = &ReadingLevel{System: stored.System, Level: stored.Level, GradeEquivalent: &grade}
+			}
+			levels = append(levels, *level)
+		}
+	}
+	return levels
+}
+
+// BookReadingLevelIn is the book's level in system: the one entered when there is one, and
+// otherwise a conversion of the most precise level entered. It is nil for books without a level
+// that converts.
+func BookReadingLevelIn(book *models.Book, system string) *ReadingLevel {
+	levels := BookReadingLevels(book)
+	for _, level := range levels {
+		if level.System == system {
+			return &level
+		}
+	}
+	for _, level := range levels {
+		if converted := level.Convert(system); converted != nil {
+			return converted
+		}
+	}
+	return nil
+}
+
+// FilterBooksByReadingLevel keeps the books whose level in system, entered or converted, is
+// from min to max; either bound may be empty. Books without a level in the system are left out.
+func FilterBooksByReadingLevel(books []models.Book, system, min, max string) ([]models.Book, error) {
+	var low, high *ReadingLevel
+	for _, bound := range []struct {
+		text  string
+		level **ReadingLevel
+	}{{min, &low}, {max, &high}} {
+		if bound.text == "" {
+			continue
+		}
+		level, err := ParseReadingLevel(system, bound.text)
+		if err != nil {
+			return nil, err
+		}
+		if level.GradeEquivalent == nil {
+			return nil, fmt.Errorf("%w: %s is not a measure to filter by", ErrInvalidReadingLevel, level.Level)
+		}
+		*bound.level = level
+	}
+
+	filtered := make([]models.Book, 0, len(books))
+	for i := range books {
+		level := BookReadingLevelIn(&books[i], system)
+		if level == nil || level.GradeEquivalent == nil {
+			continue
+		}
+		if (low != nil && level.value < low.value) || (high != nil && level.value > high.value) {
+			continue
+		}
+		filtered = append(filtered, books[i])
+	}
+	return filtered, nil
+}
+
+// ConvertReadingLevel gives a level's approximate equivalents in every other system
+func ConvertReadingLevel(system, level string) (*models.ReadingLevelConversionResponse, error) {
+	parsed, err := ParseReadingLevel(system, level)
+	if err != nil {
+		return nil, err
+	}
+	conversion := &models.ReadingLevelConversionResponse{
+		System:          parsed.System,
+		Level:           parsed.Level,
+		GradeEquivalent: parsed.GradeEquivalent,
+		Conversions:     []models.ReadingLevelResponse{},
+	}
+	for _, other := range ReadingLevelSystems {
+		if other == parsed.System {
+			continue
+		}
+		if converted := parsed.Convert(other); converted != nil {
+			conversion.Conversions = append(conversion.Conversions, converted.Response())
+		}
+	}
+	return conversion, nil
+}
+
+// ReadingLevelChart is the conversion chart, one row for the start of each grade from K to 12
+func ReadingLevelChart() []models.ReadingLevelChartRow {
+	rows := make([]models.ReadingLevelChartRow, 0, 13)
+	for grade := 0; grade <= 12; grade++ {
+		start := ReadingLevel{GradeEquivalent: floatPtr(float64(grade))}
+		rows = append(rows, models.ReadingLevelChartRow{
+			Grade:         formatGrade(grade),
+			Lexile:        start.Convert(models.ReadingLevelLexile).Level,
+			ATOS:          start.Convert(models.ReadingLevelATOS).Level,
+			GuidedReading: start.Convert(models.ReadingLevelGuidedReading).Level,
+		})
+	}
+	return rows
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+// readingLevelRows checks the levels given for a book, one per system. A Lexile level replaces
+// the book's LexileLevel; the others are returned as rows to save with saveReadingLevels.
+func readingLevelRows(book *models.Book, inputs []models.ReadingLevelInput) ([]models.BookReadingLevel, error) {
+	rows := []models.BookReadingLevel{}
+	seen := make(map[string]bool, len(inputs))
+	for _, input := range inputs {
+		system, err := ParseReadingLevelSystem(input.System)
+		if err != nil {
+			return nil, err
+		}
+		if seen[system] {
+			return nil, fmt.Errorf("%w: more than one %s level", ErrInvalidReadingLevel, readingLevelNames[system])
+		}
+		seen[system] = true
+
+		level, err := ParseReadingLevel(system, input.Level)
+		if err != nil {
+			return nil, err
+		}
+		if system == models.ReadingLevelLexile {
+			book.LexileLevel = level.Level
+			continue
+		}
+		rows = append(rows, models.BookReadingLevel{
+			System:          system,
+			Level:           level.Level,
+			GradeEquivalent: *level.GradeEquivalent,
+		})
+	}
+	return rows, nil
+}
+
+// saveReadingLevels replaces a book's levels in systems other than Lexile with rows
+func saveReadingLevels(db *gorm.DB, bookID uint, rows []models.BookReadingLevel) error {
+	if err := db.Where("book_id = ?", bookID).Delete(&models.BookReadingLevel{}).Error; err != nil {
+		return err
+	}
+	for i := range rows {
+		rows[i].ID = 0
+		rows[i].BookID = bookID
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return db.Create(&rows).Error
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestParseReadingLevel(t *testing.T) {
+	for _, test := range []struct {
+		system, level, want string
+		grade               float64
+	}{
+		{models.ReadingLevelATOS, "4.5", "4.5", 4.5},
+		{models.ReadingLevelATOS, " 3 ", "3.0", 3},
+		{models.ReadingLevelGuidedReading, "level m", "M", 3},
+		{models.ReadingLevelGuidedReading, "Z", "Z", 8},
+		{models.ReadingLevelGrade, "3rd", "3", 3.5},
+		{models.ReadingLevelGrade, "kindergarten", "K", 0.5},
+		{models.ReadingLevelLexile, "br100", "BR100L", 0.8},
+	} {
+		level, err := ParseReadingLevel(test.system, test.level)
+		if assert.NoError(t, err, test.level) {
+			assert.Equal(t, test.want, level.Level, test.level)
+			assert.InDelta(t, test.grade, *level.GradeEquivalent, 0.001, test.level)
+		}
+	}
+
+	level, err := ParseReadingLevel(models.ReadingLevelLexile, "NP")
+	assert.NoError(t, err)
+	assert.Nil(t, level.GradeEquivalent)
+
+	for _, test := range []struct{ system, level string }{
+		{models.ReadingLevelATOS, "16"},
+		{models.ReadingLevelATOS, "M"},
+		{models.ReadingLevelGuidedReading, "AA"},
+		{models.ReadingLevelGuidedReading, "3"},
+		{models.ReadingLevelGrade, "3.5"},
+		{models.ReadingLevelGrade, "13"},
+		{models.ReadingLevelGrade, ""},
+	} {
+		_, err := ParseReadingLevel(test.system, test.level)
+		assert.ErrorIs(t, err, ErrInvalidReadingLevel, test.level)
+	}
+	_, err = ParseReadingLevel(models.ReadingLevelLexile, "840X")
+	assert.ErrorIs(t, err, ErrInvalidLexile)
+
+	system, err := ParseReadingLevelSystem(" guided_reading")
+	assert.NoError(t, err)
+	assert.Equal(t, models.ReadingLevelGuidedReading, system)
+	_, err = ParseReadingLevelSystem("DRA")
+	assert.ErrorIs(t, err, ErrInvalidReadingLevelSystem)
+}
+
+func TestConvertReadingLevel(t *testing.T) {
+	converted := func(system, level string) map[string]string {
+		conversion, err := ConvertReadingLevel(system, level)
+		assert.NoError(t, err)
+		levels := make(map[string]string)
+		for _, other := range conversion.Conversions {
+			assert.True(t, other.Approximate)
+			levels[other.System] = other.Level
+		}
+		return levels
+	}
+
+	assert.Equal(t, map[string]string{
+		models.ReadingLevelLexile: "480L", models.ReadingLevelATOS: "3.0", models.ReadingLevelGrade: "3",
+	}, converted(models.ReadingLevelGuidedReading, "M"))
+	assert.Equal(t, map[string]string{
+		models.ReadingLevelATOS: "4.9", models.ReadingLevelGuidedReading: "S", models.ReadingLevelGrade: "4",
+	}, converted(models.ReadingLevelLexile, "840L"))
+	// A grade level is the middle of the grade
+	assert.Equal(t, map[string]string{
+		models.ReadingLevelLexile: "580L", models.ReadingLevelATOS: "3.5", models.ReadingLevelGuidedReading: "O",
+	}, converted(models.ReadingLevelGrade, "3"))
+	assert.Equal(t, map[string]string{
+		models.ReadingLevelLexile: "BR180L", models.ReadingLevelATOS: "0.5", models.ReadingLevelGuidedReading: "C",
+	}, converted(models.ReadingLevelGrade, "K"))
+	// Past the top of the chart levels stay at the last row
+	assert.Equal(t, "Z", converted(models.ReadingLevelATOS, "14.0")[models.ReadingLevelGuidedReading])
+	assert.Empty(t, converted(models.ReadingLevelLexile, "NP"))
+
+	chart := ReadingLevelChart()
+	assert.Len(t, chart, 13)
+	assert.Equal(t, models.ReadingLevelChartRow{Grade: "K", Lexile: "BR300L", ATOS: "0.0", GuidedReading: "A"}, chart[0])
+	assert.Equal(t, models.ReadingLevelChartRow{Grade: "3", Lexile: "480L", ATOS: "3.0", GuidedReading: "M"}, chart[3])
+	assert.Equal(t, models.ReadingLevelChartRow{Grade: "12", Lexile: "1300L", ATOS: "12.0", GuidedReading: "Z"}, chart[12])
+}
+
+type ReadingLevelTestSuite struct {
+	suite.Suite
+	owner *models.User
+	sam   *models.Child
+}
+
+func (suite *ReadingLevelTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+	SharedPermissionCache().Clear()
+
+	var err error
+	suite.owner, err = CreateUser(models.CreateUserRequest{
+		Email:     "owner@example.com",
+		Password:  "password123",
+		FirstName: "Owner",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	suite.sam, err = CreateChild(models.CreateChildRequest{FirstName: "Sam", LastName: "Reader", Grade: "3"}, suite.owner.ID)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *ReadingLevelTestSuite) TearDownTest() {
+	config.CleanupTestDatabase()
+}
+
+func (suite *ReadingLevelTestSuite) createBook(title, lexile string, levels ...models.ReadingLevelInput) *models.Book {
+	book, err := CreateCustomBook(models.CreateCustomBookRequest{
+		Title: title, Author: "Author", DateRead: "2024-09-01", ChildID: suite.sam.ID,
+		LexileLevel: lexile, ReadingLevels: levels,
+	}, SystemActor)
+	assert.NoError(suite.T(), err)
+	return book
+}
+
+func (suite *ReadingLevelTestSuite) TestBookLevels() {
+	book := suite.createBook("Frindle", "900L",
+		models.ReadingLevelInput{System: models.ReadingLevelGuidedReading, Level: "m"},
+		models.ReadingLevelInput{System: models.ReadingLevelLexile, Level: "500"})
+
+	// The Lexile entry replaces the Lexile level
+	assert.Equal(suite.T(), "500L", book.LexileLevel)
+	assert.Equal(suite.T(), 500, *book.LexileMeasure)
+
+	book, err := GetBookByID(book.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), book.ReadingLevels, 1)
+	levels := BookReadingLevels(book)
+	assert.Equal(suite.T(), []string{models.ReadingLevelLexile, models.ReadingLevelGuidedReading},
+		[]string{levels[0].System, levels[1].System})
+
+	level := BookReadingLevelIn(book, models.ReadingLevelGuidedReading)
+	assert.Equal(suite.T(), models.ReadingLevelResponse{System: models.ReadingLevelGuidedReading, Level: "M"}, level.Response())
+	// Missing systems are converted from the most precise level
+	level = BookReadingLevelIn(book, models.ReadingLevelATOS)
+	assert.Equal(suite.T(), models.ReadingLevelResponse{System: models.ReadingLevelATOS, Level: "3.1", Approximate: true}, level.Response())
+
+	// Without readingLevels an update keeps the other systems
+	updated, err := UpdateBook(book.ID, models.UpdateBookRequest{DateRead: "2024-09-01", LexileLevel: "600L"}, SystemActor)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), updated.ReadingLevels, 1)
+
+	updated, err = UpdateBook(book.ID, models.UpdateBookRequest{DateRead: "2024-09-01", LexileLevel: "600L",
+		ReadingLevels: []models.ReadingLevelInput{{System: models.ReadingLevelATOS, Level: "3.4"}}}, SystemActor)
+	assert.NoError(suite.T(), err)
+	book, err = GetBookByID(book.ID)
+	assert.NoError(suite.T(), err)
+	// readingLevels replaces the other systems
+	assert.Len(suite.T(), updated.ReadingLevels, 1)
+	assert.Len(suite.T(), book.ReadingLevels, 1)
+	assert.Equal(suite.T(), models.ReadingLevelATOS, book.ReadingLevels[0].System)
+	assert.Equal(suite.T(), "3.4", book.ReadingLevels[0].Level)
+	assert.Equal(suite.T(), 3.4, book.ReadingLevels[0].GradeEquivalent)
+
+	_, err = UpdateBook(book.ID, models.UpdateBookRequest{DateRead: "2024-09-01", ReadingLevels: []models.ReadingLevelInput{
+		{System: models.ReadingLevelGrade, Level: "3"}, {System: models.ReadingLevelGrade, Level: "4"},
+	}}, SystemActor)
+	assert.ErrorIs(suite.T(), err, ErrInvalidReadingLevel)
+	_, err = CreateCustomBook(models.CreateCustomBookRequest{
+		Title: "Bad", Author: "Author", DateRead: "2024-09-01", ChildID: suite.sam.ID,
+		ReadingLevels: []models.ReadingLevelInput{{System: models.ReadingLevelGuidedReading, Level: "4th"}},
+	}, SystemActor)
+	assert.ErrorIs(suite.T(), err, ErrInvalidReadingLevel)
+}
+
+func (suite *ReadingLevelTestSuite) TestFilter() {
+	suite.createBook("Letter", "", models.ReadingLevelInput{System: models.ReadingLevelGuidedReading, Level: "M"})
+	suite.createBook("Measure", "840L")
+	suite.createBook("Unlevelled", "")
+	suite.createBook("Early", "", models.ReadingLevelInput{System: models.ReadingLevelGrade, Level: "1"})
+	suite.createBook("Not prose", "NP")
+
+	books, err := GetBooksByChild(suite.sam.ID)
+	assert.NoError(suite.T(), err)
+
+	titles := func(books []models.Book) []string {
+		var titles []string
+		for _, book := range books {
+			titles = append(titles, book.CustomTitle)
+		}
+		return titles
+	}
+
+	// 840L is about S, grade 1 about G
+	filtered, err := FilterBooksByReadingLevel(books, models.ReadingLevelGuidedReading, "L", "T")
+	assert.NoError(suite.T(), err)
+	assert.ElementsMatch(suite.T(), []string{"Letter", "Measure"}, titles(filtered))
+
+	filtered, err = FilterBooksByReadingLevel(books, models.ReadingLevelLexile, "", "500L")
+	assert.NoError(suite.T(), err)
+	assert.ElementsMatch(suite.T(), []string{"Letter", "Early"}, titles(filtered))
+
+	_, err = FilterBooksByReadingLevel(books, models.ReadingLevelLexile, "NP", "")
+	assert.ErrorIs(suite.T(), err, ErrInvalidReadingLevel)
+}
+
+func (suite *ReadingLevelTestSuite) TestBackupAndPurge() {
+	suite.createBook("Frindle", "830L", models.ReadingLevelInput{System: models.ReadingLevelATOS, Level: "5.4"})
+
+	var archive bytes.Buffer
+	assert.NoError(suite.T(), ExportBackup(&archive, suite.owner.ID))
+	assert.Contains(suite.T(), archive.String(), `"system": "ATOS"`)
+
+	other, err := CreateUser(models.CreateUserRequest{Email: "other@example.com", Password: "password123", FirstName: "Other", LastName: "User"})
+	assert.NoError(suite.T(), err)
+	_, err = RestoreBackup(&archive, other.ID, SystemActor)
+	assert.NoError(suite.T(), err)
+
+	var restored models.Book
+	assert.NoError(suite.T(), config.DB.Preload("ReadingLevels").Where("child_id <> ?", suite.sam.ID).First(&restored).Error)
+	assert.Equal(suite.T(), "830L", restored.LexileLevel)
+	assert.Len(suite.T(), restored.ReadingLevels, 1)
+	assert.Equal(suite.T(), "5.4", restored.ReadingLevels[0].Level)
+
+	// Purged books take their levels with them
+	assert.NoError(suite.T(), DeleteBook(restored.ID, SystemActor))
+	_, err = PurgeDeletedRecords(time.Now().Add(time.Hour))
+	assert.NoError(suite.T(), err)
+	var count int64
+	config.DB.Model(&models.BookReadingLevel{}).Where("book_id = ?", restored.ID).Count(&count)
+	assert.Equal(suite.T(), int64(0), count)
+	config.DB.Model(&models.BookReadingLevel{}).Count(&count)
+	assert.Equal(suite.T(), int64(1), count)
+}
+
+func TestReadingLevelTestSuite(t *testing.T) {
+	suite.Run(t, new(ReadingLevelTestSuite))
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"gorm.io/gorm"
+)
+
+// EntityShareLink is the entity type of public share links
+const EntityShareLink = "share_link"
+
+// ErrShareLinkUnavailable is returned for unknown, revoked or expired share links
+var ErrShareLinkUnavailable = errors.New("share link not found or no longer valid")
+
+// generateShareToken generates an unguessable token for a share link
+func generateShareToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// CreateShareLink creates a read-only public link to a child's reading log
+func CreateShareLink(childID uint, req models.CreateShareLinkRequest, actor Actor) (*models.ShareLink, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("expiry must be in the future")
+	}
+
+	token, err := generateShareToken()
+	if err != nil {
+		return nil, err
+	}
+
+	link := models.ShareLink{
+		Token:        token,
+		ChildID:      childID,
+		CreatedByID:  actor.UserID,
+		HideLastName: req.HideLastName,
+		ExpiresAt:    req.ExpiresAt,
+	}
+
+	result := config.DB.Create(&link)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionCreate,
+		EntityType: EntityShareLink,
+		EntityID:   link.ID,
+		ChildID:    link.ChildID,
+		After:      shareLinkAuditView(link),
+	})
+
+	return &link, nil
+}
+
+// GetShareLinkByID gets a share link by ID
+func GetShareLinkByID(id uint) (*models.ShareLink, error) {
+	var link models.ShareLink
+	result := config.DB.First(&link, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("share link not found")
+		}
+		return nil, result.Error
+	}
+	return &link, nil
+}
+
+// GetShareLinksByChild gets every share link of a child, newest first
+func GetShareLinksByChild(childID uint) ([]models.ShareLink, error) {
+	var links []models.ShareLink
+	result := config.DB.Where("child_id = ?", childID).Order("created_at DESC, id DESC").Find(&links)
+	return links, result.Error
+}
+
+// RevokeShareLink disables a share link; revoked links are kept for their view history
+func RevokeShareLink(id uint, actor Actor) (*models.ShareLink, error) {
+	link, err := GetShareLinkByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if link.RevokedAt != nil {
+		return link, nil
+	}
+
+	before := *link
+	now := time.Now()
+	result := config.DB.Model(link).Update("revoked_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	link.RevokedAt = &now
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionUpdate,
+		EntityType: EntityShareLink,
+		EntityID:   link.ID,
+		ChildID:    link.ChildID,
+		Before:     shareLinkAuditView(before),
+		After:      shareLinkAuditView(*link),
+	})
+
+	return link, nil
+}
+
+// OpenShareLink resolves a token to its child and counts the view.
+// The returned child has its last name blanked when the link hides it.
+func OpenShareLink(token string) (*models.ShareLink, *models.Child, error) {
+	if token == "" {
+		return nil, nil, ErrShareLinkUnavailable
+	}
+
+	var link models.ShareLink
+	result := config.DB.Where("token = ?", token).First(&link)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrShareLinkUnavailable
+		}
+		return nil, nil, result.Error
+	}
+
+	now := time.Now()
+	if link.RevokedAt != nil || (link.ExpiresAt != nil && !now.Before(*link.ExpiresAt)) {
+		return nil, nil, ErrShareLinkUnavailable
+	}
+
+	// Trashed children are not shared
+	child, err := GetChildByID(link.ChildID)
+	if err != nil {
+		return nil, nil, ErrShareLinkUnavailable
+	}
+	if link.HideLastName {
+		child.LastName = ""
+	}
+
+	result = config.DB.Model(&models.ShareLink{}).Where("id = ?", link.ID).UpdateColumns(map[string]interface{}{
+		"view_count":     gorm.Expr("view_count + 1"),
+		"last_viewed_at": now,
+	})
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	link.ViewCount++
+	link.LastViewedAt = &now
+
+	return &link, child, nil
+}
+
+// shareLinkAuditView keeps the token itself out of the audit log
+func shareLinkAuditView(link models.ShareLink) models.ShareLink {
+	link.Token = ""
+	return link
+}
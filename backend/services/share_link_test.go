@@ -0,0 +1,125 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ShareLinkServiceTestSuite struct {
+	suite.Suite
+	owner *models.User
+	child *models.Child
+}
+
+func (suite *ShareLinkServiceTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+	SharedPermissionCache().Clear()
+
+	owner, err := CreateUser(models.CreateUserRequest{
+		Email:     "owner@example.com",
+		Password:  "password123",
+		FirstName: "Owner",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	suite.owner = owner
+
+	child, err := CreateChild(models.CreateChildRequest{
+		FirstName: "Test",
+		LastName:  "Child",
+		Grade:     "3rd",
+	}, owner.ID)
+	assert.NoError(suite.T(), err)
+	suite.child = child
+}
+
+func (suite *ShareLinkServiceTestSuite) TearDownTest() {
+	config.CleanupTestDatabase()
+}
+
+func (suite *ShareLinkServiceTestSuite) TestCreateAndOpenShareLink() {
+	link, err := CreateShareLink(suite.child.ID, models.CreateShareLinkRequest{}, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), link.Token, 64)
+
+	opened, child, err := OpenShareLink(link.Token)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, opened.ViewCount)
+	assert.Equal(suite.T(), "Child", child.LastName)
+
+	_, _, err = OpenShareLink(link.Token)
+	assert.NoError(suite.T(), err)
+
+	stored, err := GetShareLinkByID(link.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, stored.ViewCount)
+	assert.NotNil(suite.T(), stored.LastViewedAt)
+}
+
+func (suite *ShareLinkServiceTestSuite) TestHideLastName() {
+	link, err := CreateShareLink(suite.child.ID, models.CreateShareLinkRequest{HideLastName: true}, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+
+	_, child, err := OpenShareLink(link.Token)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Test", child.FirstName)
+	assert.Empty(suite.T(), child.LastName)
+}
+
+func (suite *ShareLinkServiceTestSuite) TestRevokedLinkIsUnavailable() {
+	link, err := CreateShareLink(suite.child.ID, models.CreateShareLinkRequest{}, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+
+	revoked, err := RevokeShareLink(link.ID, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), revoked.RevokedAt)
+
+	_, _, err = OpenShareLink(link.Token)
+	assert.ErrorIs(suite.T(), err, ErrShareLinkUnavailable)
+}
+
+func (suite *ShareLinkServiceTestSuite) TestExpiredLinkIsUnavailable() {
+	expiresAt := time.Now().Add(time.Hour)
+	link, err := CreateShareLink(suite.child.ID, models.CreateShareLinkRequest{ExpiresAt: &expiresAt}, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+
+	config.DB.Model(link).Update("expires_at", time.Now().Add(-time.Minute))
+	_, _, err = OpenShareLink(link.Token)
+	assert.ErrorIs(suite.T(), err, ErrShareLinkUnavailable)
+
+	past := time.Now().Add(-time.Hour)
+	_, err = CreateShareLink(suite.child.ID, models.CreateShareLinkRequest{ExpiresAt: &past}, Actor{UserID: suite.owner.ID})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ShareLinkServiceTestSuite) TestTrashedChildIsUnavailable() {
+	link, err := CreateShareLink(suite.child.ID, models.CreateShareLinkRequest{}, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), DeleteChild(suite.child.ID, SystemActor))
+
+	_, _, err = OpenShareLink(link.Token)
+	assert.ErrorIs(suite.T(), err, ErrShareLinkUnavailable)
+
+	_, _, err = OpenShareLink("not-a-token")
+	assert.ErrorIs(suite.T(), err, ErrShareLinkUnavailable)
+}
+
+func (suite *ShareLinkServiceTestSuite) TestTokenIsKeptOutOfAuditLog() {
+	link, err := CreateShareLink(suite.child.ID, models.CreateShareLinkRequest{}, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+
+	entries, err := GetAuditLogs(AuditLogFilter{EntityType: EntityShareLink})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), entries, 1)
+	assert.NotContains(suite.T(), entries[0].After, link.Token)
+}
+
+func TestShareLinkServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(ShareLinkServiceTestSuite))
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+)
+
+// Statistics windows are whole months; a window ends with the current month unless set
+const (
+	DefaultStatsMonths     = 12
+	MaxStatsMonths         = 60
+	DefaultStatsTopAuthors = 5
+	MaxStatsTopAuthors     = 50
+)
+
+var (
+	// ErrInvalidStatsWindow is returned for windows shorter than a month or longer than MaxStatsMonths
+	ErrInvalidStatsWindow = fmt.Errorf("months must be between 1 and %d", MaxStatsMonths)
+
+	// ErrInvalidStatsTopAuthors is returned for author limits outside 1 to MaxStatsTopAuthors
+	ErrInvalidStatsTopAuthors = fmt.Errorf("topAuthors must be between 1 and %d", MaxStatsTopAuthors)
+)
+
+// statsBooksWhere selects the live books of the children read inside the window; its arguments
+// are the child IDs, the first day of the window and the first day after it
+const statsBooksWhere = `b.deleted_at IS NULL AND b.child_id IN ? AND b.date_read >= ? AND b.date_read < ?`
+
+// monthIndexSQL numbers months consecutively across years, for the Lexile trend
+const monthIndexSQL = `(CAST(substr(b.date_read, 1, 4) AS INTEGER) * 12 + CAST(substr(b.date_read, 6, 2) AS INTEGER))`
+
+// GetReadingStats summarises the children's reading over the months from the month of start.
+// Every figure comes from an aggregate query; Go only fills in months without books.
+func GetReadingStats(childIDs []uint, start time.Time, months, topAuthors int) (*models.ReadingStatsResponse, error) {
+	if months < 1 || months > MaxStatsMonths {
+		return nil, ErrInvalidStatsWindow
+	}
+	if topAuthors < 1 || topAuthors > MaxStatsTopAuthors {
+		return nil, ErrInvalidStatsTopAuthors
+	}
+	if len(childIDs) == 0 {
+		return nil, errors.New("no children to summarise")
+	}
+
+	start = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, months, 0)
+	args := []interface{}{childIDs, start.Format("2006-01-02"), end.Format("2006-01-02")}
+
+	stats := &models.ReadingStatsResponse{
+		ChildIDs:   childIDs,
+		From:       start.Format("2006-01"),
+		To:         end.AddDate(0, -1, 0).Format("2006-01"),
+		Months:     make([]models.MonthlyReadingStats, 0, months),
+		TopAuthors: []models.AuthorReadingStats{},
+	}
+
+	var totals struct {
+		TotalBooks   int
+		PartialBooks int
+		SharedBooks  int
+	}
+	if err := config.DB.Raw(`
+		SELECT COUNT(*) AS total_books,
+			COALESCE(SUM(CASE WHEN b.is_partial THEN 1 ELSE 0 END), 0) AS partial_books,
+			COALESCE(SUM(CASE WHEN b.shared_book_id IS NOT NULL THEN 1 ELSE 0 END), 0) AS shared_books
+		FROM books b
+		WHERE `+statsBooksWhere, args...).Scan(&totals).Error; err != nil {
+		return nil, err
+	}
+	stats.TotalBooks = totals.TotalBooks
+	stats.PartialBooks = totals.PartialBooks
+	stats.CompleteBooks = totals.TotalBooks - totals.PartialBooks
+	stats.SharedBooks = totals.SharedBooks
+	stats.CustomBooks = totals.TotalBooks - totals.SharedBooks
+	if totals.TotalBooks > 0 {
+		stats.PartialShare = float64(totals.PartialBooks) / float64(totals.TotalBooks)
+		stats.SharedShare = float64(totals.SharedBooks) / float64(totals.TotalBooks)
+	}
+
+	var monthly []models.MonthlyReadingStats
+	if err := config.DB.Raw(`
+		SELECT substr(b.date_read, 1, 7) AS month, COUNT(*) AS books,
+			SUM(CASE WHEN b.is_partial THEN 1 ELSE 0 END) AS partial_books,
+			AVG(b.lexile_measure) AS average_lexile
+		FROM books b
+		WHERE `+statsBooksWhere+`
+		GROUP BY substr(b.date_read, 1, 7)
+		ORDER BY month`, args...).Scan(&monthly).Error; err != nil {
+		return nil, err
+	}
+	byMonth := make(map[string]models.MonthlyReadingStats, len(monthly))
+	for _, month := range monthly {
+		byMonth[month.Month] = month
+	}
+	for month := start; month.Before(end); month = month.AddDate(0, 1, 0) {
+		key := month.Format("2006-01")
+		if counts, ok := byMonth[key]; ok {
+			stats.Months = append(stats.Months, counts)
+		} else {
+			stats.Months = append(stats.Months, models.MonthlyReadingStats{Month: key})
+		}
+	}
+
+	// Authors are grouped regardless of case and stray spaces, most read first. MIN picks the
+	// capitalised spelling, which sorts before lower case.
+	if err := config.DB.Raw(`
+		SELECT MIN(trim(COALESCE(sb.author, b.custom_author))) AS author, COUNT(*) AS books
+		FROM books b
+		LEFT JOIN shared_books sb ON sb.id = b.shared_book_id
+		WHERE `+statsBooksWhere+` AND trim(COALESCE(sb.author, b.custom_author, '')) <> ''
+		GROUP BY lower(trim(COALESCE(sb.author, b.custom_author)))
+		ORDER BY books DESC, author
+		LIMIT ?`, append(args, topAuthors)...).Scan(&stats.TopAuthors).Error; err != nil {
+		return nil, err
+	}
+
+	// The trend is the slope of the least-squares line through (month, Lexile) for every book
+	if err := config.DB.Raw(`
+		SELECT COUNT(*) AS books, AVG(l.lexile) AS average,
+			(COUNT(*) * SUM(l.x * l.lexile) - SUM(l.x) * SUM(l.lexile)) * 1.0
+				/ NULLIF(COUNT(*) * SUM(l.x * l.x) - SUM(l.x) * SUM(l.x), 0) AS trend_per_month
+		FROM (
+			SELECT b.lexile_measure AS lexile, `+monthIndexSQL+` AS x
+			FROM books b
+			WHERE `+statsBooksWhere+`
+		) l
+		WHERE l.lexile IS NOT NULL`, args...).Scan(&stats.Lexile).Error; err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
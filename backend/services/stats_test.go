@@ -0,0 +1,121 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type StatsTestSuite struct {
+	suite.Suite
+	sam  *models.Child
+	alex *models.Child
+}
+
+func (suite *StatsTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+	SharedPermissionCache().Clear()
+
+	owner, err := CreateUser(models.CreateUserRequest{
+		Email:     "owner@example.com",
+		Password:  "password123",
+		FirstName: "Owner",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	suite.sam, err = CreateChild(models.CreateChildRequest{FirstName: "Sam", LastName: "Reader"}, owner.ID)
+	assert.NoError(suite.T(), err)
+	suite.alex, err = CreateChild(models.CreateChildRequest{FirstName: "Alex", LastName: "Reader"}, owner.ID)
+	assert.NoError(suite.T(), err)
+
+	matilda := models.SharedBook{ISBN: "9780140328721", Title: "Matilda", Author: "Roald Dahl"}
+	assert.NoError(suite.T(), config.DB.Create(&matilda).Error)
+	books := []models.Book{
+		{ChildID: suite.sam.ID, SharedBookID: &matilda.ID, DateRead: "2024-07-05", LexileLevel: "800L"},
+		{ChildID: suite.sam.ID, CustomTitle: "The BFG", CustomAuthor: "roald dahl ", DateRead: "2024-07-20", LexileLevel: "BR100L"},
+		{ChildID: suite.sam.ID, CustomTitle: "Holes", CustomAuthor: "Louis Sachar", DateRead: "2024-09-02", LexileLevel: "HL900L", IsPartial: true},
+		{ChildID: suite.sam.ID, CustomTitle: "Comics", CustomAuthor: "Various", DateRead: "2024-09-10", LexileLevel: "GN"},
+		// Outside the window
+		{ChildID: suite.sam.ID, CustomTitle: "Old Book", CustomAuthor: "Someone", DateRead: "2024-05-31", LexileLevel: "100L"},
+		{ChildID: suite.alex.ID, CustomTitle: "Frog and Toad", CustomAuthor: "Arnold Lobel", DateRead: "2024-08-15", LexileLevel: "400L"},
+	}
+	for i := range books {
+		assert.NoError(suite.T(), config.DB.Create(&books[i]).Error)
+	}
+
+	// The books were saved directly, so their measures come from the backfill
+	_, err = BackfillLexileMeasures()
+	assert.NoError(suite.T(), err)
+
+	// Trashed books are not counted
+	trashed := models.Book{ChildID: suite.sam.ID, CustomTitle: "Trashed", CustomAuthor: "Roald Dahl", DateRead: "2024-08-01"}
+	assert.NoError(suite.T(), config.DB.Create(&trashed).Error)
+	assert.NoError(suite.T(), config.DB.Delete(&trashed).Error)
+}
+
+func (suite *StatsTestSuite) TearDownTest() {
+	config.CleanupTestDatabase()
+}
+
+func (suite *StatsTestSuite) TestChildStats() {
+	stats, err := GetReadingStats([]uint{suite.sam.ID}, time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC), 3, 5)
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), "2024-07", stats.From)
+	assert.Equal(suite.T(), "2024-09", stats.To)
+	assert.Equal(suite.T(), 4, stats.TotalBooks)
+	assert.Equal(suite.T(), 3, stats.CompleteBooks)
+	assert.Equal(suite.T(), 1, stats.PartialBooks)
+	assert.Equal(suite.T(), 0.25, stats.PartialShare)
+	assert.Equal(suite.T(), 1, stats.SharedBooks)
+	assert.Equal(suite.T(), 3, stats.CustomBooks)
+
+	// Every month of the window is listed, with the average of the measurable Lexile levels
+	assert.Len(suite.T(), stats.Months, 3)
+	assert.Equal(suite.T(), "2024-07", stats.Months[0].Month)
+	assert.Equal(suite.T(), 2, stats.Months[0].Books)
+	assert.InDelta(suite.T(), 350, *stats.Months[0].AverageLexile, 0.001)
+	assert.Equal(suite.T(), models.MonthlyReadingStats{Month: "2024-08"}, stats.Months[1])
+	assert.Equal(suite.T(), 2, stats.Months[2].Books)
+	assert.Equal(suite.T(), 1, stats.Months[2].PartialBooks)
+	assert.InDelta(suite.T(), 900, *stats.Months[2].AverageLexile, 0.001)
+
+	// Roald Dahl is counted once for the shared and custom spellings
+	assert.Equal(suite.T(), models.AuthorReadingStats{Author: "Roald Dahl", Books: 2}, stats.TopAuthors[0])
+	assert.Len(suite.T(), stats.TopAuthors, 3)
+
+	// 800 and -100 in month 0, 900 in month 2: a rising trend
+	assert.Equal(suite.T(), 3, stats.Lexile.Books)
+	assert.InDelta(suite.T(), 533.333, *stats.Lexile.Average, 0.001)
+	assert.InDelta(suite.T(), 275, *stats.Lexile.TrendPerMonth, 0.001)
+}
+
+func (suite *StatsTestSuite) TestFamilyStats() {
+	stats, err := GetReadingStats([]uint{suite.sam.ID, suite.alex.ID}, time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC), 1, 1)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, stats.TotalBooks)
+	assert.Equal(suite.T(), []models.AuthorReadingStats{{Author: "Arnold Lobel", Books: 1}}, stats.TopAuthors)
+	assert.Equal(suite.T(), 1, stats.Lexile.Books)
+	// A single month has no trend
+	assert.Nil(suite.T(), stats.Lexile.TrendPerMonth)
+
+	// An empty window has no averages
+	stats, err = GetReadingStats([]uint{suite.alex.ID}, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), 2, 5)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, stats.TotalBooks)
+	assert.Equal(suite.T(), 0.0, stats.PartialShare)
+	assert.Nil(suite.T(), stats.Lexile.Average)
+	assert.Empty(suite.T(), stats.TopAuthors)
+
+	_, err = GetReadingStats([]uint{suite.sam.ID}, time.Now(), MaxStatsMonths+1, 5)
+	assert.ErrorIs(suite.T(), err, ErrInvalidStatsWindow)
+}
+
+func TestStatsTestSuite(t *testing.T) {
+	suite.Run(t, new(StatsTestSuite))
+}
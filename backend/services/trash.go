@@ -0,0 +1,264 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"gorm.io/gorm"
+)
+
+// DefaultTrashRetentionDays is how long deleted children and books stay restorable
+const DefaultTrashRetentionDays = 30
+
+// TrashRetention returns the configured retention period (TRASH_RETENTION_DAYS)
+func TrashRetention() time.Duration {
+	days := DefaultTrashRetentionDays
+	if value := os.Getenv("TRASH_RETENTION_DAYS"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			days = parsed
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// GetDeletedChildByID gets a trashed child by ID
+func GetDeletedChildByID(id uint) (*models.Child, error) {
+	var child models.Child
+	result := config.DB.Unscoped().Where("deleted_at IS NOT NULL").First(&child, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("child not found in trash")
+		}
+		return nil, result.Error
+	}
+	return &child, nil
+}
+
+// GetDeletedBookByID gets a trashed book by ID
+func GetDeletedBookByID(id uint) (*models.Book, error) {
+	var book models.Book
+	result := config.DB.Unscoped().Preload("SharedBook").Preload("ReadingLevels").Where("deleted_at IS NOT NULL").First(&book, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("book not found in trash")
+		}
+		return nil, result.Error
+	}
+	return &book, nil
+}
+
+// GetDeletedChildrenByOwner gets the trashed children of a user
+func GetDeletedChildrenByOwner(ownerID uint) ([]models.Child, error) {
+	var children []models.Child
+	result := config.DB.Unscoped().
+		Where("owner_id = ? AND deleted_at IS NOT NULL", ownerID).
+		Order("deleted_at DESC").
+		Find(&children)
+	return children, result.Error
+}
+
+// CountBooksDeletedWithChild counts the books trashed together with a child
+func CountBooksDeletedWithChild(child *models.Child) (int, error) {
+	var count int64
+	result := config.DB.Unscoped().Model(&models.Book{}).
+		Where("child_id = ? AND deleted_at = ?", child.ID, child.DeletedAt.Time).
+		Count(&count)
+	return int(count), result.Error
+}
+
+// GetDeletedBooksForUser gets individually trashed books of live children the user can edit
+func GetDeletedBooksForUser(userID uint) ([]models.Book, error) {
+	grants, err := SharedPermissionCache().GetGrants(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var childIDs []uint
+	for childID, level := range grants {
+		if accessLevelSatisfies(level, "EDIT") {
+			childIDs = append(childIDs, childID)
+		}
+	}
+	if len(childIDs) == 0 {
+		return []models.Book{}, nil
+	}
+
+	var books []models.Book
+	result := config.DB.Unscoped().Preload("SharedBook").Preload("ReadingLevels").
+		Where("child_id IN ? AND deleted_at IS NOT NULL", childIDs).
+		Order("deleted_at DESC").
+		Find(&books)
+	return books, result.Error
+}
+
+// RestoreChild brings a child back from the trash with the books and permissions deleted alongside it
+func RestoreChild(id uint, actor Actor) (*models.Child, error) {
+	child, err := GetDeletedChildByID(id)
+	if err != nil {
+		return nil, err
+	}
+	deletedAt := child.DeletedAt.Time
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Book{}).
+			Where("child_id = ? AND deleted_at = ?", id, deletedAt).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Permission{}).
+			Where("child_id = ? AND deleted_at = ?", id, deletedAt).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&models.Child{}).Where("id = ?", id).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateUserPermissions(child.OwnerID)
+	if child.HouseholdID != nil {
+		invalidateHouseholdPermissions(*child.HouseholdID)
+	}
+	var permissions []models.Permission
+	if err := config.DB.Where("child_id = ?", id).Find(&permissions).Error; err == nil {
+		for _, permission := range permissions {
+			invalidateUserPermissions(permission.UserID)
+		}
+	}
+
+	child.DeletedAt = gorm.DeletedAt{}
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionRestore,
+		EntityType: EntityChild,
+		EntityID:   child.ID,
+		ChildID:    child.ID,
+		After:      *child,
+	})
+
+	return child, nil
+}
+
+// RestoreBook brings a single book back from the trash
+func RestoreBook(id uint, actor Actor) (*models.Book, error) {
+	book, err := GetDeletedBookByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// A book trashed with its child comes back through RestoreChild
+	if _, err := GetChildByID(book.ChildID); err != nil {
+		return nil, errors.New("restore the child before restoring its books")
+	}
+
+	result := config.DB.Unscoped().Model(&models.Book{}).Where("id = ?", id).Update("deleted_at", nil)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	book.DeletedAt = gorm.DeletedAt{}
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionRestore,
+		EntityType: EntityBook,
+		EntityID:   book.ID,
+		ChildID:    book.ChildID,
+		After:      *book,
+	})
+
+	return book, nil
+}
+
+// PurgeDeletedRecords permanently removes trashed rows deleted before the cutoff
+func PurgeDeletedRecords(cutoff time.Time) (int64, error) {
+	var purged int64
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Children first collect their (possibly live) dependants so nothing dangles
+		var childIDs []uint
+		if err := tx.Unscoped().Model(&models.Child{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Pluck("id", &childIDs).Error; err != nil {
+			return err
+		}
+
+		bookQuery := tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+		permissionQuery := tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+		if len(childIDs) > 0 {
+			bookQuery = bookQuery.Or("child_id IN ?", childIDs)
+			permissionQuery = permissionQuery.Or("child_id IN ?", childIDs)
+		}
+
+		// Reading levels go with their books
+		levelBooks := tx.Unscoped().Model(&models.Book{}).Select("id").
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+		if len(childIDs) > 0 {
+			levelBooks = levelBooks.Or("child_id IN ?", childIDs)
+		}
+		if err := tx.Where("book_id IN (?)", levelBooks).Delete(&models.BookReadingLevel{}).Error; err != nil {
+			return err
+		}
+
+		result := bookQuery.Delete(&models.Book{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged += result.RowsAffected
+
+		result = permissionQuery.Delete(&models.Permission{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged += result.RowsAffected
+
+		if len(childIDs) > 0 {
+			result = tx.Where("child_id IN ?", childIDs).Delete(&models.ShareLink{})
+			if result.Error != nil {
+				return result.Error
+			}
+			purged += result.RowsAffected
+
+			if err := tx.Where("child_id IN ?", childIDs).Delete(&models.ClassroomStudent{}).Error; err != nil {
+				return err
+			}
+
+			result = tx.Unscoped().Where("id IN ?", childIDs).Delete(&models.Child{})
+			if result.Error != nil {
+				return result.Error
+			}
+			purged += result.RowsAffected
+		}
+		return nil
+	})
+	return purged, err
+}
+
+// StartTrashPurgeJob periodically purges trash older than the retention period
+func StartTrashPurgeJob(interval, retention time.Duration) chan<- struct{} {
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			purged, err := PurgeDeletedRecords(time.Now().Add(-retention))
+			if err != nil {
+				log.Printf("Trash purge failed: %v", err)
+			} else if purged > 0 {
+				log.Printf("Trash purge removed %d records", purged)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return stop
+}
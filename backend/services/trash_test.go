@@ -0,0 +1,185 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type TrashServiceTestSuite struct {
+	suite.Suite
+	owner  *models.User
+	editor *models.User
+	child  *models.Child
+}
+
+func (suite *TrashServiceTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+	SharedPermissionCache().Clear()
+
+	owner, err := CreateUser(models.CreateUserRequest{
+		Email:     "owner@example.com",
+		Password:  "password123",
+		FirstName: "Owner",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	suite.owner = owner
+
+	editor, err := CreateUser(models.CreateUserRequest{
+		Email:     "editor@example.com",
+		Password:  "password123",
+		FirstName: "Editor",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	suite.editor = editor
+
+	child, err := CreateChild(models.CreateChildRequest{
+		FirstName: "Test",
+		LastName:  "Child",
+		Grade:     "3rd",
+	}, owner.ID)
+	assert.NoError(suite.T(), err)
+	suite.child = child
+
+	assert.NoError(suite.T(), CreatePermission(editor.ID, child.ID, "EDIT", SystemActor))
+}
+
+func (suite *TrashServiceTestSuite) TearDownTest() {
+	config.CleanupTestDatabase()
+}
+
+func (suite *TrashServiceTestSuite) createBook(title string) *models.Book {
+	book, err := CreateCustomBook(models.CreateCustomBookRequest{
+		Title:    title,
+		Author:   "Author",
+		DateRead: "2024-01-01",
+		ChildID:  suite.child.ID,
+	}, SystemActor)
+	assert.NoError(suite.T(), err)
+	return book
+}
+
+func (suite *TrashServiceTestSuite) TestDeleteChildCascadesAndRestores() {
+	suite.createBook("First")
+	suite.createBook("Second")
+
+	assert.NoError(suite.T(), DeleteChild(suite.child.ID, SystemActor))
+
+	_, err := GetChildByID(suite.child.ID)
+	assert.Error(suite.T(), err)
+	books, err := GetBooksByChild(suite.child.ID)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), books)
+	hasPermission, err := HasChildPermission(suite.editor.ID, suite.child.ID, "VIEW")
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), hasPermission)
+
+	trashed, err := GetDeletedChildrenByOwner(suite.owner.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), trashed, 1)
+	count, err := CountBooksDeletedWithChild(&trashed[0])
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, count)
+
+	_, err = RestoreChild(suite.child.ID, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+
+	books, err = GetBooksByChild(suite.child.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), books, 2)
+	hasPermission, err = HasChildPermission(suite.editor.ID, suite.child.ID, "EDIT")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), hasPermission)
+}
+
+func (suite *TrashServiceTestSuite) TestRestoreChildKeepsEarlierDeletedBooksInTrash() {
+	earlier := suite.createBook("Deleted earlier")
+	suite.createBook("Deleted with child")
+
+	assert.NoError(suite.T(), DeleteBook(earlier.ID, SystemActor))
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(suite.T(), DeleteChild(suite.child.ID, SystemActor))
+
+	_, err := RestoreChild(suite.child.ID, SystemActor)
+	assert.NoError(suite.T(), err)
+
+	books, err := GetBooksByChild(suite.child.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), books, 1)
+	assert.Equal(suite.T(), "Deleted with child", books[0].CustomTitle)
+
+	trashed, err := GetDeletedBooksForUser(suite.editor.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), trashed, 1)
+	assert.Equal(suite.T(), earlier.ID, trashed[0].ID)
+}
+
+func (suite *TrashServiceTestSuite) TestRestoreBook() {
+	book := suite.createBook("Title")
+	assert.NoError(suite.T(), DeleteBook(book.ID, SystemActor))
+
+	_, err := GetBookByID(book.ID)
+	assert.Error(suite.T(), err)
+
+	restored, err := RestoreBook(book.ID, SystemActor)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), book.ID, restored.ID)
+
+	_, err = GetBookByID(book.ID)
+	assert.NoError(suite.T(), err)
+
+	_, err = RestoreBook(book.ID, SystemActor)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *TrashServiceTestSuite) TestRestoreBookOfDeletedChildFails() {
+	book := suite.createBook("Title")
+	assert.NoError(suite.T(), DeleteChild(suite.child.ID, SystemActor))
+
+	_, err := RestoreBook(book.ID, SystemActor)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *TrashServiceTestSuite) TestPurgeDeletedRecords() {
+	book := suite.createBook("Title")
+	assert.NoError(suite.T(), DeleteBook(book.ID, SystemActor))
+
+	// Nothing is old enough yet
+	purged, err := PurgeDeletedRecords(time.Now().Add(-time.Hour))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(0), purged)
+
+	assert.NoError(suite.T(), DeleteChild(suite.child.ID, SystemActor))
+	purged, err = PurgeDeletedRecords(time.Now().Add(time.Hour))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(3), purged) // book, permission and child
+
+	var remaining int64
+	config.DB.Unscoped().Model(&models.Book{}).Count(&remaining)
+	assert.Equal(suite.T(), int64(0), remaining)
+	config.DB.Unscoped().Model(&models.Child{}).Count(&remaining)
+	assert.Equal(suite.T(), int64(0), remaining)
+	assert.ErrorIs(suite.T(), config.DB.Unscoped().First(&models.Permission{}).Error, gorm.ErrRecordNotFound)
+}
+
+func (suite *TrashServiceTestSuite) TestRecreateDeletedPermission() {
+	assert.NoError(suite.T(), DeletePermission(suite.editor.ID, suite.child.ID, SystemActor))
+	assert.NoError(suite.T(), CreatePermission(suite.editor.ID, suite.child.ID, "VIEW", SystemActor))
+
+	permissions, err := GetPermissionsByChild(suite.child.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), permissions, 1)
+	assert.Equal(suite.T(), "VIEW", permissions[0].PermissionType)
+}
+
+func TestTrashServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(TrashServiceTestSuite))
+}
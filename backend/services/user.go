@@ -65,6 +65,7 @@ func CreateUser(req models.CreateUserRequest) (*models.User, error) {
 		EmailVerified:          false,       // New users need to verify their email
 		EmailVerificationToken: token,
 		TokenExpiresAt:         &expiresAt,
+		Locale:                 NormalizeLocale(req.Locale),
 	}
 
 	result = config.DB.Create(&user)
@@ -111,6 +112,24 @@ func GetAllUsers() ([]models.User, error) {
 	return users, nil
 }
 
+// GetUsersByIDs gets several users at once, keyed by ID
+func GetUsersByIDs(ids []uint) (map[uint]models.User, error) {
+	usersByID := make(map[uint]models.User, len(ids))
+	if len(ids) == 0 {
+		return usersByID, nil
+	}
+
+	var users []models.User
+	result := config.DB.Where("id IN ?", ids).Find(&users)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	for _, user := range users {
+		usersByID[user.ID] = user
+	}
+	return usersByID, nil
+}
+
 // UpdateUser updates a user
 func UpdateUser(id uint, req models.UpdateUserRequest) (*models.User, error) {
 	var user models.User
@@ -129,11 +148,21 @@ func UpdateUser(id uint, req models.UpdateUserRequest) (*models.User, error) {
 		return nil, errors.New("email already taken by another user")
 	}
 
+	if err := moveNotificationPreferences(user.Email, req.Email); err != nil {
+		return nil, err
+	}
+
 	// Update user
 	user.Email = req.Email
 	user.FirstName = req.FirstName
 	user.LastName = req.LastName
 	user.IsAdmin = req.IsAdmin
+	if req.Locale != "" {
+		user.Locale = NormalizeLocale(req.Locale)
+	}
+	if req.ReadingLevelSystem != "" {
+		user.ReadingLevelSystem = req.ReadingLevelSystem
+	}
 
 	result = config.DB.Save(&user)
 	if result.Error != nil {
@@ -145,6 +174,21 @@ func UpdateUser(id uint, req models.UpdateUserRequest) (*models.User, error) {
 
 // DeleteUser deletes a user
 func DeleteUser(id uint) error {
+	if err := config.DB.Where("user_id = ?", id).Delete(&models.DigestSubscription{}).Error; err != nil {
+		return err
+	}
+	if err := config.DB.Where("user_id = ?", id).Delete(&models.Notification{}).Error; err != nil {
+		return err
+	}
+	if err := config.DB.Where("user_id = ?", id).Delete(&models.NotificationMute{}).Error; err != nil {
+		return err
+	}
+	if err := config.DB.Where("user_id = ?", id).Delete(&models.OrganizationInvitation{}).Error; err != nil {
+		return err
+	}
+	if err := deleteWebhooksByUser(id); err != nil {
+		return err
+	}
 	result := config.DB.Delete(&models.User{}, id)
 	if result.Error != nil {
 		return result.Error
@@ -152,6 +196,7 @@ func DeleteUser(id uint) error {
 	if result.RowsAffected == 0 {
 		return errors.New("user not found")
 	}
+	invalidateUserPermissions(id)
 	return nil
 }
 
@@ -389,6 +434,7 @@ func CreateGoogleUserWithInvitation(userInfo *GoogleUserInfo, invitationToken st
 	}
 
 	// Process invitations and create permissions
+	var permissions []models.Permission
 	for _, invitation := range invitations {
 		permission := models.Permission{
 			UserID:         user.ID,
@@ -400,6 +446,7 @@ func CreateGoogleUserWithInvitation(userInfo *GoogleUserInfo, invitationToken st
 			tx.Rollback()
 			return nil, err
 		}
+		permissions = append(permissions, permission)
 	}
 
 	// Delete processed invitations
@@ -413,6 +460,19 @@ func CreateGoogleUserWithInvitation(userInfo *GoogleUserInfo, invitationToken st
 		return nil, err
 	}
 
+	invalidateUserPermissions(user.ID)
+
+	for _, permission := range permissions {
+		fireChange(ChangeEvent{
+			Actor:      Actor{UserID: user.ID},
+			Action:     ActionCreate,
+			EntityType: EntityPermission,
+			EntityID:   permission.ID,
+			ChildID:    permission.ChildID,
+			After:      permission,
+		})
+	}
+
 	return &user, nil
 }
 
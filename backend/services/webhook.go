@@ -0,0 +1,665 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+)
+
+// Webhook events
+const (
+	WebhookEventBookCreated  = "book.created"
+	WebhookEventBookUpdated  = "book.updated"
+	WebhookEventBookDeleted  = "book.deleted"
+	WebhookEventChildCreated = "child.created"
+	WebhookEventGoalReached  = "goal.reached"
+	WebhookEventTest         = "webhook.test"
+)
+
+// WebhookEvents lists the events a webhook can subscribe to
+var WebhookEvents = []string{
+	WebhookEventBookCreated,
+	WebhookEventBookUpdated,
+	WebhookEventBookDeleted,
+	WebhookEventChildCreated,
+	WebhookEventGoalReached,
+}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "PENDING"
+	WebhookDeliverySucceeded = "SUCCEEDED"
+	WebhookDeliveryFailed    = "FAILED"
+)
+
+// EntityWebhook is the entity type of webhooks in the audit log
+const EntityWebhook = "webhook"
+
+// MaxWebhookAttempts is how many deliveries are tried before a delivery is marked failed
+const MaxWebhookAttempts = 6
+
+const (
+	webhookBatchSize       = 50
+	webhookLease           = 5 * time.Minute
+	webhookTimeout         = 10 * time.Second
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 200
+)
+
+var (
+	// ErrWebhookNotFound is returned for webhooks that do not exist or belong to someone else
+	ErrWebhookNotFound = errors.New("webhook not found")
+
+	errPrivateAddress = errors.New("URL resolves to a private network address")
+)
+
+// WebhookPayload is the JSON body posted to webhooks
+type WebhookPayload struct {
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookBookData is the data of book events
+type WebhookBookData struct {
+	BookID    uint   `json:"bookId"`
+	ChildID   uint   `json:"childId"`
+	Title     string `json:"title"`
+	Author    string `json:"author,omitempty"`
+	DateRead  string `json:"dateRead"`
+	IsPartial bool   `json:"isPartial"`
+}
+
+// WebhookChildData is the data of child events
+type WebhookChildData struct {
+	ChildID   uint   `json:"childId"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Grade     string `json:"grade"`
+}
+
+// WebhookGoalData is the data of goal.reached, sent when a child reaches their classroom's monthly goal
+type WebhookGoalData struct {
+	ChildID   uint   `json:"childId"`
+	ChildName string `json:"childName"`
+	Year      int    `json:"year"`
+	Month     int    `json:"month"`
+	Goal      int    `json:"goal"`
+	BooksRead int    `json:"booksRead"`
+}
+
+func init() {
+	RegisterChangeHook(queueWebhookEvents)
+}
+
+func isWebhookEvent(event string) bool {
+	for _, e := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEventList splits a webhook's stored event names
+func WebhookEventList(webhook *models.Webhook) []string {
+	if webhook.Events == "" {
+		return []string{}
+	}
+	return strings.Split(webhook.Events, ",")
+}
+
+func subscribesTo(webhook *models.Webhook, event string) bool {
+	for _, e := range WebhookEventList(webhook) {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func joinWebhookEvents(events []string) (string, error) {
+	seen := make(map[string]bool, len(events))
+	var unique []string
+	for _, event := range events {
+		if !isWebhookEvent(event) {
+			return "", fmt.Errorf("unknown webhook event %q", event)
+		}
+		if !seen[event] {
+			seen[event] = true
+			unique = append(unique, event)
+		}
+	}
+	return strings.Join(unique, ","), nil
+}
+
+func validateWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return errors.New("webhook URL must be an absolute http or https URL")
+	}
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// CreateWebhook registers a webhook with a fresh signing secret
+func CreateWebhook(userID uint, req models.CreateWebhookRequest, actor Actor) (*models.Webhook, error) {
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	events, err := joinWebhookEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := models.Webhook{
+		UserID: userID,
+		URL:    req.URL,
+		Secret: secret,
+		Events: events,
+		Active: true,
+	}
+	if err := config.DB.Create(&webhook).Error; err != nil {
+		return nil, err
+	}
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionCreate,
+		EntityType: EntityWebhook,
+		EntityID:   webhook.ID,
+		After:      webhook,
+	})
+	return &webhook, nil
+}
+
+// GetWebhooksByUser lists a user's webhooks
+func GetWebhooksByUser(userID uint) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	result := config.DB.Where("user_id = ?", userID).Order("created_at").Find(&webhooks)
+	return webhooks, result.Error
+}
+
+// GetWebhookForUser gets one of a user's webhooks
+func GetWebhookForUser(userID, id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := config.DB.Where("id = ? AND user_id = ?", id, userID).First(&webhook).Error; err != nil {
+		return nil, ErrWebhookNotFound
+	}
+	return &webhook, nil
+}
+
+// UpdateWebhook changes a webhook's URL, events or active flag
+func UpdateWebhook(userID, id uint, req models.UpdateWebhookRequest, actor Actor) (*models.Webhook, error) {
+	webhook, err := GetWebhookForUser(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *webhook
+
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			return nil, err
+		}
+		webhook.URL = *req.URL
+	}
+	if req.Events != nil {
+		if webhook.Events, err = joinWebhookEvents(req.Events); err != nil {
+			return nil, err
+		}
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+
+	if err := config.DB.Save(webhook).Error; err != nil {
+		return nil, err
+	}
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionUpdate,
+		EntityType: EntityWebhook,
+		EntityID:   webhook.ID,
+		Before:     before,
+		After:      *webhook,
+	})
+	return webhook, nil
+}
+
+// DeleteWebhook removes a webhook and its delivery log
+func DeleteWebhook(userID, id uint, actor Actor) error {
+	webhook, err := GetWebhookForUser(userID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := config.DB.Where("webhook_id = ?", webhook.ID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+		return err
+	}
+	if err := config.DB.Delete(webhook).Error; err != nil {
+		return err
+	}
+
+	fireChange(ChangeEvent{
+		Actor:      actor,
+		Action:     ActionDelete,
+		EntityType: EntityWebhook,
+		EntityID:   webhook.ID,
+		Before:     *webhook,
+	})
+	return nil
+}
+
+// deleteWebhooksByUser removes every webhook of a user being deleted
+func deleteWebhooksByUser(userID uint) error {
+	webhookIDs := config.DB.Model(&models.Webhook{}).Select("id").Where("user_id = ?", userID)
+	if err := config.DB.Where("webhook_id IN (?)", webhookIDs).Delete(&models.WebhookDelivery{}).Error; err != nil {
+		return err
+	}
+	return config.DB.Where("user_id = ?", userID).Delete(&models.Webhook{}).Error
+}
+
+// GetWebhookDeliveries lists a webhook's deliveries, newest first
+func GetWebhookDeliveries(webhookID uint, limit, offset int) ([]models.WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = defaultDeliveriesLimit
+	}
+	if limit > maxDeliveriesLimit {
+		limit = maxDeliveriesLimit
+	}
+
+	var deliveries []models.WebhookDelivery
+	result := config.DB.Where("webhook_id = ?", webhookID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).Offset(offset).
+		Find(&deliveries)
+	return deliveries, result.Error
+}
+
+// SendTestWebhook delivers a test event right away, without retries, and returns the logged delivery
+func SendTestWebhook(webhook *models.Webhook) (*models.WebhookDelivery, error) {
+	delivery, err := newWebhookDelivery(webhook.ID, WebhookEventTest, map[string]interface{}{
+		"webhookId": webhook.ID,
+		"message":   "This is a test event from Book Tracker",
+	})
+	if err != nil {
+		return nil, err
+	}
+	delivery.Attempts = 1
+	if err := config.DB.Create(delivery).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	status, err := deliverWebhook(webhook, delivery, now)
+	updates := map[string]interface{}{"response_status": status}
+	if err == nil {
+		updates["status"] = WebhookDeliverySucceeded
+		updates["delivered_at"] = now
+	} else {
+		updates["status"] = WebhookDeliveryFailed
+		updates["last_error"] = err.Error()
+	}
+	if err := config.DB.Model(delivery).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func newWebhookDelivery(webhookID uint, event string, data interface{}) (*models.WebhookDelivery, error) {
+	payload, err := json.Marshal(WebhookPayload{Event: event, CreatedAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		return nil, err
+	}
+	return &models.WebhookDelivery{
+		WebhookID:     webhookID,
+		Event:         event,
+		Payload:       string(payload),
+		Status:        WebhookDeliveryPending,
+		NextAttemptAt: time.Now(),
+	}, nil
+}
+
+// queueWebhookEvents is the change hook that queues deliveries for book and child changes
+func queueWebhookEvents(event ChangeEvent) {
+	// Imports and restores fire one event per book, too many to send during the request; their
+	// deliveries wait for the scheduled jobs
+	inline := inlineDelivery.Load() && event.Action != ActionImport
+
+	var err error
+	switch event.EntityType {
+	case EntityBook:
+		err = queueBookWebhooks(event, inline)
+	case EntityChild:
+		if child, ok := event.After.(models.Child); ok && event.Action == ActionCreate {
+			err = enqueueWebhookEvent(WebhookEventChildCreated, child.ID, inline, WebhookChildData{
+				ChildID:   child.ID,
+				FirstName: child.FirstName,
+				LastName:  child.LastName,
+				Grade:     child.Grade,
+			})
+		}
+	}
+	if err != nil {
+		log.Printf("Failed to queue webhooks for %s %s %d: %v", event.Action, event.EntityType, event.EntityID, err)
+	}
+}
+
+func queueBookWebhooks(event ChangeEvent, inline bool) error {
+	var name string
+	var book models.Book
+	switch event.Action {
+	case ActionCreate, ActionRestore, ActionImport:
+		name = WebhookEventBookCreated
+		book, _ = event.After.(models.Book)
+	case ActionUpdate:
+		name = WebhookEventBookUpdated
+		book, _ = event.After.(models.Book)
+	case ActionDelete:
+		name = WebhookEventBookDeleted
+		book, _ = event.Before.(models.Book)
+	default:
+		return nil
+	}
+	if book.ID == 0 {
+		return nil
+	}
+
+	details := digestBook(&book)
+	if book.SharedBookID != nil && book.SharedBook == nil {
+		var sharedBook models.SharedBook
+		if err := config.DB.First(&sharedBook, *book.SharedBookID).Error; err == nil {
+			details.Title, details.Author = sharedBook.Title, sharedBook.Author
+		}
+	}
+	err := enqueueWebhookEvent(name, book.ChildID, inline, WebhookBookData{
+		BookID:    book.ID,
+		ChildID:   book.ChildID,
+		Title:     details.Title,
+		Author:    details.Author,
+		DateRead:  details.DateRead,
+		IsPartial: book.IsPartial,
+	})
+	if err != nil || name != WebhookEventBookCreated {
+		return err
+	}
+	return queueGoalReachedWebhook(&book, inline)
+}
+
+// queueGoalReachedWebhook fires goal.reached when a new book brings the month's count exactly to the goal
+func queueGoalReachedWebhook(book *models.Book, inline bool) error {
+	if len(book.DateRead) < 7 {
+		return nil
+	}
+	year, err := strconv.Atoi(book.DateRead[:4])
+	if err != nil {
+		return nil
+	}
+	month, err := strconv.Atoi(book.DateRead[5:7])
+	if err != nil {
+		return nil
+	}
+
+	goal, err := GetChildMonthlyGoal(book.ChildID)
+	if err != nil || goal == 0 {
+		return err
+	}
+	count, err := GetBookCountByChildAndMonth(book.ChildID, year, month)
+	if err != nil || count != goal {
+		return err
+	}
+
+	child, err := GetChildByID(book.ChildID)
+	if err != nil {
+		return err
+	}
+	return enqueueWebhookEvent(WebhookEventGoalReached, child.ID, inline, WebhookGoalData{
+		ChildID:   child.ID,
+		ChildName: child.FirstName,
+		Year:      year,
+		Month:     month,
+		Goal:      goal,
+		BooksRead: count,
+	})
+}
+
+// enqueueWebhookEvent queues a delivery for every active webhook subscribed to the event
+// whose owner can view the child, and attempts each one right away when inline is set
+func enqueueWebhookEvent(event string, childID uint, inline bool, data interface{}) error {
+	var webhooks []models.Webhook
+	if err := config.DB.Where("active = ? AND events LIKE ?", true, "%"+event+"%").Find(&webhooks).Error; err != nil {
+		return err
+	}
+
+	for i := range webhooks {
+		webhook := &webhooks[i]
+		if !subscribesTo(webhook, event) {
+			continue
+		}
+		allowed, err := HasChildPermission(webhook.UserID, childID, "VIEW")
+		if err != nil || !allowed {
+			continue
+		}
+
+		delivery, err := newWebhookDelivery(webhook.ID, event, data)
+		if err != nil {
+			return err
+		}
+		if err := config.DB.Create(delivery).Error; err != nil {
+			return err
+		}
+
+		// A failed attempt stays queued for the scheduled jobs to retry
+		if inline {
+			if _, err := attemptWebhookDelivery(delivery, time.Now()); err != nil {
+				log.Printf("Webhook delivery %d could not be attempted inline: %v", delivery.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ProcessWebhookDeliveries delivers the pending deliveries that are due and returns how many succeeded.
+// Failed deliveries are retried with exponential backoff until MaxWebhookAttempts.
+func ProcessWebhookDeliveries(now time.Time) (int, error) {
+	var due []models.WebhookDelivery
+	result := config.DB.
+		Where("status = ? AND next_attempt_at <= ?", WebhookDeliveryPending, now).
+		Order("next_attempt_at").
+		Limit(webhookBatchSize).
+		Find(&due)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	succeeded := 0
+	for i := range due {
+		delivered, err := attemptWebhookDelivery(&due[i], now)
+		if err != nil {
+			return succeeded, err
+		}
+		if delivered {
+			succeeded++
+		}
+	}
+
+	return succeeded, nil
+}
+
+// attemptWebhookDelivery makes one delivery attempt and reports whether the receiver accepted it.
+// Errors are only returned when the delivery log itself cannot be updated.
+func attemptWebhookDelivery(delivery *models.WebhookDelivery, now time.Time) (bool, error) {
+	// Claim the delivery by counting the attempt, so a second worker does not send it too
+	attempts := delivery.Attempts + 1
+	claim := config.DB.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND attempts = ?", delivery.ID, WebhookDeliveryPending, delivery.Attempts).
+		Updates(map[string]interface{}{"attempts": attempts, "next_attempt_at": now.Add(webhookLease)})
+	if claim.Error != nil {
+		return false, claim.Error
+	}
+	if claim.RowsAffected == 0 {
+		return false, nil
+	}
+
+	var webhook models.Webhook
+	status := 0
+	deliveryErr := config.DB.First(&webhook, delivery.WebhookID).Error
+	if deliveryErr == nil && !webhook.Active {
+		deliveryErr = errors.New("webhook is disabled")
+		attempts = MaxWebhookAttempts
+	}
+	if deliveryErr == nil {
+		status, deliveryErr = deliverWebhook(&webhook, delivery, now)
+	}
+
+	updates := map[string]interface{}{"response_status": status}
+	if deliveryErr == nil {
+		updates["status"] = WebhookDeliverySucceeded
+		updates["delivered_at"] = now
+		updates["last_error"] = ""
+	} else {
+		log.Printf("Webhook delivery %d (%s) failed (attempt %d): %v", delivery.ID, delivery.Event, attempts, deliveryErr)
+		updates["last_error"] = deliveryErr.Error()
+		if attempts >= MaxWebhookAttempts {
+			updates["status"] = WebhookDeliveryFailed
+		} else {
+			updates["next_attempt_at"] = now.Add(webhookBackoff(attempts))
+		}
+	}
+
+	if err := config.DB.Model(delivery).Updates(updates).Error; err != nil {
+		return false, err
+	}
+	return deliveryErr == nil, nil
+}
+
+// webhookBackoff doubles the wait after each failed attempt: 30s, 1m, 2m ... capped at 6h
+func webhookBackoff(attempts int) time.Duration {
+	delay := 30 * time.Second << (attempts - 1)
+	if attempts > 10 || delay > 6*time.Hour {
+		return 6 * time.Hour
+	}
+	return delay
+}
+
+// SignWebhookPayload computes the X-BookTracker-Signature value receivers should compare against:
+// sha256= followed by the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliverWebhook posts one delivery and returns the receiver's status code; any non-2xx status is an error
+func deliverWebhook(webhook *models.Webhook, delivery *models.WebhookDelivery, now time.Time) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "BookTracker-Webhooks/1.0")
+	req.Header.Set("X-BookTracker-Event", delivery.Event)
+	req.Header.Set("X-BookTracker-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-BookTracker-Timestamp", timestamp)
+	req.Header.Set("X-BookTracker-Signature", SignWebhookPayload(webhook.Secret, timestamp, body))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// webhookClient refuses to connect to private addresses so webhooks cannot probe the internal network.
+// Set WEBHOOK_ALLOW_PRIVATE_NETWORKS=true to deliver to local receivers during development.
+// It never goes through HTTP(S)_PROXY, since the address check would then see the proxy instead of the receiver.
+var webhookClient = &http.Client{
+	Timeout: webhookTimeout,
+	Transport: &http.Transport{
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout: webhookTimeout,
+			Control: checkPublicAddress,
+		}).DialContext,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// checkPublicAddress refuses connections to private addresses for clients that fetch URLs users
+// control. It runs after DNS resolution, so it also catches names that resolve to private addresses.
+func checkPublicAddress(network, address string, _ syscall.RawConn) error {
+	if os.Getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS") == "true" {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return errPrivateAddress
+	}
+	return nil
+}
+
+// StartWebhookWorker periodically delivers due webhook events
+func StartWebhookWorker(interval time.Duration) chan<- struct{} {
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := ProcessWebhookDeliveries(time.Now()); err != nil {
+				log.Printf("Webhook delivery failed: %v", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return stop
+}
@@ -0,0 +1,318 @@
+package services
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/booktracker/backend/config"
+	"github.com/booktracker/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// receivedWebhook is one request seen by the test receiver
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+type WebhookServiceTestSuite struct {
+	suite.Suite
+	receiver *httptest.Server
+	status   int
+	mu       sync.Mutex
+	received []receivedWebhook
+	owner    *models.User
+	stranger *models.User
+	child    *models.Child
+}
+
+func (suite *WebhookServiceTestSuite) SetupTest() {
+	config.TestDB = config.SetupTestDatabase()
+	config.DB = config.TestDB
+	SharedPermissionCache().Clear()
+	suite.T().Setenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS", "true")
+
+	suite.status = http.StatusOK
+	suite.received = nil
+	suite.receiver = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		suite.mu.Lock()
+		suite.received = append(suite.received, receivedWebhook{header: r.Header.Clone(), body: body})
+		status := suite.status
+		suite.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+
+	suite.owner = suite.createUser("owner@example.com")
+	suite.stranger = suite.createUser("stranger@example.com")
+
+	child, err := CreateChild(models.CreateChildRequest{
+		FirstName: "Sam",
+		LastName:  "Reader",
+		Grade:     "3rd",
+	}, suite.owner.ID)
+	assert.NoError(suite.T(), err)
+	suite.child = child
+}
+
+func (suite *WebhookServiceTestSuite) TearDownTest() {
+	suite.receiver.Close()
+	config.CleanupTestDatabase()
+}
+
+func (suite *WebhookServiceTestSuite) createUser(email string) *models.User {
+	user, err := CreateUser(models.CreateUserRequest{
+		Email:     email,
+		Password:  "password123",
+		FirstName: "Test",
+		LastName:  "User",
+	})
+	assert.NoError(suite.T(), err)
+	return user
+}
+
+func (suite *WebhookServiceTestSuite) createWebhook(userID uint, events ...string) *models.Webhook {
+	webhook, err := CreateWebhook(userID, models.CreateWebhookRequest{
+		URL:    suite.receiver.URL + "/hook",
+		Events: events,
+	}, Actor{UserID: userID})
+	assert.NoError(suite.T(), err)
+	return webhook
+}
+
+func (suite *WebhookServiceTestSuite) readBook(title, dateRead string) *models.Book {
+	book, err := CreateCustomBook(models.CreateCustomBookRequest{
+		Title:    title,
+		Author:   "Author",
+		DateRead: dateRead,
+		ChildID:  suite.child.ID,
+	}, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+	return book
+}
+
+func (suite *WebhookServiceTestSuite) deliveries(webhookID uint) []models.WebhookDelivery {
+	deliveries, err := GetWebhookDeliveries(webhookID, 0, 0)
+	assert.NoError(suite.T(), err)
+	return deliveries
+}
+
+func (suite *WebhookServiceTestSuite) TestDeliversSignedPayloads() {
+	webhook := suite.createWebhook(suite.owner.ID, WebhookEventBookCreated)
+	assert.Len(suite.T(), webhook.Secret, 64)
+	book := suite.readBook("Signed Story", "2024-03-05")
+
+	sent, err := ProcessWebhookDeliveries(time.Now())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, sent)
+	if !assert.Len(suite.T(), suite.received, 1) {
+		return
+	}
+
+	request := suite.received[0]
+	assert.Equal(suite.T(), WebhookEventBookCreated, request.header.Get("X-BookTracker-Event"))
+	timestamp := request.header.Get("X-BookTracker-Timestamp")
+	assert.Equal(suite.T(), SignWebhookPayload(webhook.Secret, timestamp, request.body), request.header.Get("X-BookTracker-Signature"))
+
+	var payload struct {
+		Event string          `json:"event"`
+		Data  WebhookBookData `json:"data"`
+	}
+	assert.NoError(suite.T(), json.Unmarshal(request.body, &payload))
+	assert.Equal(suite.T(), WebhookEventBookCreated, payload.Event)
+	assert.Equal(suite.T(), book.ID, payload.Data.BookID)
+	assert.Equal(suite.T(), "Signed Story", payload.Data.Title)
+
+	deliveries := suite.deliveries(webhook.ID)
+	if assert.Len(suite.T(), deliveries, 1) {
+		assert.Equal(suite.T(), WebhookDeliverySucceeded, deliveries[0].Status)
+		assert.Equal(suite.T(), http.StatusOK, deliveries[0].ResponseStatus)
+		assert.NotNil(suite.T(), deliveries[0].DeliveredAt)
+	}
+}
+
+func (suite *WebhookServiceTestSuite) TestQueuesOnlySubscribedEventsForViewers() {
+	owner := suite.createWebhook(suite.owner.ID, WebhookEventBookDeleted, WebhookEventChildCreated)
+	stranger := suite.createWebhook(suite.stranger.ID, WebhookEventBookCreated, WebhookEventBookDeleted)
+
+	book := suite.readBook("Quiet Book", "2024-03-06")
+	assert.NoError(suite.T(), DeleteBook(book.ID, Actor{UserID: suite.owner.ID}))
+	_, err := CreateChild(models.CreateChildRequest{FirstName: "Alex", LastName: "Reader", Grade: "1st"}, suite.owner.ID)
+	assert.NoError(suite.T(), err)
+
+	deliveries := suite.deliveries(owner.ID)
+	if assert.Len(suite.T(), deliveries, 2) {
+		assert.Equal(suite.T(), WebhookEventChildCreated, deliveries[0].Event)
+		assert.Equal(suite.T(), WebhookEventBookDeleted, deliveries[1].Event)
+	}
+	assert.Empty(suite.T(), suite.deliveries(stranger.ID))
+
+	// Disabled webhooks stop receiving events
+	active := false
+	_, err = UpdateWebhook(suite.owner.ID, owner.ID, models.UpdateWebhookRequest{Active: &active}, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+	_, err = CreateChild(models.CreateChildRequest{FirstName: "Jo", LastName: "Reader", Grade: "2nd"}, suite.owner.ID)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), suite.deliveries(owner.ID), 2)
+}
+
+func (suite *WebhookServiceTestSuite) TestRetriesFailedDeliveriesWithBackoff() {
+	webhook := suite.createWebhook(suite.owner.ID, WebhookEventBookCreated)
+	suite.readBook("Retry Story", "2024-03-07")
+	suite.status = http.StatusInternalServerError
+
+	now := time.Now()
+	sent, err := ProcessWebhookDeliveries(now)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, sent)
+
+	delivery := suite.deliveries(webhook.ID)[0]
+	assert.Equal(suite.T(), WebhookDeliveryPending, delivery.Status)
+	assert.Equal(suite.T(), 1, delivery.Attempts)
+	assert.Equal(suite.T(), http.StatusInternalServerError, delivery.ResponseStatus)
+	assert.Contains(suite.T(), delivery.LastError, "500")
+	assert.WithinDuration(suite.T(), now.Add(30*time.Second), delivery.NextAttemptAt, time.Second)
+
+	// Not due again until the backoff has passed
+	sent, err = ProcessWebhookDeliveries(now.Add(10 * time.Second))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, sent)
+	assert.Len(suite.T(), suite.received, 1)
+
+	// Gives up after the last attempt
+	for attempt := 2; attempt <= MaxWebhookAttempts; attempt++ {
+		now = now.Add(webhookBackoff(attempt - 1))
+		_, err = ProcessWebhookDeliveries(now)
+		assert.NoError(suite.T(), err)
+	}
+	delivery = suite.deliveries(webhook.ID)[0]
+	assert.Equal(suite.T(), WebhookDeliveryFailed, delivery.Status)
+	assert.Equal(suite.T(), MaxWebhookAttempts, delivery.Attempts)
+	assert.Len(suite.T(), suite.received, MaxWebhookAttempts)
+}
+
+func (suite *WebhookServiceTestSuite) TestGoalReachedFiresOnceTheGoalIsMet() {
+	webhook := suite.createWebhook(suite.owner.ID, WebhookEventGoalReached)
+	classroom, err := CreateClassroom(models.CreateClassroomRequest{Name: "3B", MonthlyGoal: 2}, Actor{UserID: suite.stranger.ID})
+	assert.NoError(suite.T(), err)
+	_, err = JoinClassroom(classroom.JoinCode, suite.child.ID, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+
+	suite.readBook("Goal One", "2024-03-01")
+	assert.Empty(suite.T(), suite.deliveries(webhook.ID))
+	suite.readBook("Goal Two", "2024-03-02")
+	suite.readBook("Goal Three", "2024-03-03")
+
+	deliveries := suite.deliveries(webhook.ID)
+	if assert.Len(suite.T(), deliveries, 1) {
+		var payload struct {
+			Data WebhookGoalData `json:"data"`
+		}
+		assert.NoError(suite.T(), json.Unmarshal([]byte(deliveries[0].Payload), &payload))
+		assert.Equal(suite.T(), 2024, payload.Data.Year)
+		assert.Equal(suite.T(), 3, payload.Data.Month)
+		assert.Equal(suite.T(), 2, payload.Data.Goal)
+	}
+}
+
+func (suite *WebhookServiceTestSuite) TestSendTestWebhook() {
+	webhook := suite.createWebhook(suite.owner.ID, WebhookEventBookCreated)
+
+	delivery, err := SendTestWebhook(webhook)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), WebhookEventTest, delivery.Event)
+	assert.Equal(suite.T(), WebhookDeliverySucceeded, delivery.Status)
+	assert.Equal(suite.T(), http.StatusOK, delivery.ResponseStatus)
+	if assert.Len(suite.T(), suite.received, 1) {
+		assert.Equal(suite.T(), WebhookEventTest, suite.received[0].header.Get("X-BookTracker-Event"))
+	}
+
+	// A failing test is logged, not retried
+	suite.status = http.StatusNotFound
+	delivery, err = SendTestWebhook(webhook)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), WebhookDeliveryFailed, delivery.Status)
+	assert.Equal(suite.T(), http.StatusNotFound, delivery.ResponseStatus)
+}
+
+func (suite *WebhookServiceTestSuite) TestBlocksPrivateNetworks() {
+	suite.T().Setenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS", "")
+	webhook := suite.createWebhook(suite.owner.ID, WebhookEventBookCreated)
+
+	delivery, err := SendTestWebhook(webhook)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), WebhookDeliveryFailed, delivery.Status)
+	assert.Contains(suite.T(), delivery.LastError, "private network")
+	assert.Empty(suite.T(), suite.received)
+}
+
+func (suite *WebhookServiceTestSuite) TestIgnoresProxySettings() {
+	// A proxy would be the only address the private network check sees
+	transport := webhookClient.Transport.(*http.Transport)
+	assert.Nil(suite.T(), transport.Proxy)
+}
+
+func (suite *WebhookServiceTestSuite) TestInlineDelivery() {
+	SetInlineDelivery(true)
+	defer SetInlineDelivery(false)
+	webhook := suite.createWebhook(suite.owner.ID, WebhookEventBookCreated)
+
+	// The event goes out while the book is logged
+	suite.readBook("Inline Story", "2024-03-05")
+	assert.Len(suite.T(), suite.received, 1)
+	deliveries := suite.deliveries(webhook.ID)
+	if assert.Len(suite.T(), deliveries, 1) {
+		assert.Equal(suite.T(), WebhookDeliverySucceeded, deliveries[0].Status)
+	}
+
+	// A failed attempt is retried by the scheduled jobs
+	suite.status = http.StatusInternalServerError
+	suite.readBook("Retried Story", "2024-03-06")
+	assert.Len(suite.T(), suite.received, 2)
+
+	suite.status = http.StatusOK
+	result, err := RunScheduledJobs(time.Now().Add(webhookBackoff(1)))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, result.WebhooksDelivered)
+	assert.Len(suite.T(), suite.received, 3)
+
+	// Imported books are left for the scheduled jobs, however many there are
+	file := "Child,Date Read,Title,Author\n" +
+		"Sam Reader,2024-04-01,First Import,Author\n" +
+		"Sam Reader,2024-04-02,Second Import,Author\n"
+	_, err = ImportBooks(strings.NewReader(file), BookImport{Children: []*models.Child{suite.child}}, Actor{UserID: suite.owner.ID})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), suite.received, 3)
+
+	result, err = RunScheduledJobs(time.Now())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, result.WebhooksDelivered)
+	assert.Len(suite.T(), suite.received, 5)
+}
+
+func (suite *WebhookServiceTestSuite) TestWebhooksBelongToTheirOwner() {
+	webhook := suite.createWebhook(suite.owner.ID, WebhookEventBookCreated)
+
+	_, err := GetWebhookForUser(suite.stranger.ID, webhook.ID)
+	assert.ErrorIs(suite.T(), err, ErrWebhookNotFound)
+	assert.ErrorIs(suite.T(), DeleteWebhook(suite.stranger.ID, webhook.ID, Actor{UserID: suite.stranger.ID}), ErrWebhookNotFound)
+
+	_, err = CreateWebhook(suite.owner.ID, models.CreateWebhookRequest{URL: "ftp://example.com", Events: []string{WebhookEventBookCreated}}, Actor{UserID: suite.owner.ID})
+	assert.Error(suite.T(), err)
+
+	assert.NoError(suite.T(), DeleteWebhook(suite.owner.ID, webhook.ID, Actor{UserID: suite.owner.ID}))
+	webhooks, err := GetWebhooksByUser(suite.owner.ID)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), webhooks)
+}
+
+func TestWebhookServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(WebhookServiceTestSuite))
+}